@@ -16,8 +16,10 @@ type aggregateBuilder[T any] struct {
 	instance *astql.ASTQL
 	builder  *astql.Builder
 	soy      soyExecutor
-	field    string // field to aggregate (empty for COUNT(*))
-	funcName string // aggregate function name (AVG, MIN, MAX, SUM, COUNT)
+	field    string        // field to aggregate (empty for COUNT(*))
+	funcName string        // aggregate function name (AVG, MIN, MAX, SUM, COUNT)
+	optional optionalWhere // conditions applied only when their params are supplied
+	scopes   scopeFilter   // scopes left out with Unscoped
 	err      error
 }
 
@@ -34,6 +36,9 @@ func newAggregateBuilder[T any](instance *astql.ASTQL, builder *astql.Builder, s
 
 // addWhere adds a simple WHERE condition.
 func (ab *aggregateBuilder[T]) addWhere(field, operator, param string) error {
+	if ab.optional.skipMissing {
+		return ab.optional.add(ab.instance, false, C(field, operator, param))
+	}
 	wb := newWhereBuilder(ab.instance, ab.builder)
 	builder, err := wb.addWhere(field, operator, param)
 	if err != nil {
@@ -45,6 +50,9 @@ func (ab *aggregateBuilder[T]) addWhere(field, operator, param string) error {
 
 // addWhereAnd adds multiple conditions combined with AND.
 func (ab *aggregateBuilder[T]) addWhereAnd(conditions ...Condition) error {
+	if ab.optional.skipMissing {
+		return ab.optional.add(ab.instance, false, conditions...)
+	}
 	wb := newWhereBuilder(ab.instance, ab.builder)
 	builder, err := wb.addWhereAnd(conditions...)
 	if err != nil {
@@ -56,6 +64,9 @@ func (ab *aggregateBuilder[T]) addWhereAnd(conditions ...Condition) error {
 
 // addWhereOr adds multiple conditions combined with OR.
 func (ab *aggregateBuilder[T]) addWhereOr(conditions ...Condition) error {
+	if ab.optional.skipMissing {
+		return ab.optional.add(ab.instance, true, conditions...)
+	}
 	wb := newWhereBuilder(ab.instance, ab.builder)
 	builder, err := wb.addWhereOr(conditions...)
 	if err != nil {
@@ -89,6 +100,9 @@ func (ab *aggregateBuilder[T]) addWhereNotNull(field string) error {
 
 // addWhereBetween adds a WHERE field BETWEEN low AND high condition.
 func (ab *aggregateBuilder[T]) addWhereBetween(field, lowParam, highParam string) error {
+	if ab.optional.skipMissing {
+		return ab.optional.add(ab.instance, false, Between(field, lowParam, highParam))
+	}
 	wb := newWhereBuilder(ab.instance, ab.builder)
 	builder, err := wb.addWhereBetween(field, lowParam, highParam)
 	if err != nil {
@@ -100,6 +114,9 @@ func (ab *aggregateBuilder[T]) addWhereBetween(field, lowParam, highParam string
 
 // addWhereNotBetween adds a WHERE field NOT BETWEEN low AND high condition.
 func (ab *aggregateBuilder[T]) addWhereNotBetween(field, lowParam, highParam string) error {
+	if ab.optional.skipMissing {
+		return ab.optional.add(ab.instance, false, NotBetween(field, lowParam, highParam))
+	}
 	wb := newWhereBuilder(ab.instance, ab.builder)
 	builder, err := wb.addWhereNotBetween(field, lowParam, highParam)
 	if err != nil {
@@ -111,7 +128,7 @@ func (ab *aggregateBuilder[T]) addWhereNotBetween(field, lowParam, highParam str
 
 // addWhereFields adds a WHERE condition comparing two fields.
 func (ab *aggregateBuilder[T]) addWhereFields(leftField, operator, rightField string) error {
-	astqlOp, err := validateOperator(operator)
+	astqlOp, err := validateConditionOperator(ab.instance, leftField, operator)
 	if err != nil {
 		return err
 	}
@@ -133,30 +150,48 @@ func (ab *aggregateBuilder[T]) addWhereFields(leftField, operator, rightField st
 // exec executes the aggregate query and returns the result as float64.
 // Handles both regular execution and transaction execution.
 func (ab *aggregateBuilder[T]) exec(ctx context.Context, execer sqlx.ExtContext, params map[string]any) (float64, error) {
+	params = ab.soy.bindParams(params)
 	// Check for builder errors first
 	if ab.err != nil {
 		return 0, fmt.Errorf("%s builder has errors: %w", ab.funcName, ab.err)
 	}
 
-	// Render the query
-	result, err := ab.builder.Render(ab.soy.renderer())
+	// Render the query, leaving out optional conditions whose params are missing
+	builder, err := ab.optional.apply(ab.instance, ab.scopes.apply(ab.soy, ab.builder), params)
+	if err != nil {
+		return 0, err
+	}
+
+	result, err := builder.Render(ab.soy.renderer())
 	if err != nil {
 		return 0, fmt.Errorf("failed to render %s query: %w", ab.funcName, err)
 	}
 
+	if err := validateParams(result.RequiredParams, params, ab.soy.strictParams(), ab.optional.paramNames()...); err != nil {
+		return 0, err
+	}
+	if err := validateEnumParams(ab.instance, builder, params); err != nil {
+		return 0, err
+	}
+
+	return ab.execSQL(ctx, execer, result.SQL, params)
+}
+
+// execSQL executes already-rendered aggregate SQL and scans the single result.
+func (ab *aggregateBuilder[T]) execSQL(ctx context.Context, execer sqlx.ExtContext, query string, params map[string]any) (float64, error) {
 	// Emit query started event
 	tableName := ab.soy.getTableName()
 	capitan.Debug(ctx, QueryStarted,
 		TableKey.Field(tableName),
 		OperationKey.Field(ab.funcName),
-		SQLKey.Field(result.SQL),
+		SQLKey.Field(query),
 		FieldKey.Field(ab.field),
 	)
 
 	startTime := time.Now()
 
 	// Execute named query
-	rows, err := sqlx.NamedQueryContext(ctx, execer, result.SQL, params)
+	rows, err := ab.soy.statements().namedQuery(ctx, execer, dialectOf(ab.soy.renderer()), query, params)
 	if err != nil {
 		durationMs := time.Since(startTime).Milliseconds()
 		capitan.Error(ctx, QueryFailed,
@@ -221,7 +256,7 @@ func (ab *aggregateBuilder[T]) render() (*astql.QueryResult, error) {
 		return nil, fmt.Errorf("%s builder has errors: %w", ab.funcName, ab.err)
 	}
 
-	result, err := ab.builder.Render(ab.soy.renderer())
+	result, err := ab.optional.applyAll(ab.scopes.apply(ab.soy, ab.builder)).Render(ab.soy.renderer())
 	if err != nil {
 		return nil, fmt.Errorf("failed to render %s query: %w", ab.funcName, err)
 	}
@@ -252,6 +287,62 @@ func (ab *Aggregate[T]) Where(field, operator, param string) *Aggregate[T] {
 	return ab
 }
 
+// WhereSearch adds a full-text match of field against the web search query in param.
+// See Query.WhereSearch.
+//
+// Example:
+//
+//	.WhereSearch("body", "q", "english")
+func (ab *Aggregate[T]) WhereSearch(field, param, config string) *Aggregate[T] {
+	if ab.agg.err != nil {
+		return ab
+	}
+	ab.agg.builder, ab.agg.err = whereSearchImpl(ab.agg.instance, ab.agg.builder, field, param, config)
+	return ab
+}
+
+// WhereJSONPath compares the text value at a key of the JSON field with param.
+// See Query.WhereJSONPath.
+//
+// Example:
+//
+//	.WhereJSONPath("metadata", "key", "=", "value")
+func (ab *Aggregate[T]) WhereJSONPath(field, path, operator, param string) *Aggregate[T] {
+	if ab.agg.err != nil {
+		return ab
+	}
+	ab.agg.builder, ab.agg.err = whereJSONPathImpl(ab.agg.instance, ab.agg.builder, field, path, operator, param)
+	return ab
+}
+
+// WhereSimilar adds a trigram similarity match of field against the text in param.
+// See Query.WhereSimilar.
+//
+// Example:
+//
+//	.WhereSimilar("name", "q", "")
+func (ab *Aggregate[T]) WhereSimilar(field, param, threshold string) *Aggregate[T] {
+	if ab.agg.err != nil {
+		return ab
+	}
+	ab.agg.builder, ab.agg.err = whereSimilarImpl(ab.agg.instance, ab.agg.builder, field, param, threshold, false)
+	return ab
+}
+
+// WhereWordSimilar adds a word similarity match of the text in param within field.
+// See Query.WhereWordSimilar.
+//
+// Example:
+//
+//	.WhereWordSimilar("title", "q", "")
+func (ab *Aggregate[T]) WhereWordSimilar(field, param, threshold string) *Aggregate[T] {
+	if ab.agg.err != nil {
+		return ab
+	}
+	ab.agg.builder, ab.agg.err = whereSimilarImpl(ab.agg.instance, ab.agg.builder, field, param, threshold, true)
+	return ab
+}
+
 // WhereAnd adds multiple conditions combined with AND.
 //
 // Example:
@@ -368,6 +459,42 @@ func (ab *Aggregate[T]) WhereFields(leftField, operator, rightField string) *Agg
 	return ab
 }
 
+// WhereOptional adds a WHERE condition that is only applied when param is present
+// and non-nil in the params passed to Exec.
+//
+// Example:
+//
+//	.WhereOptional("status", "=", "status")
+func (ab *Aggregate[T]) WhereOptional(field, operator, param string) *Aggregate[T] {
+	if ab.agg.err != nil {
+		return ab
+	}
+
+	if err := ab.agg.optional.add(ab.agg.instance, false, C(field, operator, param)); err != nil {
+		ab.agg.err = err
+	}
+	return ab
+}
+
+// SkipMissingParams switches the builder to optional-filter mode. Conditions added
+// afterwards with Where, WhereAnd, WhereOr, WhereBetween or WhereNotBetween are removed
+// at execution time when their params are missing or nil.
+// See Query.SkipMissingParams for details.
+func (ab *Aggregate[T]) SkipMissingParams() *Aggregate[T] {
+	ab.agg.optional.skipMissing = true
+	return ab
+}
+
+// Unscoped leaves the named scopes registered with AddScope out of the aggregate, or
+// every scope when no names are given. See Query.Unscoped for details.
+func (ab *Aggregate[T]) Unscoped(names ...string) *Aggregate[T] {
+	if ab.agg.err != nil {
+		return ab
+	}
+	ab.agg.err = ab.agg.scopes.exclude(ab.agg.soy, names)
+	return ab
+}
+
 // Exec executes the aggregate query with values from the provided params map.
 // Returns the result as float64.
 //
@@ -396,6 +523,14 @@ func (ab *Aggregate[T]) ExecTx(ctx context.Context, tx *sqlx.Tx, params map[stri
 	return ab.agg.exec(ctx, tx, params)
 }
 
+// Clone returns an independent copy of the builder, including any stored error.
+func (ab *Aggregate[T]) Clone() *Aggregate[T] {
+	agg := *ab.agg
+	agg.builder = cloneBuilder(ab.agg.builder)
+	agg.optional = ab.agg.optional.clone()
+	return &Aggregate[T]{agg: &agg}
+}
+
 // Render builds and renders the query to SQL with parameter placeholders.
 // Returns the SQL string and list of required parameters.
 // Useful for inspection/debugging before execution.
@@ -77,6 +77,7 @@ package soy
 import (
 	"context"
 	"fmt"
+	"reflect"
 	"strings"
 
 	"github.com/jmoiron/sqlx"
@@ -115,12 +116,17 @@ const (
 type Soy[T any] struct {
 	db          sqlx.ExtContext
 	tableName   string
-	metadata    sentinel.Metadata
+	spec        sentinel.Metadata // as inspected, returned by Metadata
+	metadata    sentinel.Metadata // with value objects flattened into columns
 	instance    *astql.ASTQL
 	sqlRenderer astql.Renderer
 	scanner     *scanner.Scanner
 	onScan      func(ctx context.Context, result *T) error
 	onRecord    func(ctx context.Context, record *T) error
+	stmts       *stmtCache
+	strict      bool
+	tenantScope *tenantScope  // set on instances returned by ForTenant
+	scopes      []*namedScope // registered with AddScope
 }
 
 // New creates a new Soy instance for type T with the given database connection, table name, and SQL renderer.
@@ -146,19 +152,23 @@ func New[T any](db sqlx.ExtContext, tableName string, renderer astql.Renderer) (
 	}
 
 	// Register all tags we use
-	sentinel.Tag("db")
-	sentinel.Tag("type")
-	sentinel.Tag("constraints")
-	sentinel.Tag("default")
-	sentinel.Tag("check")
-	sentinel.Tag("index")
-	sentinel.Tag("references")
+	for _, tag := range columnTags {
+		sentinel.Tag(tag)
+	}
 
 	// Inspect type using Sentinel (cached after first call)
-	metadata := sentinel.Inspect[T]()
+	spec := sentinel.Inspect[T]()
+
+	// Flatten value objects into the columns they map to
+	metadata, err := flattenMetadata(spec, reflect.TypeFor[T]())
+	if err != nil {
+		return nil, fmt.Errorf("soy: failed to flatten fields: %w", err)
+	}
+	registerFlattenedColumns(reflect.TypeFor[T](), metadata)
 
 	// Build DBML from struct metadata
-	project, err := buildDBMLFromStruct(metadata, tableName)
+	d := dialectOf(renderer)
+	project, err := buildDBMLFromStruct(metadata, tableName, d)
 	if err != nil {
 		return nil, fmt.Errorf("soy: failed to build DBML: %w", err)
 	}
@@ -168,16 +178,23 @@ func New[T any](db sqlx.ExtContext, tableName string, renderer astql.Renderer) (
 	if err != nil {
 		return nil, fmt.Errorf("soy: failed to create ASTQL instance: %w", err)
 	}
+	registerSchema(instance, d, project.Tables[d.schema()+"."+tableName], enumColumns(metadata))
 
 	// Build scanner for direct atom scanning from database rows
-	atomScanner, err := scanner.New(metadata)
+	atomScanner, err := scanner.NewWithFields(spec, metadata.Fields)
 	if err != nil {
 		return nil, fmt.Errorf("soy: failed to build scanner: %w", err)
 	}
 
+	// PostgreSQL renders soy's extensions to ASTQL, such as ANY(:param) and array functions
+	if d == dialectPostgres {
+		renderer = extensionRenderer{renderer}
+	}
+
 	c := &Soy[T]{
 		db:          db,
 		tableName:   tableName,
+		spec:        spec,
 		metadata:    metadata,
 		instance:    instance,
 		sqlRenderer: renderer,
@@ -203,8 +220,9 @@ func (c *Soy[T]) getTableName() string {
 }
 
 // Metadata returns the Sentinel metadata for type T.
+// Fields are as declared; value objects are not flattened into their columns.
 func (c *Soy[T]) Metadata() sentinel.Metadata {
-	return c.metadata
+	return c.spec
 }
 
 // renderer returns the SQL renderer for query building.
@@ -217,7 +235,8 @@ func (c *Soy[T]) atomScanner() *scanner.Scanner {
 	return c.scanner
 }
 
-// getMetadata returns the Sentinel metadata for type T.
+// getMetadata returns the Sentinel metadata for type T, with value objects flattened
+// into their columns.
 func (c *Soy[T]) getMetadata() sentinel.Metadata {
 	return c.metadata
 }
@@ -239,6 +258,51 @@ func (c *Soy[T]) OnRecord(fn func(ctx context.Context, record *T) error) {
 	c.onRecord = fn
 }
 
+// EnableStatementCache turns on prepared statement caching for this instance.
+// Rendered SQL is prepared once against the database and reused by later executions,
+// so hot-path queries skip parsing and planning on the server. Transactions reuse the
+// cached statements by re-preparing them on the transaction's connection.
+// At most capacity statements are kept; the least recently used are closed first.
+// Connection errors close every cached statement. Batch operations are not cached.
+//
+// The cache requires the instance to be created with a *sqlx.DB.
+func (c *Soy[T]) EnableStatementCache(capacity int) error {
+	if capacity <= 0 {
+		return fmt.Errorf("soy: statement cache capacity must be positive, got %d", capacity)
+	}
+	db, ok := c.db.(*sqlx.DB)
+	if !ok || db == nil {
+		return fmt.Errorf("soy: statement cache requires a *sqlx.DB, got %T", c.db)
+	}
+	c.stmts.clear()
+	c.stmts = newStmtCache(db, c.tableName, capacity)
+	return nil
+}
+
+// ClearStatementCache closes every cached prepared statement.
+// The cache stays enabled and is refilled by later executions.
+func (c *Soy[T]) ClearStatementCache() {
+	c.stmts.clear()
+}
+
+// statements returns the prepared statement cache, or nil when caching is disabled.
+func (c *Soy[T]) statements() *stmtCache {
+	return c.stmts
+}
+
+// StrictParams controls whether executions reject params the statement does not use.
+// Missing params are always reported as ErrMissingParams before execution; with strict
+// mode on, unused keys are reported as ErrUnknownParams as well, which catches typos in
+// param names. Configure it during initialization, before executing queries.
+func (c *Soy[T]) StrictParams(strict bool) {
+	c.strict = strict
+}
+
+// strictParams reports whether unknown params are rejected.
+func (c *Soy[T]) strictParams() bool {
+	return c.strict
+}
+
 // callOnScan invokes the onScan callback if registered.
 func (c *Soy[T]) callOnScan(ctx context.Context, result any) error {
 	if c.onScan == nil {
@@ -251,16 +315,23 @@ func (c *Soy[T]) callOnScan(ctx context.Context, result any) error {
 	return c.onScan(ctx, r)
 }
 
-// callOnRecord invokes the onRecord callback if registered.
+// callOnRecord invokes the onRecord callback if registered, then stamps the tenant
+// of a scoped instance so the callback cannot change it.
 func (c *Soy[T]) callOnRecord(ctx context.Context, record any) error {
-	if c.onRecord == nil {
+	if c.onRecord == nil && c.tenantScope == nil {
 		return nil
 	}
 	r, ok := record.(*T)
 	if !ok {
 		return fmt.Errorf("callOnRecord: expected *%T, got %T", new(T), record)
 	}
-	return c.onRecord(ctx, r)
+	if c.onRecord != nil {
+		if err := c.onRecord(ctx, r); err != nil {
+			return err
+		}
+	}
+	c.tenantScope.stampRecord(reflect.ValueOf(r))
+	return nil
 }
 
 // Instance returns the underlying ASTQL instance for advanced query building.
@@ -318,7 +389,14 @@ func (c *Soy[T]) Select() *Select[T] {
 		}
 	}
 
-	builder := astql.Select(t)
+	builder, _, err := c.scopeBuilder(astql.Select(t))
+	if err != nil {
+		return &Select[T]{
+			instance: c.instance,
+			soy:      c,
+			err:      err,
+		}
+	}
 
 	return &Select[T]{
 		instance: c.instance,
@@ -367,7 +445,14 @@ func (c *Soy[T]) Query() *Query[T] {
 		}
 	}
 
-	builder := astql.Select(t)
+	builder, _, err := c.scopeBuilder(astql.Select(t))
+	if err != nil {
+		return &Query[T]{
+			instance: c.instance,
+			soy:      c,
+			err:      err,
+		}
+	}
 
 	return &Query[T]{
 		instance: c.instance,
@@ -406,7 +491,17 @@ func (c *Soy[T]) Count() *Aggregate[T] {
 	}
 
 	// Build COUNT query
-	builder := astql.Count(t)
+	builder, _, err := c.scopeBuilder(astql.Count(t))
+	if err != nil {
+		return &Aggregate[T]{
+			agg: &aggregateBuilder[T]{
+				instance: c.instance,
+				soy:      c,
+				funcName: "COUNT",
+				err:      err,
+			},
+		}
+	}
 
 	return &Aggregate[T]{
 		agg: newAggregateBuilder[T](c.instance, builder, c, "", "COUNT"),
@@ -512,6 +607,19 @@ func (c *Soy[T]) buildFieldAggregate(field, funcName string) *Aggregate[T] {
 		}
 	}
 
+	builder, _, err = c.scopeBuilder(builder)
+	if err != nil {
+		return &Aggregate[T]{
+			agg: &aggregateBuilder[T]{
+				instance: c.instance,
+				soy:      c,
+				field:    field,
+				funcName: funcName,
+				err:      err,
+			},
+		}
+	}
+
 	return &Aggregate[T]{
 		agg: newAggregateBuilder[T](c.instance, builder, c, field, funcName),
 	}
@@ -553,9 +661,9 @@ func (c *Soy[T]) Insert() *Create[T] {
 		if dbCol == "" || dbCol == "-" {
 			continue
 		}
-		// Skip primary key columns (they're usually auto-generated)
+		// Skip primary key columns (they're usually auto-generated) and generated columns
 		constraints := field.Tags["constraints"]
-		if contains(constraints, "primarykey") || contains(constraints, "primary_key") {
+		if contains(constraints, "primarykey") || contains(constraints, "primary_key") || generatedColumn(field) {
 			continue
 		}
 
@@ -632,11 +740,11 @@ func (c *Soy[T]) InsertFull() *Create[T] {
 
 	builder := astql.Insert(t)
 
-	// Build VALUES map including ALL columns (including PK)
+	// Build VALUES map including ALL columns (including PK) except generated columns
 	values := c.instance.ValueMap()
 	for _, field := range c.metadata.Fields {
 		dbCol := field.Tags["db"]
-		if dbCol == "" || dbCol == "-" {
+		if dbCol == "" || dbCol == "-" || generatedColumn(field) {
 			continue
 		}
 
@@ -740,11 +848,27 @@ func (c *Soy[T]) Modify() *Update[T] {
 		}
 	}
 
-	return &Update[T]{
+	// Scoped instances only update the tenant's rows; the condition is not a WHERE for
+	// the full-table safety check
+	builder, condition, err := c.scopeBuilder(builder)
+	if err != nil {
+		return &Update[T]{
+			instance: c.instance,
+			soy:      c,
+			err:      err,
+		}
+	}
+
+	update := &Update[T]{
 		instance: c.instance,
 		builder:  builder,
 		soy:      c,
 	}
+	if condition != nil {
+		update.whereItems = append(update.whereItems, condition)
+		update.batch.addKey(c.tenantScope.column, "=", tenantParam)
+	}
+	return update
 }
 
 // Remove returns a Delete for building DELETE queries.
@@ -768,13 +892,26 @@ func (c *Soy[T]) Remove() *Delete[T] {
 		}
 	}
 
-	builder := astql.Delete(t)
+	// Scoped instances only delete the tenant's rows; the condition is not a WHERE for
+	// the full-table safety check
+	builder, condition, err := c.scopeBuilder(astql.Delete(t))
+	if err != nil {
+		return &Delete[T]{
+			instance: c.instance,
+			soy:      c,
+			err:      err,
+		}
+	}
 
-	return &Delete[T]{
+	remove := &Delete[T]{
 		instance: c.instance,
 		builder:  builder,
 		soy:      c,
 	}
+	if condition != nil {
+		remove.batch.addKey(c.tenantScope.column, "=", tenantParam)
+	}
+	return remove
 }
 
 // contains checks if a string contains a substring (case-insensitive).
@@ -3,25 +3,380 @@ package soy
 import (
 	"context"
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/jmoiron/sqlx"
 	"github.com/zoobzio/astql"
 	"github.com/zoobzio/capitan"
+	"github.com/zoobzio/sentinel"
 )
 
+// namedPreparer prepares a named statement once so batch entries reuse it.
+// Both *sqlx.DB and *sqlx.Tx implement it.
+type namedPreparer interface {
+	PrepareNamedContext(ctx context.Context, query string) (*sqlx.NamedStmt, error)
+}
+
+// batchColumn pairs a column with the param that supplies its value.
+type batchColumn struct {
+	field string
+	param string
+}
+
+// batchShape records the structure of an Update or Delete so ExecBatch can
+// render one set-based statement instead of executing once per param map.
+// Only "field = :param" conditions combined with AND and plain Set assignments
+// have a set-based form; anything else marks the shape complex.
+type batchShape struct {
+	keys    []batchColumn // WHERE field = :param conditions
+	sets    []batchColumn // SET field = :param assignments
+	complex bool          // true when the query needs the row-by-row fallback
+}
+
+// addKey records a WHERE condition added with Where.
+func (s *batchShape) addKey(field, operator, param string) {
+	if operator != "=" {
+		s.complex = true
+		return
+	}
+	s.keys = append(s.keys, batchColumn{field: field, param: param})
+}
+
+// addSet records a Set assignment.
+func (s *batchShape) addSet(field, param string) {
+	s.sets = append(s.sets, batchColumn{field: field, param: param})
+}
+
+// setBased reports whether the shape can be rendered as a single statement.
+func (s *batchShape) setBased() bool {
+	return !s.complex && len(s.keys) > 0
+}
+
+// setUpdate reports whether the shape can be rendered as a set-based UPDATE in d.
+func (s *batchShape) setUpdate(d dialect) bool {
+	return d == dialectPostgres && s.setBased() && len(s.sets) > 0
+}
+
+// chunkSize returns how many batch entries one set-based statement can bind within the
+// dialect's parameter limit.
+func (s *batchShape) chunkSize(d dialect) int {
+	return max(d.maxParams()/max(len(s.params()), 1), 1)
+}
+
+// chunkParams returns the indexed params of the n entries from start, reindexed from
+// zero so they bind to a statement rendered for n entries.
+func (s *batchShape) chunkParams(params map[string]any, start, n int) map[string]any {
+	cols := s.params()
+	chunk := make(map[string]any, len(cols)*n)
+	for i := range n {
+		for _, col := range cols {
+			chunk[fmt.Sprintf("%s_%d", col.param, i)] = params[fmt.Sprintf("%s_%d", col.param, start+i)]
+		}
+	}
+	return chunk
+}
+
+// params returns the distinct params of the shape in first-use order (sets, then keys).
+func (s *batchShape) params() []batchColumn {
+	seen := make(map[string]bool, len(s.sets)+len(s.keys))
+	var params []batchColumn
+	for _, col := range append(append([]batchColumn{}, s.sets...), s.keys...) {
+		if seen[col.param] {
+			continue
+		}
+		seen[col.param] = true
+		params = append(params, col)
+	}
+	return params
+}
+
+// bindParams copies each batch entry's values into indexed params (param_index),
+// encoding registered custom types. Every entry with missing params, unknown params
+// in strict mode, values outside an enum column, or values that fail to encode is
+// reported in a BatchError before anything executes.
+func (s *batchShape) bindParams(d dialect, operation string, batchParams []map[string]any, strict bool, enums enumBindings) (map[string]any, error) {
+	params := s.params()
+	names := make([]string, len(params))
+	for i, col := range params {
+		names[i] = col.param
+	}
+
+	bound := make(map[string]any, len(params)*len(batchParams))
+	var failures []IndexError
+
+	for i, entry := range batchParams {
+		if err := validateParams(names, entry, strict); err != nil {
+			failures = append(failures, IndexError{Index: i, Err: err})
+			continue
+		}
+		if err := enums.validate(entry); err != nil {
+			failures = append(failures, IndexError{Index: i, Err: err})
+			continue
+		}
+		encoded, err := encodeParams(d, entry)
+		if err != nil {
+			failures = append(failures, IndexError{Index: i, Err: err})
+			continue
+		}
+		for _, col := range params {
+			bound[fmt.Sprintf("%s_%d", col.param, i)] = encoded[col.param]
+		}
+	}
+
+	if len(failures) > 0 {
+		return nil, &BatchError{Operation: operation, Errors: failures}
+	}
+	return bound, nil
+}
+
+// renderSetUpdate renders a set-based UPDATE joined against a VALUES list.
+// Only PostgreSQL supports UPDATE ... FROM (VALUES ...) with column aliases; other
+// dialects report false and use the prepared-statement fallback.
+// Values are cast to their column types because untyped VALUES params resolve to text.
+func (s *batchShape) renderSetUpdate(d dialect, tableName string, metadata sentinel.Metadata, n int) (string, bool) {
+	if !s.setUpdate(d) {
+		return "", false
+	}
+
+	types := postgresCastTypes(tableName, metadata)
+	params := s.params()
+	alias := d.quote("soy_batch")
+
+	rows := make([]string, n)
+	for i := range n {
+		values := make([]string, len(params))
+		for j, col := range params {
+			values[j] = fmt.Sprintf("CAST(:%s_%d AS %s)", col.param, i, types[col.field])
+		}
+		rows[i] = "(" + strings.Join(values, ", ") + ")"
+	}
+
+	paramNames := make([]string, len(params))
+	for i, col := range params {
+		paramNames[i] = col.param
+	}
+
+	assignments := make([]string, len(s.sets))
+	for i, col := range s.sets {
+		assignments[i] = fmt.Sprintf("%s = %s.%s", d.quote(col.field), alias, d.quote(col.param))
+	}
+
+	conds := make([]string, len(s.keys))
+	for i, col := range s.keys {
+		conds[i] = fmt.Sprintf("%s.%s = %s.%s", d.quote(tableName), d.quote(col.field), alias, d.quote(col.param))
+	}
+
+	return "UPDATE " + d.quote(tableName) +
+		" SET " + strings.Join(assignments, ", ") +
+		" FROM (VALUES " + strings.Join(rows, ", ") + ") AS " + alias + " (" + d.quoteAll(paramNames) + ")" +
+		" WHERE " + strings.Join(conds, " AND "), true
+}
+
+// renderSetDelete renders a set-based DELETE matching any entry's keys.
+// A single key uses IN; composite keys use an OR of per-entry AND groups, which every dialect supports.
+func (s *batchShape) renderSetDelete(d dialect, tableName string, n int) (string, bool) {
+	if !s.setBased() {
+		return "", false
+	}
+
+	var where string
+	if len(s.keys) == 1 {
+		key := s.keys[0]
+		values := make([]string, n)
+		for i := range n {
+			values[i] = fmt.Sprintf(":%s_%d", key.param, i)
+		}
+		where = d.quote(key.field) + " IN (" + strings.Join(values, ", ") + ")"
+	} else {
+		groups := make([]string, n)
+		for i := range n {
+			conds := make([]string, len(s.keys))
+			for j, key := range s.keys {
+				conds[j] = fmt.Sprintf("%s = :%s_%d", d.quote(key.field), key.param, i)
+			}
+			groups[i] = "(" + strings.Join(conds, " AND ") + ")"
+		}
+		where = strings.Join(groups, " OR ")
+	}
+
+	return "DELETE FROM " + d.quote(tableName) + " WHERE " + where, true
+}
+
+// postgresCastTypes maps columns to the type used when casting batch values.
+// Serial pseudo-types are not valid in casts, so they map to their integer types, and
+// untyped enum columns map to the native enum type of the table.
+func postgresCastTypes(tableName string, metadata sentinel.Metadata) map[string]string {
+	types := make(map[string]string, len(metadata.Fields))
+	for _, field := range metadata.Fields {
+		dbCol := field.Tags["db"]
+		if dbCol == "" || dbCol == "-" {
+			continue
+		}
+		sqlType := field.Tags["type"]
+		if _, ok := field.Tags["enum"]; ok && sqlType == "" {
+			types[dbCol] = dialectPostgres.quote(enumTypeName(tableName, dbCol))
+			continue
+		}
+		if sqlType == "" {
+			// New has already rejected fields whose type cannot be inferred.
+			sqlType, _ = inferSQLType(dialectPostgres, field.ReflectType)
+		}
+		switch strings.ToUpper(sqlType) {
+		case "SERIAL":
+			sqlType = "INTEGER"
+		case "BIGSERIAL":
+			sqlType = "BIGINT"
+		case "SMALLSERIAL":
+			sqlType = "SMALLINT"
+		}
+		types[dbCol] = sqlType
+	}
+	return types
+}
+
+// executeSetBatch executes a set-based batch statement for the n entries bound in params.
+// Batches that would exceed the dialect's parameter limit are split into chunks, each
+// executed with the statement render returns for its size. Chunks run in one transaction
+// when execer can begin one.
+func executeSetBatch(
+	ctx context.Context,
+	execer sqlx.ExtContext,
+	d dialect,
+	shape *batchShape,
+	render func(n int) string,
+	params map[string]any,
+	n int,
+	tableName string,
+	operation string,
+) (int64, error) {
+	size := shape.chunkSize(d)
+	if n <= size {
+		return executeSetStatement(ctx, execer, render(n), params, tableName, operation)
+	}
+
+	beginner, ok := execer.(txBeginner)
+	if !ok {
+		return executeSetChunks(ctx, execer, shape, render, params, n, size, tableName, operation)
+	}
+
+	tx, err := beginner.BeginTxx(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin batch %s transaction: %w", operation, err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	affected, err := executeSetChunks(ctx, tx, shape, render, params, n, size, tableName, operation)
+	if err != nil {
+		return 0, err
+	}
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("failed to commit batch %s transaction: %w", operation, err)
+	}
+	return affected, nil
+}
+
+// executeSetChunks executes the set-based statement for each chunk of at most size
+// entries and adds up the rows they affect.
+func executeSetChunks(
+	ctx context.Context,
+	execer sqlx.ExtContext,
+	shape *batchShape,
+	render func(n int) string,
+	params map[string]any,
+	n, size int,
+	tableName string,
+	operation string,
+) (int64, error) {
+	// Every chunk but the last has the same size, so at most two statements are rendered
+	queries := make(map[int]string, 2)
+	var total int64
+	for start := 0; start < n; start += size {
+		count := min(size, n-start)
+		query, ok := queries[count]
+		if !ok {
+			query = render(count)
+			queries[count] = query
+		}
+		affected, err := executeSetStatement(ctx, execer, query, shape.chunkParams(params, start, count), tableName, operation)
+		if err != nil {
+			return 0, fmt.Errorf("batch %s of entries %d to %d: %w", operation, start, start+count-1, err)
+		}
+		total += affected
+	}
+	return total, nil
+}
+
+// executeSetStatement executes a set-based batch statement once with indexed params.
+func executeSetStatement(
+	ctx context.Context,
+	execer sqlx.ExtContext,
+	query string,
+	params map[string]any,
+	tableName string,
+	operation string,
+) (int64, error) {
+	capitan.Debug(ctx, QueryStarted,
+		TableKey.Field(tableName),
+		OperationKey.Field(operation+"_BATCH"),
+		SQLKey.Field(query),
+	)
+
+	startTime := time.Now()
+
+	res, err := sqlx.NamedExecContext(ctx, execer, query, params)
+	if err != nil {
+		durationMs := time.Since(startTime).Milliseconds()
+		capitan.Error(ctx, QueryFailed,
+			TableKey.Field(tableName),
+			OperationKey.Field(operation+"_BATCH"),
+			DurationMsKey.Field(durationMs),
+			ErrorKey.Field(err.Error()),
+		)
+		return 0, fmt.Errorf("batch %s failed: %w", operation, err)
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		durationMs := time.Since(startTime).Milliseconds()
+		capitan.Error(ctx, QueryFailed,
+			TableKey.Field(tableName),
+			OperationKey.Field(operation+"_BATCH"),
+			DurationMsKey.Field(durationMs),
+			ErrorKey.Field(err.Error()),
+		)
+		return 0, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	// Emit query completed event
+	durationMs := time.Since(startTime).Milliseconds()
+	capitan.Info(ctx, QueryCompleted,
+		TableKey.Field(tableName),
+		OperationKey.Field(operation+"_BATCH"),
+		DurationMsKey.Field(durationMs),
+		RowsAffectedKey.Field(affected),
+	)
+
+	return affected, nil
+}
+
 // executeBatch is a shared helper for batch execution logic used by Update and Delete.
-// It handles rendering, logging, execution, and error reporting for batch operations.
+// It handles rendering, logging, execution, and error reporting for batch operations
+// that have no set-based form. The statement is prepared once and reused for every
+// param map when the executor supports it. When execer can begin a transaction the batch
+// runs in one, so a failing entry rolls back the entries before it.
 func executeBatch(
 	ctx context.Context,
 	execer sqlx.ExtContext,
 	batchParams []map[string]any,
 	builder *astql.Builder,
+	enums enumBindings,
 	renderer astql.Renderer,
 	tableName string,
 	operation string,
 	hasWhere bool,
 	builderErr error,
+	strict bool,
 ) (int64, error) {
 	// Check for builder errors first
 	if builderErr != nil {
@@ -43,6 +398,26 @@ func executeBatch(
 		return 0, fmt.Errorf("failed to render %s query: %w", operation, err)
 	}
 
+	// Check and encode every entry's params before executing any of them
+	var failures []IndexError
+	encoded := make([]map[string]any, len(batchParams))
+	for i, params := range batchParams {
+		if err := validateParams(result.RequiredParams, params, strict); err != nil {
+			failures = append(failures, IndexError{Index: i, Err: err})
+			continue
+		}
+		if err := enums.validate(params); err != nil {
+			failures = append(failures, IndexError{Index: i, Err: err})
+			continue
+		}
+		if encoded[i], err = encodeParams(dialectOf(renderer), params); err != nil {
+			failures = append(failures, IndexError{Index: i, Err: err})
+		}
+	}
+	if len(failures) > 0 {
+		return 0, &BatchError{Operation: operation, Errors: failures}
+	}
+
 	// Emit query started event
 	capitan.Debug(ctx, QueryStarted,
 		TableKey.Field(tableName),
@@ -52,20 +427,42 @@ func executeBatch(
 
 	startTime := time.Now()
 
-	var totalAffected int64
-	for i, params := range batchParams {
+	// A bare connection pool runs the batch in a transaction so it is all or nothing
+	var tx *sqlx.Tx
+	if beginner, ok := execer.(txBeginner); ok {
+		tx, err = beginner.BeginTxx(ctx, nil)
+		if err != nil {
+			return 0, fmt.Errorf("failed to begin batch %s transaction: %w", operation, err)
+		}
+		defer func() { _ = tx.Rollback() }()
+		execer = tx
+	}
+
+	fail := func(affected int64, index int, err error) (int64, error) {
+		if tx != nil {
+			// The rows affected so far are rolled back
+			affected = 0
+		}
+		durationMs := time.Since(startTime).Milliseconds()
+		capitan.Error(ctx, QueryFailed,
+			TableKey.Field(tableName),
+			OperationKey.Field(operation+"_BATCH"),
+			DurationMsKey.Field(durationMs),
+			ErrorKey.Field(err.Error()),
+		)
+		return affected, &BatchError{Operation: operation, Affected: affected, Errors: []IndexError{{Index: index, Err: err}}}
+	}
+
+	// Prepare once when possible; otherwise fall back to a named exec per entry
+	exec := func(params map[string]any) (int64, error) {
 		res, err := sqlx.NamedExecContext(ctx, execer, result.SQL, params)
 		if err != nil {
-			durationMs := time.Since(startTime).Milliseconds()
-			capitan.Error(ctx, QueryFailed,
-				TableKey.Field(tableName),
-				OperationKey.Field(operation+"_BATCH"),
-				DurationMsKey.Field(durationMs),
-				ErrorKey.Field(err.Error()),
-			)
-			return totalAffected, fmt.Errorf("batch %s failed at index %d after %d rows: %w", operation, i, totalAffected, err)
+			return 0, err
 		}
-		affected, err := res.RowsAffected()
+		return res.RowsAffected()
+	}
+	if preparer, ok := execer.(namedPreparer); ok {
+		stmt, err := preparer.PrepareNamedContext(ctx, result.SQL)
 		if err != nil {
 			durationMs := time.Since(startTime).Milliseconds()
 			capitan.Error(ctx, QueryFailed,
@@ -74,11 +471,33 @@ func executeBatch(
 				DurationMsKey.Field(durationMs),
 				ErrorKey.Field(err.Error()),
 			)
-			return totalAffected, fmt.Errorf("failed to get rows affected: %w", err)
+			return 0, fmt.Errorf("failed to prepare batch %s: %w", operation, err)
+		}
+		defer func() { _ = stmt.Close() }()
+		exec = func(params map[string]any) (int64, error) {
+			res, err := stmt.ExecContext(ctx, params)
+			if err != nil {
+				return 0, err
+			}
+			return res.RowsAffected()
+		}
+	}
+
+	var totalAffected int64
+	for i, params := range encoded {
+		affected, err := exec(params)
+		if err != nil {
+			return fail(totalAffected, i, err)
 		}
 		totalAffected += affected
 	}
 
+	if tx != nil {
+		if err := tx.Commit(); err != nil {
+			return 0, fmt.Errorf("failed to commit batch %s transaction: %w", operation, err)
+		}
+	}
+
 	// Emit query completed event
 	durationMs := time.Since(startTime).Milliseconds()
 	capitan.Info(ctx, QueryCompleted,
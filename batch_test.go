@@ -3,10 +3,17 @@ package soy
 import (
 	"context"
 	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
 	"testing"
 
 	"github.com/jmoiron/sqlx"
 	"github.com/zoobzio/astql"
+	"github.com/zoobzio/astql/mariadb"
+	"github.com/zoobzio/astql/mssql"
 	"github.com/zoobzio/astql/postgres"
 )
 
@@ -34,7 +41,7 @@ func TestExecuteBatch_RequiresWhere(t *testing.T) {
 	}
 
 	ctx := context.Background()
-	_, err = executeBatch(ctx, db, batchParams, builder, postgres.New(), "users", "UPDATE", false, nil)
+	_, err = executeBatch(ctx, db, batchParams, builder, nil, postgres.New(), "users", "UPDATE", false, nil, false)
 
 	if err == nil {
 		t.Error("executeBatch() should error without WHERE clause")
@@ -55,7 +62,7 @@ func TestExecuteBatch_EmptyBatch(t *testing.T) {
 	var batchParams []map[string]any
 
 	ctx := context.Background()
-	affected, err := executeBatch(ctx, db, batchParams, builder, postgres.New(), "users", "UPDATE", true, nil)
+	affected, err := executeBatch(ctx, db, batchParams, builder, nil, postgres.New(), "users", "UPDATE", true, nil, false)
 
 	if err != nil {
 		t.Errorf("executeBatch() error = %v", err)
@@ -84,9 +91,265 @@ func TestExecuteBatch_BuilderError(t *testing.T) {
 	builderErr := sql.ErrNoRows
 
 	ctx := context.Background()
-	_, err = executeBatch(ctx, db, batchParams, builder, postgres.New(), "users", "UPDATE", true, builderErr)
+	_, err = executeBatch(ctx, db, batchParams, builder, nil, postgres.New(), "users", "UPDATE", true, builderErr, false)
 
 	if err == nil {
 		t.Error("executeBatch() should propagate builder error")
 	}
 }
+
+func TestBatchShape_SetUpdate(t *testing.T) {
+	db := &sqlx.DB{}
+
+	soy, err := New[batchTestUser](db, "users", postgres.New())
+	if err != nil {
+		t.Fatalf("New() failed: %v", err)
+	}
+
+	t.Run("renders UPDATE FROM VALUES", func(t *testing.T) {
+		ub := soy.Modify().Set("name", "new_name").Where("id", "=", "user_id")
+
+		query, ok := ub.batch.renderSetUpdate(dialectPostgres, "users", soy.metadata, 2)
+		if !ok {
+			t.Fatal("expected set-based UPDATE")
+		}
+
+		want := `UPDATE "users" SET "name" = "soy_batch"."new_name"` +
+			` FROM (VALUES (CAST(:new_name_0 AS text), CAST(:user_id_0 AS integer)), (CAST(:new_name_1 AS text), CAST(:user_id_1 AS integer)))` +
+			` AS "soy_batch" ("new_name", "user_id")` +
+			` WHERE "users"."id" = "soy_batch"."user_id"`
+		if query != want {
+			t.Errorf("query =\n%s\nwant\n%s", query, want)
+		}
+	})
+
+	t.Run("other dialects fall back", func(t *testing.T) {
+		ub := soy.Modify().Set("name", "new_name").Where("id", "=", "user_id")
+
+		if _, ok := ub.batch.renderSetUpdate(dialectMariaDB, "users", soy.metadata, 2); ok {
+			t.Error("expected MariaDB to use the prepared-statement fallback")
+		}
+	})
+
+	t.Run("SetExpr falls back", func(t *testing.T) {
+		ub := soy.Modify().SetExpr("age", "+", "increment").Where("id", "=", "user_id")
+
+		if _, ok := ub.batch.renderSetUpdate(dialectPostgres, "users", soy.metadata, 2); ok {
+			t.Error("expected SetExpr to use the prepared-statement fallback")
+		}
+	})
+
+	t.Run("non-equality WHERE falls back", func(t *testing.T) {
+		ub := soy.Modify().Set("name", "new_name").Where("age", ">", "min_age")
+
+		if _, ok := ub.batch.renderSetUpdate(dialectPostgres, "users", soy.metadata, 2); ok {
+			t.Error("expected non-equality WHERE to use the prepared-statement fallback")
+		}
+	})
+}
+
+func TestBatchShape_SetDelete(t *testing.T) {
+	db := &sqlx.DB{}
+
+	soy, err := New[batchTestUser](db, "users", postgres.New())
+	if err != nil {
+		t.Fatalf("New() failed: %v", err)
+	}
+
+	t.Run("single key uses IN", func(t *testing.T) {
+		del := soy.Remove().Where("id", "=", "user_id")
+
+		query, ok := del.batch.renderSetDelete(dialectPostgres, "users", 3)
+		if !ok {
+			t.Fatal("expected set-based DELETE")
+		}
+
+		want := `DELETE FROM "users" WHERE "id" IN (:user_id_0, :user_id_1, :user_id_2)`
+		if query != want {
+			t.Errorf("query = %s, want %s", query, want)
+		}
+	})
+
+	t.Run("composite key uses OR groups", func(t *testing.T) {
+		del := soy.Remove().Where("email", "=", "email").Where("name", "=", "name")
+
+		query, ok := del.batch.renderSetDelete(dialectMSSQL, "users", 2)
+		if !ok {
+			t.Fatal("expected set-based DELETE")
+		}
+
+		want := `DELETE FROM [users] WHERE ([email] = :email_0 AND [name] = :name_0) OR ([email] = :email_1 AND [name] = :name_1)`
+		if query != want {
+			t.Errorf("query = %s, want %s", query, want)
+		}
+	})
+
+	t.Run("WhereOr falls back", func(t *testing.T) {
+		del := soy.Remove().WhereOr(C("id", "=", "a"), C("id", "=", "b"))
+
+		if _, ok := del.batch.renderSetDelete(dialectPostgres, "users", 2); ok {
+			t.Error("expected WhereOr to use the prepared-statement fallback")
+		}
+	})
+}
+
+func TestBatchShape_BindParams(t *testing.T) {
+	shape := batchShape{}
+	shape.addSet("name", "new_name")
+	shape.addKey("id", "=", "user_id")
+
+	t.Run("indexes params", func(t *testing.T) {
+		params, err := shape.bindParams(dialectPostgres, "UPDATE", []map[string]any{
+			{"new_name": "A", "user_id": 1},
+			{"new_name": "B", "user_id": 2},
+		}, false, nil)
+		if err != nil {
+			t.Fatalf("bindParams() failed: %v", err)
+		}
+		if params["new_name_1"] != "B" || params["user_id_0"] != 1 {
+			t.Errorf("unexpected params: %v", params)
+		}
+	})
+
+	t.Run("reports every missing index", func(t *testing.T) {
+		_, err := shape.bindParams(dialectPostgres, "UPDATE", []map[string]any{
+			{"new_name": "A"},
+			{"new_name": "B", "user_id": 2},
+			{"user_id": 3},
+		}, false, nil)
+
+		var bErr *BatchError
+		if !errors.As(err, &bErr) {
+			t.Fatalf("expected BatchError, got %v", err)
+		}
+		if len(bErr.Errors) != 2 || bErr.Errors[0].Index != 0 || bErr.Errors[1].Index != 2 {
+			t.Errorf("unexpected index errors: %v", bErr.Errors)
+		}
+	})
+}
+
+func TestExecBatch_Chunked(t *testing.T) {
+	drv := &nearestDriver{}
+	name := fmt.Sprintf("soy_batch_%d", verifyDriverSeq.Add(1))
+	sql.Register(name, drv)
+	db, err := sqlx.Open(name, "")
+	if err != nil {
+		t.Fatalf("sqlx.Open() failed: %v", err)
+	}
+	t.Cleanup(func() { _ = db.Close() })
+
+	// placeholders counts the bound params of each executed statement.
+	placeholders := func() []int {
+		var counts []int
+		for _, stmt := range drv.log {
+			if stmt != "BEGIN" && stmt != "COMMIT" && stmt != "ROLLBACK" {
+				counts = append(counts, strings.Count(stmt, "?"))
+			}
+		}
+		return counts
+	}
+
+	t.Run("PostgreSQL update", func(t *testing.T) {
+		drv.log = nil
+		s, err := New[batchTestUser](db, "users", postgres.New())
+		if err != nil {
+			t.Fatalf("New() failed: %v", err)
+		}
+		// Three params per entry: 21845 entries fit in 65535 params
+		batch := make([]map[string]any, 30000)
+		for i := range batch {
+			batch[i] = map[string]any{"name": "n", "age": i, "id": i}
+		}
+		if _, err := s.Modify().Set("name", "name").Set("age", "age").Where("id", "=", "id").ExecBatch(t.Context(), batch); err != nil {
+			t.Fatalf("ExecBatch() failed: %v", err)
+		}
+		if got := placeholders(); !slices.Equal(got, []int{21845 * 3, 8155 * 3}) {
+			t.Errorf("params per statement = %v", got)
+		}
+		if drv.log[0] != "BEGIN" || drv.log[len(drv.log)-1] != "COMMIT" {
+			t.Errorf("expected the chunks in one transaction, got %d statements", len(drv.log))
+		}
+	})
+
+	t.Run("SQL Server delete", func(t *testing.T) {
+		drv.log = nil
+		s, err := New[batchTestUser](db, "users", mssql.New())
+		if err != nil {
+			t.Fatalf("New() failed: %v", err)
+		}
+		batch := make([]map[string]any, 5000)
+		for i := range batch {
+			batch[i] = map[string]any{"id": i}
+		}
+		if _, err := s.Remove().Where("id", "=", "id").ExecBatch(t.Context(), batch); err != nil {
+			t.Fatalf("ExecBatch() failed: %v", err)
+		}
+		if got := placeholders(); !slices.Equal(got, []int{2100, 2100, 800}) {
+			t.Errorf("params per statement = %v", got)
+		}
+		if drv.log[0] != "BEGIN" || drv.log[len(drv.log)-1] != "COMMIT" {
+			t.Errorf("expected the chunks in one transaction, got %q", drv.log[len(drv.log)-1])
+		}
+
+		drv.log = nil
+		if _, err := s.Remove().Where("id", "=", "id").ExecBatch(t.Context(), batch[:3]); err != nil {
+			t.Fatalf("ExecBatch() failed: %v", err)
+		}
+		if len(drv.log) != 1 {
+			t.Errorf("a batch within the limit should run as one statement, got %d", len(drv.log))
+		}
+	})
+}
+
+func TestExecBatch_FallbackTransaction(t *testing.T) {
+	drv := &nearestDriver{execErr: func(args []driver.Value) error {
+		if len(args) > 0 && args[len(args)-1] == int64(2) {
+			return errors.New("constraint violation")
+		}
+		return nil
+	}}
+	name := fmt.Sprintf("soy_batch_%d", verifyDriverSeq.Add(1))
+	sql.Register(name, drv)
+	db, err := sqlx.Open(name, "")
+	if err != nil {
+		t.Fatalf("sqlx.Open() failed: %v", err)
+	}
+	t.Cleanup(func() { _ = db.Close() })
+
+	s, err := New[batchTestUser](db, "users", mariadb.New())
+	if err != nil {
+		t.Fatalf("New() failed: %v", err)
+	}
+	// MariaDB has no set-based UPDATE, so every entry runs the prepared statement
+	update := s.Modify().Set("name", "name").Where("id", "=", "id")
+
+	_, err = update.ExecBatch(t.Context(), []map[string]any{{"name": "a", "id": 1}, {"name": "b", "id": 2}, {"name": "c", "id": 3}})
+	var bErr *BatchError
+	if !errors.As(err, &bErr) || bErr.Errors[0].Index != 1 || bErr.Affected != 0 {
+		t.Fatalf("ExecBatch() error = %v", err)
+	}
+	if len(drv.log) != 4 || drv.log[0] != "BEGIN" || drv.log[3] != "ROLLBACK" {
+		t.Errorf("expected the failed batch to roll back, got %q", drv.log)
+	}
+
+	drv.log = nil
+	if _, err := update.ExecBatch(t.Context(), []map[string]any{{"name": "a", "id": 1}, {"name": "c", "id": 3}}); err != nil {
+		t.Fatalf("ExecBatch() failed: %v", err)
+	}
+	if len(drv.log) != 4 || drv.log[0] != "BEGIN" || drv.log[3] != "COMMIT" {
+		t.Errorf("expected the batch in one transaction, got %q", drv.log)
+	}
+
+	drv.log = nil
+	tx, err := db.Beginx()
+	if err != nil {
+		t.Fatalf("Beginx() failed: %v", err)
+	}
+	if _, err := update.ExecBatchTx(t.Context(), tx, []map[string]any{{"name": "a", "id": 1}}); err != nil {
+		t.Fatalf("ExecBatchTx() failed: %v", err)
+	}
+	_ = tx.Rollback()
+	if len(drv.log) != 3 || drv.log[0] != "BEGIN" || drv.log[2] != "ROLLBACK" {
+		t.Errorf("ExecBatchTx should use the caller's transaction, got %q", drv.log)
+	}
+}
@@ -3,8 +3,10 @@ package soy
 import (
 	"fmt"
 	"strings"
+	"sync"
 
 	"github.com/zoobzio/astql"
+	"github.com/zoobzio/dbml"
 )
 
 // operatorMap translates string operators to ASTQL operators.
@@ -38,12 +40,51 @@ var operatorMap = map[string]astql.Operator{
 	"<@": astql.ArrayContainedBy,
 	"&&": astql.ArrayOverlap,
 
+	// Quantified comparisons against an array param (PostgreSQL).
+	// "= ANY" renders as field = ANY(:param).
+	"= ANY":         "= ANY",
+	"!= ANY":        "!= ANY",
+	"> ANY":         "> ANY",
+	">= ANY":        ">= ANY",
+	"< ANY":         "< ANY",
+	"<= ANY":        "<= ANY",
+	"LIKE ANY":      "LIKE ANY",
+	"ILIKE ANY":     "ILIKE ANY",
+	"= ALL":         "= ALL",
+	"!= ALL":        "!= ALL",
+	"> ALL":         "> ALL",
+	">= ALL":        ">= ALL",
+	"< ALL":         "< ALL",
+	"<= ALL":        "<= ALL",
+	"NOT LIKE ALL":  "NOT LIKE ALL",
+	"NOT ILIKE ALL": "NOT ILIKE ALL",
+
+	// JSON operators (PostgreSQL). These are only valid on json and jsonb columns.
+	// @? is the operator form of jsonb_path_exists.
+	"->":  "->",
+	"->>": "->>",
+	"#>":  "#>",
+	"#>>": "#>>",
+	"?":   "?",
+	"?|":  "?|",
+	"?&":  "?&",
+	"@?":  "@?",
+
 	// Vector operators (pgvector).
 	"<->": astql.VectorL2Distance,
 	"<#>": astql.VectorInnerProduct,
 	"<=>": astql.VectorCosineDistance,
 	"<+>": astql.VectorL1Distance,
 
+	// Trigram operators (pg_trgm). On text columns % is the similarity match and <->
+	// the trigram distance; the others are the word and strict word similarity forms.
+	"<%":   "<%",
+	"%>":   "%>",
+	"<<%":  "<<%",
+	"%>>":  "%>>",
+	"<<->": "<<->",
+	"<->>": "<->>",
+
 	// Arithmetic operators.
 	"+": "+",
 	"-": "-",
@@ -52,6 +93,68 @@ var operatorMap = map[string]astql.Operator{
 	"%": "%",
 }
 
+// jsonOperators are the operators that require a json or jsonb column.
+var jsonOperators = map[string]bool{
+	"->": true, "->>": true, "#>": true, "#>>": true,
+	"?": true, "?|": true, "?&": true, "@?": true,
+}
+
+// jsonPathOperators are the JSON operators that extract a value rather than test one, so
+// they cannot be conditions. WhereJSONPath compares the value at a key instead.
+var jsonPathOperators = map[string]bool{
+	"->": true, "->>": true, "#>": true, "#>>": true,
+}
+
+// quantifiedOperators are the ANY and ALL comparisons, which require PostgreSQL.
+var quantifiedOperators = map[string]bool{
+	"= ANY": true, "!= ANY": true, "> ANY": true, ">= ANY": true, "< ANY": true, "<= ANY": true,
+	"LIKE ANY": true, "ILIKE ANY": true,
+	"= ALL": true, "!= ALL": true, "> ALL": true, ">= ALL": true, "< ALL": true, "<= ALL": true,
+	"NOT LIKE ALL": true, "NOT ILIKE ALL": true,
+}
+
+// instanceSchema records what the validators need to know about the table behind an
+// ASTQL instance that ASTQL itself does not expose.
+type instanceSchema struct {
+	dialect     dialect
+	columnTypes map[string]string   // column name -> SQL type
+	enums       map[string][]string // column name -> values allowed by its enum tag
+}
+
+// instanceSchemas maps each ASTQL instance created by New to its schema.
+var instanceSchemas sync.Map
+
+// registerSchema records the dialect, column types and enum values of an instance's table.
+func registerSchema(instance *astql.ASTQL, d dialect, table *dbml.Table, enums map[string][]string) {
+	if table == nil {
+		return
+	}
+	schema := &instanceSchema{dialect: d, columnTypes: make(map[string]string, len(table.Columns)), enums: enums}
+	for _, col := range table.Columns {
+		schema.columnTypes[col.Name] = col.Type
+	}
+	instanceSchemas.Store(instance, schema)
+}
+
+// schemaOf returns the schema registered for instance, or nil.
+func schemaOf(instance *astql.ASTQL) *instanceSchema {
+	schema, ok := instanceSchemas.Load(instance)
+	if !ok {
+		return nil
+	}
+	return schema.(*instanceSchema)
+}
+
+// isJSON reports whether column is a json or jsonb column.
+func (s *instanceSchema) isJSON(column string) bool {
+	return strings.HasPrefix(strings.ToLower(s.columnTypes[column]), "json")
+}
+
+// isArray reports whether column is a native array column.
+func (s *instanceSchema) isArray(column string) bool {
+	return strings.HasSuffix(s.columnTypes[column], "[]")
+}
+
 // directionMap translates string directions to ASTQL directions.
 var directionMap = map[string]astql.Direction{
 	"asc":  astql.ASC,
@@ -73,6 +176,72 @@ func validateOperator(op string) (astql.Operator, error) {
 	return astqlOp, nil
 }
 
+// validateFieldOperator converts a string operator to ASTQL operator and checks that
+// operators restricted to a column type, such as the JSON operators, are applied to
+// a column of that type in a dialect that supports them.
+func validateFieldOperator(instance *astql.ASTQL, field, op string) (astql.Operator, error) {
+	astqlOp, err := validateOperator(op)
+	if err != nil {
+		return "", err
+	}
+	if !jsonOperators[op] && !quantifiedOperators[op] && !trigramOperators[op] {
+		return astqlOp, nil
+	}
+	schema := schemaOf(instance)
+	if schema == nil {
+		return astqlOp, nil
+	}
+	if quantifiedOperators[op] || trigramOperators[op] {
+		if schema.dialect != dialectPostgres {
+			return "", newOperatorUsageError(op, fmt.Sprintf("operator %q requires PostgreSQL", op))
+		}
+		return astqlOp, nil
+	}
+	if schema.dialect != dialectPostgres {
+		return "", newOperatorUsageError(op, fmt.Sprintf("JSON operator %q requires PostgreSQL", op))
+	}
+	if column := columnName(field); !schema.isJSON(column) {
+		return "", newOperatorUsageError(op, fmt.Sprintf("JSON operator %q requires a json or jsonb column, %q is %s", op, column, schema.columnTypes[column]))
+	}
+	return astqlOp, nil
+}
+
+// validateArrayField checks that field is a native array column, which requires PostgreSQL.
+func validateArrayField(instance *astql.ASTQL, field string) error {
+	schema := schemaOf(instance)
+	if schema == nil {
+		return nil
+	}
+	if schema.dialect != dialectPostgres {
+		return newFieldUsageError(field, fmt.Sprintf("array column %q requires PostgreSQL", field))
+	}
+	if column := columnName(field); !schema.isArray(column) {
+		return newFieldUsageError(field, fmt.Sprintf("%q is not an array column, it is %s", column, schema.columnTypes[column]))
+	}
+	return nil
+}
+
+// validateConditionOperator is validateFieldOperator for the operator of a condition.
+// It also rejects the JSON path operators, whose result is a JSON or text value rather
+// than a boolean.
+func validateConditionOperator(instance *astql.ASTQL, field, op string) (astql.Operator, error) {
+	if jsonPathOperators[op] {
+		return "", newOperatorUsageError(op, fmt.Sprintf("JSON operator %q extracts a value and cannot be a condition, use WhereJSONPath", op))
+	}
+	return validateFieldOperator(instance, field, op)
+}
+
+// columnName strips a table alias and an AS alias from a field reference.
+func columnName(field string) string {
+	if i := strings.Index(strings.ToUpper(field), " AS "); i != -1 {
+		field = field[:i]
+	}
+	if i := strings.LastIndex(field, "."); i != -1 {
+		field = field[i+1:]
+	}
+	return strings.TrimSpace(field)
+}
+
 // validateDirection converts a string direction to ASTQL direction.
 func validateDirection(dir string) (astql.Direction, error) {
 	lower := strings.ToLower(dir)
@@ -155,7 +324,7 @@ func fieldsImpl(instance *astql.ASTQL, builder *astql.Builder, fields ...string)
 
 // whereImpl adds a WHERE condition.
 func whereImpl(instance *astql.ASTQL, builder *astql.Builder, field, operator, param string) (*astql.Builder, error) {
-	astqlOp, err := validateOperator(operator)
+	astqlOp, err := validateConditionOperator(instance, field, operator)
 	if err != nil {
 		return builder, err
 	}
@@ -296,7 +465,7 @@ func whereNotBetweenImpl(instance *astql.ASTQL, builder *astql.Builder, field, l
 
 // whereFieldsImpl adds a WHERE condition comparing two fields.
 func whereFieldsImpl(instance *astql.ASTQL, builder *astql.Builder, leftField, operator, rightField string) (*astql.Builder, error) {
-	astqlOp, err := validateOperator(operator)
+	astqlOp, err := validateConditionOperator(instance, leftField, operator)
 	if err != nil {
 		return builder, err
 	}
@@ -356,7 +525,7 @@ func orderByExprImpl(instance *astql.ASTQL, builder *astql.Builder, field, opera
 		return builder, err
 	}
 
-	astqlOp, err := validateOperator(operator)
+	astqlOp, err := validateFieldOperator(instance, field, operator)
 	if err != nil {
 		return builder, err
 	}
@@ -427,7 +596,7 @@ func groupByImpl(instance *astql.ASTQL, builder *astql.Builder, fields ...string
 
 // havingImpl adds a HAVING condition.
 func havingImpl(instance *astql.ASTQL, builder *astql.Builder, field, operator, param string) (*astql.Builder, error) {
-	astqlOp, err := validateOperator(operator)
+	astqlOp, err := validateConditionOperator(instance, field, operator)
 	if err != nil {
 		return builder, err
 	}
@@ -748,7 +917,7 @@ func selectNullIfImpl(instance *astql.ASTQL, builder *astql.Builder, param1, par
 // setExprImpl adds a field update with a binary expression value for UPDATE queries.
 // Use this for computed assignments like `age = age + :increment`.
 func setExprImpl(instance *astql.ASTQL, builder *astql.Builder, field, operator, param string) (*astql.Builder, error) {
-	astqlOp, err := validateOperator(operator)
+	astqlOp, err := validateFieldOperator(instance, field, operator)
 	if err != nil {
 		return builder, err
 	}
@@ -771,7 +940,7 @@ func setExprImpl(instance *astql.ASTQL, builder *astql.Builder, field, operator,
 // selectExprImpl adds a binary expression (field <op> param) AS alias to the SELECT clause.
 // Useful for vector distance calculations with pgvector.
 func selectExprImpl(instance *astql.ASTQL, builder *astql.Builder, field, operator, param, alias string) (*astql.Builder, error) {
-	astqlOp, err := validateOperator(operator)
+	astqlOp, err := validateFieldOperator(instance, field, operator)
 	if err != nil {
 		return builder, err
 	}
@@ -793,7 +962,7 @@ func selectExprImpl(instance *astql.ASTQL, builder *astql.Builder, field, operat
 
 // buildSimpleConditionImpl creates a simple condition (field op param) for FILTER clauses.
 func buildSimpleConditionImpl(instance *astql.ASTQL, field, operator, param string) (astql.ConditionItem, error) {
-	astqlOp, err := validateOperator(operator)
+	astqlOp, err := validateConditionOperator(instance, field, operator)
 	if err != nil {
 		return nil, err
 	}
@@ -1,10 +1,13 @@
 package soy
 
 import (
+	"errors"
+	"slices"
 	"strings"
 	"testing"
 
 	"github.com/jmoiron/sqlx"
+	"github.com/zoobzio/astql/mariadb"
 	"github.com/zoobzio/astql/postgres"
 	"github.com/zoobzio/sentinel"
 )
@@ -59,6 +62,9 @@ func TestValidateOperator(t *testing.T) {
 		{"regex match", "~", false},
 		{"array contains", "@>", false},
 		{"vector distance", "<->", false},
+		{"JSON text", "->>", false},
+		{"JSON key exists", "?", false},
+		{"JSON path exists", "@?", false},
 		{"invalid operator", "INVALID", true},
 		{"empty operator", "", true},
 	}
@@ -73,6 +79,164 @@ func TestValidateOperator(t *testing.T) {
 	}
 }
 
+type builderTestDoc struct {
+	ID       int            `db:"id" type:"integer" constraints:"primarykey"`
+	Email    string         `db:"email" type:"text"`
+	Metadata map[string]any `db:"metadata"`
+	Settings *struct {
+		Theme string `json:"theme"`
+	} `db:"settings" type:"json"`
+}
+
+func TestValidateFieldOperator(t *testing.T) {
+	c, err := New[builderTestDoc](&sqlx.DB{}, "docs", postgres.New())
+	if err != nil {
+		t.Fatalf("New() failed: %v", err)
+	}
+
+	tests := []struct {
+		name    string
+		field   string
+		op      string
+		wantErr bool
+	}{
+		{"jsonb column", "metadata", "?", false},
+		{"json column", "settings", "#>>", false},
+		{"aliased column", "d.metadata", "->", false},
+		{"text column", "email", "?", true},
+		{"non-JSON operator on text column", "email", "=", false},
+		{"invalid operator", "metadata", "INVALID", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := validateFieldOperator(c.instance, tt.field, tt.op)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("validateFieldOperator(%q, %q) error = %v, wantErr %v", tt.field, tt.op, err, tt.wantErr)
+			}
+			if err != nil && !errors.Is(err, ErrInvalidOperator) {
+				t.Errorf("error = %v, want ErrInvalidOperator", err)
+			}
+		})
+	}
+}
+
+func TestJSONOperators_ViaSelect(t *testing.T) {
+	c, err := New[builderTestDoc](&sqlx.DB{}, "docs", postgres.New())
+	if err != nil {
+		t.Fatalf("New() failed: %v", err)
+	}
+
+	result, err := c.Query().
+		Where("metadata", "?", "key").
+		Where("metadata", "@>", "match").
+		Where("metadata", "@?", "path").
+		SelectExpr("settings", "->>", "theme_key", "theme").
+		OrderByExpr("metadata", "#>>", "sort_path", "asc").
+		Render()
+	if err != nil {
+		t.Fatalf("Render() error = %v", err)
+	}
+	for _, want := range []string{
+		`"metadata" ? :key`,
+		`"metadata" @> :match`,
+		`"metadata" @? :path`,
+		`"settings" ->> :theme_key AS "theme"`,
+		`ORDER BY "metadata" #>> :sort_path ASC`,
+	} {
+		if !strings.Contains(result.SQL, want) {
+			t.Errorf("SQL missing %q: %s", want, result.SQL)
+		}
+	}
+
+	if _, err := c.Query().Where("email", "?", "key").Render(); !errors.Is(err, ErrInvalidOperator) {
+		t.Errorf("JSON operator on text column error = %v, want ErrInvalidOperator", err)
+	}
+
+	m, err := New[builderTestDoc](&sqlx.DB{}, "docs", mariadb.New())
+	if err != nil {
+		t.Fatalf("New() failed: %v", err)
+	}
+	if _, err := m.Query().Where("metadata", "?", "key").Render(); !errors.Is(err, ErrInvalidOperator) {
+		t.Errorf("JSON operator on MariaDB error = %v, want ErrInvalidOperator", err)
+	}
+
+	t.Run("path operators are not conditions", func(t *testing.T) {
+		for _, op := range []string{"->", "->>", "#>", "#>>"} {
+			renders := map[string]func() error{
+				"Where": func() error { _, err := c.Query().Where("metadata", op, "k").Render(); return err },
+				"WhereAnd": func() error {
+					_, err := c.Query().WhereAnd(C("metadata", op, "k")).Render()
+					return err
+				},
+				"Select": func() error { _, err := c.Select().Where("metadata", op, "k").Render(); return err },
+				"Update": func() error {
+					_, err := c.Modify().Set("email", "email").Where("metadata", op, "k").Render()
+					return err
+				},
+				"Delete":      func() error { _, err := c.Remove().Where("metadata", op, "k").Render(); return err },
+				"WhereFields": func() error { _, err := c.Query().WhereFields("metadata", op, "settings").Render(); return err },
+			}
+			for name, render := range renders {
+				if err := render(); !errors.Is(err, ErrInvalidOperator) || !strings.Contains(err.Error(), "WhereJSONPath") {
+					t.Errorf("%s with %q error = %v, want ErrInvalidOperator", name, op, err)
+				}
+			}
+		}
+	})
+}
+
+func TestWhereJSONPath(t *testing.T) {
+	c, err := New[builderTestDoc](&sqlx.DB{}, "docs", postgres.New())
+	if err != nil {
+		t.Fatalf("New() failed: %v", err)
+	}
+
+	result, err := c.Query().
+		Where("id", ">", "min_id").
+		WhereJSONPath("metadata", "k", "=", "v").
+		WhereJSONPath("settings", "theme_key", "NOT ILIKE", "theme").
+		Render()
+	if err != nil {
+		t.Fatalf("Render() error = %v", err)
+	}
+	for _, want := range []string{`("metadata" ->> :k) = :v`, `("settings" ->> :theme_key) NOT ILIKE :theme`} {
+		if !strings.Contains(result.SQL, want) {
+			t.Errorf("SQL missing %q: %s", want, result.SQL)
+		}
+	}
+	for _, want := range []string{"min_id", "k", "v", "theme_key", "theme"} {
+		if !slices.Contains(result.RequiredParams, want) {
+			t.Errorf("RequiredParams() = %v, missing %s", result.RequiredParams, want)
+		}
+	}
+
+	compound, err := c.Query().WhereJSONPath("metadata", "k", "=", "v").
+		Union(c.Query().WhereJSONPath("metadata", "k", "!=", "v")).
+		Render()
+	if err != nil {
+		t.Fatalf("compound Render() error = %v", err)
+	}
+	for _, want := range []string{`("metadata" ->> :q0_k) = :q0_v`, `("metadata" ->> :q1_k) != :q1_v`} {
+		if !strings.Contains(compound.SQL, want) {
+			t.Errorf("compound SQL missing %q: %s", want, compound.SQL)
+		}
+	}
+
+	if _, err := c.Select().WhereJSONPath("metadata", "k", "=", "v").Render(); err != nil {
+		t.Errorf("Select WhereJSONPath() error = %v", err)
+	}
+	if _, err := c.Count().WhereJSONPath("metadata", "k", "=", "v").Render(); err != nil {
+		t.Errorf("Count WhereJSONPath() error = %v", err)
+	}
+
+	if _, err := c.Query().WhereJSONPath("metadata", "k", "@>", "v").Render(); !errors.Is(err, ErrInvalidOperator) {
+		t.Errorf("non-comparison operator error = %v, want ErrInvalidOperator", err)
+	}
+	if _, err := c.Query().WhereJSONPath("email", "k", "=", "v").Render(); !errors.Is(err, ErrInvalidOperator) {
+		t.Errorf("text column error = %v, want ErrInvalidOperator", err)
+	}
+}
+
 func TestValidateDirection(t *testing.T) {
 	tests := []struct {
 		name    string
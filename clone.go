@@ -0,0 +1,114 @@
+package soy
+
+import (
+	"maps"
+	"slices"
+
+	"github.com/zoobzio/astql"
+)
+
+// cloneBuilder returns an independent copy of an astql.Builder, including its error state.
+// Conditions and expressions are immutable once added, so only the containers that
+// builder methods append to or write into are copied.
+func cloneBuilder(b *astql.Builder) *astql.Builder {
+	if b == nil {
+		return nil
+	}
+	src := b.GetAST()
+	clone := astql.Select(src.Target)
+	*clone.GetAST() = cloneAST(src)
+	clone.SetError(b.GetError())
+	return clone
+}
+
+// cloneAST deep-copies the mutable parts of an AST.
+func cloneAST(src *astql.AST) astql.AST {
+	dst := *src
+
+	if src.Lock != nil {
+		lock := *src.Lock
+		dst.Lock = &lock
+	}
+	if src.OnConflict != nil {
+		conflict := *src.OnConflict
+		conflict.Updates = maps.Clone(conflict.Updates)
+		conflict.Columns = slices.Clone(conflict.Columns)
+		dst.OnConflict = &conflict
+	}
+	if src.Limit != nil {
+		limit := *src.Limit
+		dst.Limit = &limit
+	}
+	if src.Offset != nil {
+		offset := *src.Offset
+		dst.Offset = &offset
+	}
+
+	dst.Updates = maps.Clone(src.Updates)
+	dst.UpdateExpressions = maps.Clone(src.UpdateExpressions)
+	dst.Values = slices.Clone(src.Values)
+	for i, row := range dst.Values {
+		dst.Values[i] = maps.Clone(row)
+	}
+	dst.Ordering = slices.Clone(src.Ordering)
+	dst.Joins = slices.Clone(src.Joins)
+	dst.GroupBy = slices.Clone(src.GroupBy)
+	dst.Having = slices.Clone(src.Having)
+	dst.FieldExpressions = slices.Clone(src.FieldExpressions)
+	dst.Returning = slices.Clone(src.Returning)
+	dst.DistinctOn = slices.Clone(src.DistinctOn)
+	dst.Fields = slices.Clone(src.Fields)
+
+	return dst
+}
+
+// cloneCompoundBuilder returns an independent copy of an astql.CompoundBuilder.
+// Operand ASTs are copied too, since they are shared with the queries that produced them.
+func cloneCompoundBuilder(cb *astql.CompoundBuilder) *astql.CompoundBuilder {
+	if cb == nil {
+		return nil
+	}
+	src, err := cb.Build()
+	if err != nil {
+		// Carry the error over through a builder that is already in error state
+		var empty astql.AST
+		failed := astql.Select(empty.Target)
+		failed.SetError(err)
+		return failed.Union(failed)
+	}
+
+	base := astql.Select(src.Base.Target)
+	clone := base.Union(astql.Select(src.Base.Target))
+	dst, err := clone.Build()
+	if err != nil {
+		return clone
+	}
+
+	*dst = *src
+	baseAST := cloneAST(src.Base)
+	dst.Base = &baseAST
+	if src.Limit != nil {
+		limit := *src.Limit
+		dst.Limit = &limit
+	}
+	if src.Offset != nil {
+		offset := *src.Offset
+		dst.Offset = &offset
+	}
+	dst.Ordering = slices.Clone(src.Ordering)
+	dst.Operands = make([]astql.SetOperand, len(src.Operands))
+	for i, operand := range src.Operands {
+		ast := cloneAST(operand.AST)
+		dst.Operands[i] = astql.SetOperand{AST: &ast, Operation: operand.Operation}
+	}
+	return clone
+}
+
+// clone returns an independent copy of the batch shape.
+func (s batchShape) clone() batchShape {
+	return batchShape{
+		keys:    slices.Clone(s.keys),
+		sets:    slices.Clone(s.sets),
+		complex: s.complex,
+	}
+}
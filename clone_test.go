@@ -0,0 +1,149 @@
+package soy
+
+import (
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/zoobzio/astql/postgres"
+)
+
+func newCloneTestSoy(t *testing.T) *Soy[batchTestUser] {
+	t.Helper()
+	s, err := New[batchTestUser](&sqlx.DB{}, "users", postgres.New())
+	if err != nil {
+		t.Fatalf("New() failed: %v", err)
+	}
+	return s
+}
+
+func TestQuery_Clone(t *testing.T) {
+	s := newCloneTestSoy(t)
+	base := s.Query().Where("name", "=", "name").OrderBy("id", "asc")
+	want := base.MustRender().SQL
+
+	derived := base.Clone().Where("age", ">", "min_age").OrderBy("email", "desc").Limit(10)
+
+	if got := base.MustRender().SQL; got != want {
+		t.Errorf("base changed after deriving:\nwant %s\ngot  %s", want, got)
+	}
+	got := derived.MustRender().SQL
+	for _, part := range []string{`"age" > :min_age`, `"email" DESC`, "LIMIT 10"} {
+		if !strings.Contains(got, part) {
+			t.Errorf("derived SQL missing %q: %s", part, got)
+		}
+	}
+}
+
+func TestQuery_CloneConcurrent(t *testing.T) {
+	s := newCloneTestSoy(t)
+	base := s.Query().Where("name", "=", "name")
+	want := base.MustRender().SQL
+
+	var wg sync.WaitGroup
+	for range 8 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := base.Clone().Where("age", ">", "min_age").OrderBy("id", "asc").Render(); err != nil {
+				t.Errorf("Render() failed: %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if got := base.MustRender().SQL; got != want {
+		t.Errorf("base changed after concurrent derivation: %s", got)
+	}
+}
+
+func TestSelect_Clone(t *testing.T) {
+	s := newCloneTestSoy(t)
+	base := s.Select().Where("name", "=", "name")
+	want := base.MustRender().SQL
+
+	derived := base.Clone().Where("email", "=", "email")
+
+	if got := base.MustRender().SQL; got != want {
+		t.Errorf("base changed after deriving: %s", got)
+	}
+	if got := derived.MustRender().SQL; !strings.Contains(got, `"email" = :email`) {
+		t.Errorf("derived SQL missing email condition: %s", got)
+	}
+}
+
+func TestSelect_CloneKeepsError(t *testing.T) {
+	s := newCloneTestSoy(t)
+	base := s.Select().Where("missing", "=", "x")
+
+	if _, err := base.Clone().Render(); err == nil {
+		t.Error("expected clone to carry the builder error")
+	}
+}
+
+func TestUpdate_Clone(t *testing.T) {
+	s := newCloneTestSoy(t)
+	base := s.Modify().Set("name", "name")
+
+	derived := base.Clone().Where("id", "=", "user_id")
+
+	if base.hasWhere {
+		t.Error("base should not gain a WHERE clause")
+	}
+	if len(base.whereItems) != 0 || len(base.batch.keys) != 0 {
+		t.Error("base WHERE tracking should be unchanged")
+	}
+	if !derived.hasWhere || len(derived.whereItems) != 1 {
+		t.Error("derived should track its WHERE clause")
+	}
+	if len(derived.batch.sets) != 1 {
+		t.Errorf("derived batch sets = %d, want 1", len(derived.batch.sets))
+	}
+}
+
+func TestDelete_Clone(t *testing.T) {
+	s := newCloneTestSoy(t)
+	base := s.Remove().Where("id", "=", "user_id")
+	want := base.MustRender().SQL
+
+	derived := base.Clone().Where("name", "=", "name")
+
+	if got := base.MustRender().SQL; got != want {
+		t.Errorf("base changed after deriving: %s", got)
+	}
+	if len(base.batch.keys) != 1 || len(derived.batch.keys) != 2 {
+		t.Errorf("batch keys = %d/%d, want 1/2", len(base.batch.keys), len(derived.batch.keys))
+	}
+}
+
+func TestAggregate_Clone(t *testing.T) {
+	s := newCloneTestSoy(t)
+	base := s.Count().Where("name", "=", "name")
+	want := base.MustRender().SQL
+
+	derived := base.Clone().Where("age", ">", "min_age")
+
+	if got := base.MustRender().SQL; got != want {
+		t.Errorf("base changed after deriving: %s", got)
+	}
+	if got := derived.MustRender().SQL; !strings.Contains(got, `"age" > :min_age`) {
+		t.Errorf("derived SQL missing age condition: %s", got)
+	}
+}
+
+func TestCompound_Clone(t *testing.T) {
+	s := newCloneTestSoy(t)
+	base := s.Query().Where("name", "=", "a").Union(s.Query().Where("name", "=", "b"))
+	want := base.MustRender().SQL
+
+	derived := base.Clone().OrderBy("id", "asc").Limit(5)
+
+	if got := base.MustRender().SQL; got != want {
+		t.Errorf("base changed after deriving:\nwant %s\ngot  %s", want, got)
+	}
+	got := derived.MustRender().SQL
+	if !strings.Contains(got, "LIMIT 5") || !strings.Contains(got, "UNION") {
+		t.Errorf("unexpected derived SQL: %s", got)
+	}
+}
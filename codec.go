@@ -0,0 +1,429 @@
+package soy
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sync"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/jmoiron/sqlx/reflectx"
+	"github.com/zoobzio/atom"
+	"github.com/zoobzio/sentinel"
+	"github.com/zoobzio/soy/internal/scanner"
+)
+
+// DriverValue is the set of representations a custom type can be stored as.
+// They are the value types every database/sql driver accepts and returns.
+type DriverValue interface {
+	string | int64 | float64 | bool | []byte | time.Time
+}
+
+// SQLTypes names a custom type's column type in each dialect.
+// A dialect left empty has no column type for the type, so untagged fields of that
+// type fail with ErrUnmappableType when a Soy instance for that dialect is created.
+type SQLTypes struct {
+	Postgres string
+	MariaDB  string
+	SQLite   string
+	MSSQL    string
+}
+
+// forDialect returns the column type for a dialect.
+func (t SQLTypes) forDialect(d dialect) string {
+	switch d {
+	case dialectMariaDB:
+		return t.MariaDB
+	case dialectSQLite:
+		return t.SQLite
+	case dialectMSSQL:
+		return t.MSSQL
+	default:
+		return t.Postgres
+	}
+}
+
+// typeCodec converts a registered Go type to and from its driver representation.
+type typeCodec struct {
+	goType   reflect.Type
+	sqlTypes SQLTypes
+	encode   func(v any) (any, error)
+	// scan decodes a column value. null reports a NULL column, in which case value is nil.
+	scan func(src any) (value any, null bool, err error)
+}
+
+var codecs = struct {
+	sync.RWMutex
+	byType map[reflect.Type]*typeCodec
+}{byType: make(map[reflect.Type]*typeCodec)}
+
+// jsonCodecs caches the codecs of JSON-stored types, keyed by reflect.Type.
+var jsonCodecs sync.Map
+
+// arrayCodecs caches the codecs of native array types, keyed by reflect.Type.
+var arrayCodecs sync.Map
+
+// RegisterType teaches soy a Go type that the database driver does not understand.
+// V is stored as D: encode converts V for parameters and decode converts scanned
+// values back. Registration covers V and *V, where a nil *V is stored as NULL.
+//
+// A registered type is used everywhere soy sees it:
+//   - fields without a type tag get the dialect's column type from types in DBML and DDL
+//   - Create binds record fields through encode, and map params (Where, Set, batch
+//     params) with V values are encoded before execution
+//   - *T results are scanned through decode
+//   - atom results store the D value in the atom table for D (Strings for string, and so on)
+//
+// Register types before creating the Soy instances that use them, typically in init.
+// Registering a type again replaces its codec.
+//
+// Example:
+//
+//	soy.RegisterType(
+//	    soy.SQLTypes{Postgres: "UUID", MariaDB: "UUID", SQLite: "TEXT", MSSQL: "UNIQUEIDENTIFIER"},
+//	    func(id uuid.UUID) (string, error) { return id.String(), nil },
+//	    uuid.Parse,
+//	)
+func RegisterType[V any, D DriverValue](types SQLTypes, encode func(V) (D, error), decode func(D) (V, error)) {
+	goType := reflect.TypeFor[V]()
+	codec := &typeCodec{
+		goType:   goType,
+		sqlTypes: types,
+		encode: func(v any) (any, error) {
+			return encode(v.(V))
+		},
+		scan: func(src any) (any, bool, error) {
+			var n sql.Null[D]
+			if err := n.Scan(src); err != nil {
+				return nil, false, err
+			}
+			if !n.Valid {
+				return nil, true, nil
+			}
+			v, err := decode(n.V)
+			if err != nil {
+				return nil, false, err
+			}
+			return v, false, nil
+		},
+	}
+
+	codecs.Lock()
+	codecs.byType[goType] = codec
+	codecs.Unlock()
+
+	scanner.RegisterTable(goType, driverTable[D]())
+}
+
+// driverTable returns the atom table that holds values of D.
+func driverTable[D DriverValue]() atom.Table {
+	var zero D
+	switch any(zero).(type) {
+	case string:
+		return atom.TableStrings
+	case int64:
+		return atom.TableInts
+	case float64:
+		return atom.TableFloats
+	case bool:
+		return atom.TableBools
+	case time.Time:
+		return atom.TableTimes
+	default:
+		return atom.TableBytes
+	}
+}
+
+// lookupCodec returns the codec for t or for the type t points to, or nil.
+// Registered types come first; slices of scalars fall back to an array codec on
+// PostgreSQL and to a JSON codec on dialects without arrays, and structs, maps and
+// other JSON-stored types to a JSON codec.
+func lookupCodec(d dialect, t reflect.Type) *typeCodec {
+	if t == nil {
+		return nil
+	}
+	if codec := registeredCodec(t); codec != nil {
+		return codec
+	}
+	if t.Kind() == reflect.Pointer && (scanner.IsArray(t) || scanner.IsJSON(t)) {
+		t = t.Elem()
+	}
+	if scanner.IsArray(t) {
+		if d != dialectPostgres {
+			return jsonCodec(t)
+		}
+		return arrayCodec(t)
+	}
+	if scanner.IsJSON(t) {
+		return jsonCodec(t)
+	}
+	return nil
+}
+
+// registeredCodec returns the RegisterType codec for t or for the type t points to, or nil.
+func registeredCodec(t reflect.Type) *typeCodec {
+	codecs.RLock()
+	defer codecs.RUnlock()
+	if len(codecs.byType) == 0 {
+		return nil
+	}
+	if codec, ok := codecs.byType[t]; ok {
+		return codec
+	}
+	if t.Kind() == reflect.Pointer {
+		return codecs.byType[t.Elem()]
+	}
+	return nil
+}
+
+// jsonCodec returns the codec that stores t as a JSON document.
+// Values are marshaled to a string, which every driver binds to JSON columns.
+func jsonCodec(t reflect.Type) *typeCodec {
+	if codec, ok := jsonCodecs.Load(t); ok {
+		return codec.(*typeCodec)
+	}
+	codec := &typeCodec{
+		goType: t,
+		encode: func(v any) (any, error) {
+			if raw, ok := v.(json.RawMessage); ok {
+				if raw == nil {
+					return nil, nil
+				}
+				return string(raw), nil
+			}
+			data, err := json.Marshal(v)
+			if err != nil {
+				return nil, err
+			}
+			return string(data), nil
+		},
+		scan: func(src any) (any, bool, error) {
+			var data []byte
+			switch src := src.(type) {
+			case nil:
+				return nil, true, nil
+			case []byte:
+				data = src
+			case string:
+				data = []byte(src)
+			default:
+				return nil, false, fmt.Errorf("unsupported JSON column value %T", src)
+			}
+			if t == rawMessageType {
+				return json.RawMessage(bytes.Clone(data)), false, nil
+			}
+			ptr := reflect.New(t)
+			if err := json.Unmarshal(data, ptr.Interface()); err != nil {
+				return nil, false, err
+			}
+			return ptr.Elem().Interface(), false, nil
+		},
+	}
+	actual, _ := jsonCodecs.LoadOrStore(t, codec)
+	return actual.(*typeCodec)
+}
+
+// arrayCodec returns the codec that stores t as a native PostgreSQL array.
+// Values are bound as array literals, which the PostgreSQL drivers accept for array
+// columns and ANY(:param). A nil slice is bound as NULL and an empty one as {}.
+func arrayCodec(t reflect.Type) *typeCodec {
+	if codec, ok := arrayCodecs.Load(t); ok {
+		return codec.(*typeCodec)
+	}
+	codec := &typeCodec{
+		goType: t,
+		encode: func(v any) (any, error) {
+			literal, ok := scanner.FormatArray(reflect.ValueOf(v))
+			if !ok {
+				return nil, nil
+			}
+			return literal, nil
+		},
+		scan: func(src any) (any, bool, error) {
+			var data []byte
+			switch src := src.(type) {
+			case nil:
+				return nil, true, nil
+			case []byte:
+				data = src
+			case string:
+				data = []byte(src)
+			default:
+				return nil, false, fmt.Errorf("unsupported array column value %T", src)
+			}
+			v, err := scanner.DecodeArray(t, data)
+			if err != nil {
+				return nil, false, err
+			}
+			return v.Interface(), false, nil
+		},
+	}
+	actual, _ := arrayCodecs.LoadOrStore(t, codec)
+	return actual.(*typeCodec)
+}
+
+// needsEncoding reports whether any column of metadata has a codec.
+func needsEncoding(d dialect, metadata sentinel.Metadata) bool {
+	for _, field := range metadata.Fields {
+		if dbCol := field.Tags["db"]; dbCol != "" && dbCol != "-" && lookupCodec(d, field.ReflectType) != nil {
+			return true
+		}
+	}
+	return false
+}
+
+// encodeValue converts a value of a registered, array or JSON-stored type, or a pointer to one,
+// to its driver representation. Values of other types are returned unchanged.
+func encodeValue(d dialect, v any) (any, error) {
+	if v == nil {
+		return nil, nil
+	}
+	rv := reflect.ValueOf(v)
+	codec := lookupCodec(d, rv.Type())
+	if codec == nil {
+		return v, nil
+	}
+	if rv.Kind() == reflect.Pointer && rv.Type().Elem() == codec.goType {
+		if rv.IsNil() {
+			return nil, nil
+		}
+		rv = rv.Elem()
+	}
+	encoded, err := codec.encode(rv.Interface())
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode %s: %w", codec.goType, err)
+	}
+	return encoded, nil
+}
+
+// encodeParams returns params with values of registered, array and JSON-stored types encoded.
+// Slices of scalars are only encoded as PostgreSQL arrays; other dialects receive them
+// unchanged, so a slice bound where a single value belongs fails in the driver instead of
+// matching a literal. The map is only copied when a value needs encoding.
+func encodeParams(d dialect, params map[string]any) (map[string]any, error) {
+	var encoded map[string]any
+	for name, v := range params {
+		if v == nil || lookupCodec(d, reflect.TypeOf(v)) == nil {
+			continue
+		}
+		if d != dialectPostgres && scanner.IsArray(reflect.TypeOf(v)) && registeredCodec(reflect.TypeOf(v)) == nil {
+			continue
+		}
+		if encoded == nil {
+			encoded = make(map[string]any, len(params))
+			for k, val := range params {
+				encoded[k] = val
+			}
+		}
+		ev, err := encodeValue(d, v)
+		if err != nil {
+			return nil, fmt.Errorf("param %q: %w", name, err)
+		}
+		encoded[name] = ev
+	}
+	if encoded == nil {
+		return params, nil
+	}
+	return encoded, nil
+}
+
+// encodeArg encodes a named query argument when it is a params map.
+func encodeArg(d dialect, arg any) (any, error) {
+	if params, ok := arg.(map[string]any); ok {
+		return encodeParams(d, params)
+	}
+	return arg, nil
+}
+
+// recordArg returns the named query argument for a record. The record is bound directly
+// unless a column needs encoding or belongs to a value object, in which case its column
+// values are extracted and encoded.
+func recordArg[T any](d dialect, metadata sentinel.Metadata, record *T) (any, error) {
+	if !needsEncoding(d, metadata) && !hasFlattenedColumns(metadata) {
+		return record, nil
+	}
+	rv := reflect.ValueOf(record).Elem()
+	fields := columnFields(metadata)
+	params := make(map[string]any, len(fields))
+	for column, field := range fields {
+		v, err := encodeValue(d, rv.FieldByIndex(field.Index).Interface())
+		if err != nil {
+			return nil, fmt.Errorf("field %q: %w", field.Name, err)
+		}
+		params[column] = v
+	}
+	return params, nil
+}
+
+// scanStruct scans the current row into dest like rows.StructScan, decoding
+// columns whose field has a registered, array or JSON-stored type.
+func scanStruct(d dialect, rows *sqlx.Rows, dest any) error {
+	return scanStructWith(d, rows, dest, nil)
+}
+
+// scanStructWith is scanStruct with extra columns that are scanned into their own
+// targets instead of fields of dest, such as the distance column of Nearest.
+func scanStructWith(d dialect, rows *sqlx.Rows, dest any, extra map[string]any) error {
+	v := reflect.ValueOf(dest)
+	if v.Kind() != reflect.Pointer || v.IsNil() {
+		return fmt.Errorf("scan destination must be a non-nil pointer, got %T", dest)
+	}
+	v = v.Elem()
+
+	columns, err := rows.Columns()
+	if err != nil {
+		return err
+	}
+	traversals := rows.Mapper.TraversalsByName(v.Type(), columns)
+
+	values := make([]any, len(columns))
+	for i, traversal := range traversals {
+		if target, ok := extra[columns[i]]; ok {
+			values[i] = target
+			continue
+		}
+		if len(traversal) == 0 {
+			traversal = flattenedColumn(v.Type(), columns[i])
+		}
+		if len(traversal) == 0 {
+			return fmt.Errorf("missing destination name %s in %T", columns[i], dest)
+		}
+		field := reflectx.FieldByIndexes(v, traversal)
+		if codec := lookupCodec(d, field.Type()); codec != nil {
+			values[i] = &codecScanner{field: field, codec: codec}
+			continue
+		}
+		values[i] = field.Addr().Interface()
+	}
+	return rows.Scan(values...)
+}
+
+// codecScanner decodes a column into a field of a registered type.
+type codecScanner struct {
+	field reflect.Value
+	codec *typeCodec
+}
+
+// Scan implements sql.Scanner.
+func (s *codecScanner) Scan(src any) error {
+	value, null, err := s.codec.scan(src)
+	if err != nil {
+		return fmt.Errorf("failed to decode %s: %w", s.codec.goType, err)
+	}
+	if null {
+		s.field.SetZero()
+		return nil
+	}
+	decoded := reflect.ValueOf(value)
+	if s.field.Kind() == reflect.Pointer && s.field.Type().Elem() == s.codec.goType {
+		ptr := reflect.New(s.codec.goType)
+		ptr.Elem().Set(decoded)
+		s.field.Set(ptr)
+		return nil
+	}
+	s.field.Set(decoded)
+	return nil
+}
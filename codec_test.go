@@ -0,0 +1,425 @@
+package soy
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/zoobzio/sentinel"
+)
+
+// codecTestCode is a struct type that would otherwise be inferred as JSON.
+type codecTestCode struct {
+	Prefix string
+	Num    int
+}
+
+var errNegativeCode = errors.New("negative code")
+
+func init() {
+	RegisterType(
+		SQLTypes{Postgres: "VARCHAR(32)", MariaDB: "VARCHAR(32)", SQLite: "TEXT"},
+		func(c codecTestCode) (string, error) {
+			if c.Num < 0 {
+				return "", errNegativeCode
+			}
+			return fmt.Sprintf("%s-%d", c.Prefix, c.Num), nil
+		},
+		func(s string) (codecTestCode, error) {
+			var c codecTestCode
+			prefix, num, ok := strings.Cut(s, "-")
+			if !ok {
+				return c, fmt.Errorf("malformed code %q", s)
+			}
+			c.Prefix = prefix
+			_, err := fmt.Sscan(num, &c.Num)
+			return c, err
+		},
+	)
+}
+
+type codecTestRecord struct {
+	ID   int            `db:"id" type:"serial" constraints:"primary_key"`
+	Code codecTestCode  `db:"code"`
+	Alt  *codecTestCode `db:"alt"`
+}
+
+func TestInferSQLType_RegisteredType(t *testing.T) {
+	tests := []struct {
+		d    dialect
+		want string
+	}{
+		{dialectPostgres, "VARCHAR(32)"},
+		{dialectMariaDB, "VARCHAR(32)"},
+		{dialectSQLite, "TEXT"},
+	}
+	for _, tt := range tests {
+		for _, typ := range []reflect.Type{reflect.TypeFor[codecTestCode](), reflect.TypeFor[*codecTestCode]()} {
+			got, err := inferSQLType(tt.d, typ)
+			if err != nil {
+				t.Fatalf("inferSQLType(%s, %s) error = %v", tt.d, typ, err)
+			}
+			if got != tt.want {
+				t.Errorf("inferSQLType(%s, %s) = %q, want %q", tt.d, typ, got, tt.want)
+			}
+		}
+	}
+
+	if _, err := inferSQLType(dialectMSSQL, reflect.TypeFor[codecTestCode]()); !errors.Is(err, ErrUnmappableType) {
+		t.Errorf("inferSQLType(mssql) error = %v, want ErrUnmappableType", err)
+	}
+}
+
+func TestEncodeParams(t *testing.T) {
+	var nilCode *codecTestCode
+	params := map[string]any{
+		"id":   1,
+		"code": codecTestCode{Prefix: "AB", Num: 7},
+		"ptr":  &codecTestCode{Prefix: "CD", Num: 8},
+		"nil":  nilCode,
+	}
+	encoded, err := encodeParams(dialectPostgres, params)
+	if err != nil {
+		t.Fatalf("encodeParams() error = %v", err)
+	}
+	want := map[string]any{"id": 1, "code": "AB-7", "ptr": "CD-8", "nil": nil}
+	if !reflect.DeepEqual(encoded, want) {
+		t.Errorf("encodeParams() = %v, want %v", encoded, want)
+	}
+	if _, ok := params["code"].(codecTestCode); !ok {
+		t.Error("encodeParams() modified the caller's map")
+	}
+
+	plain := map[string]any{"id": 1}
+	if got, _ := encodeParams(dialectPostgres, plain); reflect.ValueOf(got).Pointer() != reflect.ValueOf(plain).Pointer() {
+		t.Error("encodeParams() copied a map with nothing to encode")
+	}
+
+	_, err = encodeParams(dialectPostgres, map[string]any{"code": codecTestCode{Num: -1}})
+	if !errors.Is(err, errNegativeCode) {
+		t.Errorf("encodeParams() error = %v, want errNegativeCode", err)
+	}
+}
+
+func TestRecordArg(t *testing.T) {
+	metadata := sentinel.Inspect[codecTestRecord]()
+	record := &codecTestRecord{ID: 3, Code: codecTestCode{Prefix: "AB", Num: 7}}
+
+	arg, err := recordArg(dialectPostgres, metadata, record)
+	if err != nil {
+		t.Fatalf("recordArg() error = %v", err)
+	}
+	want := map[string]any{"id": 3, "code": "AB-7", "alt": nil}
+	if !reflect.DeepEqual(arg, want) {
+		t.Errorf("recordArg() = %v, want %v", arg, want)
+	}
+
+	record.Alt = &codecTestCode{Num: -1}
+	if _, err := recordArg(dialectPostgres, metadata, record); !errors.Is(err, errNegativeCode) {
+		t.Errorf("recordArg() error = %v, want errNegativeCode", err)
+	}
+}
+
+// codecDriver is a database/sql driver that returns fixed rows for every query.
+type codecDriver struct {
+	cols []string
+	data [][]driver.Value
+}
+
+func (d *codecDriver) Open(_ string) (driver.Conn, error) { return codecConn{d}, nil }
+
+type codecConn struct{ driver *codecDriver }
+
+func (c codecConn) Prepare(_ string) (driver.Stmt, error) { return codecStmt(c), nil }
+func (codecConn) Close() error                            { return nil }
+func (codecConn) Begin() (driver.Tx, error)               { return nil, errors.New("not supported") }
+
+type codecStmt struct{ driver *codecDriver }
+
+func (codecStmt) Close() error  { return nil }
+func (codecStmt) NumInput() int { return -1 }
+func (codecStmt) Exec(_ []driver.Value) (driver.Result, error) {
+	return nil, errors.New("not supported")
+}
+func (s codecStmt) Query(_ []driver.Value) (driver.Rows, error) {
+	return &verifyRows{cols: s.driver.cols, data: slices.Clone(s.driver.data)}, nil
+}
+
+func queryCodecRows(t *testing.T, data [][]driver.Value) *sqlx.Rows {
+	t.Helper()
+	name := fmt.Sprintf("soy_codec_%d", verifyDriverSeq.Add(1))
+	sql.Register(name, &codecDriver{cols: []string{"id", "code", "alt"}, data: data})
+
+	db, err := sqlx.Open(name, "")
+	if err != nil {
+		t.Fatalf("sqlx.Open() failed: %v", err)
+	}
+	t.Cleanup(func() { _ = db.Close() })
+
+	rows, err := db.Queryx("SELECT id, code, alt FROM records")
+	if err != nil {
+		t.Fatalf("Queryx() failed: %v", err)
+	}
+	t.Cleanup(func() { _ = rows.Close() })
+	return rows
+}
+
+func TestScanStruct(t *testing.T) {
+	rows := queryCodecRows(t, [][]driver.Value{
+		{int64(1), "AB-7", nil},
+		{int64(2), "CD-8", "EF-9"},
+	})
+
+	var got []codecTestRecord
+	for rows.Next() {
+		var record codecTestRecord
+		if err := scanStruct(dialectPostgres, rows, &record); err != nil {
+			t.Fatalf("scanStruct() error = %v", err)
+		}
+		got = append(got, record)
+	}
+
+	want := []codecTestRecord{
+		{ID: 1, Code: codecTestCode{Prefix: "AB", Num: 7}},
+		{ID: 2, Code: codecTestCode{Prefix: "CD", Num: 8}, Alt: &codecTestCode{Prefix: "EF", Num: 9}},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("scanStruct() = %+v, want %+v", got, want)
+	}
+}
+
+func TestScanStruct_DecodeError(t *testing.T) {
+	rows := queryCodecRows(t, [][]driver.Value{{int64(1), "malformed", nil}})
+	if !rows.Next() {
+		t.Fatal("expected a row")
+	}
+	var record codecTestRecord
+	err := scanStruct(dialectPostgres, rows, &record)
+	if err == nil || !strings.Contains(err.Error(), "failed to decode") {
+		t.Errorf("scanStruct() error = %v, want decode error", err)
+	}
+}
+
+func TestBuildDBMLFromStruct_RegisteredType(t *testing.T) {
+	project, err := buildDBMLFromStruct(sentinel.Inspect[codecTestRecord](), "records", dialectPostgres)
+	if err != nil {
+		t.Fatalf("buildDBMLFromStruct() error = %v", err)
+	}
+	for _, col := range project.Tables["public.records"].Columns {
+		if col.Name == "code" && col.Type != "VARCHAR(32)" {
+			t.Errorf("code column type = %q, want VARCHAR(32)", col.Type)
+		}
+	}
+}
+
+type codecTestSettings struct {
+	Theme string   `json:"theme"`
+	Tags  []string `json:"tags"`
+}
+
+type codecTestDoc struct {
+	ID       int                `db:"id" type:"serial" constraints:"primary_key"`
+	Settings codecTestSettings  `db:"settings"`
+	Extra    *codecTestSettings `db:"extra"`
+	Labels   map[string]string  `db:"labels"`
+}
+
+func TestEncodeParams_JSON(t *testing.T) {
+	encoded, err := encodeParams(dialectPostgres, map[string]any{
+		"match":    map[string]any{"role": "admin"},
+		"settings": codecTestSettings{Theme: "dark"},
+		"raw":      json.RawMessage(`{"a":1}`),
+		"key":      "role",
+		"ids":      []int{1, 2},
+	})
+	if err != nil {
+		t.Fatalf("encodeParams() error = %v", err)
+	}
+	want := map[string]any{
+		"match":    `{"role":"admin"}`,
+		"settings": `{"theme":"dark","tags":null}`,
+		"raw":      `{"a":1}`,
+		"key":      "role",
+		"ids":      "{1,2}",
+	}
+	if !reflect.DeepEqual(encoded, want) {
+		t.Errorf("encodeParams() = %v, want %v", encoded, want)
+	}
+}
+
+func TestRecordArg_JSON(t *testing.T) {
+	record := &codecTestDoc{ID: 1, Settings: codecTestSettings{Theme: "dark", Tags: []string{"a"}}}
+	arg, err := recordArg(dialectPostgres, sentinel.Inspect[codecTestDoc](), record)
+	if err != nil {
+		t.Fatalf("recordArg() error = %v", err)
+	}
+	want := map[string]any{
+		"id":       1,
+		"settings": `{"theme":"dark","tags":["a"]}`,
+		"extra":    nil,
+		"labels":   "null",
+	}
+	if !reflect.DeepEqual(arg, want) {
+		t.Errorf("recordArg() = %v, want %v", arg, want)
+	}
+
+	plain := &createTestUser{ID: 1}
+	if arg, _ := recordArg(dialectPostgres, sentinel.Inspect[createTestUser](), plain); arg != any(plain) {
+		t.Errorf("recordArg() = %v, want the record itself when nothing needs encoding", arg)
+	}
+}
+
+func TestScanStruct_JSON(t *testing.T) {
+	name := fmt.Sprintf("soy_codec_%d", verifyDriverSeq.Add(1))
+	sql.Register(name, &codecDriver{
+		cols: []string{"id", "settings", "extra", "labels"},
+		data: [][]driver.Value{
+			{int64(1), []byte(`{"theme":"dark","tags":["a","b"]}`), nil, `{"env":"prod"}`},
+		},
+	})
+	db, err := sqlx.Open(name, "")
+	if err != nil {
+		t.Fatalf("sqlx.Open() failed: %v", err)
+	}
+	t.Cleanup(func() { _ = db.Close() })
+
+	rows, err := db.Queryx("SELECT * FROM docs")
+	if err != nil {
+		t.Fatalf("Queryx() failed: %v", err)
+	}
+	defer rows.Close()
+	if !rows.Next() {
+		t.Fatal("expected a row")
+	}
+
+	var got codecTestDoc
+	if err := scanStruct(dialectPostgres, rows, &got); err != nil {
+		t.Fatalf("scanStruct() error = %v", err)
+	}
+	want := codecTestDoc{
+		ID:       1,
+		Settings: codecTestSettings{Theme: "dark", Tags: []string{"a", "b"}},
+		Labels:   map[string]string{"env": "prod"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("scanStruct() = %+v, want %+v", got, want)
+	}
+}
+
+type codecTestPost struct {
+	ID     int        `db:"id" type:"serial" constraints:"primary_key"`
+	Tags   []string   `db:"tags"`
+	Scores *[]float64 `db:"scores"`
+	Ranks  []*int     `db:"ranks"`
+}
+
+func TestEncodeParams_Array(t *testing.T) {
+	encoded, err := encodeParams(dialectPostgres, map[string]any{
+		"ids":   []int{1, 2, 3},
+		"tags":  []string{"go", `a "b"`},
+		"none":  []string(nil),
+		"bytes": []byte("raw"),
+	})
+	if err != nil {
+		t.Fatalf("encodeParams() error = %v", err)
+	}
+	want := map[string]any{
+		"ids":   "{1,2,3}",
+		"tags":  `{"go","a \"b\""}`,
+		"none":  nil,
+		"bytes": []byte("raw"),
+	}
+	if !reflect.DeepEqual(encoded, want) {
+		t.Errorf("encodeParams() = %v, want %v", encoded, want)
+	}
+}
+
+func TestRecordArg_Array(t *testing.T) {
+	rank := 2
+	record := &codecTestPost{ID: 1, Tags: []string{}, Ranks: []*int{&rank, nil}}
+	arg, err := recordArg(dialectPostgres, sentinel.Inspect[codecTestPost](), record)
+	if err != nil {
+		t.Fatalf("recordArg() error = %v", err)
+	}
+	want := map[string]any{"id": 1, "tags": "{}", "scores": nil, "ranks": "{2,NULL}"}
+	if !reflect.DeepEqual(arg, want) {
+		t.Errorf("recordArg() = %v, want %v", arg, want)
+	}
+}
+
+func TestScanStruct_Array(t *testing.T) {
+	name := fmt.Sprintf("soy_codec_%d", verifyDriverSeq.Add(1))
+	sql.Register(name, &codecDriver{
+		cols: []string{"id", "tags", "scores", "ranks"},
+		data: [][]driver.Value{
+			{int64(1), []byte(`{go,"a b"}`), "{1.5,2}", []byte(`{3,NULL}`)},
+			{int64(2), nil, nil, []byte(`{}`)},
+		},
+	})
+	db, err := sqlx.Open(name, "")
+	if err != nil {
+		t.Fatalf("sqlx.Open() failed: %v", err)
+	}
+	t.Cleanup(func() { _ = db.Close() })
+
+	rows, err := db.Queryx("SELECT * FROM posts")
+	if err != nil {
+		t.Fatalf("Queryx() failed: %v", err)
+	}
+	defer rows.Close()
+
+	var got []codecTestPost
+	for rows.Next() {
+		var post codecTestPost
+		if err := scanStruct(dialectPostgres, rows, &post); err != nil {
+			t.Fatalf("scanStruct() error = %v", err)
+		}
+		got = append(got, post)
+	}
+
+	if len(got) != 2 {
+		t.Fatalf("scanned %d rows, want 2", len(got))
+	}
+	if !reflect.DeepEqual(got[0].Tags, []string{"go", "a b"}) || !reflect.DeepEqual(*got[0].Scores, []float64{1.5, 2}) {
+		t.Errorf("row 1 = %+v", got[0])
+	}
+	if len(got[0].Ranks) != 2 || *got[0].Ranks[0] != 3 || got[0].Ranks[1] != nil {
+		t.Errorf("row 1 ranks = %v", got[0].Ranks)
+	}
+	if got[1].Tags != nil || got[1].Scores != nil || got[1].Ranks == nil || len(got[1].Ranks) != 0 {
+		t.Errorf("row 2 = %+v", got[1])
+	}
+}
+
+func TestEncodeParams_ArrayWithoutArrayColumns(t *testing.T) {
+	for _, d := range []dialect{dialectMariaDB, dialectSQLite} {
+		t.Run(string(d), func(t *testing.T) {
+			params := map[string]any{"ids": []int{1, 2, 3}, "tags": []string{"go"}}
+			encoded, err := encodeParams(d, params)
+			if err != nil {
+				t.Fatalf("encodeParams() error = %v", err)
+			}
+			if !reflect.DeepEqual(encoded, params) {
+				t.Errorf("encodeParams() = %v, want slices left unencoded", encoded)
+			}
+
+			rank := 2
+			record := &codecTestPost{ID: 1, Tags: []string{"go"}, Ranks: []*int{&rank, nil}}
+			arg, err := recordArg(d, sentinel.Inspect[codecTestPost](), record)
+			if err != nil {
+				t.Fatalf("recordArg() error = %v", err)
+			}
+			want := map[string]any{"id": 1, "tags": `["go"]`, "scores": nil, "ranks": `[2,null]`}
+			if !reflect.DeepEqual(arg, want) {
+				t.Errorf("recordArg() = %v, want %v", arg, want)
+			}
+		})
+	}
+}
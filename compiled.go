@@ -0,0 +1,315 @@
+package soy
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/zoobzio/astql"
+	"github.com/zoobzio/atom"
+)
+
+// errOptionalCompile is returned when compiling a builder whose SQL depends on
+// which optional params are supplied.
+var errOptionalCompile = errors.New("queries with optional conditions cannot be compiled")
+
+// compiledSQL holds SQL rendered once at compile time together with the
+// parameters it requires and the enum columns they are bound to.
+// It is never mutated after construction.
+type compiledSQL struct {
+	sql    string
+	params []string
+	enums  enumBindings
+}
+
+func newCompiledSQL(result *astql.QueryResult, enums enumBindings) compiledSQL {
+	params := make([]string, len(result.RequiredParams))
+	copy(params, result.RequiredParams)
+	return compiledSQL{sql: result.SQL, params: params, enums: enums}
+}
+
+// SQL returns the rendered SQL statement.
+func (c compiledSQL) SQL() string {
+	return c.sql
+}
+
+// RequiredParams returns the named parameters the statement expects.
+// The returned slice is a copy and may be modified by the caller.
+func (c compiledSQL) RequiredParams() []string {
+	params := make([]string, len(c.params))
+	copy(params, c.params)
+	return params
+}
+
+// validate checks params against the compiled statement before execution.
+func (c compiledSQL) validate(params map[string]any, strict bool) error {
+	if err := validateParams(c.params, params, strict); err != nil {
+		return err
+	}
+	return c.enums.validate(params)
+}
+
+// Compiled is a frozen multi-row query produced by Query.Compile.
+// The SQL is rendered once; executions only bind parameters, so a Compiled
+// value is safe for concurrent use and can be stored for the lifetime of the program.
+type Compiled[T any] struct {
+	compiledSQL
+	soy soyExecutor
+}
+
+// Compile renders the query once and returns a reusable, concurrency-safe handle.
+// Later changes to the builder do not affect the compiled query.
+//
+// Example:
+//
+//	activeUsers, err := soy.Query().
+//	    Where("status", "=", "status").
+//	    OrderBy("name", "asc").
+//	    Compile()
+//	// later, from any goroutine
+//	users, err := activeUsers.Exec(ctx, map[string]any{"status": "active"})
+func (qb *Query[T]) Compile() (*Compiled[T], error) {
+	if qb.optional.active() {
+		return nil, errOptionalCompile
+	}
+	result, err := qb.Render()
+	if err != nil {
+		return nil, err
+	}
+	return &Compiled[T]{compiledSQL: newCompiledSQL(result, enumBindingsOf(qb.instance, qb.builder)), soy: qb.soy}, nil
+}
+
+// Exec executes the compiled query and returns all matching records.
+func (c *Compiled[T]) Exec(ctx context.Context, params map[string]any) ([]*T, error) {
+	return c.exec(ctx, c.soy.execer(), params)
+}
+
+// ExecTx executes the compiled query within a transaction.
+func (c *Compiled[T]) ExecTx(ctx context.Context, tx *sqlx.Tx, params map[string]any) ([]*T, error) {
+	return c.exec(ctx, tx, params)
+}
+
+// ExecAtom executes the compiled query and returns all results as Atoms.
+func (c *Compiled[T]) ExecAtom(ctx context.Context, params map[string]any) ([]*atom.Atom, error) {
+	params = c.soy.bindParams(params)
+	if err := c.validate(params, c.soy.strictParams()); err != nil {
+		return nil, err
+	}
+	return execAtomMultipleRows(ctx, c.soy.execer(), c.soy.statements(), dialectOf(c.soy.renderer()), c.soy.atomScanner(), c.sql, params, c.soy.getTableName(), "QUERY")
+}
+
+// ExecTxAtom executes the compiled query within a transaction and returns all results as Atoms.
+func (c *Compiled[T]) ExecTxAtom(ctx context.Context, tx *sqlx.Tx, params map[string]any) ([]*atom.Atom, error) {
+	params = c.soy.bindParams(params)
+	if err := c.validate(params, c.soy.strictParams()); err != nil {
+		return nil, err
+	}
+	return execAtomMultipleRows(ctx, tx, c.soy.statements(), dialectOf(c.soy.renderer()), c.soy.atomScanner(), c.sql, params, c.soy.getTableName(), "QUERY")
+}
+
+func (c *Compiled[T]) exec(ctx context.Context, execer sqlx.ExtContext, params map[string]any) ([]*T, error) {
+	params = c.soy.bindParams(params)
+	if err := c.validate(params, c.soy.strictParams()); err != nil {
+		return nil, err
+	}
+	return execMultipleRows[T](ctx, execer, c.soy.statements(), dialectOf(c.soy.renderer()), c.sql, params, c.soy.getTableName(), "QUERY", func(ctx context.Context, result *T) error {
+		return c.soy.callOnScan(ctx, result)
+	})
+}
+
+// CompiledSelect is a frozen single-row query produced by Select.Compile.
+// It is safe for concurrent use.
+type CompiledSelect[T any] struct {
+	compiledSQL
+	sb *Select[T]
+}
+
+// Compile renders the SELECT once and returns a reusable, concurrency-safe handle.
+// Later changes to the builder do not affect the compiled query.
+func (sb *Select[T]) Compile() (*CompiledSelect[T], error) {
+	if sb.optional.active() {
+		return nil, errOptionalCompile
+	}
+	result, err := sb.Render()
+	if err != nil {
+		return nil, err
+	}
+	frozen := *sb
+	return &CompiledSelect[T]{compiledSQL: newCompiledSQL(result, enumBindingsOf(sb.instance, sb.builder)), sb: &frozen}, nil
+}
+
+// Exec executes the compiled query and returns exactly one record.
+// Returns ErrNotFound if no rows match and ErrMultipleRows if more than one does.
+func (c *CompiledSelect[T]) Exec(ctx context.Context, params map[string]any) (*T, error) {
+	return c.exec(ctx, c.sb.soy.execer(), params)
+}
+
+// ExecTx executes the compiled query within a transaction.
+func (c *CompiledSelect[T]) ExecTx(ctx context.Context, tx *sqlx.Tx, params map[string]any) (*T, error) {
+	return c.exec(ctx, tx, params)
+}
+
+// ExecAtom executes the compiled query and returns the result as an Atom.
+func (c *CompiledSelect[T]) ExecAtom(ctx context.Context, params map[string]any) (*atom.Atom, error) {
+	return c.execAtom(ctx, c.sb.soy.execer(), params)
+}
+
+// ExecTxAtom executes the compiled query within a transaction and returns the result as an Atom.
+func (c *CompiledSelect[T]) ExecTxAtom(ctx context.Context, tx *sqlx.Tx, params map[string]any) (*atom.Atom, error) {
+	return c.execAtom(ctx, tx, params)
+}
+
+func (c *CompiledSelect[T]) exec(ctx context.Context, execer sqlx.ExtContext, params map[string]any) (*T, error) {
+	params = c.sb.soy.bindParams(params)
+	if err := c.validate(params, c.sb.soy.strictParams()); err != nil {
+		return nil, err
+	}
+	return c.sb.execSQL(ctx, execer, c.sql, params)
+}
+
+func (c *CompiledSelect[T]) execAtom(ctx context.Context, execer sqlx.ExtContext, params map[string]any) (*atom.Atom, error) {
+	params = c.sb.soy.bindParams(params)
+	if err := c.validate(params, c.sb.soy.strictParams()); err != nil {
+		return nil, err
+	}
+	return execAtomSingleRow(ctx, execer, c.sb.soy.statements(), dialectOf(c.sb.soy.renderer()), c.sb.soy.atomScanner(), c.sql, params, c.sb.soy.getTableName(), "SELECT")
+}
+
+// CompiledUpdate is a frozen single-row UPDATE produced by Update.Compile.
+// It is safe for concurrent use.
+type CompiledUpdate[T any] struct {
+	compiledSQL
+	ub        *Update[T]
+	selectSQL string
+}
+
+// Compile renders the UPDATE once and returns a reusable, concurrency-safe handle.
+// Like Exec, it requires at least one WHERE condition. On dialects without
+// RETURNING support the fallback SELECT is rendered up front as well.
+func (ub *Update[T]) Compile() (*CompiledUpdate[T], error) {
+	if ub.err != nil {
+		return nil, fmt.Errorf("update builder has errors: %w", ub.err)
+	}
+	if !ub.hasWhere {
+		return nil, fmt.Errorf("UPDATE requires at least one WHERE condition to prevent accidental full-table update")
+	}
+
+	result, err := ub.Render()
+	if err != nil {
+		return nil, err
+	}
+
+	var selectSQL string
+	if !ub.soy.renderer().Capabilities().ReturningOnUpdate {
+		selectSQL, err = ub.renderFallbackSelect()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	frozen := *ub
+	return &CompiledUpdate[T]{compiledSQL: newCompiledSQL(result, enumBindingsOf(ub.instance, ub.builder)), ub: &frozen, selectSQL: selectSQL}, nil
+}
+
+// Exec executes the compiled UPDATE and returns the updated record.
+func (c *CompiledUpdate[T]) Exec(ctx context.Context, params map[string]any) (*T, error) {
+	return c.exec(ctx, c.ub.soy.execer(), params)
+}
+
+// ExecTx executes the compiled UPDATE within a transaction.
+func (c *CompiledUpdate[T]) ExecTx(ctx context.Context, tx *sqlx.Tx, params map[string]any) (*T, error) {
+	return c.exec(ctx, tx, params)
+}
+
+func (c *CompiledUpdate[T]) exec(ctx context.Context, execer sqlx.ExtContext, params map[string]any) (*T, error) {
+	params = c.ub.soy.bindParams(params)
+	if err := c.validate(params, c.ub.soy.strictParams()); err != nil {
+		return nil, err
+	}
+	if c.selectSQL == "" {
+		return c.ub.execWithReturning(ctx, execer, c.sql, params)
+	}
+	return c.ub.execThenSelect(ctx, execer, c.sql, c.selectSQL, params)
+}
+
+// CompiledDelete is a frozen DELETE produced by Delete.Compile.
+// It is safe for concurrent use.
+type CompiledDelete[T any] struct {
+	compiledSQL
+	db *Delete[T]
+}
+
+// Compile renders the DELETE once and returns a reusable, concurrency-safe handle.
+// Like Exec, it requires at least one WHERE condition.
+func (db *Delete[T]) Compile() (*CompiledDelete[T], error) {
+	if db.err != nil {
+		return nil, fmt.Errorf("delete builder has errors: %w", db.err)
+	}
+	if !db.hasWhere {
+		return nil, fmt.Errorf("DELETE requires at least one WHERE condition to prevent accidental full-table deletion")
+	}
+
+	result, err := db.Render()
+	if err != nil {
+		return nil, err
+	}
+	frozen := *db
+	return &CompiledDelete[T]{compiledSQL: newCompiledSQL(result, enumBindingsOf(db.instance, db.builder)), db: &frozen}, nil
+}
+
+// Exec executes the compiled DELETE and returns the number of rows deleted.
+func (c *CompiledDelete[T]) Exec(ctx context.Context, params map[string]any) (int64, error) {
+	return c.exec(ctx, c.db.soy.execer(), params)
+}
+
+// ExecTx executes the compiled DELETE within a transaction.
+func (c *CompiledDelete[T]) ExecTx(ctx context.Context, tx *sqlx.Tx, params map[string]any) (int64, error) {
+	return c.exec(ctx, tx, params)
+}
+
+func (c *CompiledDelete[T]) exec(ctx context.Context, execer sqlx.ExtContext, params map[string]any) (int64, error) {
+	params = c.db.soy.bindParams(params)
+	if err := c.validate(params, c.db.soy.strictParams()); err != nil {
+		return 0, err
+	}
+	return c.db.execSQL(ctx, execer, c.sql, params)
+}
+
+// CompiledAggregate is a frozen aggregate query produced by Aggregate.Compile.
+// It is safe for concurrent use.
+type CompiledAggregate[T any] struct {
+	compiledSQL
+	agg *aggregateBuilder[T]
+}
+
+// Compile renders the aggregate once and returns a reusable, concurrency-safe handle.
+func (ab *Aggregate[T]) Compile() (*CompiledAggregate[T], error) {
+	if ab.agg.optional.active() {
+		return nil, errOptionalCompile
+	}
+	result, err := ab.agg.render()
+	if err != nil {
+		return nil, err
+	}
+	frozen := *ab.agg
+	return &CompiledAggregate[T]{compiledSQL: newCompiledSQL(result, enumBindingsOf(ab.agg.instance, ab.agg.builder)), agg: &frozen}, nil
+}
+
+// Exec executes the compiled aggregate and returns the result as float64.
+func (c *CompiledAggregate[T]) Exec(ctx context.Context, params map[string]any) (float64, error) {
+	return c.exec(ctx, c.agg.soy.execer(), params)
+}
+
+// ExecTx executes the compiled aggregate within a transaction.
+func (c *CompiledAggregate[T]) ExecTx(ctx context.Context, tx *sqlx.Tx, params map[string]any) (float64, error) {
+	return c.exec(ctx, tx, params)
+}
+
+func (c *CompiledAggregate[T]) exec(ctx context.Context, execer sqlx.ExtContext, params map[string]any) (float64, error) {
+	params = c.agg.soy.bindParams(params)
+	if err := c.validate(params, c.agg.soy.strictParams()); err != nil {
+		return 0, err
+	}
+	return c.agg.execSQL(ctx, execer, c.sql, params)
+}
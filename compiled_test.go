@@ -0,0 +1,130 @@
+package soy
+
+import (
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/zoobzio/astql/mariadb"
+	"github.com/zoobzio/astql/postgres"
+)
+
+func TestCompile_FreezesSQL(t *testing.T) {
+	db := &sqlx.DB{}
+	s, err := New[batchTestUser](db, "users", postgres.New())
+	if err != nil {
+		t.Fatalf("New() failed: %v", err)
+	}
+
+	t.Run("query", func(t *testing.T) {
+		q := s.Query().Where("name", "=", "name")
+		compiled, err := q.Compile()
+		if err != nil {
+			t.Fatalf("Compile() failed: %v", err)
+		}
+		before := compiled.SQL()
+
+		q.Where("age", ">", "min_age")
+
+		if compiled.SQL() != before {
+			t.Errorf("compiled SQL changed after builder mutation: %s", compiled.SQL())
+		}
+		if got := compiled.RequiredParams(); len(got) != 1 || got[0] != "name" {
+			t.Errorf("RequiredParams() = %v, want [name]", got)
+		}
+	})
+
+	t.Run("required params are copied", func(t *testing.T) {
+		compiled, err := s.Select().Where("id", "=", "user_id").Compile()
+		if err != nil {
+			t.Fatalf("Compile() failed: %v", err)
+		}
+		compiled.RequiredParams()[0] = "mutated"
+		if got := compiled.RequiredParams()[0]; got != "user_id" {
+			t.Errorf("RequiredParams()[0] = %q, want user_id", got)
+		}
+	})
+
+	t.Run("aggregate", func(t *testing.T) {
+		compiled, err := s.Sum("age").Where("name", "=", "name").Compile()
+		if err != nil {
+			t.Fatalf("Compile() failed: %v", err)
+		}
+		if !strings.Contains(compiled.SQL(), "SUM") {
+			t.Errorf("expected SUM in SQL: %s", compiled.SQL())
+		}
+	})
+}
+
+func TestCompile_Errors(t *testing.T) {
+	db := &sqlx.DB{}
+	s, err := New[batchTestUser](db, "users", postgres.New())
+	if err != nil {
+		t.Fatalf("New() failed: %v", err)
+	}
+
+	if _, err := s.Remove().Compile(); err == nil {
+		t.Error("expected error compiling DELETE without WHERE")
+	}
+	if _, err := s.Modify().Set("name", "name").Compile(); err == nil {
+		t.Error("expected error compiling UPDATE without WHERE")
+	}
+	if _, err := s.Query().Where("missing", "=", "x").Compile(); err == nil {
+		t.Error("expected error compiling query with invalid field")
+	}
+}
+
+func TestCompile_UpdateFallbackSelect(t *testing.T) {
+	db := &sqlx.DB{}
+	s, err := New[batchTestUser](db, "users", mariadb.New())
+	if err != nil {
+		t.Fatalf("New() failed: %v", err)
+	}
+
+	compiled, err := s.Modify().Set("name", "name").Where("id", "=", "user_id").Compile()
+	if err != nil {
+		t.Fatalf("Compile() failed: %v", err)
+	}
+	if !strings.HasPrefix(compiled.selectSQL, "SELECT") {
+		t.Errorf("expected fallback SELECT to be rendered, got %q", compiled.selectSQL)
+	}
+}
+
+func TestCompile_ConcurrentExec(t *testing.T) {
+	db, _ := newStmtCacheDB(t)
+	db.SetMaxOpenConns(4)
+	s, err := New[batchTestUser](db, "users", postgres.New())
+	if err != nil {
+		t.Fatalf("New() failed: %v", err)
+	}
+	if err := s.EnableStatementCache(4); err != nil {
+		t.Fatalf("EnableStatementCache() failed: %v", err)
+	}
+
+	compiled, err := s.Remove().Where("id", "=", "user_id").Compile()
+	if err != nil {
+		t.Fatalf("Compile() failed: %v", err)
+	}
+
+	var wg sync.WaitGroup
+	for i := range 16 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			affected, err := compiled.Exec(t.Context(), map[string]any{"user_id": i})
+			if err != nil {
+				t.Errorf("Exec() failed: %v", err)
+				return
+			}
+			if affected != 1 {
+				t.Errorf("affected = %d, want 1", affected)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if got := s.statements().size(); got != 1 {
+		t.Errorf("cached statements = %d, want 1", got)
+	}
+}
@@ -29,12 +29,12 @@ func (cb *Compound[T]) Union(other *Query[T]) *Compound[T] {
 	if cb.err != nil {
 		return cb
 	}
-	if other.err != nil {
-		cb.err = other.err
+	if err := compoundOperandErr(cb.soy, other); err != nil {
+		cb.err = err
 		return cb
 	}
 
-	cb.builder = cb.builder.Union(other.builder)
+	cb.builder = cb.builder.Union(other.withConditions())
 	return cb
 }
 
@@ -50,12 +50,12 @@ func (cb *Compound[T]) UnionAll(other *Query[T]) *Compound[T] {
 	if cb.err != nil {
 		return cb
 	}
-	if other.err != nil {
-		cb.err = other.err
+	if err := compoundOperandErr(cb.soy, other); err != nil {
+		cb.err = err
 		return cb
 	}
 
-	cb.builder = cb.builder.UnionAll(other.builder)
+	cb.builder = cb.builder.UnionAll(other.withConditions())
 	return cb
 }
 
@@ -71,12 +71,12 @@ func (cb *Compound[T]) Intersect(other *Query[T]) *Compound[T] {
 	if cb.err != nil {
 		return cb
 	}
-	if other.err != nil {
-		cb.err = other.err
+	if err := compoundOperandErr(cb.soy, other); err != nil {
+		cb.err = err
 		return cb
 	}
 
-	cb.builder = cb.builder.Intersect(other.builder)
+	cb.builder = cb.builder.Intersect(other.withConditions())
 	return cb
 }
 
@@ -86,12 +86,12 @@ func (cb *Compound[T]) IntersectAll(other *Query[T]) *Compound[T] {
 	if cb.err != nil {
 		return cb
 	}
-	if other.err != nil {
-		cb.err = other.err
+	if err := compoundOperandErr(cb.soy, other); err != nil {
+		cb.err = err
 		return cb
 	}
 
-	cb.builder = cb.builder.IntersectAll(other.builder)
+	cb.builder = cb.builder.IntersectAll(other.withConditions())
 	return cb
 }
 
@@ -107,12 +107,12 @@ func (cb *Compound[T]) Except(other *Query[T]) *Compound[T] {
 	if cb.err != nil {
 		return cb
 	}
-	if other.err != nil {
-		cb.err = other.err
+	if err := compoundOperandErr(cb.soy, other); err != nil {
+		cb.err = err
 		return cb
 	}
 
-	cb.builder = cb.builder.Except(other.builder)
+	cb.builder = cb.builder.Except(other.withConditions())
 	return cb
 }
 
@@ -122,12 +122,12 @@ func (cb *Compound[T]) ExceptAll(other *Query[T]) *Compound[T] {
 	if cb.err != nil {
 		return cb
 	}
-	if other.err != nil {
-		cb.err = other.err
+	if err := compoundOperandErr(cb.soy, other); err != nil {
+		cb.err = err
 		return cb
 	}
 
-	cb.builder = cb.builder.ExceptAll(other.builder)
+	cb.builder = cb.builder.ExceptAll(other.withConditions())
 	return cb
 }
 
@@ -214,6 +214,16 @@ func (cb *Compound[T]) OffsetParam(param string) *Compound[T] {
 	return cb
 }
 
+// Clone returns an independent copy of the compound query, including any stored error.
+func (cb *Compound[T]) Clone() *Compound[T] {
+	return &Compound[T]{
+		instance: cb.instance,
+		builder:  cloneCompoundBuilder(cb.builder),
+		soy:      cb.soy,
+		err:      cb.err,
+	}
+}
+
 // Render builds and renders the compound query to SQL.
 func (cb *Compound[T]) Render() (*astql.QueryResult, error) {
 	if cb.err != nil {
@@ -257,7 +267,15 @@ func (cb *Compound[T]) exec(ctx context.Context, execer sqlx.ExtContext, params
 		return nil, fmt.Errorf("failed to render compound query: %w", err)
 	}
 
-	return execMultipleRows[T](ctx, execer, result.SQL, params, cb.soy.getTableName(), "COMPOUND", func(ctx context.Context, result *T) error {
+	params = cb.soy.bindCompoundParams(params, result.RequiredParams)
+	if err := validateParams(result.RequiredParams, params, cb.soy.strictParams()); err != nil {
+		return nil, err
+	}
+	if err := compoundEnumBindings(cb.instance, cb.builder).validate(params); err != nil {
+		return nil, err
+	}
+
+	return execMultipleRows[T](ctx, execer, cb.soy.statements(), dialectOf(cb.soy.renderer()), result.SQL, params, cb.soy.getTableName(), "COMPOUND", func(ctx context.Context, result *T) error {
 		return cb.soy.callOnScan(ctx, result)
 	})
 }
@@ -266,3 +284,19 @@ func (cb *Compound[T]) exec(ctx context.Context, execer sqlx.ExtContext, params
 func (cb *Compound[T]) Instance() *astql.ASTQL {
 	return cb.instance
 }
+
+// compoundOperandErr returns the error that prevents other from being combined into a
+// compound query of soy: its stored error, or a tenant scope or named scopes that differ
+// from soy's, whose implicit params are bound for every operand.
+func compoundOperandErr[T any](soy soyExecutor, other *Query[T]) error {
+	if other.err != nil {
+		return other.err
+	}
+	if !sameTenant(soy.tenant(), other.soy.tenant()) {
+		return fmt.Errorf("cannot combine queries with different tenant scopes")
+	}
+	if !sameScopes(soy.namedScopes(), other.soy.namedScopes()) {
+		return fmt.Errorf("cannot combine queries with different named scopes")
+	}
+	return nil
+}
@@ -0,0 +1,185 @@
+package soy
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"iter"
+	"reflect"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/zoobzio/capitan"
+)
+
+// txBeginner starts the transaction that statements needing a single connection run in,
+// such as COPY and queries with SET LOCAL settings.
+type txBeginner interface {
+	BeginTxx(ctx context.Context, opts *sql.TxOptions) (*sqlx.Tx, error)
+}
+
+// copyPreparer prepares the COPY statement that rows are streamed through.
+type copyPreparer interface {
+	PrepareContext(ctx context.Context, query string) (*sql.Stmt, error)
+}
+
+// Copy streams records into the table using the PostgreSQL COPY protocol.
+// Columns match ExecBatch: every mapped column except the primary key.
+// Returns the number of records copied.
+//
+// COPY is sent as a prepared "COPY ... FROM STDIN" statement, with one Exec per record
+// and a final empty Exec to flush, which is the COPY interface exposed by lib/pq.
+// When the Soy was created with a *sqlx.DB, the copy runs in its own transaction.
+// Conflict clauses are not supported by COPY.
+//
+// Example:
+//
+//	count, err := soy.Insert().Copy(ctx, slices.Values(users))
+func (cb *Create[T]) Copy(ctx context.Context, source iter.Seq[*T]) (int64, error) {
+	return cb.copy(ctx, cb.soy.execer(), source)
+}
+
+// CopyTx streams records into the table using the PostgreSQL COPY protocol within a transaction.
+//
+// Example:
+//
+//	tx, _ := db.BeginTxx(ctx, nil)
+//	defer tx.Rollback()
+//	count, err := soy.Insert().CopyTx(ctx, tx, slices.Values(users))
+//	tx.Commit()
+func (cb *Create[T]) CopyTx(ctx context.Context, tx *sqlx.Tx, source iter.Seq[*T]) (int64, error) {
+	return cb.copy(ctx, tx, source)
+}
+
+// copy is the internal COPY method used by both Copy and CopyTx.
+func (cb *Create[T]) copy(ctx context.Context, execer sqlx.ExtContext, source iter.Seq[*T]) (int64, error) {
+	if cb.err != nil {
+		return 0, fmt.Errorf("create builder has errors: %w", cb.err)
+	}
+
+	if dialectOf(cb.soy.renderer()) != dialectPostgres {
+		return 0, fmt.Errorf("COPY is only supported for PostgreSQL")
+	}
+
+	if cb.hasConflict {
+		return 0, fmt.Errorf("COPY does not support ON CONFLICT clauses; use ExecBatchUpsert")
+	}
+
+	if source == nil {
+		return 0, fmt.Errorf("COPY source cannot be nil")
+	}
+
+	// A bare connection pool needs a transaction; COPY must run on a single connection
+	if beginner, ok := execer.(txBeginner); ok {
+		tx, err := beginner.BeginTxx(ctx, nil)
+		if err != nil {
+			return 0, fmt.Errorf("failed to begin COPY transaction: %w", err)
+		}
+		defer func() { _ = tx.Rollback() }()
+
+		count, err := cb.copyIn(ctx, tx, source)
+		if err != nil {
+			return 0, err
+		}
+		if err := tx.Commit(); err != nil {
+			return 0, fmt.Errorf("failed to commit COPY transaction: %w", err)
+		}
+		return count, nil
+	}
+
+	preparer, ok := execer.(copyPreparer)
+	if !ok {
+		return 0, fmt.Errorf("COPY requires an executor that can prepare statements")
+	}
+	return cb.copyIn(ctx, preparer, source)
+}
+
+// copyIn streams records through a prepared COPY statement and emits INSERT_COPY events.
+func (cb *Create[T]) copyIn(ctx context.Context, preparer copyPreparer, source iter.Seq[*T]) (int64, error) {
+	tableName := cb.soy.getTableName()
+	columns := cb.insertColumns(false)
+	fields := columnFields(cb.soy.getMetadata())
+	query := cb.renderCopy(columns)
+
+	capitan.Debug(ctx, QueryStarted,
+		TableKey.Field(tableName),
+		OperationKey.Field("INSERT_COPY"),
+		SQLKey.Field(query),
+	)
+
+	startTime := time.Now()
+
+	fail := func(err error) (int64, error) {
+		durationMs := time.Since(startTime).Milliseconds()
+		capitan.Error(ctx, QueryFailed,
+			TableKey.Field(tableName),
+			OperationKey.Field("INSERT_COPY"),
+			DurationMsKey.Field(durationMs),
+			ErrorKey.Field(err.Error()),
+		)
+		return 0, err
+	}
+
+	stmt, err := preparer.PrepareContext(ctx, query)
+	if err != nil {
+		return fail(fmt.Errorf("failed to prepare COPY: %w", err))
+	}
+	defer func() { _ = stmt.Close() }()
+
+	var i int
+	for record := range source {
+		// Guard against nil records
+		if record == nil {
+			return fail(fmt.Errorf("nil record at index %d", i))
+		}
+		// Call onRecord before processing
+		if cbErr := cb.soy.callOnRecord(ctx, record); cbErr != nil {
+			return fail(fmt.Errorf("onRecord callback failed at index %d: %w", i, cbErr))
+		}
+		if err := validateEnumRecord(cb.soy.getInstance(), cb.soy.getMetadata(), record); err != nil {
+			return fail(fmt.Errorf("record at index %d: %w", i, err))
+		}
+
+		rv := reflect.ValueOf(record).Elem()
+		values := make([]any, len(columns))
+		for j, dbCol := range columns {
+			value, err := encodeValue(dialectOf(cb.soy.renderer()), rv.FieldByIndex(fields[dbCol].Index).Interface())
+			if err != nil {
+				return fail(fmt.Errorf("record at index %d field %q: %w", i, fields[dbCol].Name, err))
+			}
+			values[j] = value
+		}
+
+		if _, err := stmt.ExecContext(ctx, values...); err != nil {
+			return fail(fmt.Errorf("COPY failed at index %d: %w", i, err))
+		}
+		i++
+	}
+
+	// An empty Exec flushes the buffered rows and ends the COPY
+	res, err := stmt.ExecContext(ctx)
+	if err != nil {
+		return fail(fmt.Errorf("COPY failed: %w", err))
+	}
+
+	count, err := res.RowsAffected()
+	if err != nil {
+		count = int64(i)
+	}
+
+	durationMs := time.Since(startTime).Milliseconds()
+	capitan.Info(ctx, QueryCompleted,
+		TableKey.Field(tableName),
+		OperationKey.Field("INSERT_COPY"),
+		DurationMsKey.Field(durationMs),
+		RowsAffectedKey.Field(count),
+	)
+
+	return count, nil
+}
+
+// renderCopy renders the COPY FROM STDIN statement for columns.
+func (cb *Create[T]) renderCopy(columns []string) string {
+	d := dialectPostgres
+	return "COPY " + d.quote(cb.soy.getTableName()) + " (" + d.quoteAll(columns) + ") FROM STDIN"
+}
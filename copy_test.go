@@ -0,0 +1,70 @@
+package soy
+
+import (
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/zoobzio/astql/postgres"
+	"github.com/zoobzio/astql/sqlite"
+	"github.com/zoobzio/sentinel"
+)
+
+func TestCopy_Render(t *testing.T) {
+	sentinel.Tag("db")
+	sentinel.Tag("type")
+	sentinel.Tag("constraints")
+
+	db := &sqlx.DB{}
+	s, err := New[createTestUser](db, "users", postgres.New())
+	if err != nil {
+		t.Fatalf("New() failed: %v", err)
+	}
+
+	create := s.Insert()
+	sql := create.renderCopy(create.insertColumns(false))
+
+	want := `COPY "users" ("email", "name", "age") FROM STDIN`
+	if sql != want {
+		t.Errorf("renderCopy() = %s, want %s", sql, want)
+	}
+}
+
+func TestCopy_ErrorPaths(t *testing.T) {
+	db := &sqlx.DB{}
+
+	t.Run("non-PostgreSQL renderer", func(t *testing.T) {
+		s, err := New[createTestUser](db, "users", sqlite.New())
+		if err != nil {
+			t.Fatalf("New() failed: %v", err)
+		}
+
+		_, err = s.Insert().Copy(t.Context(), slices.Values([]*createTestUser{{Email: "a@example.com"}}))
+		if err == nil {
+			t.Fatal("expected error for non-PostgreSQL renderer")
+		}
+		if !strings.Contains(err.Error(), "PostgreSQL") {
+			t.Errorf("error should mention PostgreSQL: %v", err)
+		}
+	})
+
+	s, err := New[createTestUser](db, "users", postgres.New())
+	if err != nil {
+		t.Fatalf("New() failed: %v", err)
+	}
+
+	t.Run("ON CONFLICT is rejected", func(t *testing.T) {
+		_, err := s.Insert().OnConflict("email").DoNothing().Copy(t.Context(), slices.Values([]*createTestUser{}))
+		if err == nil {
+			t.Fatal("expected error for COPY with ON CONFLICT")
+		}
+	})
+
+	t.Run("nil source", func(t *testing.T) {
+		_, err := s.Insert().Copy(t.Context(), nil)
+		if err == nil {
+			t.Fatal("expected error for nil source")
+		}
+	})
+}
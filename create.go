@@ -29,6 +29,8 @@ type Create[T any] struct {
 
 // OnConflict adds an ON CONFLICT clause for handling unique constraint violations.
 // Specify the column(s) that might conflict.
+// On MariaDB they must be the primary key or a unique key of T, since ON DUPLICATE KEY
+// UPDATE matches on the table's unique keys rather than on named columns.
 //
 // Example:
 //
@@ -43,6 +45,12 @@ func (cb *Create[T]) OnConflict(columns ...string) *Conflict[T] {
 		}
 	}
 
+	if cb.err = cb.soy.tenant().checkConflict(columns); cb.err != nil {
+		return &Conflict[T]{
+			create: cb,
+		}
+	}
+
 	// Track conflict for fallback upsert
 	cb.hasConflict = true
 	cb.conflictColumns = columns
@@ -61,6 +69,12 @@ func (cb *Create[T]) OnConflict(columns ...string) *Conflict[T] {
 		fields = append(fields, f)
 	}
 
+	if cb.err = cb.checkUniqueKey(columns); cb.err != nil {
+		return &Conflict[T]{
+			create: cb,
+		}
+	}
+
 	astqlConflict := cb.builder.OnConflict(fields...)
 
 	return &Conflict[T]{
@@ -110,6 +124,7 @@ func (cb *Create[T]) ExecTxAtom(ctx context.Context, tx *sqlx.Tx, params map[str
 
 // execAtom is the internal atom execution method used by both ExecAtom and ExecTxAtom.
 func (cb *Create[T]) execAtom(ctx context.Context, execer sqlx.ExtContext, params map[string]any) (*atom.Atom, error) {
+	params = cb.soy.tenant().insertParams(params)
 	if cb.err != nil {
 		return nil, fmt.Errorf("create builder has errors: %w", cb.err)
 	}
@@ -119,11 +134,20 @@ func (cb *Create[T]) execAtom(ctx context.Context, execer sqlx.ExtContext, param
 		return nil, fmt.Errorf("failed to render INSERT query: %w", err)
 	}
 
-	return execAtomSingleRow(ctx, execer, cb.soy.atomScanner(), result.SQL, params, cb.soy.getTableName(), "INSERT")
+	if err := validateParams(result.RequiredParams, params, cb.soy.strictParams()); err != nil {
+		return nil, err
+	}
+	if err := validateEnumParams(cb.instance, cb.builder, params); err != nil {
+		return nil, err
+	}
+
+	return execAtomSingleRow(ctx, execer, cb.soy.statements(), dialectOf(cb.soy.renderer()), cb.soy.atomScanner(), result.SQL, params, cb.soy.getTableName(), "INSERT")
 }
 
 // ExecBatch executes the INSERT query for multiple records.
 // Returns the number of records inserted.
+// With OnConflict, the batch is upserted and the count includes updated rows;
+// use ExecBatchUpsert for separate inserted, updated, and skipped counts.
 //
 // Example:
 //
@@ -151,6 +175,7 @@ func (cb *Create[T]) ExecBatchTx(ctx context.Context, tx *sqlx.Tx, records []*T)
 
 // execBatch is the internal batch execution method.
 // It builds a single multi-row INSERT query and executes it once.
+// When ON CONFLICT is configured, it delegates to the set-based batch upsert.
 func (cb *Create[T]) execBatch(ctx context.Context, execer sqlx.ExtContext, records []*T) (int64, error) {
 	if cb.err != nil {
 		return 0, fmt.Errorf("create builder has errors: %w", cb.err)
@@ -160,72 +185,20 @@ func (cb *Create[T]) execBatch(ctx context.Context, execer sqlx.ExtContext, reco
 		return 0, nil
 	}
 
-	// Batch insert does not support ON CONFLICT clauses
 	if cb.hasConflict {
-		return 0, fmt.Errorf("batch insert does not support ON CONFLICT clauses; use individual Exec calls for upsert operations")
+		result, err := cb.execBatchUpsert(ctx, execer, records)
+		if err != nil {
+			return 0, err
+		}
+		return result.Inserted + result.Updated, nil
 	}
 
-	instance := cb.soy.getInstance()
-	metadata := cb.soy.getMetadata()
 	tableName := cb.soy.getTableName()
 
-	t, err := instance.TryT(tableName)
-	if err != nil {
-		return 0, fmt.Errorf("invalid table %q: %w", tableName, err)
-	}
-
 	// Build multi-row INSERT with indexed params
-	builder := astql.Insert(t)
-	combinedParams := make(map[string]any)
-
-	for i, record := range records {
-		// Guard against nil records
-		if record == nil {
-			return 0, fmt.Errorf("nil record at index %d", i)
-		}
-		rv := reflect.ValueOf(record)
-		if !rv.IsValid() || (rv.Kind() == reflect.Ptr && rv.IsNil()) {
-			return 0, fmt.Errorf("invalid record at index %d", i)
-		}
-		// Call onRecord before processing
-		if cbErr := cb.soy.callOnRecord(ctx, record); cbErr != nil {
-			return 0, fmt.Errorf("onRecord callback failed at index %d: %w", i, cbErr)
-		}
-		rv = rv.Elem()
-
-		values := instance.ValueMap()
-
-		for _, field := range metadata.Fields {
-			dbCol := field.Tags["db"]
-			if dbCol == "" || dbCol == "-" {
-				continue
-			}
-			// Skip primary key columns (auto-generated)
-			constraints := field.Tags["constraints"]
-			if contains(constraints, "primarykey") || contains(constraints, "primary_key") {
-				continue
-			}
-
-			// Create indexed param name
-			indexedParam := fmt.Sprintf("%s_%d", dbCol, i)
-
-			f, fErr := instance.TryF(dbCol)
-			if fErr != nil {
-				return 0, fmt.Errorf("invalid field %q: %w", dbCol, fErr)
-			}
-			p, pErr := instance.TryP(indexedParam)
-			if pErr != nil {
-				return 0, fmt.Errorf("invalid param %q: %w", indexedParam, pErr)
-			}
-
-			values[f] = p
-
-			// Extract value from struct field
-			fieldVal := rv.FieldByName(field.Name)
-			combinedParams[indexedParam] = fieldVal.Interface()
-		}
-
-		builder = builder.Values(values)
+	builder, combinedParams, err := cb.buildBatchInsert(ctx, records, cb.insertColumns(false))
+	if err != nil {
+		return 0, err
 	}
 
 	// Render the multi-row query
@@ -280,6 +253,93 @@ func (cb *Create[T]) execBatch(ctx context.Context, execer sqlx.ExtContext, reco
 	return count, nil
 }
 
+// insertColumns returns the columns written by a batch INSERT, in metadata order.
+// Primary key columns are skipped unless includePK is set, since they are usually auto-generated.
+// Generated columns are always skipped.
+func (cb *Create[T]) insertColumns(includePK bool) []string {
+	metadata := cb.soy.getMetadata()
+	columns := make([]string, 0, len(metadata.Fields))
+	for _, field := range metadata.Fields {
+		dbCol := field.Tags["db"]
+		if dbCol == "" || dbCol == "-" || generatedColumn(field) {
+			continue
+		}
+		constraints := field.Tags["constraints"]
+		if !includePK && (contains(constraints, "primarykey") || contains(constraints, "primary_key")) {
+			continue
+		}
+		columns = append(columns, dbCol)
+	}
+	return columns
+}
+
+// buildBatchInsert builds a multi-row INSERT for records over the given columns.
+// Each record's values are bound to indexed params (column_index) in the returned params map.
+// The onRecord callback is invoked for every record before its values are extracted.
+func (cb *Create[T]) buildBatchInsert(ctx context.Context, records []*T, columns []string) (*astql.Builder, map[string]any, error) {
+	instance := cb.soy.getInstance()
+	metadata := cb.soy.getMetadata()
+	tableName := cb.soy.getTableName()
+
+	t, err := instance.TryT(tableName)
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid table %q: %w", tableName, err)
+	}
+
+	fields := columnFields(metadata)
+
+	builder := astql.Insert(t)
+	combinedParams := make(map[string]any, len(records)*len(columns))
+
+	for i, record := range records {
+		// Guard against nil records
+		if record == nil {
+			return nil, nil, fmt.Errorf("nil record at index %d", i)
+		}
+		rv := reflect.ValueOf(record)
+		if !rv.IsValid() || (rv.Kind() == reflect.Ptr && rv.IsNil()) {
+			return nil, nil, fmt.Errorf("invalid record at index %d", i)
+		}
+		// Call onRecord before processing
+		if cbErr := cb.soy.callOnRecord(ctx, record); cbErr != nil {
+			return nil, nil, fmt.Errorf("onRecord callback failed at index %d: %w", i, cbErr)
+		}
+		if err := validateEnumRecord(instance, metadata, record); err != nil {
+			return nil, nil, fmt.Errorf("record at index %d: %w", i, err)
+		}
+		rv = rv.Elem()
+
+		values := instance.ValueMap()
+
+		for _, dbCol := range columns {
+			// Create indexed param name
+			indexedParam := fmt.Sprintf("%s_%d", dbCol, i)
+
+			f, fErr := instance.TryF(dbCol)
+			if fErr != nil {
+				return nil, nil, fmt.Errorf("invalid field %q: %w", dbCol, fErr)
+			}
+			p, pErr := instance.TryP(indexedParam)
+			if pErr != nil {
+				return nil, nil, fmt.Errorf("invalid param %q: %w", indexedParam, pErr)
+			}
+
+			values[f] = p
+
+			// Extract value from struct field, encoding registered custom types
+			value, vErr := encodeValue(dialectOf(cb.soy.renderer()), rv.FieldByIndex(fields[dbCol].Index).Interface())
+			if vErr != nil {
+				return nil, nil, fmt.Errorf("record at index %d field %q: %w", i, fields[dbCol].Name, vErr)
+			}
+			combinedParams[indexedParam] = value
+		}
+
+		builder = builder.Values(values)
+	}
+
+	return builder, combinedParams, nil
+}
+
 // exec is the internal execution method used by both Exec and ExecTx.
 func (cb *Create[T]) exec(ctx context.Context, execer sqlx.ExtContext, record *T) (*T, error) {
 	// Check for errors first
@@ -319,9 +379,17 @@ func (cb *Create[T]) execWithUpsert(ctx context.Context, execer sqlx.ExtContext,
 	if cbErr := cb.soy.callOnRecord(ctx, record); cbErr != nil {
 		return nil, fmt.Errorf("onRecord callback failed: %w", cbErr)
 	}
+	if err := validateEnumRecord(cb.instance, cb.soy.getMetadata(), record); err != nil {
+		return nil, err
+	}
+
+	arg, err := recordArg(dialectOf(cb.soy.renderer()), cb.soy.getMetadata(), record)
+	if err != nil {
+		return nil, err
+	}
 
 	// Execute named query with RETURNING
-	rows, err := sqlx.NamedQueryContext(ctx, execer, result.SQL, record)
+	rows, err := cb.soy.statements().namedQuery(ctx, execer, dialectOf(cb.soy.renderer()), result.SQL, arg)
 	if err != nil {
 		durationMs := time.Since(startTime).Milliseconds()
 		capitan.Error(ctx, QueryFailed,
@@ -347,7 +415,7 @@ func (cb *Create[T]) execWithUpsert(ctx context.Context, execer sqlx.ExtContext,
 	}
 
 	var inserted T
-	if err := rows.StructScan(&inserted); err != nil {
+	if err := scanStruct(dialectOf(cb.soy.renderer()), rows, &inserted); err != nil {
 		durationMs := time.Since(startTime).Milliseconds()
 		capitan.Error(ctx, QueryFailed,
 			TableKey.Field(tableName),
@@ -420,6 +488,9 @@ func (cb *Create[T]) execUpdateThenInsert(ctx context.Context, execer sqlx.ExtCo
 	if cbErr := cb.soy.callOnRecord(ctx, record); cbErr != nil {
 		return nil, fmt.Errorf("onRecord callback failed: %w", cbErr)
 	}
+	if err := validateEnumRecord(cb.instance, cb.soy.getMetadata(), record); err != nil {
+		return nil, err
+	}
 
 	// Try UPDATE first
 	result, err := updateBuilder.Render(cb.soy.renderer())
@@ -433,7 +504,11 @@ func (cb *Create[T]) execUpdateThenInsert(ctx context.Context, execer sqlx.ExtCo
 		SQLKey.Field(result.SQL),
 	)
 
-	res, err := sqlx.NamedExecContext(ctx, execer, result.SQL, record)
+	arg, err := recordArg(dialectOf(cb.soy.renderer()), cb.soy.getMetadata(), record)
+	if err != nil {
+		return nil, err
+	}
+	res, err := cb.soy.statements().namedExec(ctx, execer, dialectOf(cb.soy.renderer()), result.SQL, arg)
 	if err != nil {
 		durationMs := time.Since(startTime).Milliseconds()
 		capitan.Error(ctx, QueryFailed,
@@ -469,7 +544,7 @@ func (cb *Create[T]) execUpdateThenInsert(ctx context.Context, execer sqlx.ExtCo
 			continue
 		}
 		constraints := field.Tags["constraints"]
-		if contains(constraints, "primarykey") || contains(constraints, "primary_key") {
+		if contains(constraints, "primarykey") || contains(constraints, "primary_key") || generatedColumn(field) {
 			continue
 		}
 		f, fErr := instance.TryF(dbCol)
@@ -508,7 +583,7 @@ func (cb *Create[T]) execUpdateThenInsert(ctx context.Context, execer sqlx.ExtCo
 		SQLKey.Field(insertResult.SQL),
 	)
 
-	rows, err := sqlx.NamedQueryContext(ctx, execer, insertResult.SQL, record)
+	rows, err := cb.soy.statements().namedQuery(ctx, execer, dialectOf(cb.soy.renderer()), insertResult.SQL, arg)
 	if err != nil {
 		durationMs := time.Since(startTime).Milliseconds()
 		capitan.Error(ctx, QueryFailed,
@@ -526,7 +601,7 @@ func (cb *Create[T]) execUpdateThenInsert(ctx context.Context, execer sqlx.ExtCo
 	}
 
 	var inserted T
-	if err := rows.StructScan(&inserted); err != nil {
+	if err := scanStruct(dialectOf(cb.soy.renderer()), rows, &inserted); err != nil {
 		return nil, fmt.Errorf("failed to scan INSERT result: %w", err)
 	}
 
@@ -577,7 +652,11 @@ func (cb *Create[T]) selectByConflictColumns(ctx context.Context, execer sqlx.Ex
 		return nil, fmt.Errorf("failed to render SELECT query: %w", err)
 	}
 
-	rows, err := sqlx.NamedQueryContext(ctx, execer, result.SQL, record)
+	arg, err := recordArg(dialectOf(cb.soy.renderer()), cb.soy.getMetadata(), record)
+	if err != nil {
+		return nil, err
+	}
+	rows, err := cb.soy.statements().namedQuery(ctx, execer, dialectOf(cb.soy.renderer()), result.SQL, arg)
 	if err != nil {
 		return nil, fmt.Errorf("SELECT failed: %w", err)
 	}
@@ -588,7 +667,7 @@ func (cb *Create[T]) selectByConflictColumns(ctx context.Context, execer sqlx.Ex
 	}
 
 	var selected T
-	if err := rows.StructScan(&selected); err != nil {
+	if err := scanStruct(dialectOf(cb.soy.renderer()), rows, &selected); err != nil {
 		return nil, fmt.Errorf("failed to scan SELECT result: %w", err)
 	}
 
@@ -655,6 +734,8 @@ func (cfb *Conflict[T]) DoNothing() *Create[T] {
 
 // DoUpdate starts building a DO UPDATE SET clause.
 // Use Set() to specify which fields to update on conflict.
+// Instances returned by ForTenant cannot use it on MariaDB, where ON DUPLICATE KEY UPDATE
+// matches any unique key, not just the conflict columns.
 //
 // Example:
 //
@@ -669,6 +750,10 @@ func (cfb *Conflict[T]) DoUpdate() *ConflictUpdate[T] {
 		astqlUpdate = cfb.astqlConflict.DoUpdate()
 	}
 
+	if cfb.create.err == nil {
+		cfb.create.err = cfb.create.soy.tenant().checkUpdate(dialectOf(cfb.create.soy.renderer()))
+	}
+
 	return &ConflictUpdate[T]{
 		create:      cfb.create,
 		astqlUpdate: astqlUpdate,
@@ -691,6 +776,9 @@ func (cub *ConflictUpdate[T]) Set(field, param string) *ConflictUpdate[T] {
 	if cub.create.err != nil {
 		return cub
 	}
+	if cub.create.err = cub.create.soy.tenant().checkSet(field); cub.create.err != nil {
+		return cub
+	}
 
 	// Track for fallback upsert
 	cub.create.updateFields[field] = param
@@ -713,7 +801,9 @@ func (cub *ConflictUpdate[T]) Set(field, param string) *ConflictUpdate[T] {
 
 // Build finalizes the conflict update and returns the Create for execution.
 func (cub *ConflictUpdate[T]) Build() *Create[T] {
-	cub.create.builder = cub.astqlUpdate.Build()
+	if cub.astqlUpdate != nil {
+		cub.create.builder = cub.astqlUpdate.Build()
+	}
 	return cub.create
 }
 
@@ -619,20 +619,21 @@ func TestCreate_BatchErrorPaths(t *testing.T) {
 		t.Fatalf("New() failed: %v", err)
 	}
 
-	t.Run("batch with ON CONFLICT returns error", func(t *testing.T) {
+	t.Run("batch with ON CONFLICT checks records", func(t *testing.T) {
 		create := s.Insert().OnConflict("email").DoNothing()
 
 		age := 25
 		users := []*createTestUser{
 			{Email: "a@example.com", Name: "A", Age: &age},
+			nil,
 		}
 
 		_, err := create.ExecBatch(t.Context(), users)
 		if err == nil {
-			t.Fatal("expected error for batch with ON CONFLICT")
+			t.Fatal("expected error for nil record")
 		}
-		if !strings.Contains(err.Error(), "ON CONFLICT") {
-			t.Errorf("error should mention ON CONFLICT: %v", err)
+		if !strings.Contains(err.Error(), "nil record at index 1") {
+			t.Errorf("error should identify nil record index: %v", err)
 		}
 	})
 
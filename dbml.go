@@ -2,45 +2,83 @@ package soy
 
 import (
 	"fmt"
+	"regexp"
+	"slices"
+	"strconv"
 	"strings"
 
 	"github.com/zoobzio/dbml"
 	"github.com/zoobzio/sentinel"
+	"github.com/zoobzio/soy/internal/ddl"
 )
 
+// generatedColumn reports whether field's column is generated by the database, like a
+// tsvector column, and so is never written.
+func generatedColumn(field sentinel.FieldMetadata) bool {
+	_, ok := field.Tags["tsvector"]
+	return ok
+}
+
 // buildDBMLFromStruct creates a DBML project from a struct's Sentinel metadata.
 // This converts struct tags (db, type, constraints, etc.) into a complete DBML schema.
-func buildDBMLFromStruct(metadata sentinel.Metadata, tableName string) (*dbml.Project, error) {
+// Untagged column types, the project database type and the schema follow the dialect.
+func buildDBMLFromStruct(metadata sentinel.Metadata, tableName string, d dialect) (*dbml.Project, error) {
 	project := dbml.NewProject(tableName).
-		WithDatabaseType("PostgreSQL")
+		WithDatabaseType(d.databaseType())
 
 	table := dbml.NewTable(tableName).
-		WithSchema("public")
-
-	// Track which columns are part of indexes
-	indexedColumns := make(map[string]string) // column name -> index name
+		WithSchema(d.schema())
 
 	// First pass: collect index information
-	for _, field := range metadata.Fields {
-		if indexName, hasIndex := field.Tags["index"]; hasIndex {
-			if dbTag, ok := field.Tags["db"]; ok && dbTag != "" {
-				indexedColumns[dbTag] = indexName
-			}
-		}
+	indexes, err := collectIndexes(metadata)
+	if err != nil {
+		return nil, err
 	}
 
 	// Second pass: build columns
 	for _, field := range metadata.Fields {
 		// Skip fields without db tag
 		dbTag, ok := field.Tags["db"]
-		if !ok || dbTag == "" {
+		if !ok || dbTag == "" || dbTag == "-" {
 			continue
 		}
 
-		// Get SQL type (explicit or inferred)
+		// Enum values, as a native enum type where the dialect has one and the column
+		// is untyped, otherwise as a CHECK constraint
+		var enumValues []string
+		if enumTag, ok := field.Tags["enum"]; ok {
+			values, err := parseEnumTag(enumTag)
+			if err != nil {
+				return nil, fmt.Errorf("field %q: %w", field.Name, err)
+			}
+			enumValues = values
+		}
+
+		// Get SQL type (explicit, generated tsvector, native enum or inferred)
 		sqlType := field.Tags["type"]
+		if tsvectorTag, ok := field.Tags["tsvector"]; ok {
+			spec, err := parseTSVectorTag(tsvectorTag)
+			if err != nil {
+				return nil, fmt.Errorf("field %q: %w", field.Name, err)
+			}
+			if err := spec.validate(d, metadata); err != nil {
+				return nil, fmt.Errorf("field %q: %w", field.Name, err)
+			}
+			if sqlType != "" {
+				return nil, fmt.Errorf("field %q: tsvector tag sets the column type, remove the type tag", field.Name)
+			}
+			sqlType = spec.columnType(d)
+		}
+		nativeEnum := false
+		if sqlType == "" && enumValues != nil {
+			sqlType, nativeEnum = enumColumnType(project, d, tableName, dbTag, enumValues)
+		}
 		if sqlType == "" {
-			sqlType = inferPostgresType(field.Type)
+			inferred, err := inferSQLType(d, field.ReflectType)
+			if err != nil {
+				return nil, fmt.Errorf("field %q: %w", field.Name, err)
+			}
+			sqlType = inferred
 		}
 
 		// Create column
@@ -71,35 +109,52 @@ func buildDBMLFromStruct(metadata sentinel.Metadata, tableName string) (*dbml.Pr
 		}
 
 		// Check constraint
-		if checkExpr, ok := field.Tags["check"]; ok {
+		checkExpr, hasCheck := field.Tags["check"]
+		if enumValues != nil && !nativeEnum {
+			checkExpr, hasCheck = enumCheck(d, dbTag, enumValues, checkExpr), true
+		}
+		if hasCheck {
 			col.WithCheck(checkExpr)
 		}
 
-		// Foreign key reference
+		// Column comment
+		if description, ok := field.Tags["description"]; ok && description != "" {
+			col.WithNote(description)
+		}
+
+		// Foreign key reference, with actions, as a standalone ref
 		if references, ok := field.Tags["references"]; ok {
-			refTable, refColumn, err := parseReferenceTag(references)
+			ref, err := parseReferencesTag(references)
 			if err != nil {
 				return nil, fmt.Errorf("field %q: %w", field.Name, err)
 			}
 
-			// Determine relationship type (default to many-to-one)
-			relType := dbml.ManyToOne
-
-			// Create inline reference
-			col.WithRef(relType, "public", refTable, refColumn)
+			// One-to-one means at most one row per referenced row
+			if ref.relType == dbml.OneToOne {
+				col.WithUnique()
+			}
 
-			// Note: ON DELETE/ON UPDATE actions would need to be standalone Ref objects
-			// For now, inline refs don't support these actions in our DBML
-			// We could add them as standalone Ref objects if needed
+			dbmlRef := dbml.NewRef(ref.relType).
+				From(d.schema(), tableName, dbTag).
+				To(d.schema(), ref.table, ref.column)
+			if ref.onDelete != "" {
+				dbmlRef.WithOnDelete(ref.onDelete)
+			}
+			if ref.onUpdate != "" {
+				dbmlRef.WithOnUpdate(ref.onUpdate)
+			}
+			project.AddRef(dbmlRef)
 		}
 
 		table.AddColumn(col)
 	}
 
 	// Third pass: build indexes
-	for columnName, indexName := range indexedColumns {
-		index := dbml.NewIndex(columnName).
-			WithName(indexName)
+	built, err := indexes.build()
+	if err != nil {
+		return nil, err
+	}
+	for _, index := range built {
 		table.AddIndex(index)
 	}
 
@@ -113,6 +168,352 @@ func buildDBMLFromStruct(metadata sentinel.Metadata, tableName string) (*dbml.Pr
 	return project, nil
 }
 
+// collectIndexes collects the indexes declared by index tags, in field order so output
+// is stable. Generated tsvector columns get a GIN index.
+func collectIndexes(metadata sentinel.Metadata) (*indexSet, error) {
+	indexes := &indexSet{}
+	for _, field := range metadata.Fields {
+		if _, ok := field.Tags["tsvector"]; ok && field.Tags["db"] != "" && field.Tags["db"] != "-" {
+			if err := indexes.add(field.Tags["db"], indexSpec{method: "gin"}); err != nil {
+				return nil, fmt.Errorf("field %q: %w", field.Name, err)
+			}
+		}
+		indexTag, hasIndex := field.Tags["index"]
+		dbTag, ok := field.Tags["db"]
+		if !hasIndex || !ok || dbTag == "" {
+			continue
+		}
+		specs, err := parseIndexTag(indexTag)
+		if err != nil {
+			return nil, fmt.Errorf("field %q: %w", field.Name, err)
+		}
+		for _, spec := range specs {
+			if err := indexes.add(dbTag, spec); err != nil {
+				return nil, fmt.Errorf("field %q: %w", field.Name, err)
+			}
+		}
+	}
+	return indexes, nil
+}
+
+// uniqueKeys returns the column sets of the table's unique keys: the primary key, unique
+// and one-to-one columns, and unique indexes. Partial unique indexes are left out, since
+// not every row is unique on their columns.
+func uniqueKeys(metadata sentinel.Metadata) ([][]string, error) {
+	var keys [][]string
+	var primaryKey []string
+	for _, field := range metadata.Fields {
+		dbTag := field.Tags["db"]
+		if dbTag == "" || dbTag == "-" {
+			continue
+		}
+		_, unique, primary := parseConstraintsTag(field.Tags["constraints"])
+		if primary {
+			primaryKey = append(primaryKey, dbTag)
+		}
+		if references, ok := field.Tags["references"]; ok && !unique {
+			ref, err := parseReferencesTag(references)
+			if err != nil {
+				return nil, fmt.Errorf("field %q: %w", field.Name, err)
+			}
+			unique = ref.relType == dbml.OneToOne
+		}
+		if unique {
+			keys = append(keys, []string{dbTag})
+		}
+	}
+	if primaryKey != nil {
+		keys = append(keys, primaryKey)
+	}
+
+	indexes, err := collectIndexes(metadata)
+	if err != nil {
+		return nil, err
+	}
+	for _, entry := range indexes.indexes {
+		if !entry.unique || entry.where != "" {
+			continue
+		}
+		columns := make([]string, len(entry.columns))
+		for i, col := range entry.columns {
+			columns[i] = col.name
+		}
+		keys = append(keys, columns)
+	}
+	return keys, nil
+}
+
+// buildIndexOptions returns the options DBML cannot express of the indexes declared by
+// index tags, keyed by index name. Indexes without any are left out.
+func buildIndexOptions(metadata sentinel.Metadata, tableName string) (map[string]ddl.IndexOptions, error) {
+	indexes, err := collectIndexes(metadata)
+	if err != nil {
+		return nil, err
+	}
+	built, err := indexes.build()
+	if err != nil {
+		return nil, err
+	}
+	options := make(map[string]ddl.IndexOptions)
+	for i, entry := range indexes.indexes {
+		opts := ddl.IndexOptions{Where: entry.where}
+		for _, col := range entry.columns {
+			if col.opClass != "" {
+				if opts.OpClasses == nil {
+					opts.OpClasses = make(map[string]string)
+				}
+				opts.OpClasses[col.name] = col.opClass
+			}
+		}
+		if opts.Where != "" || opts.OpClasses != nil {
+			options[ddl.IndexName(tableName, built[i])] = opts
+		}
+	}
+	return options, nil
+}
+
+// opClassName matches the operator class names accepted by the index tag.
+var opClassName = regexp.MustCompile(`^[a-z_][a-z0-9_]*$`)
+
+// indexSpec is one index entry of an index tag.
+type indexSpec struct {
+	name     string // Empty for unnamed indexes
+	position int    // Column position within the index; 0 means field order
+	unique   bool
+	method   string // Index method, e.g. gin or hnsw
+	opClass  string // Operator class of the field's column, e.g. gin_trgm_ops
+	where    string // Partial index predicate
+}
+
+// parseIndexTag parses an index tag. Entries are separated by semicolons so a field
+// can belong to several indexes; each entry is a name followed by comma-separated options.
+// Example: "idx_members_org_email,position:2,unique,where:deleted_at IS NULL;idx_email".
+// ops: sets the operator class of the field's column, as in "true,type:gin,ops:gin_trgm_ops".
+// A name of "true" or "" declares an unnamed index. where: takes the rest of the entry,
+// so it must be the last option.
+func parseIndexTag(tag string) ([]indexSpec, error) {
+	var specs []indexSpec
+	for _, entry := range strings.Split(tag, ";") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		name, rest, _ := strings.Cut(entry, ",")
+		spec := indexSpec{name: strings.TrimSpace(name)}
+		if spec.name == "true" {
+			spec.name = ""
+		}
+
+		for rest != "" {
+			var opt string
+			if strings.HasPrefix(strings.TrimSpace(rest), "where:") {
+				opt, rest = strings.TrimSpace(rest), ""
+			} else {
+				opt, rest, _ = strings.Cut(rest, ",")
+				opt = strings.TrimSpace(opt)
+			}
+
+			key, value, _ := strings.Cut(opt, ":")
+			value = strings.TrimSpace(value)
+			switch key {
+			case "unique":
+				spec.unique = true
+			case "position":
+				n, err := strconv.Atoi(value)
+				if err != nil || n < 1 {
+					return nil, fmt.Errorf("invalid index position %q in %q, expected a positive integer", value, entry)
+				}
+				spec.position = n
+			case "type":
+				if value == "" {
+					return nil, fmt.Errorf("empty index type in %q", entry)
+				}
+				spec.method = value
+			case "ops":
+				if !opClassName.MatchString(value) {
+					return nil, fmt.Errorf("invalid operator class %q in %q", value, entry)
+				}
+				spec.opClass = value
+			case "where":
+				if value == "" {
+					return nil, fmt.Errorf("empty index predicate in %q", entry)
+				}
+				spec.where = value
+			default:
+				return nil, fmt.Errorf("unknown index option %q in %q", opt, entry)
+			}
+		}
+
+		if spec.name == "" && spec.position != 0 {
+			return nil, fmt.Errorf("unnamed index %q cannot have a position", entry)
+		}
+		specs = append(specs, spec)
+	}
+
+	// An empty tag or index:"true" declares one unnamed index
+	if len(specs) == 0 {
+		specs = append(specs, indexSpec{})
+	}
+	return specs, nil
+}
+
+// indexSet groups index tag entries by index name, in order of first appearance.
+type indexSet struct {
+	indexes []*indexEntry
+}
+
+type indexEntry struct {
+	indexSpec
+	columns []indexEntryColumn
+}
+
+type indexEntryColumn struct {
+	name     string
+	position int
+	opClass  string
+}
+
+// add records that column belongs to the index described by spec. Options of a named
+// index may be declared on any of its fields but must not conflict.
+func (s *indexSet) add(column string, spec indexSpec) error {
+	col := indexEntryColumn{name: column, position: spec.position, opClass: spec.opClass}
+
+	if spec.name != "" {
+		for _, entry := range s.indexes {
+			if entry.name != spec.name {
+				continue
+			}
+			if spec.method != "" {
+				if entry.method != "" && entry.method != spec.method {
+					return fmt.Errorf("index %s has conflicting types %s and %s", spec.name, entry.method, spec.method)
+				}
+				entry.method = spec.method
+			}
+			if spec.where != "" {
+				if entry.where != "" && entry.where != spec.where {
+					return fmt.Errorf("index %s has conflicting predicates", spec.name)
+				}
+				entry.where = spec.where
+			}
+			entry.unique = entry.unique || spec.unique
+			entry.columns = append(entry.columns, col)
+			return nil
+		}
+	}
+
+	s.indexes = append(s.indexes, &indexEntry{indexSpec: spec, columns: []indexEntryColumn{col}})
+	return nil
+}
+
+// build returns the DBML indexes. Columns with a position come first, in position
+// order; the rest follow in field order.
+func (s *indexSet) build() ([]*dbml.Index, error) {
+	indexes := make([]*dbml.Index, 0, len(s.indexes))
+	for _, entry := range s.indexes {
+		positions := make(map[int]string)
+		for _, col := range entry.columns {
+			if col.position == 0 {
+				continue
+			}
+			if other, dup := positions[col.position]; dup {
+				return nil, fmt.Errorf("index %s has columns %s and %s at position %d", entry.name, other, col.name, col.position)
+			}
+			positions[col.position] = col.name
+		}
+
+		columns := slices.Clone(entry.columns)
+		slices.SortStableFunc(columns, func(a, b indexEntryColumn) int {
+			switch {
+			case a.position == 0 && b.position == 0:
+				return 0
+			case a.position == 0:
+				return 1
+			case b.position == 0:
+				return -1
+			default:
+				return a.position - b.position
+			}
+		})
+
+		names := make([]string, len(columns))
+		for i, col := range columns {
+			names[i] = col.name
+		}
+		index := dbml.NewIndex(names...)
+		if entry.name != "" {
+			index.WithName(entry.name)
+		}
+		if entry.unique {
+			index.WithUnique()
+		}
+		if entry.method != "" {
+			index.WithType(entry.method)
+		}
+		indexes = append(indexes, index)
+	}
+	return indexes, nil
+}
+
+// reference is a parsed references tag.
+type reference struct {
+	table    string
+	column   string
+	relType  dbml.RelType
+	onDelete dbml.RefAction
+	onUpdate dbml.RefAction
+}
+
+// referenceActions maps tag spellings to DBML referential actions.
+var referenceActions = map[string]dbml.RefAction{
+	"cascade":     dbml.Cascade,
+	"restrict":    dbml.Restrict,
+	"set_null":    dbml.SetNull,
+	"set_default": dbml.SetDefault,
+	"no_action":   dbml.NoAction,
+}
+
+// parseReferencesTag parses a full references tag: the target followed by optional
+// space-separated options.
+// Example: "users(id) one_to_one on_delete:cascade on_update:restrict".
+// The relationship defaults to many_to_one; actions default to the database's own default.
+func parseReferencesTag(tag string) (reference, error) {
+	parts := strings.Fields(tag)
+	if len(parts) == 0 {
+		return reference{}, fmt.Errorf("empty references tag")
+	}
+
+	table, column, err := parseReferenceTag(parts[0])
+	if err != nil {
+		return reference{}, err
+	}
+	ref := reference{table: table, column: column, relType: dbml.ManyToOne}
+
+	for _, opt := range parts[1:] {
+		key, value, _ := strings.Cut(opt, ":")
+		switch key {
+		case "one_to_one":
+			ref.relType = dbml.OneToOne
+		case "many_to_one":
+			ref.relType = dbml.ManyToOne
+		case "on_delete", "on_update":
+			action, ok := referenceActions[value]
+			if !ok {
+				return reference{}, fmt.Errorf("invalid %s action %q in references %q, expected cascade, restrict, set_null, set_default or no_action", key, value, tag)
+			}
+			if key == "on_delete" {
+				ref.onDelete = action
+			} else {
+				ref.onUpdate = action
+			}
+		default:
+			return reference{}, fmt.Errorf("unknown option %q in references %q", opt, tag)
+		}
+	}
+
+	return ref, nil
+}
+
 // parseReferenceTag parses a references tag value.
 // Expected format: "table(column)"
 // Returns table name, column name, and error.
@@ -143,6 +544,7 @@ func parseReferenceTag(ref string) (table, column string, err error) {
 
 // parseConstraintsTag parses the constraints tag into individual flags.
 // Example: "unique,not_null,primary_key" → (notNull=true, unique=true, primaryKey=true).
+// The unseparated spellings "notnull" and "primarykey" are accepted as well.
 func parseConstraintsTag(constraintsTag string) (notNull, unique, primaryKey bool) {
 	if constraintsTag == "" {
 		return false, false, false
@@ -154,73 +556,12 @@ func parseConstraintsTag(constraintsTag string) (notNull, unique, primaryKey boo
 		switch c {
 		case "unique":
 			unique = true
-		case "not_null":
+		case "not_null", "notnull":
 			notNull = true
-		case "primary_key":
+		case "primary_key", "primarykey":
 			primaryKey = true
 		}
 	}
 
 	return notNull, unique, primaryKey
 }
-
-const (
-	pgTypeSmallInt = "SMALLINT"
-)
-
-// inferPostgresType maps Go types to default Postgres types.
-// This is the same logic from the OLD soy code.
-func inferPostgresType(goType string) string {
-	// Handle pointer types
-	goType = strings.TrimPrefix(goType, "*")
-
-	// Handle array/slice types
-	if strings.HasPrefix(goType, "[]") {
-		elementType := strings.TrimPrefix(goType, "[]")
-
-		// Special case: []byte is BYTEA, not an array
-		if elementType == "byte" || elementType == "uint8" {
-			return "BYTEA"
-		}
-
-		// Map element type and add array suffix
-		baseType := inferPostgresType(elementType)
-		return baseType + "[]"
-	}
-
-	switch goType {
-	case "string":
-		return "TEXT"
-	case "int", "int32":
-		return "INTEGER"
-	case "int64":
-		return "BIGINT"
-	case "int16":
-		return pgTypeSmallInt
-	case "int8":
-		return pgTypeSmallInt
-	case "uint", "uint32":
-		return "INTEGER"
-	case "uint64":
-		return "BIGINT"
-	case "uint16":
-		return pgTypeSmallInt
-	case "uint8":
-		return pgTypeSmallInt
-	case "float32":
-		return "REAL"
-	case "float64":
-		return "DOUBLE PRECISION"
-	case "bool":
-		return "BOOLEAN"
-	case "time.Time":
-		return "TIMESTAMPTZ"
-	default:
-		// For map types, use JSONB
-		if strings.HasPrefix(goType, "map[") {
-			return "JSONB"
-		}
-		// For custom types (package-qualified or not), use JSONB
-		return "JSONB"
-	}
-}
@@ -1,6 +1,7 @@
 package soy
 
 import (
+	"slices"
 	"strings"
 	"testing"
 
@@ -40,7 +41,7 @@ func TestBuildDBMLFromStruct(t *testing.T) {
 		metadata := sentinel.Inspect[TestUser]()
 
 		// Build DBML
-		project, err := buildDBMLFromStruct(metadata, "users")
+		project, err := buildDBMLFromStruct(metadata, "users", dialectPostgres)
 		if err != nil {
 			t.Fatalf("buildDBMLFromStruct() error = %v", err)
 		}
@@ -154,49 +155,38 @@ func TestBuildDBMLFromStruct(t *testing.T) {
 		metadata := sentinel.Inspect[TestProfile]()
 
 		// Build DBML
-		project, err := buildDBMLFromStruct(metadata, "profiles")
+		project, err := buildDBMLFromStruct(metadata, "profiles", dialectPostgres)
 		if err != nil {
 			t.Fatalf("buildDBMLFromStruct() error = %v", err)
 		}
 
 		// Check table exists (keyed by schema.name)
-		table, ok := project.Tables["public.profiles"]
-		if !ok {
+		if _, ok := project.Tables["public.profiles"]; !ok {
 			t.Fatalf("profiles table not found in project. Available tables: %v", getTableKeys(project.Tables))
 		}
 
-		// Find user_id column
-		var userIDCol *struct {
-			found bool
+		// References become standalone refs
+		if len(project.Refs) != 1 {
+			t.Fatalf("expected 1 ref, got %d", len(project.Refs))
 		}
-		for _, col := range table.Columns {
-			if col.Name == "user_id" {
-				userIDCol = &struct {
-					found bool
-				}{true}
-				// Check inline ref exists
-				if col.InlineRef == nil {
-					t.Error("user_id should have inline reference")
-				} else {
-					if col.InlineRef.Table != "users" {
-						t.Errorf("reference table = %s, want 'users'", col.InlineRef.Table)
-					}
-					if col.InlineRef.Column != "id" {
-						t.Errorf("reference column = %s, want 'id'", col.InlineRef.Column)
-					}
-				}
-				break
-			}
+		ref := project.Refs[0]
+		if ref.Type != dbml.ManyToOne {
+			t.Errorf("ref type = %s, want %s", ref.Type, dbml.ManyToOne)
 		}
-
-		if userIDCol == nil || !userIDCol.found {
-			t.Error("user_id column not found")
+		if ref.Left.Table != "profiles" || len(ref.Left.Columns) != 1 || ref.Left.Columns[0] != "user_id" {
+			t.Errorf("ref left = %+v, want profiles(user_id)", ref.Left)
+		}
+		if ref.Right.Table != "users" || len(ref.Right.Columns) != 1 || ref.Right.Columns[0] != "id" {
+			t.Errorf("ref right = %+v, want users(id)", ref.Right)
+		}
+		if ref.OnDelete != nil || ref.OnUpdate != nil {
+			t.Error("ref should have no actions")
 		}
 	})
 
 	t.Run("generates valid DBML string", func(t *testing.T) {
 		metadata := sentinel.Inspect[TestUser]()
-		project, err := buildDBMLFromStruct(metadata, "users")
+		project, err := buildDBMLFromStruct(metadata, "users", dialectPostgres)
 		if err != nil {
 			t.Fatalf("buildDBMLFromStruct() error = %v", err)
 		}
@@ -236,34 +226,6 @@ func getTableKeys(tables map[string]*dbml.Table) []string {
 	return keys
 }
 
-func TestInferPostgresType(t *testing.T) {
-	tests := []struct {
-		goType   string
-		expected string
-	}{
-		{"string", "TEXT"},
-		{"int64", "BIGINT"},
-		{"int", "INTEGER"},
-		{"bool", "BOOLEAN"},
-		{"time.Time", "TIMESTAMPTZ"},
-		{"[]string", "TEXT[]"},
-		{"[]int", "INTEGER[]"},
-		{"[]byte", "BYTEA"},
-		{"map[string]any", "JSONB"},
-		{"*string", "TEXT"},
-		{"*int64", "BIGINT"},
-	}
-
-	for _, tt := range tests {
-		t.Run(tt.goType, func(t *testing.T) {
-			result := inferPostgresType(tt.goType)
-			if result != tt.expected {
-				t.Errorf("inferPostgresType(%q) = %q, want %q", tt.goType, result, tt.expected)
-			}
-		})
-	}
-}
-
 func TestParseReferenceTag(t *testing.T) {
 	tests := []struct {
 		name       string
@@ -298,3 +260,161 @@ func TestParseReferenceTag(t *testing.T) {
 		})
 	}
 }
+
+func TestParseReferencesTag(t *testing.T) {
+	tests := []struct {
+		name    string
+		tag     string
+		want    reference
+		wantErr bool
+	}{
+		{"target only", "users(id)", reference{table: "users", column: "id", relType: dbml.ManyToOne}, false},
+		{
+			"actions", "users(id) on_delete:cascade on_update:set_null",
+			reference{table: "users", column: "id", relType: dbml.ManyToOne, onDelete: dbml.Cascade, onUpdate: dbml.SetNull},
+			false,
+		},
+		{"one to one", "users(id) one_to_one", reference{table: "users", column: "id", relType: dbml.OneToOne}, false},
+		{"extra spaces", "  users(id)   on_delete:no_action ", reference{table: "users", column: "id", relType: dbml.ManyToOne, onDelete: dbml.NoAction}, false},
+		{"empty", " ", reference{}, true},
+		{"invalid target", "users on_delete:cascade", reference{}, true},
+		{"invalid action", "users(id) on_delete:explode", reference{}, true},
+		{"unknown option", "users(id) deferrable", reference{}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseReferencesTag(tt.tag)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseReferencesTag() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("parseReferencesTag() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBuildDBMLFromStruct_OneToOne(t *testing.T) {
+	type account struct {
+		ID     int64 `db:"id" type:"bigserial" constraints:"primary_key"`
+		UserID int64 `db:"user_id" type:"bigint" constraints:"not_null" references:"users(id) one_to_one on_delete:cascade"`
+	}
+
+	project, err := buildDBMLFromStruct(sentinel.Inspect[account](), "accounts", dialectPostgres)
+	if err != nil {
+		t.Fatalf("buildDBMLFromStruct() error = %v", err)
+	}
+	if len(project.Refs) != 1 {
+		t.Fatalf("expected 1 ref, got %d", len(project.Refs))
+	}
+	ref := project.Refs[0]
+	if ref.Type != dbml.OneToOne || ref.OnDelete == nil || *ref.OnDelete != dbml.Cascade || ref.OnUpdate != nil {
+		t.Errorf("unexpected ref: type %s, on delete %v, on update %v", ref.Type, ref.OnDelete, ref.OnUpdate)
+	}
+	for _, col := range project.Tables["public.accounts"].Columns {
+		if col.Name == "user_id" && !col.Settings.Unique {
+			t.Error("one_to_one reference column should be unique")
+		}
+	}
+	if err := project.Validate(); err != nil {
+		t.Errorf("Validate() error = %v", err)
+	}
+
+	type broken struct {
+		UserID int64 `db:"user_id" type:"bigint" references:"users(id) on_delete:sometimes"`
+	}
+	if _, err := buildDBMLFromStruct(sentinel.Inspect[broken](), "broken", dialectPostgres); err == nil {
+		t.Error("expected error for invalid action")
+	}
+}
+
+func TestParseIndexTag(t *testing.T) {
+	tests := []struct {
+		name    string
+		tag     string
+		want    []indexSpec
+		wantErr bool
+	}{
+		{"named", "idx_email", []indexSpec{{name: "idx_email"}}, false},
+		{"unnamed", "true", []indexSpec{{}}, false},
+		{"empty", "", []indexSpec{{}}, false},
+		{
+			"options", "idx_org_email, position:2, unique, type:btree",
+			[]indexSpec{{name: "idx_org_email", position: 2, unique: true, method: "btree"}}, false,
+		},
+		{
+			"predicate keeps commas", "idx_active,where:status IN ('a', 'b')",
+			[]indexSpec{{name: "idx_active", where: "status IN ('a', 'b')"}}, false,
+		},
+		{
+			"several indexes", "idx_a;true,type:gin",
+			[]indexSpec{{name: "idx_a"}, {method: "gin"}}, false,
+		},
+		{
+			"operator class", "true,type:gin,ops:gin_trgm_ops",
+			[]indexSpec{{method: "gin", opClass: "gin_trgm_ops"}}, false,
+		},
+		{"bad position", "idx_a,position:0", nil, true},
+		{"unnamed with position", "true,position:1", nil, true},
+		{"unknown option", "idx_a,sparse", nil, true},
+		{"empty type", "idx_a,type:", nil, true},
+		{"bad operator class", "idx_a,ops:gin_trgm_ops)", nil, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseIndexTag(tt.tag)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseIndexTag() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("parseIndexTag() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBuildDBMLFromStruct_CompositeIndexes(t *testing.T) {
+	type membership struct {
+		UserID int    `db:"user_id" type:"integer" index:"idx_membership,position:2;idx_user"`
+		OrgID  int    `db:"org_id" type:"integer" index:"idx_membership,position:1,unique"`
+		Role   string `db:"role" type:"text" index:"idx_membership"`
+	}
+
+	project, err := buildDBMLFromStruct(sentinel.Inspect[membership](), "memberships", dialectPostgres)
+	if err != nil {
+		t.Fatalf("buildDBMLFromStruct() error = %v", err)
+	}
+	indexes := project.Tables["public.memberships"].Indexes
+	if len(indexes) != 2 {
+		t.Fatalf("expected 2 indexes, got %d", len(indexes))
+	}
+
+	var columns []string
+	for _, ic := range indexes[0].Columns {
+		columns = append(columns, *ic.Name)
+	}
+	if *indexes[0].Name != "idx_membership" || !indexes[0].Unique || !slices.Equal(columns, []string{"org_id", "user_id", "role"}) {
+		t.Errorf("unexpected composite index %s unique=%v columns=%v", *indexes[0].Name, indexes[0].Unique, columns)
+	}
+	if *indexes[1].Name != "idx_user" || indexes[1].Unique {
+		t.Errorf("unexpected second index %s", *indexes[1].Name)
+	}
+
+	type duplicatePosition struct {
+		A int `db:"a" type:"integer" index:"idx_ab,position:1"`
+		B int `db:"b" type:"integer" index:"idx_ab,position:1"`
+	}
+	if _, err := buildDBMLFromStruct(sentinel.Inspect[duplicatePosition](), "dups", dialectPostgres); err == nil {
+		t.Error("expected error for duplicate index positions")
+	}
+
+	type conflictingType struct {
+		A int `db:"a" type:"integer" index:"idx_ab,type:btree"`
+		B int `db:"b" type:"integer" index:"idx_ab,type:hash"`
+	}
+	if _, err := buildDBMLFromStruct(sentinel.Inspect[conflictingType](), "conflicts", dialectPostgres); err == nil {
+		t.Error("expected error for conflicting index types")
+	}
+}
@@ -0,0 +1,104 @@
+package soy
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/zoobzio/dbml"
+	"github.com/zoobzio/soy/internal/ddl"
+)
+
+// DBML returns the DBML project derived from T's struct tags.
+// A new project is built on every call, so callers may modify it freely.
+func (c *Soy[T]) DBML() (*dbml.Project, error) {
+	project, err := buildDBMLFromStruct(c.metadata, c.tableName, dialectOf(c.renderer()))
+	if err != nil {
+		return nil, fmt.Errorf("soy: failed to build DBML: %w", err)
+	}
+	return project, nil
+}
+
+// IndexOptions are the settings of an index that DBML cannot express: the predicate of a
+// partial index and the operator class of each column.
+type IndexOptions = ddl.IndexOptions
+
+// IndexOptions returns the options of the indexes declared in T's index tags that the
+// DBML project cannot carry, keyed by index name. Indexes without a where: predicate or
+// ops: operator class are left out.
+func (c *Soy[T]) IndexOptions() (map[string]IndexOptions, error) {
+	options, err := buildIndexOptions(c.metadata, c.tableName)
+	if err != nil {
+		return nil, fmt.Errorf("soy: failed to build index options: %w", err)
+	}
+	return options, nil
+}
+
+// dbmlTable returns this instance's table from the struct-derived DBML project,
+// along with the project, which holds the refs declared by its references tags and
+// the enum types of its enum tags.
+// DDL generation and schema verification share it so both see the same expected schema.
+func (c *Soy[T]) dbmlTable() (*dbml.Project, *dbml.Table, error) {
+	project, err := c.DBML()
+	if err != nil {
+		return nil, nil, err
+	}
+	for _, table := range project.Tables {
+		if table.Name == c.tableName {
+			return project, table, nil
+		}
+	}
+	return nil, nil, fmt.Errorf("soy: table %q missing from generated DBML", c.tableName)
+}
+
+// CreateTableSQL renders the CREATE TABLE statement for T in the renderer's dialect.
+// Columns carry their type, NOT NULL, DEFAULT, CHECK and UNIQUE settings; the primary key
+// and foreign keys from references tags, with their ON DELETE and ON UPDATE actions,
+// are emitted as table constraints.
+//
+// Column comments from the description tag are inline on MariaDB and SQLite. PostgreSQL
+// (COMMENT ON COLUMN) and SQL Server (sp_addextendedproperty) need separate statements,
+// which follow the CREATE TABLE in the returned script, each terminated by a semicolon.
+//
+// Types are emitted as tagged or inferred, except that serial types become the dialect's
+// auto-increment column. Indexes are rendered separately by CreateIndexesSQL.
+//
+// Untyped enum columns use a native enum: on PostgreSQL a CREATE TYPE ... AS ENUM
+// statement for each one precedes the CREATE TABLE, each terminated by a semicolon, and
+// on MariaDB the column is an inline ENUM. Other dialects, and enum columns with a type
+// tag, restrict the column with a CHECK constraint instead.
+func (c *Soy[T]) CreateTableSQL() (string, error) {
+	project, table, err := c.dbmlTable()
+	if err != nil {
+		return "", err
+	}
+	d := ddl.Dialect(dialectOf(c.renderer()))
+	var sb strings.Builder
+	for _, enum := range ddl.TableEnums(project, table) {
+		sb.WriteString(d.CreateEnum(enum))
+		sb.WriteString(";\n")
+	}
+	sb.WriteString(d.CreateTable(table, project.Refs))
+	return sb.String(), nil
+}
+
+// CreateIndexesSQL renders one CREATE INDEX statement per index declared in T's index tags,
+// in field order. Unnamed indexes are named idx_<table>_<columns>.
+// Composite indexes list their columns by position, unique indexes render as
+// CREATE UNIQUE INDEX and partial indexes carry their WHERE predicate. Index types
+// (gin, hnsw, ...) are PostgreSQL access methods and are ignored by other dialects.
+// MariaDB has no partial indexes, so a partial index is an error there.
+func (c *Soy[T]) CreateIndexesSQL() ([]string, error) {
+	_, table, err := c.dbmlTable()
+	if err != nil {
+		return nil, err
+	}
+	options, err := c.IndexOptions()
+	if err != nil {
+		return nil, err
+	}
+	stmts, err := ddl.Dialect(dialectOf(c.renderer())).CreateIndexes(table, options)
+	if err != nil {
+		return nil, fmt.Errorf("soy: %w", err)
+	}
+	return stmts, nil
+}
@@ -0,0 +1,189 @@
+package soy
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/zoobzio/astql"
+	"github.com/zoobzio/astql/mariadb"
+	"github.com/zoobzio/astql/mssql"
+	"github.com/zoobzio/astql/postgres"
+	"github.com/zoobzio/astql/sqlite"
+)
+
+type ddlTestPost struct {
+	ID       int    `db:"id" type:"serial" constraints:"primary_key"`
+	Slug     string `db:"slug" type:"text" constraints:"not_null,unique" description:"URL slug, e.g. 'hello-world'"`
+	AuthorID int    `db:"author_id" type:"integer" constraints:"not_null" references:"users(id) on_delete:cascade on_update:restrict" index:"idx_posts_author"`
+	Views    int    `db:"views" type:"integer" constraints:"not_null" default:"0" check:"views >= 0"`
+	Title    string `db:"title" type:"text" index:"true"`
+}
+
+func newDDLTestSoy(t *testing.T, renderer astql.Renderer) *Soy[ddlTestPost] {
+	t.Helper()
+	s, err := New[ddlTestPost](&sqlx.DB{}, "posts", renderer)
+	if err != nil {
+		t.Fatalf("New() failed: %v", err)
+	}
+	return s
+}
+
+func TestCreateTableSQL_Postgres(t *testing.T) {
+	s := newDDLTestSoy(t, postgres.New())
+
+	got, err := s.CreateTableSQL()
+	if err != nil {
+		t.Fatalf("CreateTableSQL() failed: %v", err)
+	}
+
+	want := `CREATE TABLE "posts" (
+    "id" serial NOT NULL,
+    "slug" text NOT NULL UNIQUE,
+    "author_id" integer NOT NULL,
+    "views" integer NOT NULL DEFAULT 0 CHECK (views >= 0),
+    "title" text,
+    PRIMARY KEY ("id"),
+    FOREIGN KEY ("author_id") REFERENCES "users" ("id") ON DELETE CASCADE ON UPDATE RESTRICT
+);
+COMMENT ON COLUMN "posts"."slug" IS 'URL slug, e.g. ''hello-world''';
+`
+	if got != want {
+		t.Errorf("CreateTableSQL() mismatch:\nwant:\n%s\ngot:\n%s", want, got)
+	}
+}
+
+func TestCreateTableSQL_Dialects(t *testing.T) {
+	tests := []struct {
+		name     string
+		renderer astql.Renderer
+		contains []string
+		excludes []string
+	}{
+		{
+			name:     "mariadb",
+			renderer: mariadb.New(),
+			contains: []string{
+				"CREATE TABLE `posts`",
+				"`id` INTEGER AUTO_INCREMENT NOT NULL",
+				"`slug` text NOT NULL UNIQUE COMMENT 'URL slug, e.g. ''hello-world'''",
+				"FOREIGN KEY (`author_id`) REFERENCES `users` (`id`) ON DELETE CASCADE ON UPDATE RESTRICT",
+			},
+			excludes: []string{";"},
+		},
+		{
+			name:     "sqlite",
+			renderer: sqlite.New(),
+			contains: []string{
+				`"id" INTEGER NOT NULL`,
+				`"slug" text NOT NULL UNIQUE /* URL slug, e.g. 'hello-world' */`,
+				`PRIMARY KEY ("id")`,
+				`FOREIGN KEY ("author_id") REFERENCES "users" ("id") ON DELETE CASCADE ON UPDATE RESTRICT`,
+			},
+			excludes: []string{";"},
+		},
+		{
+			name:     "mssql",
+			renderer: mssql.New(),
+			contains: []string{
+				"CREATE TABLE [posts]",
+				"[id] INTEGER IDENTITY(1,1) NOT NULL",
+				"FOREIGN KEY ([author_id]) REFERENCES [users] ([id]) ON DELETE CASCADE ON UPDATE NO ACTION",
+				"EXEC sp_addextendedproperty @name = N'MS_Description', @value = N'URL slug, e.g. ''hello-world'''",
+				"@level1name = N'posts', @level2type = N'COLUMN', @level2name = N'slug';",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := newDDLTestSoy(t, tt.renderer).CreateTableSQL()
+			if err != nil {
+				t.Fatalf("CreateTableSQL() failed: %v", err)
+			}
+			for _, part := range tt.contains {
+				if !strings.Contains(got, part) {
+					t.Errorf("missing %q in:\n%s", part, got)
+				}
+			}
+			for _, part := range tt.excludes {
+				if strings.Contains(got, part) {
+					t.Errorf("unexpected %q in:\n%s", part, got)
+				}
+			}
+		})
+	}
+}
+
+func TestCreateIndexesSQL(t *testing.T) {
+	got, err := newDDLTestSoy(t, postgres.New()).CreateIndexesSQL()
+	if err != nil {
+		t.Fatalf("CreateIndexesSQL() failed: %v", err)
+	}
+	want := []string{
+		`CREATE INDEX "idx_posts_author" ON "posts" ("author_id")`,
+		`CREATE INDEX "idx_posts_title" ON "posts" ("title")`,
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("CreateIndexesSQL() = %q, want %q", got, want)
+	}
+
+	got, err = newDDLTestSoy(t, mssql.New()).CreateIndexesSQL()
+	if err != nil {
+		t.Fatalf("CreateIndexesSQL() failed: %v", err)
+	}
+	if got[0] != "CREATE INDEX [idx_posts_author] ON [posts] ([author_id])" {
+		t.Errorf("unexpected MSSQL index: %s", got[0])
+	}
+}
+
+type ddlTestMember struct {
+	ID        int      `db:"id" type:"serial" constraints:"primary_key"`
+	Email     string   `db:"email" type:"text" index:"idx_members_org_email,position:2"`
+	OrgID     int      `db:"org_id" type:"integer" index:"idx_members_org_email,position:1,unique,where:deleted_at IS NULL"`
+	Tags      []string `db:"tags" type:"text[]" index:"true,type:gin"`
+	DeletedAt *string  `db:"deleted_at" type:"timestamptz"`
+}
+
+func TestCreateIndexesSQL_Composite(t *testing.T) {
+	s, err := New[ddlTestMember](&sqlx.DB{}, "members", postgres.New())
+	if err != nil {
+		t.Fatalf("New() failed: %v", err)
+	}
+	got, err := s.CreateIndexesSQL()
+	if err != nil {
+		t.Fatalf("CreateIndexesSQL() failed: %v", err)
+	}
+	want := []string{
+		`CREATE UNIQUE INDEX "idx_members_org_email" ON "members" ("org_id", "email") WHERE deleted_at IS NULL`,
+		`CREATE INDEX "idx_members_tags" ON "members" USING gin ("tags")`,
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("CreateIndexesSQL() = %q, want %q", got, want)
+	}
+
+	options, err := s.IndexOptions()
+	if err != nil {
+		t.Fatalf("IndexOptions() failed: %v", err)
+	}
+	if len(options) != 1 || options["idx_members_org_email"].Where != "deleted_at IS NULL" {
+		t.Errorf("IndexOptions() = %v", options)
+	}
+	_, table, err := s.dbmlTable()
+	if err != nil {
+		t.Fatalf("dbmlTable() failed: %v", err)
+	}
+	for _, idx := range table.Indexes {
+		if idx.Note != nil {
+			t.Errorf("index options leaked into the DBML note: %q", *idx.Note)
+		}
+	}
+
+	s, err = New[ddlTestMember](&sqlx.DB{}, "members", mariadb.New())
+	if err != nil {
+		t.Fatalf("New() failed: %v", err)
+	}
+	if _, err := s.CreateIndexesSQL(); err == nil {
+		t.Error("expected error for partial index on MariaDB")
+	}
+}
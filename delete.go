@@ -18,6 +18,8 @@ type Delete[T any] struct {
 	builder  *astql.Builder
 	soy      soyExecutor // interface for execution
 	hasWhere bool        // tracks if WHERE was called
+	batch    batchShape  // tracks structure for set-based ExecBatch
+	scopes   scopeFilter // scopes left out with Unscoped
 	err      error       // stores first error encountered during building
 }
 
@@ -42,6 +44,7 @@ func (db *Delete[T]) Where(field, operator, param string) *Delete[T] {
 
 	db.builder = builder
 	db.hasWhere = true
+	db.batch.addKey(field, operator, param)
 	return db
 }
 
@@ -80,6 +83,7 @@ func (db *Delete[T]) WhereAnd(conditions ...Condition) *Delete[T] {
 
 	db.builder = db.builder.Where(andGroup)
 	db.hasWhere = true
+	db.batch.complex = true
 	return db
 }
 
@@ -118,6 +122,7 @@ func (db *Delete[T]) WhereOr(conditions ...Condition) *Delete[T] {
 
 	db.builder = db.builder.Where(orGroup)
 	db.hasWhere = true
+	db.batch.complex = true
 	return db
 }
 
@@ -141,6 +146,7 @@ func (db *Delete[T]) WhereNull(field string) *Delete[T] {
 
 	db.builder = db.builder.Where(condition)
 	db.hasWhere = true
+	db.batch.complex = true
 	return db
 }
 
@@ -164,6 +170,7 @@ func (db *Delete[T]) WhereNotNull(field string) *Delete[T] {
 
 	db.builder = db.builder.Where(condition)
 	db.hasWhere = true
+	db.batch.complex = true
 	return db
 }
 
@@ -199,6 +206,7 @@ func (db *Delete[T]) WhereBetween(field, lowParam, highParam string) *Delete[T]
 
 	db.builder = db.builder.Where(astql.Between(f, lowP, highP))
 	db.hasWhere = true
+	db.batch.complex = true
 	return db
 }
 
@@ -234,6 +242,7 @@ func (db *Delete[T]) WhereNotBetween(field, lowParam, highParam string) *Delete[
 
 	db.builder = db.builder.Where(astql.NotBetween(f, lowP, highP))
 	db.hasWhere = true
+	db.batch.complex = true
 	return db
 }
 
@@ -249,7 +258,7 @@ func (db *Delete[T]) WhereFields(leftField, operator, rightField string) *Delete
 		return db
 	}
 
-	astqlOp, err := validateOperator(operator)
+	astqlOp, err := validateConditionOperator(db.instance, leftField, operator)
 	if err != nil {
 		db.err = err
 		return db
@@ -269,6 +278,22 @@ func (db *Delete[T]) WhereFields(leftField, operator, rightField string) *Delete
 
 	db.builder = db.builder.Where(astql.CF(left, astqlOp, right))
 	db.hasWhere = true
+	db.batch.complex = true
+	return db
+}
+
+// Unscoped leaves the named scopes registered with AddScope out of the delete, or every
+// scope when no names are given. Scopes are not WHERE conditions, so the delete still
+// needs one.
+//
+// Example:
+//
+//	soy.Remove().Unscoped("live").Where("id", "=", "id")
+func (db *Delete[T]) Unscoped(names ...string) *Delete[T] {
+	if db.err != nil {
+		return db
+	}
+	db.err = db.scopes.exclude(db.soy, names)
 	return db
 }
 
@@ -298,7 +323,10 @@ func (db *Delete[T]) ExecTx(ctx context.Context, tx *sqlx.Tx, params map[string]
 
 // ExecBatch executes the DELETE query for multiple parameter sets.
 // Returns the total number of rows deleted.
-// Each parameter set is executed separately with the same WHERE clause.
+// When every WHERE condition is a Where(field, "=", param), the whole batch runs as one
+// DELETE matching any entry's keys. Otherwise one prepared statement is executed per parameter
+// set, in a transaction that a failing set rolls back.
+// Failures are reported as a *BatchError identifying the failing indexes.
 //
 // Example:
 //
@@ -320,12 +348,33 @@ func (db *Delete[T]) ExecBatchTx(ctx context.Context, tx *sqlx.Tx, batchParams [
 }
 
 // execBatch is the internal batch execution method.
+// Deletes keyed by "field = :param" conditions run as a single DELETE ... WHERE ... IN (...),
+// split into chunks within the parameter limit; everything else reuses one prepared
+// statement per entry.
 func (db *Delete[T]) execBatch(ctx context.Context, execer sqlx.ExtContext, batchParams []map[string]any) (int64, error) {
-	return executeBatch(ctx, execer, batchParams, db.builder, db.soy.renderer(), db.soy.getTableName(), "DELETE", db.hasWhere, db.err)
+	batchParams = db.soy.bindBatchParams(batchParams)
+	if db.err == nil && db.hasWhere && len(batchParams) > 0 {
+		tableName := db.soy.getTableName()
+		d := dialectOf(db.soy.renderer())
+		batch := db.scopes.batchShape(db.soy, db.batch)
+		if batch.setBased() {
+			params, err := batch.bindParams(d, "DELETE", batchParams, db.soy.strictParams(), enumBindingsOf(db.instance, db.builder))
+			if err != nil {
+				return 0, err
+			}
+			render := func(n int) string {
+				query, _ := batch.renderSetDelete(d, tableName, n)
+				return query
+			}
+			return executeSetBatch(ctx, execer, d, &batch, render, params, len(batchParams), tableName, "DELETE")
+		}
+	}
+	return executeBatch(ctx, execer, batchParams, db.scopes.apply(db.soy, db.builder), enumBindingsOf(db.instance, db.builder), db.soy.renderer(), db.soy.getTableName(), "DELETE", db.hasWhere, db.err, db.soy.strictParams())
 }
 
 // exec is the internal execution method used by both Exec and ExecTx.
 func (db *Delete[T]) exec(ctx context.Context, execer sqlx.ExtContext, params map[string]any) (int64, error) {
+	params = db.soy.bindParams(params)
 	// Check for  errors first
 	if db.err != nil {
 		return 0, fmt.Errorf("delete  has errors: %w", db.err)
@@ -337,23 +386,35 @@ func (db *Delete[T]) exec(ctx context.Context, execer sqlx.ExtContext, params ma
 	}
 
 	// Render the query
-	result, err := db.builder.Render(db.soy.renderer())
+	result, err := db.scopes.apply(db.soy, db.builder).Render(db.soy.renderer())
 	if err != nil {
 		return 0, fmt.Errorf("failed to render DELETE query: %w", err)
 	}
 
+	if err := validateParams(result.RequiredParams, params, db.soy.strictParams()); err != nil {
+		return 0, err
+	}
+	if err := validateEnumParams(db.instance, db.builder, params); err != nil {
+		return 0, err
+	}
+
+	return db.execSQL(ctx, execer, result.SQL, params)
+}
+
+// execSQL executes already-rendered DELETE SQL and returns the affected row count.
+func (db *Delete[T]) execSQL(ctx context.Context, execer sqlx.ExtContext, query string, params map[string]any) (int64, error) {
 	// Emit query started event
 	tableName := db.soy.getTableName()
 	capitan.Debug(ctx, QueryStarted,
 		TableKey.Field(tableName),
 		OperationKey.Field("DELETE"),
-		SQLKey.Field(result.SQL),
+		SQLKey.Field(query),
 	)
 
 	startTime := time.Now()
 
 	// Execute named query
-	res, err := sqlx.NamedExecContext(ctx, execer, result.SQL, params)
+	res, err := db.soy.statements().namedExec(ctx, execer, dialectOf(db.soy.renderer()), query, params)
 	if err != nil {
 		durationMs := time.Since(startTime).Milliseconds()
 		capitan.Error(ctx, QueryFailed,
@@ -390,6 +451,20 @@ func (db *Delete[T]) exec(ctx context.Context, execer sqlx.ExtContext, params ma
 	return affected, nil
 }
 
+// Clone returns an independent copy of the builder, including its WHERE tracking
+// and any stored error.
+func (db *Delete[T]) Clone() *Delete[T] {
+	return &Delete[T]{
+		instance: db.instance,
+		builder:  cloneBuilder(db.builder),
+		soy:      db.soy,
+		hasWhere: db.hasWhere,
+		batch:    db.batch.clone(),
+		scopes:   db.scopes,
+		err:      db.err,
+	}
+}
+
 // Render builds and renders the query to SQL with parameter placeholders.
 // Returns the SQL string and list of required parameters.
 // Useful for inspection/debugging before execution.
@@ -399,7 +474,7 @@ func (db *Delete[T]) Render() (*astql.QueryResult, error) {
 		return nil, fmt.Errorf("delete  has errors: %w", db.err)
 	}
 
-	result, err := db.builder.Render(db.soy.renderer())
+	result, err := db.scopes.apply(db.soy, db.builder).Render(db.soy.renderer())
 	if err != nil {
 		return nil, fmt.Errorf("failed to render DELETE query: %w", err)
 	}
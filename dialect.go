@@ -0,0 +1,79 @@
+package soy
+
+import (
+	"github.com/zoobzio/astql"
+	"github.com/zoobzio/soy/internal/ddl"
+)
+
+// dialect identifies the SQL dialect targeted by a renderer.
+// ASTQL hides dialect details behind the Renderer interface, but a few soy features
+// (set-based batch statements, DDL, introspection) need to emit SQL that ASTQL cannot express.
+type dialect string
+
+// Supported dialects.
+const (
+	dialectPostgres = dialect(ddl.Postgres)
+	dialectMariaDB  = dialect(ddl.MariaDB)
+	dialectSQLite   = dialect(ddl.SQLite)
+	dialectMSSQL    = dialect(ddl.MSSQL)
+)
+
+// dialectOf returns the dialect for a renderer.
+// Unknown renderers are treated as PostgreSQL, which is ASTQL's reference dialect.
+func dialectOf(renderer astql.Renderer) dialect {
+	if r, ok := renderer.(extensionRenderer); ok {
+		renderer = r.Renderer
+	}
+	return dialect(ddl.Of(renderer))
+}
+
+// databaseType returns the DBML project database type for the dialect.
+// DBML has no MariaDB type; MySQL is its closest match.
+func (d dialect) databaseType() string {
+	switch d {
+	case dialectMariaDB:
+		return "MySQL"
+	case dialectSQLite:
+		return "SQLite"
+	case dialectMSSQL:
+		return "SQL Server"
+	default:
+		return "PostgreSQL"
+	}
+}
+
+// schema returns the default schema that DBML tables and refs are placed in.
+// MariaDB schemas are databases, which are unknown here, so it keeps DBML's default.
+func (d dialect) schema() string {
+	switch d {
+	case dialectSQLite:
+		return "main"
+	case dialectMSSQL:
+		return "dbo"
+	default:
+		return "public"
+	}
+}
+
+// quote quotes an identifier using the dialect's quoting rules.
+// Embedded quote characters are escaped by doubling, matching the ASTQL renderers.
+func (d dialect) quote(name string) string {
+	return ddl.Dialect(d).Quote(name)
+}
+
+// quoteAll quotes each identifier and joins them with commas.
+func (d dialect) quoteAll(names []string) string {
+	return ddl.Dialect(d).QuoteAll(names)
+}
+
+// maxParams returns the most bound parameters a single statement can carry.
+func (d dialect) maxParams() int {
+	switch d {
+	case dialectMSSQL:
+		return 2100
+	case dialectSQLite:
+		return 32766
+	default:
+		return 65535
+	}
+}
@@ -0,0 +1,57 @@
+package soy
+
+import (
+	"testing"
+
+	"github.com/zoobzio/astql/mariadb"
+	"github.com/zoobzio/astql/mssql"
+	"github.com/zoobzio/astql/postgres"
+	"github.com/zoobzio/astql/sqlite"
+)
+
+func TestDialect_Of(t *testing.T) {
+	tests := []struct {
+		name string
+		got  dialect
+		want dialect
+	}{
+		{"postgres", dialectOf(postgres.New()), dialectPostgres},
+		{"mariadb", dialectOf(mariadb.New()), dialectMariaDB},
+		{"sqlite", dialectOf(sqlite.New()), dialectSQLite},
+		{"mssql", dialectOf(mssql.New()), dialectMSSQL},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.got != tt.want {
+				t.Errorf("dialectOf() = %s, want %s", tt.got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDialect_Quote(t *testing.T) {
+	tests := []struct {
+		dialect dialect
+		name    string
+		want    string
+	}{
+		{dialectPostgres, "users", `"users"`},
+		{dialectPostgres, `we"ird`, `"we""ird"`},
+		{dialectSQLite, "users", `"users"`},
+		{dialectMariaDB, "users", "`users`"},
+		{dialectMariaDB, "we`ird", "`we``ird`"},
+		{dialectMSSQL, "users", "[users]"},
+		{dialectMSSQL, "we]ird", "[we]]ird]"},
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.dialect)+"/"+tt.name, func(t *testing.T) {
+			if got := tt.dialect.quote(tt.name); got != tt.want {
+				t.Errorf("quote(%q) = %s, want %s", tt.name, got, tt.want)
+			}
+		})
+	}
+
+	if got := dialectMSSQL.quoteAll([]string{"a", "b"}); got != "[a], [b]" {
+		t.Errorf("quoteAll() = %s, want [a], [b]", got)
+	}
+}
@@ -0,0 +1,169 @@
+package soy
+
+import (
+	"fmt"
+	"maps"
+	"reflect"
+	"slices"
+	"sync"
+
+	"github.com/zoobzio/sentinel"
+)
+
+// columnTags are the struct tags soy reads from the fields of a model.
+var columnTags = []string{
+	"db", "type", "constraints", "default", "check", "index", "references", "description", "enum", "prefix", "tsvector",
+}
+
+// flattenMetadata returns metadata with its value objects flattened into columns.
+//
+// A struct field with a prefix tag contributes each of its columns, named with the prefix:
+//
+//	type User struct {
+//	    ID      int     `db:"id" constraints:"primarykey"`
+//	    Address Address `db:"addr" prefix:"addr_"`
+//	}
+//
+// maps Address.Street with db:"street" to the addr_street column. Embedded structs without
+// a db tag are flattened without a prefix. Value objects may nest, concatenating prefixes.
+// Flattened fields are named by their field path, such as Address.Street, and carry their
+// full index, so their values are read with reflect.Value.FieldByIndex.
+func flattenMetadata(metadata sentinel.Metadata, t reflect.Type) (sentinel.Metadata, error) {
+	fields, err := flattenFields(metadata.Fields, t, "", nil, "")
+	if err != nil {
+		return sentinel.Metadata{}, err
+	}
+	flattened := metadata
+	flattened.Fields = fields
+	return flattened, nil
+}
+
+// flattenFields flattens the fields of struct type t. Column names are prefixed with prefix,
+// indexes with index and field names with path.
+func flattenFields(fields []sentinel.FieldMetadata, t reflect.Type, prefix string, index []int, path string) ([]sentinel.FieldMetadata, error) {
+	flattened := make([]sentinel.FieldMetadata, 0, len(fields))
+	for _, field := range fields {
+		dbCol := field.Tags["db"]
+		fieldPrefix, hasPrefix := field.Tags["prefix"]
+		embedded := t.Field(field.Index[0]).Anonymous && dbCol == "" && field.ReflectType.Kind() == reflect.Struct
+
+		field.Name = path + field.Name
+		field.Index = append(slices.Clone(index), field.Index...)
+
+		if dbCol != "-" && (hasPrefix || embedded) {
+			if field.ReflectType.Kind() != reflect.Struct {
+				return nil, fmt.Errorf("field %q: prefix tag requires a struct field, got %s", field.Name, field.ReflectType)
+			}
+			nested, err := flattenFields(structFields(field.ReflectType), field.ReflectType, prefix+fieldPrefix, field.Index, field.Name+".")
+			if err != nil {
+				return nil, err
+			}
+			flattened = append(flattened, nested...)
+			continue
+		}
+
+		if prefix != "" && dbCol != "" && dbCol != "-" {
+			field.Tags = maps.Clone(field.Tags)
+			field.Tags["db"] = prefix + dbCol
+		}
+		flattened = append(flattened, field)
+	}
+	return flattened, nil
+}
+
+// structFields returns field metadata for the exported fields of a nested struct type,
+// with the tags soy reads.
+func structFields(t reflect.Type) []sentinel.FieldMetadata {
+	fields := make([]sentinel.FieldMetadata, 0, t.NumField())
+	for i := range t.NumField() {
+		sf := t.Field(i)
+		if !sf.IsExported() {
+			continue
+		}
+		tags := make(map[string]string)
+		for _, name := range columnTags {
+			if value := sf.Tag.Get(name); value != "" {
+				tags[name] = value
+			}
+		}
+		fields = append(fields, sentinel.FieldMetadata{
+			ReflectType: sf.Type,
+			Tags:        tags,
+			Name:        sf.Name,
+			Type:        sf.Type.String(),
+			Kind:        fieldKind(sf.Type),
+			Index:       sf.Index,
+		})
+	}
+	return fields
+}
+
+// fieldKind returns the sentinel kind of a field type.
+func fieldKind(t reflect.Type) sentinel.FieldKind {
+	switch t.Kind() {
+	case reflect.Pointer:
+		return sentinel.KindPointer
+	case reflect.Slice, reflect.Array:
+		return sentinel.KindSlice
+	case reflect.Struct:
+		return sentinel.KindStruct
+	case reflect.Map:
+		return sentinel.KindMap
+	case reflect.Interface:
+		return sentinel.KindInterface
+	default:
+		return sentinel.KindScalar
+	}
+}
+
+// columnFields maps database columns to the fields their values are read from.
+func columnFields(metadata sentinel.Metadata) map[string]sentinel.FieldMetadata {
+	fields := make(map[string]sentinel.FieldMetadata, len(metadata.Fields))
+	for _, field := range metadata.Fields {
+		if dbCol := field.Tags["db"]; dbCol != "" && dbCol != "-" {
+			fields[dbCol] = field
+		}
+	}
+	return fields
+}
+
+// flattenedColumns maps model types to the field index of each column that belongs to a
+// value object. scanStruct resolves other columns with the sqlx mapper, which does not
+// know the prefixed column names.
+var flattenedColumns sync.Map // reflect.Type -> map[string][]int
+
+// registerFlattenedColumns records the value object columns of metadata for type t.
+func registerFlattenedColumns(t reflect.Type, metadata sentinel.Metadata) {
+	var indexes map[string][]int
+	for column, field := range columnFields(metadata) {
+		if len(field.Index) > 1 {
+			if indexes == nil {
+				indexes = make(map[string][]int)
+			}
+			indexes[column] = field.Index
+		}
+	}
+	if indexes != nil {
+		flattenedColumns.Store(t, indexes)
+	}
+}
+
+// flattenedColumn returns the field index of a value object column of type t.
+func flattenedColumn(t reflect.Type, column string) []int {
+	indexes, ok := flattenedColumns.Load(t)
+	if !ok {
+		return nil
+	}
+	return indexes.(map[string][]int)[column]
+}
+
+// hasFlattenedColumns reports whether any column of metadata belongs to a value object.
+// Such records cannot be bound by sqlx, which does not know the prefixed column names.
+func hasFlattenedColumns(metadata sentinel.Metadata) bool {
+	for _, field := range metadata.Fields {
+		if dbCol := field.Tags["db"]; dbCol != "" && dbCol != "-" && len(field.Index) > 1 {
+			return true
+		}
+	}
+	return false
+}
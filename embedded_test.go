@@ -0,0 +1,213 @@
+package soy
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/zoobzio/astql/postgres"
+)
+
+type embeddedTestGeo struct {
+	Lat float64 `db:"lat"`
+	Lng float64 `db:"lng"`
+}
+
+type embeddedTestAddress struct {
+	Street string          `db:"street" type:"text" constraints:"notnull"`
+	City   string          `db:"city" index:"true"`
+	Kind   string          `db:"kind" enum:"home,work"`
+	Geo    embeddedTestGeo `db:"geo" prefix:"geo_"`
+}
+
+// EmbeddedTestAudit is exported because Sentinel skips embedded fields of unexported types.
+type EmbeddedTestAudit struct {
+	CreatedBy string `db:"created_by"`
+}
+
+type embeddedTestCustomer struct {
+	ID       int                 `db:"id" type:"serial" constraints:"primarykey"`
+	Name     string              `db:"name"`
+	Address  embeddedTestAddress `db:"addr" prefix:"addr_"`
+	Shipping embeddedTestAddress `db:"ship" prefix:"ship_"`
+	EmbeddedTestAudit
+}
+
+var embeddedTestColumns = []string{
+	"id", "name",
+	"addr_street", "addr_city", "addr_kind", "addr_geo_lat", "addr_geo_lng",
+	"ship_street", "ship_city", "ship_kind", "ship_geo_lat", "ship_geo_lng",
+	"created_by",
+}
+
+func TestFlattenMetadata(t *testing.T) {
+	s, err := New[embeddedTestCustomer](nil, "customers", postgres.New())
+	if err != nil {
+		t.Fatalf("New() failed: %v", err)
+	}
+
+	var columns []string
+	for _, field := range s.getMetadata().Fields {
+		columns = append(columns, field.Tags["db"])
+	}
+	if !reflect.DeepEqual(columns, embeddedTestColumns) {
+		t.Errorf("columns = %q, want %q", columns, embeddedTestColumns)
+	}
+
+	lat := columnFields(s.getMetadata())["ship_geo_lat"]
+	if lat.Name != "Shipping.Geo.Lat" || !reflect.DeepEqual(lat.Index, []int{3, 3, 0}) {
+		t.Errorf("ship_geo_lat field = %s %v", lat.Name, lat.Index)
+	}
+
+	if got := len(s.Metadata().Fields); got != 5 {
+		t.Errorf("Metadata() has %d fields, want the 5 declared fields", got)
+	}
+
+	t.Run("prefix on non-struct", func(t *testing.T) {
+		type badPrefix struct {
+			ID   int    `db:"id"`
+			Name string `db:"name" prefix:"n_"`
+		}
+		_, err := New[badPrefix](nil, "bad", postgres.New())
+		if err == nil || !strings.Contains(err.Error(), `field "Name": prefix tag requires a struct field`) {
+			t.Errorf("New() error = %v", err)
+		}
+	})
+
+	t.Run("colliding columns", func(t *testing.T) {
+		type collision struct {
+			ID      int                 `db:"id"`
+			Street  string              `db:"addr_street"`
+			Address embeddedTestAddress `db:"addr" prefix:"addr_"`
+		}
+		if _, err := New[collision](nil, "bad", postgres.New()); err == nil {
+			t.Error("expected error for column mapped by two fields")
+		}
+	})
+}
+
+func TestEmbeddedDDL(t *testing.T) {
+	s, err := New[embeddedTestCustomer](nil, "customers", postgres.New())
+	if err != nil {
+		t.Fatalf("New() failed: %v", err)
+	}
+	sql, err := s.CreateTableSQL()
+	if err != nil {
+		t.Fatalf("CreateTableSQL() error = %v", err)
+	}
+	for _, want := range []string{
+		`"addr_street" text NOT NULL`,
+		`"ship_geo_lng" DOUBLE PRECISION,`,
+		`"created_by" TEXT`,
+		`CREATE TYPE "customers_ship_kind" AS ENUM ('home', 'work');`,
+	} {
+		if !strings.Contains(sql, want) {
+			t.Errorf("CreateTableSQL() missing %q:\n%s", want, sql)
+		}
+	}
+	if strings.Contains(sql, `"addr" `) {
+		t.Errorf("CreateTableSQL() has a column for the value object itself:\n%s", sql)
+	}
+
+	indexes, err := s.CreateIndexesSQL()
+	if err != nil {
+		t.Fatalf("CreateIndexesSQL() error = %v", err)
+	}
+	if len(indexes) != 2 || !strings.Contains(indexes[0], `("addr_city")`) || !strings.Contains(indexes[1], `("ship_city")`) {
+		t.Errorf("CreateIndexesSQL() = %q", indexes)
+	}
+}
+
+func TestEmbeddedInsert(t *testing.T) {
+	s, err := New[embeddedTestCustomer](&sqlx.DB{}, "customers", postgres.New())
+	if err != nil {
+		t.Fatalf("New() failed: %v", err)
+	}
+	record := &embeddedTestCustomer{
+		Name:              "Ada",
+		Address:           embeddedTestAddress{Street: "1 Main St", Kind: "home", Geo: embeddedTestGeo{Lat: 1.5}},
+		Shipping:          embeddedTestAddress{City: "Leeds", Kind: "work"},
+		EmbeddedTestAudit: EmbeddedTestAudit{CreatedBy: "system"},
+	}
+
+	result, err := s.Insert().Render()
+	if err != nil {
+		t.Fatalf("Render() failed: %v", err)
+	}
+	for _, want := range []string{`"addr_geo_lat"`, `:addr_geo_lat`, `"created_by"`} {
+		if !strings.Contains(result.SQL, want) {
+			t.Errorf("Insert() SQL missing %q: %s", want, result.SQL)
+		}
+	}
+
+	arg, err := recordArg(dialectPostgres, s.getMetadata(), record)
+	if err != nil {
+		t.Fatalf("recordArg() error = %v", err)
+	}
+	params, ok := arg.(map[string]any)
+	if !ok {
+		t.Fatalf("recordArg() = %T, want params extracted by column", arg)
+	}
+	if params["addr_street"] != "1 Main St" || params["addr_geo_lat"] != 1.5 || params["ship_city"] != "Leeds" || params["created_by"] != "system" {
+		t.Errorf("recordArg() = %v", params)
+	}
+
+	builder, batchParams, err := s.Insert().buildBatchInsert(t.Context(), []*embeddedTestCustomer{record}, s.Insert().insertColumns(false))
+	if err != nil || builder == nil {
+		t.Fatalf("buildBatchInsert() error = %v", err)
+	}
+	if batchParams["ship_kind_0"] != "work" || batchParams["addr_geo_lng_0"] != 0.0 {
+		t.Errorf("buildBatchInsert() params = %v", batchParams)
+	}
+
+	record.Shipping.Kind = "depot"
+	_, err = s.Insert().Exec(t.Context(), record)
+	if !errors.Is(err, ErrInvalidEnum) || !strings.Contains(err.Error(), "field Shipping.Kind") {
+		t.Errorf("Exec() error = %v, want ErrInvalidEnum for Shipping.Kind", err)
+	}
+}
+
+func TestScanStruct_Embedded(t *testing.T) {
+	if _, err := New[embeddedTestCustomer](nil, "customers", postgres.New()); err != nil {
+		t.Fatalf("New() failed: %v", err)
+	}
+
+	name := fmt.Sprintf("soy_codec_%d", verifyDriverSeq.Add(1))
+	sql.Register(name, &codecDriver{
+		cols: []string{"id", "addr_street", "addr_geo_lat", "ship_city", "created_by"},
+		data: [][]driver.Value{{int64(7), "1 Main St", 1.5, "Leeds", "system"}},
+	})
+	db, err := sqlx.Open(name, "")
+	if err != nil {
+		t.Fatalf("sqlx.Open() failed: %v", err)
+	}
+	t.Cleanup(func() { _ = db.Close() })
+
+	rows, err := db.Queryx("SELECT * FROM customers")
+	if err != nil {
+		t.Fatalf("Queryx() failed: %v", err)
+	}
+	defer rows.Close()
+	if !rows.Next() {
+		t.Fatal("expected a row")
+	}
+
+	var got embeddedTestCustomer
+	if err := scanStruct(dialectPostgres, rows, &got); err != nil {
+		t.Fatalf("scanStruct() error = %v", err)
+	}
+	want := embeddedTestCustomer{
+		ID:                7,
+		Address:           embeddedTestAddress{Street: "1 Main St", Geo: embeddedTestGeo{Lat: 1.5}},
+		Shipping:          embeddedTestAddress{City: "Leeds"},
+		EmbeddedTestAudit: EmbeddedTestAudit{CreatedBy: "system"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("scanStruct() = %+v, want %+v", got, want)
+	}
+}
@@ -0,0 +1,301 @@
+package soy
+
+import (
+	"database/sql/driver"
+	"fmt"
+	"maps"
+	"reflect"
+	"slices"
+	"strings"
+
+	"github.com/zoobzio/astql"
+	"github.com/zoobzio/dbml"
+	"github.com/zoobzio/sentinel"
+	"github.com/zoobzio/soy/internal/ddl"
+)
+
+// enumOperators are the comparisons whose params are checked against the values of an
+// enum column. Pattern and range comparisons are left alone.
+var enumOperators = map[astql.Operator]bool{
+	astql.EQ: true, astql.NE: true, astql.IN: true, astql.NotIn: true,
+	"= ANY": true, "!= ANY": true, "= ALL": true, "!= ALL": true,
+}
+
+// parseEnumTag parses an enum tag such as "draft,published,archived".
+// Values are trimmed; empty and duplicate values are errors.
+func parseEnumTag(tag string) ([]string, error) {
+	var values []string
+	for _, value := range strings.Split(tag, ",") {
+		value = strings.TrimSpace(value)
+		if value == "" {
+			return nil, fmt.Errorf("empty value in enum tag %q", tag)
+		}
+		if slices.Contains(values, value) {
+			return nil, fmt.Errorf("duplicate value %q in enum tag %q", value, tag)
+		}
+		values = append(values, value)
+	}
+	return values, nil
+}
+
+// enumColumns returns the allowed values of each column with an enum tag.
+// Tags are validated by buildDBMLFromStruct, so malformed tags are skipped here.
+func enumColumns(metadata sentinel.Metadata) map[string][]string {
+	var enums map[string][]string
+	for _, field := range metadata.Fields {
+		tag, ok := field.Tags["enum"]
+		dbCol := field.Tags["db"]
+		if !ok || dbCol == "" || dbCol == "-" {
+			continue
+		}
+		values, err := parseEnumTag(tag)
+		if err != nil {
+			continue
+		}
+		if enums == nil {
+			enums = make(map[string][]string)
+		}
+		enums[dbCol] = values
+	}
+	return enums
+}
+
+// enumColumnType returns the native enum column type for an untyped enum column.
+// PostgreSQL gets an enum type named <table>_<column>, which is added to project;
+// MariaDB gets an inline ENUM column. Other dialects have no native enum and report
+// false, so the column falls back to its inferred type and a CHECK constraint.
+func enumColumnType(project *dbml.Project, d dialect, table, column string, values []string) (string, bool) {
+	switch d {
+	case dialectPostgres:
+		name := enumTypeName(table, column)
+		project.AddEnum(dbml.NewEnum(name, values...).WithSchema(d.schema()))
+		return name, true
+	case dialectMariaDB:
+		return "ENUM(" + sqlStrings(values) + ")", true
+	default:
+		return "", false
+	}
+}
+
+// enumTypeName returns the name of the PostgreSQL enum type of an untyped enum column.
+func enumTypeName(table, column string) string {
+	return strings.ToLower(table + "_" + column)
+}
+
+// enumCheck returns the CHECK expression restricting column to values, combined with
+// the column's own check expression, if any.
+func enumCheck(d dialect, column string, values []string, check string) string {
+	expr := fmt.Sprintf("%s IN (%s)", d.quote(column), sqlStrings(values))
+	if check == "" {
+		return expr
+	}
+	return "(" + check + ") AND " + expr
+}
+
+// sqlStrings renders values as comma-separated SQL string literals.
+func sqlStrings(values []string) string {
+	literals := make([]string, len(values))
+	for i, value := range values {
+		literals[i] = ddl.SQLString(value)
+	}
+	return strings.Join(literals, ", ")
+}
+
+// EnumValues returns the values allowed by the enum tag of column, or nil if the column
+// has no enum tag. The raw tag is also available in Metadata() as the field's "enum" tag.
+func (c *Soy[T]) EnumValues(column string) []string {
+	schema := schemaOf(c.instance)
+	if schema == nil {
+		return nil
+	}
+	return slices.Clone(schema.enums[column])
+}
+
+// enumBinding is the enum column a param is bound to.
+type enumBinding struct {
+	column string
+	values []string
+}
+
+// enumBindings maps param names to the enum columns they are compared with or assigned to.
+type enumBindings map[string]enumBinding
+
+// validateEnumParams checks the params bound to enum columns in builder's WHERE, SET and
+// VALUES clauses against the columns' allowed values.
+func validateEnumParams(instance *astql.ASTQL, builder *astql.Builder, params map[string]any) error {
+	return enumBindingsOf(instance, builder).validate(params)
+}
+
+// enumBindingsOf returns the enum bindings of builder's statement.
+// Builders that fail to build have no bindings; rendering reports their error.
+func enumBindingsOf(instance *astql.ASTQL, builder *astql.Builder) enumBindings {
+	schema := schemaOf(instance)
+	if schema == nil || len(schema.enums) == 0 {
+		return nil
+	}
+	ast, err := builder.Build()
+	if err != nil {
+		return nil
+	}
+	bindings := enumBindings{}
+	bindings.collect(schema, ast)
+	return bindings
+}
+
+// compoundEnumBindings returns the enum bindings of every query in a compound query.
+func compoundEnumBindings(instance *astql.ASTQL, builder *astql.CompoundBuilder) enumBindings {
+	schema := schemaOf(instance)
+	if schema == nil || len(schema.enums) == 0 {
+		return nil
+	}
+	query, err := builder.Build()
+	if err != nil {
+		return nil
+	}
+	bindings := enumBindings{}
+	bindings.collect(schema, query.Base)
+	for _, operand := range query.Operands {
+		bindings.collect(schema, operand.AST)
+	}
+	return bindings
+}
+
+// collect adds the params of ast bound to enum columns.
+func (b enumBindings) collect(schema *instanceSchema, ast *astql.AST) {
+	if ast == nil {
+		return
+	}
+	b.collectCondition(schema, reflect.ValueOf(ast.WhereClause))
+	for f, p := range ast.Updates {
+		b.bind(schema, f.Name, p.Name)
+	}
+	for _, values := range ast.Values {
+		for f, p := range values {
+			b.bind(schema, f.Name, p.Name)
+		}
+	}
+	if ast.OnConflict != nil {
+		for f, p := range ast.OnConflict.Updates {
+			b.bind(schema, f.Name, p.Name)
+		}
+	}
+}
+
+// collectCondition walks a WHERE condition tree. ASTQL does not export its condition
+// types, so conditions and groups are recognized by their fields.
+func (b enumBindings) collectCondition(schema *instanceSchema, v reflect.Value) {
+	for v.Kind() == reflect.Interface || v.Kind() == reflect.Pointer {
+		if v.IsNil() {
+			return
+		}
+		v = v.Elem()
+	}
+	if v.Kind() != reflect.Struct {
+		return
+	}
+
+	if group := v.FieldByName("Conditions"); group.IsValid() && group.Kind() == reflect.Slice {
+		for i := range group.Len() {
+			b.collectCondition(schema, group.Index(i))
+		}
+		return
+	}
+
+	field, op, value := v.FieldByName("Field"), v.FieldByName("Operator"), v.FieldByName("Value")
+	if field.Kind() != reflect.Struct || !op.IsValid() || value.Kind() != reflect.Struct {
+		return
+	}
+	operator, ok := op.Interface().(astql.Operator)
+	if !ok || !enumOperators[operator] {
+		return
+	}
+	// JSON key extraction compares a value inside the column, not the column itself
+	if key := field.FieldByName("JSONBTextKey"); key.IsValid() && !key.IsNil() {
+		return
+	}
+	if key := field.FieldByName("JSONBPathKey"); key.IsValid() && !key.IsNil() {
+		return
+	}
+	b.bind(schema, field.FieldByName("Name").String(), value.FieldByName("Name").String())
+}
+
+// bind records param as bound to column if column is an enum column.
+func (b enumBindings) bind(schema *instanceSchema, column, param string) {
+	if values, ok := schema.enums[column]; ok {
+		b[param] = enumBinding{column: column, values: values}
+	}
+}
+
+// validate checks every bound param that is present in params, in name order.
+// Missing params are left to validateParams.
+func (b enumBindings) validate(params map[string]any) error {
+	for _, param := range slices.Sorted(maps.Keys(b)) {
+		binding := b[param]
+		value, ok := params[param]
+		if !ok {
+			continue
+		}
+		if invalid, ok := checkEnumValue(binding.values, reflect.ValueOf(value)); !ok {
+			return newEnumError(fmt.Sprintf("param %q", param), binding.column, invalid, binding.values)
+		}
+	}
+	return nil
+}
+
+// validateEnumRecord checks the enum columns of record against their allowed values.
+func validateEnumRecord(instance *astql.ASTQL, metadata sentinel.Metadata, record any) error {
+	schema := schemaOf(instance)
+	if schema == nil || len(schema.enums) == 0 {
+		return nil
+	}
+	rv := reflect.Indirect(reflect.ValueOf(record))
+	fields := columnFields(metadata)
+	for _, column := range slices.Sorted(maps.Keys(schema.enums)) {
+		values := schema.enums[column]
+		if invalid, ok := checkEnumValue(values, rv.FieldByIndex(fields[column].Index)); !ok {
+			return newEnumError("field "+fields[column].Name, column, invalid, values)
+		}
+	}
+	return nil
+}
+
+// checkEnumValue reports whether v is one of values, returning the first value that is
+// not. Nil values bind as NULL and are allowed; slices, as bound to IN and ANY, have
+// each element checked.
+func checkEnumValue(values []string, v reflect.Value) (string, bool) {
+	for v.Kind() == reflect.Interface || v.Kind() == reflect.Pointer {
+		if v.IsNil() {
+			return "", true
+		}
+		v = v.Elem()
+	}
+	if !v.IsValid() {
+		return "", true
+	}
+
+	if valuer, ok := v.Interface().(driver.Valuer); ok {
+		dv, err := valuer.Value()
+		if err != nil || dv == nil {
+			return "", true
+		}
+		v = reflect.ValueOf(dv)
+	}
+
+	var s string
+	switch {
+	case v.Kind() == reflect.String:
+		s = v.String()
+	case v.Kind() == reflect.Slice && v.Type().Elem().Kind() == reflect.Uint8:
+		s = string(v.Bytes())
+	case v.Kind() == reflect.Slice || v.Kind() == reflect.Array:
+		for i := range v.Len() {
+			if invalid, ok := checkEnumValue(values, v.Index(i)); !ok {
+				return invalid, false
+			}
+		}
+		return "", true
+	default:
+		s = fmt.Sprint(v.Interface())
+	}
+	return s, slices.Contains(values, s)
+}
@@ -0,0 +1,249 @@
+package soy
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/zoobzio/astql/mariadb"
+	"github.com/zoobzio/astql/postgres"
+	"github.com/zoobzio/astql/sqlite"
+)
+
+type enumTestPost struct {
+	ID     int     `db:"id" type:"integer" constraints:"primarykey"`
+	Title  string  `db:"title" type:"text"`
+	Status string  `db:"status" enum:"draft,published,archived" constraints:"notnull"`
+	Kind   *string `db:"kind" type:"varchar(16)" enum:"note, page" check:"kind <> ''"`
+}
+
+func TestParseEnumTag(t *testing.T) {
+	values, err := parseEnumTag(" draft, published ,archived")
+	if err != nil {
+		t.Fatalf("parseEnumTag() error = %v", err)
+	}
+	if want := []string{"draft", "published", "archived"}; !reflect.DeepEqual(values, want) {
+		t.Errorf("parseEnumTag() = %q, want %q", values, want)
+	}
+
+	for _, bad := range []string{"", "a,,b", "a,b,a"} {
+		if _, err := parseEnumTag(bad); err == nil {
+			t.Errorf("parseEnumTag(%q) expected error", bad)
+		}
+	}
+}
+
+func TestEnumDDL(t *testing.T) {
+	t.Run("postgres native enum", func(t *testing.T) {
+		s, err := New[enumTestPost](nil, "posts", postgres.New())
+		if err != nil {
+			t.Fatalf("New() failed: %v", err)
+		}
+		project, err := s.DBML()
+		if err != nil {
+			t.Fatalf("DBML() error = %v", err)
+		}
+		enum, ok := project.Enums["public.posts_status"]
+		if !ok || !reflect.DeepEqual(enum.Values, []string{"draft", "published", "archived"}) {
+			t.Fatalf("Enums = %v", project.Enums)
+		}
+
+		sql, err := s.CreateTableSQL()
+		if err != nil {
+			t.Fatalf("CreateTableSQL() error = %v", err)
+		}
+		for _, want := range []string{
+			`CREATE TYPE "posts_status" AS ENUM ('draft', 'published', 'archived');` + "\nCREATE TABLE",
+			`"status" posts_status NOT NULL`,
+			`"kind" varchar(16) CHECK ((kind <> '') AND "kind" IN ('note', 'page'))`,
+		} {
+			if !strings.Contains(sql, want) {
+				t.Errorf("CreateTableSQL() missing %q:\n%s", want, sql)
+			}
+		}
+	})
+
+	t.Run("mariadb inline enum", func(t *testing.T) {
+		s, err := New[enumTestPost](nil, "posts", mariadb.New())
+		if err != nil {
+			t.Fatalf("New() failed: %v", err)
+		}
+		sql, err := s.CreateTableSQL()
+		if err != nil {
+			t.Fatalf("CreateTableSQL() error = %v", err)
+		}
+		if !strings.Contains(sql, "`status` ENUM('draft', 'published', 'archived') NOT NULL") || strings.Contains(sql, "CREATE TYPE") {
+			t.Errorf("CreateTableSQL() = %s", sql)
+		}
+	})
+
+	t.Run("sqlite check constraint", func(t *testing.T) {
+		s, err := New[enumTestPost](nil, "posts", sqlite.New())
+		if err != nil {
+			t.Fatalf("New() failed: %v", err)
+		}
+		sql, err := s.CreateTableSQL()
+		if err != nil {
+			t.Fatalf("CreateTableSQL() error = %v", err)
+		}
+		if !strings.Contains(sql, `"status" TEXT NOT NULL CHECK ("status" IN ('draft', 'published', 'archived'))`) {
+			t.Errorf("CreateTableSQL() = %s", sql)
+		}
+	})
+
+	t.Run("invalid tag", func(t *testing.T) {
+		type badEnum struct {
+			Status string `db:"status" enum:"a,a"`
+		}
+		if _, err := New[badEnum](nil, "bad", postgres.New()); err == nil {
+			t.Error("expected error for duplicate enum value")
+		}
+	})
+}
+
+func TestEnumValues(t *testing.T) {
+	s, err := New[enumTestPost](nil, "posts", postgres.New())
+	if err != nil {
+		t.Fatalf("New() failed: %v", err)
+	}
+	if got := s.EnumValues("kind"); !reflect.DeepEqual(got, []string{"note", "page"}) {
+		t.Errorf("EnumValues(kind) = %q", got)
+	}
+	if got := s.EnumValues("title"); got != nil {
+		t.Errorf("EnumValues(title) = %q, want nil", got)
+	}
+
+	for _, field := range s.Metadata().Fields {
+		if field.Name == "Status" && field.Tags["enum"] != "draft,published,archived" {
+			t.Errorf("Metadata() enum tag = %q", field.Tags["enum"])
+		}
+	}
+}
+
+func TestEnumParamValidation(t *testing.T) {
+	// The zero sqlx.DB cannot execute, so only validation errors are expected here
+	s, err := New[enumTestPost](&sqlx.DB{}, "posts", postgres.New())
+	if err != nil {
+		t.Fatalf("New() failed: %v", err)
+	}
+	ctx := t.Context()
+
+	t.Run("where", func(t *testing.T) {
+		_, err := s.Query().Where("status", "=", "status").Exec(ctx, map[string]any{"status": "deleted"})
+		var vErr *ValidationError
+		if !errors.Is(err, ErrInvalidEnum) || !errors.As(err, &vErr) || vErr.Name != "deleted" {
+			t.Fatalf("expected ErrInvalidEnum for deleted, got %v", err)
+		}
+		if !strings.Contains(err.Error(), `expected one of: draft, published, archived`) {
+			t.Errorf("error message = %q", err.Error())
+		}
+	})
+
+	t.Run("where in and any", func(t *testing.T) {
+		_, err := s.Query().
+			WhereOr(C("status", "IN", "statuses"), C("title", "=", "title")).
+			Exec(ctx, map[string]any{"statuses": []string{"draft", "gone"}, "title": "deleted"})
+		if !errors.Is(err, ErrInvalidEnum) {
+			t.Errorf("IN: expected ErrInvalidEnum, got %v", err)
+		}
+		_, err = s.Select().Where("status", "= ANY", "statuses").Exec(ctx, map[string]any{"statuses": []string{"gone"}})
+		if !errors.Is(err, ErrInvalidEnum) {
+			t.Errorf("ANY: expected ErrInvalidEnum, got %v", err)
+		}
+	})
+
+	t.Run("set", func(t *testing.T) {
+		kind := "post"
+		_, err := s.Modify().Set("kind", "kind").Where("id", "=", "id").Exec(ctx, map[string]any{"kind": &kind, "id": 1})
+		if !errors.Is(err, ErrInvalidEnum) {
+			t.Errorf("expected ErrInvalidEnum, got %v", err)
+		}
+		_, err = s.Modify().Set("kind", "kind").Where("id", "=", "id").ExecBatch(ctx, []map[string]any{{"kind": "note", "id": 1}, {"kind": "post", "id": 2}})
+		var bErr *BatchError
+		if !errors.As(err, &bErr) || len(bErr.Errors) != 1 || bErr.Errors[0].Index != 1 || !errors.Is(bErr.Errors[0].Err, ErrInvalidEnum) {
+			t.Errorf("ExecBatch: expected ErrInvalidEnum at index 1, got %v", err)
+		}
+	})
+
+	t.Run("insert", func(t *testing.T) {
+		_, err := s.Insert().Exec(ctx, &enumTestPost{Title: "t", Status: "deleted"})
+		if !errors.Is(err, ErrInvalidEnum) {
+			t.Errorf("Exec: expected ErrInvalidEnum, got %v", err)
+		}
+		_, err = s.Insert().ExecBatch(ctx, []*enumTestPost{{Status: "draft"}, {Status: "deleted"}})
+		if !errors.Is(err, ErrInvalidEnum) || !strings.Contains(err.Error(), "record at index 1") {
+			t.Errorf("ExecBatch: expected ErrInvalidEnum at index 1, got %v", err)
+		}
+		_, err = s.Insert().ExecAtom(ctx, map[string]any{"title": "t", "status": "deleted", "kind": nil})
+		if !errors.Is(err, ErrInvalidEnum) {
+			t.Errorf("ExecAtom: expected ErrInvalidEnum, got %v", err)
+		}
+	})
+
+	t.Run("compiled", func(t *testing.T) {
+		compiled, err := s.Query().Where("status", "!=", "status").Compile()
+		if err != nil {
+			t.Fatalf("Compile() error = %v", err)
+		}
+		if _, err := compiled.Exec(ctx, map[string]any{"status": "deleted"}); !errors.Is(err, ErrInvalidEnum) {
+			t.Errorf("expected ErrInvalidEnum, got %v", err)
+		}
+	})
+}
+
+func TestEnumBindings(t *testing.T) {
+	s, err := New[enumTestPost](nil, "posts", postgres.New())
+	if err != nil {
+		t.Fatalf("New() failed: %v", err)
+	}
+
+	q := s.Query().
+		Where("status", "=", "s1").
+		Where("title", "=", "t").
+		Where("status", "LIKE", "pattern").
+		WhereAnd(C("kind", "!=", "k"), C("id", ">", "min"))
+	bindings := enumBindingsOf(q.instance, q.builder)
+	want := enumBindings{
+		"s1": {column: "status", values: []string{"draft", "published", "archived"}},
+		"k":  {column: "kind", values: []string{"note", "page"}},
+	}
+	if !reflect.DeepEqual(bindings, want) {
+		t.Errorf("enumBindingsOf() = %v, want %v", bindings, want)
+	}
+
+	if err := bindings.validate(map[string]any{"s1": "draft", "k": nil}); err != nil {
+		t.Errorf("validate() unexpected error: %v", err)
+	}
+	if err := bindings.validate(map[string]any{"s1": "Draft"}); !errors.Is(err, ErrInvalidEnum) {
+		t.Errorf("validate() should be case-sensitive, got %v", err)
+	}
+}
+
+func TestEnumBatchUpdate(t *testing.T) {
+	s, err := New[enumTestPost](&sqlx.DB{}, "Posts", postgres.New())
+	if err != nil {
+		t.Fatalf("New() failed: %v", err)
+	}
+
+	update := s.Modify().Set("status", "st").Set("kind", "kind").Where("id", "=", "id")
+	query, ok := update.batch.renderSetUpdate(dialectPostgres, "Posts", s.getMetadata(), 1)
+	if !ok {
+		t.Fatal("expected set-based UPDATE")
+	}
+	// The native enum column takes the enum type; a typed enum column keeps its type
+	for _, want := range []string{`CAST(:st_0 AS "posts_status")`, `CAST(:kind_0 AS varchar(16))`} {
+		if !strings.Contains(query, want) {
+			t.Errorf("query missing %q:\n%s", want, query)
+		}
+	}
+
+	ddl, err := s.CreateTableSQL()
+	if err != nil {
+		t.Fatalf("CreateTableSQL() failed: %v", err)
+	}
+	if !strings.Contains(ddl, `CREATE TYPE "posts_status"`) {
+		t.Errorf("cast type does not match the enum type:\n%s", ddl)
+	}
+}
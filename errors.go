@@ -3,6 +3,7 @@ package soy
 import (
 	"errors"
 	"fmt"
+	"strings"
 )
 
 // Sentinel errors for soy package.
@@ -15,6 +16,10 @@ var (
 
 	// ErrNilRenderer is returned when a renderer is nil.
 	ErrNilRenderer = errors.New("soy: renderer cannot be nil")
+
+	// ErrUnmappableType is returned when a field without a type tag has a Go type
+	// with no default column type in the renderer's dialect.
+	ErrUnmappableType = errors.New("soy: no default column type for Go type, add a type tag")
 )
 
 // Data errors.
@@ -96,6 +101,9 @@ var (
 
 	// ErrInvalidAggregateFunc is returned when an aggregate function is not supported.
 	ErrInvalidAggregateFunc = &ValidationError{Kind: "aggregate function"}
+
+	// ErrInvalidEnum is returned when a value bound to an enum column is not one of its values.
+	ErrInvalidEnum = &ValidationError{Kind: "enum"}
 )
 
 // newFieldError creates a ValidationError for an invalid field.
@@ -103,20 +111,45 @@ func newFieldError(name string, err error) error {
 	return &ValidationError{Kind: "field", Name: name, Err: err}
 }
 
+// newFieldUsageError creates a ValidationError for a valid field used where its column type does not apply.
+func newFieldUsageError(name, message string) error {
+	return &ValidationError{Kind: "field", Name: name, Message: message}
+}
+
+// newEnumError creates a ValidationError for a value outside an enum column's values.
+// source names the param or field the value came from.
+func newEnumError(source, column, value string, values []string) error {
+	return &ValidationError{
+		Kind:    "enum",
+		Name:    value,
+		Message: fmt.Sprintf("%s: %q is not a value of enum column %q, expected one of: %s", source, value, column, strings.Join(values, ", ")),
+	}
+}
+
 // newParamError creates a ValidationError for an invalid param.
 func newParamError(name string, err error) error {
 	return &ValidationError{Kind: "param", Name: name, Err: err}
 }
 
+// newParamUsageError creates a ValidationError for a param that is used incorrectly.
+func newParamUsageError(name, message string) error {
+	return &ValidationError{Kind: "param", Name: name, Message: message}
+}
+
 // newOperatorError creates a ValidationError for an invalid operator.
 func newOperatorError(op string) error {
 	return &ValidationError{
 		Kind:    "operator",
 		Name:    op,
-		Message: fmt.Sprintf("invalid operator %q, supported: =, !=, >, >=, <, <=, LIKE, NOT LIKE, ILIKE, NOT ILIKE, IN, NOT IN, ~, ~*, !~, !~*, @>, <@, &&, <->, <#>, <=>, <+>", op),
+		Message: fmt.Sprintf("invalid operator %q, supported: =, !=, >, >=, <, <=, LIKE, NOT LIKE, ILIKE, NOT ILIKE, IN, NOT IN, ~, ~*, !~, !~*, @>, <@, &&, = ANY, != ANY, > ANY, >= ANY, < ANY, <= ANY, LIKE ANY, ILIKE ANY, = ALL, != ALL, > ALL, >= ALL, < ALL, <= ALL, NOT LIKE ALL, NOT ILIKE ALL, ->, ->>, #>, #>>, ?, ?|, ?&, @?, <->, <#>, <=>, <+>, <%%, %%>, <<%%, %%>>, <<->, <->>", op),
 	}
 }
 
+// newOperatorUsageError creates a ValidationError for a valid operator used where it does not apply.
+func newOperatorUsageError(op, message string) error {
+	return &ValidationError{Kind: "operator", Name: op, Message: message}
+}
+
 // newDirectionError creates a ValidationError for an invalid direction.
 func newDirectionError(dir string) error {
 	return &ValidationError{
@@ -286,3 +319,115 @@ func (e *UnsafeOperationError) Is(target error) bool {
 
 // ErrUnsafeOperation is returned when any unsafe operation is attempted.
 var ErrUnsafeOperation = &UnsafeOperationError{}
+
+// BatchError reports which entries of a batch operation failed.
+// Set-based batches fail as a whole before execution, so every invalid index is reported;
+// row-by-row batches stop at the first failing index.
+type BatchError struct {
+	Operation string       // The operation: "UPDATE", "DELETE"
+	Affected  int64        // Rows affected before the failure; 0 when ExecBatch rolled them back
+	Errors    []IndexError // Failures by batch index, in index order
+}
+
+// IndexError is the failure of a single batch entry.
+type IndexError struct {
+	Index int   // Position in the batch
+	Err   error // Underlying error
+}
+
+func (e *IndexError) Error() string {
+	return fmt.Sprintf("index %d: %v", e.Index, e.Err)
+}
+
+func (e *IndexError) Unwrap() error {
+	return e.Err
+}
+
+func (e *BatchError) Error() string {
+	if len(e.Errors) == 1 {
+		return fmt.Sprintf("batch %s failed at index %d after %d rows: %v", e.Operation, e.Errors[0].Index, e.Affected, e.Errors[0].Err)
+	}
+	msgs := make([]string, len(e.Errors))
+	for i := range e.Errors {
+		msgs[i] = e.Errors[i].Error()
+	}
+	return fmt.Sprintf("batch %s failed at %d indexes: %s", e.Operation, len(e.Errors), strings.Join(msgs, "; "))
+}
+
+// Unwrap returns the per-index errors so errors.Is and errors.As see through a BatchError.
+func (e *BatchError) Unwrap() []error {
+	errs := make([]error, len(e.Errors))
+	for i := range e.Errors {
+		errs[i] = &e.Errors[i]
+	}
+	return errs
+}
+
+// Is implements errors.Is for BatchError.
+func (e *BatchError) Is(target error) bool {
+	t, ok := target.(*BatchError)
+	if !ok {
+		return false
+	}
+	return t.Operation == "" || e.Operation == t.Operation
+}
+
+// ErrBatchFailed is returned when one or more entries of a batch operation fail.
+var ErrBatchFailed = &BatchError{}
+
+// MissingParamsError is returned before execution when the params map lacks
+// one or more params the rendered statement requires.
+type MissingParamsError struct {
+	Names []string // Missing param names, sorted
+}
+
+func (e *MissingParamsError) Error() string {
+	return fmt.Sprintf("missing params: %s", strings.Join(e.Names, ", "))
+}
+
+// Is implements errors.Is for MissingParamsError.
+func (e *MissingParamsError) Is(target error) bool {
+	_, ok := target.(*MissingParamsError)
+	return ok
+}
+
+// ErrMissingParams is returned when required params are absent from the params map.
+var ErrMissingParams = &MissingParamsError{}
+
+// UnknownParamsError is returned before execution in strict mode when the params
+// map contains keys the rendered statement does not use.
+type UnknownParamsError struct {
+	Names []string // Unused param names, sorted
+}
+
+func (e *UnknownParamsError) Error() string {
+	return fmt.Sprintf("unknown params: %s", strings.Join(e.Names, ", "))
+}
+
+// Is implements errors.Is for UnknownParamsError.
+func (e *UnknownParamsError) Is(target error) bool {
+	_, ok := target.(*UnknownParamsError)
+	return ok
+}
+
+// ErrUnknownParams is returned in strict mode when the params map has unused keys.
+var ErrUnknownParams = &UnknownParamsError{}
+
+// SchemaDriftError is returned by DriftReport.Err when the live table does not
+// match the schema derived from the model's struct tags.
+type SchemaDriftError struct {
+	Report *DriftReport
+}
+
+func (e *SchemaDriftError) Error() string {
+	return "schema drift: " + e.Report.String()
+}
+
+// Is implements errors.Is for SchemaDriftError.
+func (e *SchemaDriftError) Is(target error) bool {
+	_, ok := target.(*SchemaDriftError)
+	return ok
+}
+
+// ErrSchemaDrift is returned when a verified table differs from its model.
+var ErrSchemaDrift = &SchemaDriftError{}
@@ -2,6 +2,7 @@ package soy
 
 import (
 	"errors"
+	"fmt"
 	"testing"
 )
 
@@ -390,3 +391,70 @@ func TestAggregateFuncError(t *testing.T) {
 		}
 	})
 }
+
+func TestBatchError(t *testing.T) {
+	t.Run("single index message", func(t *testing.T) {
+		err := &BatchError{Operation: "UPDATE", Affected: 3, Errors: []IndexError{{Index: 3, Err: errors.New("boom")}}}
+
+		want := "batch UPDATE failed at index 3 after 3 rows: boom"
+		if err.Error() != want {
+			t.Errorf("Error() = %q, want %q", err.Error(), want)
+		}
+	})
+
+	t.Run("multiple index message", func(t *testing.T) {
+		err := &BatchError{Operation: "DELETE", Errors: []IndexError{
+			{Index: 0, Err: errors.New("a")},
+			{Index: 2, Err: errors.New("b")},
+		}}
+
+		want := "batch DELETE failed at 2 indexes: index 0: a; index 2: b"
+		if err.Error() != want {
+			t.Errorf("Error() = %q, want %q", err.Error(), want)
+		}
+	})
+
+	t.Run("Is matches ErrBatchFailed and per-index errors", func(t *testing.T) {
+		underlying := errors.New("constraint violation")
+		var err error = &BatchError{Operation: "UPDATE", Errors: []IndexError{{Index: 1, Err: underlying}}}
+
+		if !errors.Is(err, ErrBatchFailed) {
+			t.Error("expected BatchError to match ErrBatchFailed")
+		}
+		if !errors.Is(err, &BatchError{Operation: "UPDATE"}) {
+			t.Error("expected BatchError to match same operation")
+		}
+		if errors.Is(err, &BatchError{Operation: "DELETE"}) {
+			t.Error("expected BatchError not to match other operation")
+		}
+		if !errors.Is(err, underlying) {
+			t.Error("expected Unwrap to expose per-index errors")
+		}
+
+		var iErr *IndexError
+		if !errors.As(err, &iErr) {
+			t.Fatal("expected errors.As to find IndexError")
+		}
+		if iErr.Index != 1 {
+			t.Errorf("Index = %d, want 1", iErr.Index)
+		}
+	})
+}
+
+func TestParamsErrors(t *testing.T) {
+	missing := &MissingParamsError{Names: []string{"a", "b"}}
+	if got := missing.Error(); got != "missing params: a, b" {
+		t.Errorf("Error() = %q", got)
+	}
+	if !errors.Is(fmt.Errorf("wrapped: %w", missing), ErrMissingParams) {
+		t.Error("expected MissingParamsError to match ErrMissingParams")
+	}
+
+	unknown := &UnknownParamsError{Names: []string{"x"}}
+	if got := unknown.Error(); got != "unknown params: x" {
+		t.Errorf("Error() = %q", got)
+	}
+	if errors.Is(unknown, ErrMissingParams) || !errors.Is(unknown, ErrUnknownParams) {
+		t.Error("UnknownParamsError should only match ErrUnknownParams")
+	}
+}
@@ -10,6 +10,7 @@ var (
 
 	// QueryCompleted is emitted when a query completes successfully.
 	// Fields: TableKey, OperationKey, DurationMsKey, RowsAffectedKey or RowsReturnedKey.
+	// Batch upserts also include RowsInsertedKey, RowsUpdatedKey, and RowsSkippedKey.
 	QueryCompleted = capitan.NewSignal("db.query.completed", "Database query completed successfully")
 
 	// QueryFailed is emitted when a query fails with an error.
@@ -17,6 +18,22 @@ var (
 	QueryFailed = capitan.NewSignal("db.query.failed", "Database query failed with error")
 )
 
+// Statement cache signals.
+// Emitted only when the statement cache is enabled with EnableStatementCache.
+var (
+	// StatementCacheHit is emitted when a query reuses a cached prepared statement.
+	// Fields: TableKey, SQLKey.
+	StatementCacheHit = capitan.NewSignal("db.stmt_cache.hit", "Prepared statement reused from cache")
+
+	// StatementCacheMiss is emitted when a query has no cached prepared statement.
+	// Fields: TableKey, SQLKey.
+	StatementCacheMiss = capitan.NewSignal("db.stmt_cache.miss", "Prepared statement not found in cache")
+
+	// StatementCacheEvicted is emitted when the least recently used statement is closed to make room.
+	// Fields: TableKey, SQLKey.
+	StatementCacheEvicted = capitan.NewSignal("db.stmt_cache.evicted", "Prepared statement evicted from cache")
+)
+
 // Event field keys for query operations.
 var (
 	// TableKey identifies the database table being operated on.
@@ -45,4 +62,13 @@ var (
 
 	// ResultValueKey contains the result value for COUNT and aggregate operations.
 	ResultValueKey = capitan.NewFloat64Key("result_value")
+
+	// RowsInsertedKey contains the number of rows inserted by batch upsert operations.
+	RowsInsertedKey = capitan.NewInt64Key("rows_inserted")
+
+	// RowsUpdatedKey contains the number of existing rows updated by batch upsert operations.
+	RowsUpdatedKey = capitan.NewInt64Key("rows_updated")
+
+	// RowsSkippedKey contains the number of conflicting rows left untouched by batch upsert operations.
+	RowsSkippedKey = capitan.NewInt64Key("rows_skipped")
 )
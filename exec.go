@@ -16,6 +16,8 @@ import (
 func execMultipleRows[T any](
 	ctx context.Context,
 	execer sqlx.ExtContext,
+	stmts *stmtCache,
+	d dialect,
 	sql string,
 	params map[string]any,
 	tableName string,
@@ -30,7 +32,7 @@ func execMultipleRows[T any](
 
 	startTime := time.Now()
 
-	rows, err := sqlx.NamedQueryContext(ctx, execer, sql, params)
+	rows, err := stmts.namedQuery(ctx, execer, d, sql, params)
 	if err != nil {
 		durationMs := time.Since(startTime).Milliseconds()
 		capitan.Error(ctx, QueryFailed,
@@ -46,7 +48,7 @@ func execMultipleRows[T any](
 	var records []*T
 	for rows.Next() {
 		var record T
-		if err := rows.StructScan(&record); err != nil {
+		if err := scanStruct(d, rows, &record); err != nil {
 			durationMs := time.Since(startTime).Milliseconds()
 			capitan.Error(ctx, QueryFailed,
 				TableKey.Field(tableName),
@@ -86,11 +88,92 @@ func execMultipleRows[T any](
 	return records, nil
 }
 
+// execScoredRows executes a query that returns records with a score column, such as the
+// distance of a nearest-neighbour search, and scans the score into the Distance of each result.
+func execScoredRows[T any](
+	ctx context.Context,
+	execer sqlx.ExtContext,
+	stmts *stmtCache,
+	d dialect,
+	sql string,
+	params map[string]any,
+	tableName string,
+	operation string,
+	scoreColumn string,
+	onScan func(context.Context, *T) error,
+) ([]Scored[T], error) {
+	capitan.Debug(ctx, QueryStarted,
+		TableKey.Field(tableName),
+		OperationKey.Field(operation),
+		SQLKey.Field(sql),
+	)
+
+	startTime := time.Now()
+
+	rows, err := stmts.namedQuery(ctx, execer, d, sql, params)
+	if err != nil {
+		durationMs := time.Since(startTime).Milliseconds()
+		capitan.Error(ctx, QueryFailed,
+			TableKey.Field(tableName),
+			OperationKey.Field(operation),
+			DurationMsKey.Field(durationMs),
+			ErrorKey.Field(err.Error()),
+		)
+		return nil, newQueryError(operation, err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	var results []Scored[T]
+	for rows.Next() {
+		var record T
+		var score float64
+		if err := scanStructWith(d, rows, &record, map[string]any{scoreColumn: &score}); err != nil {
+			durationMs := time.Since(startTime).Milliseconds()
+			capitan.Error(ctx, QueryFailed,
+				TableKey.Field(tableName),
+				OperationKey.Field(operation),
+				DurationMsKey.Field(durationMs),
+				ErrorKey.Field(err.Error()),
+			)
+			return nil, newScanError(operation, err)
+		}
+		if onScan != nil {
+			if err := onScan(ctx, &record); err != nil {
+				return nil, fmt.Errorf("onScan callback failed: %w", err)
+			}
+		}
+		results = append(results, Scored[T]{Record: &record, Distance: score})
+	}
+
+	if err := rows.Err(); err != nil {
+		durationMs := time.Since(startTime).Milliseconds()
+		capitan.Error(ctx, QueryFailed,
+			TableKey.Field(tableName),
+			OperationKey.Field(operation),
+			DurationMsKey.Field(durationMs),
+			ErrorKey.Field(err.Error()),
+		)
+		return nil, newIterationError(err)
+	}
+
+	durationMs := time.Since(startTime).Milliseconds()
+	capitan.Info(ctx, QueryCompleted,
+		TableKey.Field(tableName),
+		OperationKey.Field(operation),
+		DurationMsKey.Field(durationMs),
+		RowsReturnedKey.Field(len(results)),
+	)
+
+	return results, nil
+}
+
 // execAtomSingleRow executes a query and scans the single result directly into an Atom.
 // Returns an error if zero rows or more than one row is found.
 func execAtomSingleRow(
 	ctx context.Context,
 	execer sqlx.ExtContext,
+	stmts *stmtCache,
+	d dialect,
 	sc *scanner.Scanner,
 	sql string,
 	params map[string]any,
@@ -105,7 +188,7 @@ func execAtomSingleRow(
 
 	startTime := time.Now()
 
-	rows, err := sqlx.NamedQueryContext(ctx, execer, sql, params)
+	rows, err := stmts.namedQuery(ctx, execer, d, sql, params)
 	if err != nil {
 		durationMs := time.Since(startTime).Milliseconds()
 		capitan.Error(ctx, QueryFailed,
@@ -170,6 +253,8 @@ func execAtomSingleRow(
 func execAtomMultipleRows(
 	ctx context.Context,
 	execer sqlx.ExtContext,
+	stmts *stmtCache,
+	d dialect,
 	sc *scanner.Scanner,
 	sql string,
 	params map[string]any,
@@ -184,7 +269,7 @@ func execAtomMultipleRows(
 
 	startTime := time.Now()
 
-	rows, err := sqlx.NamedQueryContext(ctx, execer, sql, params)
+	rows, err := stmts.namedQuery(ctx, execer, d, sql, params)
 	if err != nil {
 		durationMs := time.Since(startTime).Milliseconds()
 		capitan.Error(ctx, QueryFailed,
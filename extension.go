@@ -0,0 +1,93 @@
+package soy
+
+import (
+	"fmt"
+	"regexp"
+
+	"github.com/zoobzio/astql"
+)
+
+// extensionRenderer wraps the PostgreSQL renderer to expand the SQL that soy adds on top
+// of ASTQL. ASTQL has no AST nodes for these expressions, so builders add a stand-in that
+// ASTQL renders verbatim and the rendered stand-in is rewritten:
+//   - quantified comparisons such as "= ANY" render as "field = ANY :param", and the
+//     param is wrapped in parentheses
+//   - array functions render as a CAST to a stand-in type that names the function, and
+//     the CAST is replaced with the function call
+//   - full-text search renders as "field soy_search:<config> :param" and is replaced
+//     with the tsvector and tsquery expression
+//   - trigram similarity renders as "field soy_similarity :param" and is replaced with
+//     the similarity or word_similarity call
+//   - JSON path comparisons render as "(field soy_json_path :key AND field
+//     soy_json_path:<op> :param)" and are replaced with the ->> comparison
+//   - distance thresholds render as "field soy_within:<metric>:<param> :max" and are
+//     replaced with the distance comparison
+type extensionRenderer struct {
+	astql.Renderer
+}
+
+// Stand-in cast types for the array functions.
+const (
+	castArrayLength astql.CastType = "soy_array_length"
+	castUnnest      astql.CastType = "soy_unnest"
+)
+
+// extensionFuncs are the function calls that replace each stand-in cast.
+var extensionFuncs = map[string]string{
+	string(castArrayLength): "array_length(%s, 1)",
+	string(castUnnest):      "unnest(%s)",
+}
+
+var (
+	quantifiedParam = regexp.MustCompile(` (ANY|ALL) (:[A-Za-z_][A-Za-z0-9_]*)`)
+	extensionCast   = regexp.MustCompile(`CAST\(((?:[a-z]\.)?"(?:[^"]|"")*") AS (soy_[a-z_]+)\)`)
+)
+
+// Render renders a query and expands soy's extensions.
+func (r extensionRenderer) Render(ast *astql.AST) (*astql.QueryResult, error) {
+	result, err := r.Renderer.Render(ast)
+	if err != nil {
+		return nil, err
+	}
+	result.SQL = expandExtensions(result.SQL)
+	return result, nil
+}
+
+// RenderCompound renders a compound query and expands soy's extensions.
+func (r extensionRenderer) RenderCompound(query *astql.CompoundQuery) (*astql.QueryResult, error) {
+	result, err := r.Renderer.RenderCompound(query)
+	if err != nil {
+		return nil, err
+	}
+	result.SQL = expandExtensions(result.SQL)
+	return result, nil
+}
+
+// expandExtensions rewrites the stand-ins in rendered SQL.
+func expandExtensions(sql string) string {
+	sql = quantifiedParam.ReplaceAllString(sql, " $1($2)")
+	sql = expandSearch(sql)
+	sql = expandSimilarity(sql)
+	sql = expandJSONPath(sql)
+	sql = expandVectorWithin(sql)
+	return extensionCast.ReplaceAllStringFunc(sql, func(match string) string {
+		parts := extensionCast.FindStringSubmatch(match)
+		format, ok := extensionFuncs[parts[2]]
+		if !ok {
+			return match
+		}
+		return fmt.Sprintf(format, parts[1])
+	})
+}
+
+// selectArrayFuncImpl adds an array function of field AS alias to the SELECT clause.
+func selectArrayFuncImpl(instance *astql.ASTQL, builder *astql.Builder, fn astql.CastType, field, alias string) (*astql.Builder, error) {
+	f, err := instance.TryF(field)
+	if err != nil {
+		return builder, newFieldError(field, err)
+	}
+	if err := validateArrayField(instance, field); err != nil {
+		return builder, err
+	}
+	return builder.SelectExpr(astql.As(astql.Cast(f, fn), alias)), nil
+}
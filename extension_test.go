@@ -0,0 +1,129 @@
+package soy
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/zoobzio/astql/mariadb"
+	"github.com/zoobzio/astql/postgres"
+)
+
+type extensionTestPost struct {
+	ID     int      `db:"id" type:"integer" constraints:"primarykey"`
+	Title  string   `db:"title" type:"text"`
+	Tags   []string `db:"tags"`
+	Scores []int64  `db:"scores"`
+}
+
+// extensionTestNote stores its tags as text so it can be used with MariaDB.
+type extensionTestNote struct {
+	ID   int      `db:"id" type:"integer" constraints:"primarykey"`
+	Tags []string `db:"tags" type:"text"`
+}
+
+func TestExpandExtensions(t *testing.T) {
+	tests := []struct {
+		sql  string
+		want string
+	}{
+		{`"id" = ANY :ids`, `"id" = ANY(:ids)`},
+		{`"title" NOT ILIKE ALL :patterns AND "id" > ANY :floor`, `"title" NOT ILIKE ALL(:patterns) AND "id" > ANY(:floor)`},
+		{`"id" = ANY(:ids)`, `"id" = ANY(:ids)`},
+		{`CAST("tags" AS soy_array_length) AS "n"`, `array_length("tags", 1) AS "n"`},
+		{`CAST(p."tags" AS soy_unnest) AS "tag"`, `unnest(p."tags") AS "tag"`},
+		{`CAST("tags" AS TEXT)`, `CAST("tags" AS TEXT)`},
+		{`CAST("tags" AS soy_unknown)`, `CAST("tags" AS soy_unknown)`},
+	}
+	for _, tt := range tests {
+		if got := expandExtensions(tt.sql); got != tt.want {
+			t.Errorf("expandExtensions(%q) = %q, want %q", tt.sql, got, tt.want)
+		}
+	}
+}
+
+func TestQuantifiedOperators(t *testing.T) {
+	c, err := New[extensionTestPost](&sqlx.DB{}, "posts", postgres.New())
+	if err != nil {
+		t.Fatalf("New() failed: %v", err)
+	}
+	if dialectOf(c.renderer()) != dialectPostgres {
+		t.Errorf("dialectOf(extension renderer) = %s, want postgres", dialectOf(c.renderer()))
+	}
+
+	result, err := c.Query().
+		Where("id", "IN", "ids").
+		Where("title", "ILIKE ANY", "patterns").
+		WhereOr(C("id", ">= ALL", "floors"), C("id", "!= ALL", "excluded")).
+		Render()
+	if err != nil {
+		t.Fatalf("Render() error = %v", err)
+	}
+	for _, want := range []string{
+		`"id" = ANY(:ids)`,
+		`"title" ILIKE ANY(:patterns)`,
+		`"id" >= ALL(:floors)`,
+		`"id" != ALL(:excluded)`,
+	} {
+		if !strings.Contains(result.SQL, want) {
+			t.Errorf("SQL missing %q: %s", want, result.SQL)
+		}
+	}
+
+	update, err := c.Modify().Set("title", "title").Where("id", "= ANY", "ids").Render()
+	if err != nil {
+		t.Fatalf("Modify().Render() error = %v", err)
+	}
+	if !strings.Contains(update.SQL, `"id" = ANY(:ids)`) {
+		t.Errorf("UPDATE SQL missing ANY: %s", update.SQL)
+	}
+
+	m, err := New[extensionTestNote](&sqlx.DB{}, "notes", mariadb.New())
+	if err != nil {
+		t.Fatalf("New() failed: %v", err)
+	}
+	if _, err := m.Query().Where("id", "= ANY", "ids").Render(); !errors.Is(err, ErrInvalidOperator) {
+		t.Errorf("ANY on MariaDB error = %v, want ErrInvalidOperator", err)
+	}
+}
+
+func TestArrayFunctions(t *testing.T) {
+	c, err := New[extensionTestPost](&sqlx.DB{}, "posts", postgres.New())
+	if err != nil {
+		t.Fatalf("New() failed: %v", err)
+	}
+
+	result, err := c.Query().
+		Fields("id").
+		SelectArrayLength("tags", "tag_count").
+		SelectUnnest("scores", "score").
+		Render()
+	if err != nil {
+		t.Fatalf("Render() error = %v", err)
+	}
+	want := `SELECT "id", array_length("tags", 1) AS "tag_count", unnest("scores") AS "score" FROM "posts"`
+	if result.SQL != want {
+		t.Errorf("SQL = %q, want %q", result.SQL, want)
+	}
+
+	single, err := c.Select().SelectUnnest("tags", "tag").Where("id", "=", "id").Render()
+	if err != nil {
+		t.Fatalf("Select().Render() error = %v", err)
+	}
+	if !strings.Contains(single.SQL, `unnest("tags") AS "tag"`) {
+		t.Errorf("SQL missing unnest: %s", single.SQL)
+	}
+
+	if _, err := c.Query().SelectArrayLength("title", "n").Render(); !errors.Is(err, ErrInvalidField) {
+		t.Errorf("array_length on text column error = %v, want ErrInvalidField", err)
+	}
+
+	m, err := New[extensionTestNote](&sqlx.DB{}, "notes", mariadb.New())
+	if err != nil {
+		t.Fatalf("New() failed: %v", err)
+	}
+	if _, err := m.Query().SelectUnnest("tags", "tag").Render(); !errors.Is(err, ErrInvalidField) {
+		t.Errorf("unnest on MariaDB error = %v, want ErrInvalidField", err)
+	}
+}
@@ -0,0 +1,347 @@
+package soy
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"maps"
+	"reflect"
+	"slices"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/zoobzio/sentinel"
+)
+
+// defaultRRFConstant is the rank constant of reciprocal rank fusion.
+const defaultRRFConstant = 60
+
+// defaultHybridCandidates is the number of candidates each search contributes by default.
+const defaultHybridCandidates = 50
+
+// hybridRankAlias is the result column that the text search selects its rank as.
+const hybridRankAlias = "soy_rank"
+
+// HybridScored is a record found by a hybrid search, with its fused score and the scores
+// of the searches that found it.
+type HybridScored[T any] struct {
+	Record   *T
+	Score    float64  // Fused score; higher is better
+	Distance *float64 // Vector distance; nil when only the text search found the record
+	TextRank *float64 // ts_rank; nil when only the vector search found the record
+}
+
+// HybridSearch combines a pgvector nearest-neighbour search and a full-text search over
+// the same table (PostgreSQL). It is created by Query.HybridSearch, and both searches
+// keep the query's conditions.
+//
+// Each search returns its best candidates and the two rankings are fused in Go, matching
+// records by primary key. Reciprocal rank fusion is used unless Weighted is called.
+type HybridSearch[T any] struct {
+	query       *Query[T]
+	k           int
+	candidates  int
+	vectorField string
+	vectorParam string
+	metric      DistanceMetric
+	textField   string
+	textParam   string
+	textConfig  string
+	rrf         int
+	weights     *[2]float64 // Vector and text weights for weighted fusion
+	err         error
+}
+
+// HybridSearch starts a hybrid search that returns the k best records.
+// Vector and Text must both be called before Exec.
+//
+// Example:
+//
+//	results, err := soy.Query().
+//	    Where("tenant_id", "=", "tenant").
+//	    HybridSearch(10).
+//	    Vector("embedding", "query_vec", soy.DistanceCosine).
+//	    Text("body", "query_text", "english").
+//	    Exec(ctx, map[string]any{
+//	        "tenant":     tenantID,
+//	        "query_vec":  soy.Vector(embedding),
+//	        "query_text": question,
+//	    })
+func (qb *Query[T]) HybridSearch(k int) *HybridSearch[T] {
+	hs := &HybridSearch[T]{query: qb.Clone(), k: k, rrf: defaultRRFConstant, err: qb.err}
+	if hs.err != nil {
+		return hs
+	}
+	if k < 1 {
+		hs.err = fmt.Errorf("hybrid search needs k of at least 1, got %d", k)
+		return hs
+	}
+	if len(primaryKeyFields(qb.soy.getMetadata())) == 0 {
+		hs.err = fmt.Errorf("hybrid search requires a primary key to match records")
+	}
+	return hs
+}
+
+// Vector sets the nearest-neighbour search: the distance of field from the vector in param.
+func (hs *HybridSearch[T]) Vector(field, param string, metric DistanceMetric) *HybridSearch[T] {
+	if hs.err != nil {
+		return hs
+	}
+	if nb := hs.query.Nearest(field, param, metric, 1); nb.err != nil {
+		hs.err = nb.err
+		return hs
+	}
+	hs.vectorField, hs.vectorParam, hs.metric = field, param, metric
+	return hs
+}
+
+// Text sets the full-text search: a match of field against the web search query in param.
+// An empty config uses the configuration of a generated tsvector column, or "english".
+func (hs *HybridSearch[T]) Text(field, param, config string) *HybridSearch[T] {
+	if hs.err != nil {
+		return hs
+	}
+	if q := hs.query.Clone().WhereSearch(field, param, config); q.err != nil {
+		hs.err = q.err
+		return hs
+	}
+	hs.textField, hs.textParam, hs.textConfig = field, param, config
+	return hs
+}
+
+// Candidates sets how many of its best records each search contributes, 50 by default.
+// It is raised to k when smaller.
+func (hs *HybridSearch[T]) Candidates(n int) *HybridSearch[T] {
+	if hs.err != nil {
+		return hs
+	}
+	if n < 1 {
+		hs.err = fmt.Errorf("hybrid search needs at least 1 candidate, got %d", n)
+		return hs
+	}
+	hs.candidates = n
+	return hs
+}
+
+// RRF fuses the rankings with reciprocal rank fusion: a record scores 1/(k+rank) for
+// each search that found it, with one-based ranks. The constant k is 60 by default.
+func (hs *HybridSearch[T]) RRF(k int) *HybridSearch[T] {
+	if hs.err != nil {
+		return hs
+	}
+	if k < 0 {
+		hs.err = fmt.Errorf("reciprocal rank fusion constant must not be negative, got %d", k)
+		return hs
+	}
+	hs.rrf, hs.weights = k, nil
+	return hs
+}
+
+// Weighted fuses the rankings with weighted scores. Each search's scores are scaled to
+// [0, 1] over its candidates, nearest or best ranked as 1, and a record scores
+// vectorWeight times its vector score plus textWeight times its text score.
+// A search that did not find a record adds nothing.
+func (hs *HybridSearch[T]) Weighted(vectorWeight, textWeight float64) *HybridSearch[T] {
+	if hs.err != nil {
+		return hs
+	}
+	if vectorWeight < 0 || textWeight < 0 || vectorWeight+textWeight == 0 {
+		hs.err = fmt.Errorf("hybrid search weights must not be negative or both zero, got %g and %g", vectorWeight, textWeight)
+		return hs
+	}
+	hs.weights = &[2]float64{vectorWeight, textWeight}
+	return hs
+}
+
+// Exec runs both searches and returns the k best records by fused score. OnScan runs
+// once for each returned record, after the rankings are fused.
+func (hs *HybridSearch[T]) Exec(ctx context.Context, params map[string]any) ([]HybridScored[T], error) {
+	return hs.exec(ctx, hs.query.soy.execer(), params)
+}
+
+// ExecTx runs both searches within a transaction.
+func (hs *HybridSearch[T]) ExecTx(ctx context.Context, tx *sqlx.Tx, params map[string]any) ([]HybridScored[T], error) {
+	return hs.exec(ctx, tx, params)
+}
+
+// exec is the internal execution method used by both Exec and ExecTx.
+func (hs *HybridSearch[T]) exec(ctx context.Context, execer sqlx.ExtContext, params map[string]any) ([]HybridScored[T], error) {
+	params = hs.query.soy.bindParams(params)
+	if hs.err != nil {
+		return nil, fmt.Errorf("hybrid search has errors: %w", hs.err)
+	}
+	if hs.vectorField == "" || hs.textField == "" {
+		return nil, fmt.Errorf("hybrid search requires both Vector and Text")
+	}
+
+	candidates := hs.candidates
+	if candidates == 0 {
+		candidates = defaultHybridCandidates
+	}
+	candidates = max(candidates, hs.k)
+
+	// Each search only sees its own query param, so strict params do not reject the other's
+	vectorParams := maps.Clone(params)
+	delete(vectorParams, hs.textParam)
+	// OnScan runs once per merged record below, not for each search's hits
+	nearest := hs.query.Nearest(hs.vectorField, hs.vectorParam, hs.metric, candidates)
+	nearest.noScan = true
+	vector, err := nearest.exec(ctx, execer, vectorParams)
+	if err != nil {
+		return nil, fmt.Errorf("hybrid vector search failed: %w", err)
+	}
+
+	textParams := maps.Clone(params)
+	delete(textParams, hs.vectorParam)
+	text, err := hs.textSearch(ctx, execer, candidates, textParams)
+	if err != nil {
+		return nil, fmt.Errorf("hybrid text search failed: %w", err)
+	}
+
+	key := recordKey[T](primaryKeyFields(hs.query.soy.getMetadata()))
+	var fused []HybridScored[T]
+	if hs.weights != nil {
+		fused = fuseWeighted(vector, text, key, hs.weights[0], hs.weights[1])
+	} else {
+		fused = fuseRRF(vector, text, key, hs.rrf)
+	}
+	if len(fused) > hs.k {
+		fused = fused[:hs.k]
+	}
+	for _, r := range fused {
+		if err := hs.query.soy.callOnScan(ctx, r.Record); err != nil {
+			return nil, fmt.Errorf("onScan callback failed: %w", err)
+		}
+	}
+	return fused, nil
+}
+
+// textSearch runs the full-text search for the best candidates by ts_rank.
+func (hs *HybridSearch[T]) textSearch(ctx context.Context, execer sqlx.ExtContext, candidates int, params map[string]any) ([]Scored[T], error) {
+	q := hs.query.Clone().
+		WhereSearch(hs.textField, hs.textParam, hs.textConfig).
+		SelectSearchRank(hs.textField, hs.textParam, hybridRankAlias).
+		OrderBySearchRank(hs.textField, hs.textParam, "desc").
+		Limit(candidates)
+	if q.err != nil {
+		return nil, q.err
+	}
+	q.builder = orderByFirst(q.builder)
+
+	result, err := q.renderFor(params)
+	if err != nil {
+		return nil, err
+	}
+	return execScoredRows[T](ctx, execer, q.soy.statements(), dialectOf(q.soy.renderer()), result.SQL, params, q.soy.getTableName(), "QUERY_TEXT_SEARCH", hybridRankAlias, nil)
+}
+
+// hybridEntry accumulates the fused score of one record.
+type hybridEntry[T any] struct {
+	result HybridScored[T]
+	order  int // Order of first appearance, vector results first, to break ties
+}
+
+// fuseRRF fuses two rankings with reciprocal rank fusion.
+func fuseRRF[T any](vector, text []Scored[T], key func(*T) string, k int) []HybridScored[T] {
+	return fuse(vector, text, key,
+		func(rank int, _ float64) float64 { return 1 / float64(k+rank+1) },
+		func(rank int, _ float64) float64 { return 1 / float64(k+rank+1) },
+	)
+}
+
+// fuseWeighted fuses two rankings with min-max scaled, weighted scores. Smaller
+// distances and larger text ranks score higher.
+func fuseWeighted[T any](vector, text []Scored[T], key func(*T) string, vectorWeight, textWeight float64) []HybridScored[T] {
+	vMin, vMax := scoreRange(vector)
+	tMin, tMax := scoreRange(text)
+	return fuse(vector, text, key,
+		func(_ int, distance float64) float64 { return vectorWeight * scaled(vMax-distance, vMax-vMin) },
+		func(_ int, rank float64) float64 { return textWeight * scaled(rank-tMin, tMax-tMin) },
+	)
+}
+
+// fuse merges two rankings by record key, summing the score each contributes, and
+// returns the records by descending fused score.
+func fuse[T any](vector, text []Scored[T], key func(*T) string, vectorScore, textScore func(rank int, score float64) float64) []HybridScored[T] {
+	entries := make(map[string]*hybridEntry[T], len(vector)+len(text))
+	add := func(r Scored[T]) *hybridEntry[T] {
+		k := key(r.Record)
+		entry, ok := entries[k]
+		if !ok {
+			entry = &hybridEntry[T]{result: HybridScored[T]{Record: r.Record}, order: len(entries)}
+			entries[k] = entry
+		}
+		return entry
+	}
+	for rank, r := range vector {
+		entry := add(r)
+		distance := r.Distance
+		entry.result.Distance = &distance
+		entry.result.Score += vectorScore(rank, distance)
+	}
+	for rank, r := range text {
+		entry := add(r)
+		textRank := r.Distance
+		entry.result.TextRank = &textRank
+		entry.result.Score += textScore(rank, textRank)
+	}
+
+	sorted := slices.SortedFunc(maps.Values(entries), func(a, b *hybridEntry[T]) int {
+		if c := cmp.Compare(b.result.Score, a.result.Score); c != 0 {
+			return c
+		}
+		return cmp.Compare(a.order, b.order)
+	})
+	fused := make([]HybridScored[T], len(sorted))
+	for i, entry := range sorted {
+		fused[i] = entry.result
+	}
+	return fused
+}
+
+// scoreRange returns the smallest and largest scores of results.
+func scoreRange[T any](results []Scored[T]) (lo, hi float64) {
+	for i, r := range results {
+		if i == 0 || r.Distance < lo {
+			lo = r.Distance
+		}
+		if i == 0 || r.Distance > hi {
+			hi = r.Distance
+		}
+	}
+	return lo, hi
+}
+
+// scaled divides a score offset by the width of its range. When all scores are equal
+// every result scores 1.
+func scaled(offset, width float64) float64 {
+	if width == 0 {
+		return 1
+	}
+	return offset / width
+}
+
+// primaryKeyFields returns the fields of the primary key columns of metadata.
+func primaryKeyFields(metadata sentinel.Metadata) []sentinel.FieldMetadata {
+	var fields []sentinel.FieldMetadata
+	for _, field := range metadata.Fields {
+		if dbCol := field.Tags["db"]; dbCol == "" || dbCol == "-" {
+			continue
+		}
+		if _, _, primaryKey := parseConstraintsTag(field.Tags["constraints"]); primaryKey {
+			fields = append(fields, field)
+		}
+	}
+	return fields
+}
+
+// recordKey returns a function that identifies a record by its primary key values.
+func recordKey[T any](fields []sentinel.FieldMetadata) func(*T) string {
+	return func(record *T) string {
+		v := reflect.ValueOf(record).Elem()
+		values := make([]any, len(fields))
+		for i, field := range fields {
+			values[i] = v.FieldByIndex(field.Index).Interface()
+		}
+		return fmt.Sprintf("%#v", values)
+	}
+}
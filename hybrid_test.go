@@ -0,0 +1,172 @@
+package soy
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/zoobzio/astql/postgres"
+)
+
+type hybridTestDoc struct {
+	ID        int    `db:"id" type:"serial" constraints:"primarykey"`
+	Body      string `db:"body" type:"text"`
+	Embedding Vector `db:"embedding" type:"vector(2)"`
+}
+
+func hybridTestSoy(t *testing.T) (*Soy[hybridTestDoc], *nearestDriver) {
+	t.Helper()
+	drv := &nearestDriver{respond: func(query string) ([]string, [][]driver.Value) {
+		if strings.Contains(query, "soy_rank") {
+			return []string{"id", "body", "soy_rank"}, [][]driver.Value{
+				{int64(3), "c", 0.9}, {int64(4), "d", 0.6}, {int64(1), "a", 0.2},
+			}
+		}
+		return []string{"id", "body", "soy_distance"}, [][]driver.Value{
+			{int64(1), "a", 0.1}, {int64(2), "b", 0.3}, {int64(3), "c", 0.5},
+		}
+	}}
+	name := fmt.Sprintf("soy_hybrid_%d", verifyDriverSeq.Add(1))
+	sql.Register(name, drv)
+	db, err := sqlx.Open(name, "")
+	if err != nil {
+		t.Fatalf("sqlx.Open() failed: %v", err)
+	}
+	t.Cleanup(func() { _ = db.Close() })
+
+	s, err := New[hybridTestDoc](db, "docs", postgres.New())
+	if err != nil {
+		t.Fatalf("New() failed: %v", err)
+	}
+	s.StrictParams(true)
+	return s, drv
+}
+
+func hybridIDs(results []HybridScored[hybridTestDoc]) []int {
+	ids := make([]int, len(results))
+	for i, r := range results {
+		ids[i] = r.Record.ID
+	}
+	return ids
+}
+
+func TestHybridSearch_RRF(t *testing.T) {
+	s, drv := hybridTestSoy(t)
+	params := map[string]any{"q_vec": Vector{1, 0}, "q_text": "alpha"}
+
+	results, err := s.Query().
+		HybridSearch(3).
+		Vector("embedding", "q_vec", DistanceCosine).
+		Text("body", "q_text", "english").
+		Candidates(10).
+		Exec(t.Context(), params)
+	if err != nil {
+		t.Fatalf("Exec() error = %v", err)
+	}
+
+	// 1 and 3 are found by both searches and tie; 2 beats 4 by appearing first
+	if got := fmt.Sprint(hybridIDs(results)); got != "[1 3 2]" {
+		t.Errorf("ids = %s, want [1 3 2]", got)
+	}
+	first := results[0]
+	if first.Distance == nil || *first.Distance != 0.1 || first.TextRank == nil || *first.TextRank != 0.2 {
+		t.Errorf("component scores = %v, %v", first.Distance, first.TextRank)
+	}
+	if want := 1.0/61 + 1.0/63; first.Score != want {
+		t.Errorf("Score = %v, want %v", first.Score, want)
+	}
+	if results[2].TextRank != nil {
+		t.Errorf("record 2 was only found by the vector search, TextRank = %v", *results[2].TextRank)
+	}
+	if len(drv.log) != 2 {
+		t.Errorf("statements = %q, want one query per search", drv.log)
+	}
+}
+
+func TestHybridSearch_Weighted(t *testing.T) {
+	s, _ := hybridTestSoy(t)
+	results, err := s.Query().
+		HybridSearch(3).
+		Vector("embedding", "q_vec", DistanceCosine).
+		Text("body", "q_text", "").
+		Weighted(1, 1).
+		Exec(t.Context(), map[string]any{"q_vec": Vector{1, 0}, "q_text": "alpha"})
+	if err != nil {
+		t.Fatalf("Exec() error = %v", err)
+	}
+	if got := fmt.Sprint(hybridIDs(results)); got != "[1 3 4]" {
+		t.Errorf("ids = %s, want [1 3 4]", got)
+	}
+	if results[0].Score != 1 || results[2].Score != (0.6-0.2)/(0.9-0.2) {
+		t.Errorf("scores = %v, %v", results[0].Score, results[2].Score)
+	}
+}
+
+func TestHybridSearch_OnScan(t *testing.T) {
+	s, _ := hybridTestSoy(t)
+	scanned := make(map[int]int)
+	s.OnScan(func(_ context.Context, doc *hybridTestDoc) error {
+		scanned[doc.ID]++
+		return nil
+	})
+
+	results, err := s.Query().
+		HybridSearch(3).
+		Vector("embedding", "q_vec", DistanceCosine).
+		Text("body", "q_text", "").
+		Exec(t.Context(), map[string]any{"q_vec": Vector{1, 0}, "q_text": "alpha"})
+	if err != nil {
+		t.Fatalf("Exec() error = %v", err)
+	}
+	// 1 and 3 are found by both searches; 4 is found but not returned
+	if len(scanned) != len(results) {
+		t.Errorf("OnScan ran for %v, want the %d returned records", scanned, len(results))
+	}
+	for _, r := range results {
+		if scanned[r.Record.ID] != 1 {
+			t.Errorf("OnScan ran %d times for record %d, want once", scanned[r.Record.ID], r.Record.ID)
+		}
+	}
+
+	s.OnScan(func(context.Context, *hybridTestDoc) error { return errors.New("denied") })
+	if _, err := s.Query().
+		HybridSearch(3).
+		Vector("embedding", "q_vec", DistanceCosine).
+		Text("body", "q_text", "").
+		Exec(t.Context(), map[string]any{"q_vec": Vector{1, 0}, "q_text": "alpha"}); err == nil || !strings.Contains(err.Error(), "denied") {
+		t.Errorf("Exec() error = %v, want the OnScan error", err)
+	}
+}
+
+func TestHybridSearch_Invalid(t *testing.T) {
+	s, _ := hybridTestSoy(t)
+	ctx := t.Context()
+	params := map[string]any{"q_vec": Vector{1, 0}, "q_text": "alpha"}
+
+	if _, err := s.Query().HybridSearch(3).Vector("embedding", "q_vec", DistanceCosine).Exec(ctx, params); err == nil || !strings.Contains(err.Error(), "both Vector and Text") {
+		t.Errorf("expected error without Text, got %v", err)
+	}
+	if _, err := s.Query().HybridSearch(3).Vector("body", "q_vec", "~").Exec(ctx, params); err == nil {
+		t.Error("expected error for invalid metric")
+	}
+	if _, err := s.Query().HybridSearch(3).Weighted(0, 0).Exec(ctx, params); err == nil {
+		t.Error("expected error for zero weights")
+	}
+
+	type noKey struct {
+		Body      string `db:"body"`
+		Embedding Vector `db:"embedding"`
+	}
+	plain, err := New[noKey](nil, "docs", postgres.New())
+	if err != nil {
+		t.Fatalf("New() failed: %v", err)
+	}
+	if _, err := plain.Query().HybridSearch(3).Exec(ctx, params); err == nil || !strings.Contains(err.Error(), "primary key") {
+		t.Errorf("expected primary key error, got %v", err)
+	}
+}
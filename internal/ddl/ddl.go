@@ -0,0 +1,439 @@
+// Package ddl renders schema DDL from DBML tables for the SQL dialects soy supports.
+// It is shared by soy's CreateTableSQL and the migrate package's scaffolding so both
+// emit identical column and index definitions.
+package ddl
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/zoobzio/astql"
+	"github.com/zoobzio/astql/mariadb"
+	"github.com/zoobzio/astql/mssql"
+	"github.com/zoobzio/astql/postgres"
+	"github.com/zoobzio/astql/sqlite"
+	"github.com/zoobzio/dbml"
+)
+
+// Dialect identifies the SQL dialect targeted by a renderer.
+type Dialect string
+
+// Supported dialects.
+const (
+	Postgres Dialect = "postgres"
+	MariaDB  Dialect = "mariadb"
+	SQLite   Dialect = "sqlite"
+	MSSQL    Dialect = "mssql"
+)
+
+// Of returns the dialect for a renderer.
+// Unknown renderers are treated as PostgreSQL, which is ASTQL's reference dialect.
+func Of(renderer astql.Renderer) Dialect {
+	switch renderer.(type) {
+	case *mariadb.Renderer:
+		return MariaDB
+	case *sqlite.Renderer:
+		return SQLite
+	case *mssql.Renderer:
+		return MSSQL
+	case *postgres.Renderer:
+		return Postgres
+	default:
+		return Postgres
+	}
+}
+
+// Quote quotes an identifier using the dialect's quoting rules.
+// Embedded quote characters are escaped by doubling, matching the ASTQL renderers.
+func (d Dialect) Quote(name string) string {
+	switch d {
+	case MariaDB:
+		return "`" + strings.ReplaceAll(name, "`", "``") + "`"
+	case MSSQL:
+		return "[" + strings.ReplaceAll(name, "]", "]]") + "]"
+	default:
+		return `"` + strings.ReplaceAll(name, `"`, `""`) + `"`
+	}
+}
+
+// QuoteAll quotes each identifier and joins them with commas.
+func (d Dialect) QuoteAll(names []string) string {
+	quoted := make([]string, len(names))
+	for i, name := range names {
+		quoted[i] = d.Quote(name)
+	}
+	return strings.Join(quoted, ", ")
+}
+
+// CreateTable renders the CREATE TABLE statement and any column comment statements.
+// Foreign keys are rendered for each ref whose left endpoint is the table.
+// Statements after the CREATE TABLE are separated and terminated by semicolons.
+func (d Dialect) CreateTable(table *dbml.Table, refs []*dbml.Ref) string {
+	var (
+		defs       []string
+		primaryKey []string
+		foreign    []string
+		comments   []string
+	)
+
+	for _, col := range table.Columns {
+		defs = append(defs, d.ColumnDefinition(col))
+		if col.Settings.PrimaryKey {
+			primaryKey = append(primaryKey, col.Name)
+		}
+		if col.Note != nil {
+			if stmt := d.ColumnComment(table.Name, col.Name, *col.Note); stmt != "" {
+				comments = append(comments, stmt)
+			}
+		}
+	}
+
+	if len(primaryKey) > 0 {
+		defs = append(defs, fmt.Sprintf("PRIMARY KEY (%s)", d.QuoteAll(primaryKey)))
+	}
+	for _, ref := range refs {
+		if ref.Left != nil && ref.Left.Table == table.Name {
+			foreign = append(foreign, fmt.Sprintf("FOREIGN KEY (%s) %s", d.QuoteAll(ref.Left.Columns), d.References(ref)))
+		}
+	}
+	defs = append(defs, foreign...)
+
+	var sb strings.Builder
+	sb.WriteString("CREATE TABLE ")
+	sb.WriteString(d.Quote(table.Name))
+	sb.WriteString(" (\n    ")
+	sb.WriteString(strings.Join(defs, ",\n    "))
+	sb.WriteString("\n)")
+	if len(comments) == 0 {
+		return sb.String()
+	}
+
+	sb.WriteString(";\n")
+	for _, stmt := range comments {
+		sb.WriteString(stmt)
+		sb.WriteString(";\n")
+	}
+	return sb.String()
+}
+
+// ColumnDefinition renders a single column as used in CREATE TABLE and ADD COLUMN.
+func (d Dialect) ColumnDefinition(col *dbml.Column) string {
+	parts := []string{d.Quote(col.Name), d.ColumnType(col.Type)}
+
+	if !col.Settings.Null || col.Settings.PrimaryKey {
+		parts = append(parts, "NOT NULL")
+	}
+	if col.Settings.Default != nil {
+		parts = append(parts, "DEFAULT "+*col.Settings.Default)
+	}
+	if col.Settings.Unique && !col.Settings.PrimaryKey {
+		parts = append(parts, "UNIQUE")
+	}
+	if col.Settings.Check != nil {
+		parts = append(parts, "CHECK ("+*col.Settings.Check+")")
+	}
+	if col.Note != nil {
+		switch d {
+		case MariaDB:
+			parts = append(parts, "COMMENT "+SQLString(*col.Note))
+		case SQLite:
+			// SQLite has no column comments, but keeps comments in the stored schema.
+			parts = append(parts, "/* "+strings.ReplaceAll(*col.Note, "*/", "* /")+" */")
+		}
+	}
+
+	return strings.Join(parts, " ")
+}
+
+// ColumnType maps serial types to the dialect's auto-increment column.
+// Every other type is emitted unchanged.
+func (d Dialect) ColumnType(sqlType string) string {
+	var base string
+	switch strings.ToLower(sqlType) {
+	case "serial", "serial4":
+		base = "INTEGER"
+	case "bigserial", "serial8":
+		base = "BIGINT"
+	case "smallserial", "serial2":
+		base = "SMALLINT"
+	default:
+		return sqlType
+	}
+
+	switch d {
+	case MariaDB:
+		return base + " AUTO_INCREMENT"
+	case MSSQL:
+		return base + " IDENTITY(1,1)"
+	case SQLite:
+		// INTEGER PRIMARY KEY columns alias the rowid and are assigned automatically.
+		return "INTEGER"
+	default:
+		return sqlType
+	}
+}
+
+// ColumnComment renders a standalone statement that sets a column comment.
+// Dialects with inline comments return an empty string.
+func (d Dialect) ColumnComment(table, column, note string) string {
+	switch d {
+	case Postgres:
+		return fmt.Sprintf("COMMENT ON COLUMN %s.%s IS %s", d.Quote(table), d.Quote(column), SQLString(note))
+	case MSSQL:
+		return fmt.Sprintf("EXEC sp_addextendedproperty @name = N'MS_Description', @value = N%s, "+
+			"@level0type = N'SCHEMA', @level0name = N'dbo', @level1type = N'TABLE', @level1name = N%s, "+
+			"@level2type = N'COLUMN', @level2name = N%s", SQLString(note), SQLString(table), SQLString(column))
+	default:
+		return ""
+	}
+}
+
+// IndexOptions are the settings of an index that DBML cannot express. They are kept
+// beside the DBML table, keyed by index name (see IndexName).
+type IndexOptions struct {
+	Where     string            // Partial index predicate
+	OpClasses map[string]string // Operator class by column name, e.g. gin_trgm_ops
+}
+
+// CreateIndexes renders a CREATE INDEX statement for each DBML index, with the options
+// of each index looked up by its name.
+func (d Dialect) CreateIndexes(table *dbml.Table, options map[string]IndexOptions) ([]string, error) {
+	stmts := make([]string, 0, len(table.Indexes))
+	for _, idx := range table.Indexes {
+		stmt, err := d.CreateIndex(table.Name, idx, options[IndexName(table.Name, idx)])
+		if err != nil {
+			return nil, err
+		}
+		if stmt != "" {
+			stmts = append(stmts, stmt)
+		}
+	}
+	return stmts, nil
+}
+
+// CreateIndex renders a CREATE INDEX statement. Expression indexes are emitted as written.
+// Primary key indexes and indexes without columns render as an empty string.
+// MariaDB has no partial indexes, so a partial index is an error there.
+func (d Dialect) CreateIndex(table string, idx *dbml.Index, options IndexOptions) (string, error) {
+	if idx.PrimaryKey {
+		return "", nil
+	}
+
+	columns := make([]string, 0, len(idx.Columns))
+	for _, ic := range idx.Columns {
+		switch {
+		case ic.Name != nil:
+			column := d.Quote(*ic.Name)
+			// Operator classes are PostgreSQL only, like index methods.
+			if opClass := options.OpClasses[*ic.Name]; opClass != "" && d == Postgres {
+				column += " " + opClass
+			}
+			columns = append(columns, column)
+		case ic.Expression != nil:
+			columns = append(columns, "("+*ic.Expression+")")
+		}
+	}
+	if len(columns) == 0 {
+		return "", nil
+	}
+
+	where := options.Where
+	if where != "" && d == MariaDB {
+		return "", fmt.Errorf("MariaDB does not support partial index %s", IndexName(table, idx))
+	}
+
+	var sb strings.Builder
+	sb.WriteString("CREATE ")
+	if idx.Unique {
+		sb.WriteString("UNIQUE ")
+	}
+	sb.WriteString("INDEX ")
+	sb.WriteString(d.Quote(IndexName(table, idx)))
+	sb.WriteString(" ON ")
+	sb.WriteString(d.Quote(table))
+	// Only PostgreSQL chooses the access method per index with USING.
+	if idx.Type != nil && *idx.Type != "" && d == Postgres {
+		sb.WriteString(" USING ")
+		sb.WriteString(*idx.Type)
+	}
+	sb.WriteString(" (")
+	sb.WriteString(strings.Join(columns, ", "))
+	sb.WriteString(")")
+	if where != "" {
+		sb.WriteString(" WHERE ")
+		sb.WriteString(where)
+	}
+	return sb.String(), nil
+}
+
+// IndexName returns the index's name, or idx_<table>_<columns> for unnamed indexes.
+func IndexName(table string, idx *dbml.Index) string {
+	if idx.Name != nil && *idx.Name != "" {
+		return *idx.Name
+	}
+	names := make([]string, 0, len(idx.Columns))
+	for _, ic := range idx.Columns {
+		if ic.Name != nil {
+			names = append(names, *ic.Name)
+		}
+	}
+	return fmt.Sprintf("idx_%s_%s", table, strings.Join(names, "_"))
+}
+
+// DropTable renders a DROP TABLE statement.
+func (d Dialect) DropTable(table string) string {
+	return "DROP TABLE " + d.Quote(table)
+}
+
+// CreateEnum renders a PostgreSQL CREATE TYPE ... AS ENUM statement.
+func (d Dialect) CreateEnum(enum *dbml.Enum) string {
+	values := make([]string, len(enum.Values))
+	for i, value := range enum.Values {
+		values[i] = SQLString(value)
+	}
+	return fmt.Sprintf("CREATE TYPE %s AS ENUM (%s)", d.Quote(enum.Name), strings.Join(values, ", "))
+}
+
+// AddEnumValue renders a PostgreSQL ALTER TYPE ... ADD VALUE statement.
+func (d Dialect) AddEnumValue(name, value string) string {
+	return fmt.Sprintf("ALTER TYPE %s ADD VALUE %s", d.Quote(name), SQLString(value))
+}
+
+// DropEnum renders a PostgreSQL DROP TYPE statement.
+func (d Dialect) DropEnum(name string) string {
+	return "DROP TYPE " + d.Quote(name)
+}
+
+// TableEnums returns the enums of project used as column types of table, in column order.
+func TableEnums(project *dbml.Project, table *dbml.Table) []*dbml.Enum {
+	var enums []*dbml.Enum
+	for _, col := range table.Columns {
+		if enum, ok := project.Enums[table.Schema+"."+col.Type]; ok {
+			enums = append(enums, enum)
+		}
+	}
+	return enums
+}
+
+// References renders the REFERENCES clause of a foreign key, including its actions.
+func (d Dialect) References(ref *dbml.Ref) string {
+	stmt := fmt.Sprintf("REFERENCES %s (%s)", d.Quote(ref.Right.Table), d.QuoteAll(ref.Right.Columns))
+	if ref.OnDelete != nil {
+		stmt += " ON DELETE " + d.refAction(*ref.OnDelete)
+	}
+	if ref.OnUpdate != nil {
+		stmt += " ON UPDATE " + d.refAction(*ref.OnUpdate)
+	}
+	return stmt
+}
+
+// refAction renders a referential action. SQL Server has no RESTRICT; NO ACTION
+// is its equivalent since it does not defer constraint checks.
+func (d Dialect) refAction(action dbml.RefAction) string {
+	if d == MSSQL && action == dbml.Restrict {
+		return "NO ACTION"
+	}
+	return strings.ToUpper(string(action))
+}
+
+// ColumnRef returns the single-column ref whose left endpoint is table.column, or nil.
+func ColumnRef(refs []*dbml.Ref, table, column string) *dbml.Ref {
+	for _, ref := range refs {
+		if ref.Left != nil && ref.Right != nil && ref.Left.Table == table &&
+			len(ref.Left.Columns) == 1 && ref.Left.Columns[0] == column {
+			return ref
+		}
+	}
+	return nil
+}
+
+// AddColumn renders an ALTER TABLE statement that adds col, including its foreign key
+// when refs has one for the column.
+func (d Dialect) AddColumn(table string, col *dbml.Column, refs []*dbml.Ref) string {
+	stmt := fmt.Sprintf("ALTER TABLE %s ADD %s", d.Quote(table), d.ColumnDefinition(col))
+	if ref := ColumnRef(refs, table, col.Name); ref != nil {
+		stmt += " " + d.References(ref)
+	}
+	return stmt
+}
+
+// DropColumn renders an ALTER TABLE statement that drops column.
+func (d Dialect) DropColumn(table, column string) string {
+	return fmt.Sprintf("ALTER TABLE %s DROP COLUMN %s", d.Quote(table), d.Quote(column))
+}
+
+// DropIndex renders a DROP INDEX statement.
+func (d Dialect) DropIndex(table, name string) string {
+	switch d {
+	case MariaDB, MSSQL:
+		return fmt.Sprintf("DROP INDEX %s ON %s", d.Quote(name), d.Quote(table))
+	default:
+		return "DROP INDEX " + d.Quote(name)
+	}
+}
+
+// AlterColumn renders the statements that change column from to the definition of to.
+// Type, nullability and default changes are supported where the dialect can alter them
+// in place. Other changes return an error: SQL Server defaults are named constraints,
+// and SQLite cannot alter a column without rebuilding the table.
+func (d Dialect) AlterColumn(table string, from, to *dbml.Column) ([]string, error) {
+	typeChanged := !strings.EqualFold(from.Type, to.Type)
+	nullChanged := from.Settings.Null != to.Settings.Null
+	defaultChanged := stringValue(from.Settings.Default) != stringValue(to.Settings.Default)
+	if !typeChanged && !nullChanged && !defaultChanged {
+		return nil, nil
+	}
+
+	qt, qc := d.Quote(table), d.Quote(to.Name)
+	switch d {
+	case Postgres:
+		var stmts []string
+		if typeChanged {
+			stmts = append(stmts, fmt.Sprintf("ALTER TABLE %s ALTER COLUMN %s TYPE %s", qt, qc, to.Type))
+		}
+		if nullChanged {
+			action := "SET NOT NULL"
+			if to.Settings.Null {
+				action = "DROP NOT NULL"
+			}
+			stmts = append(stmts, fmt.Sprintf("ALTER TABLE %s ALTER COLUMN %s %s", qt, qc, action))
+		}
+		if defaultChanged {
+			action := "DROP DEFAULT"
+			if to.Settings.Default != nil {
+				action = "SET DEFAULT " + *to.Settings.Default
+			}
+			stmts = append(stmts, fmt.Sprintf("ALTER TABLE %s ALTER COLUMN %s %s", qt, qc, action))
+		}
+		return stmts, nil
+	case MariaDB:
+		return []string{fmt.Sprintf("ALTER TABLE %s MODIFY COLUMN %s", qt, d.ColumnDefinition(to))}, nil
+	case MSSQL:
+		if defaultChanged {
+			return nil, fmt.Errorf("cannot change the default of %s.%s: SQL Server defaults are named constraints", table, to.Name)
+		}
+		var stmts []string
+		if typeChanged || nullChanged {
+			null := "NOT NULL"
+			if to.Settings.Null {
+				null = "NULL"
+			}
+			stmts = append(stmts, fmt.Sprintf("ALTER TABLE %s ALTER COLUMN %s %s %s", qt, qc, d.ColumnType(to.Type), null))
+		}
+		return stmts, nil
+	default:
+		return nil, fmt.Errorf("cannot alter %s.%s: SQLite cannot alter columns without rebuilding the table", table, to.Name)
+	}
+}
+
+// SQLString renders s as a single-quoted SQL string literal.
+func SQLString(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", "''") + "'"
+}
+
+func stringValue(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
@@ -0,0 +1,174 @@
+package ddl
+
+import (
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/zoobzio/dbml"
+)
+
+func TestQuote(t *testing.T) {
+	tests := map[Dialect]string{
+		Postgres: `"a""b"`,
+		SQLite:   `"a""b"`,
+		MariaDB:  "`a\"b`",
+		MSSQL:    `[a"b]`,
+	}
+	for d, want := range tests {
+		if got := d.Quote(`a"b`); got != want {
+			t.Errorf("%s.Quote() = %s, want %s", d, got, want)
+		}
+	}
+}
+
+func TestAddColumn(t *testing.T) {
+	col := dbml.NewColumn("org_id", "integer")
+	refs := []*dbml.Ref{
+		dbml.NewRef(dbml.ManyToOne).From("public", "users", "org_id").To("public", "orgs", "id").WithOnDelete(dbml.Cascade),
+	}
+	got := Postgres.AddColumn("users", col, refs)
+	want := `ALTER TABLE "users" ADD "org_id" integer NOT NULL REFERENCES "orgs" ("id") ON DELETE CASCADE`
+	if got != want {
+		t.Errorf("AddColumn() = %s, want %s", got, want)
+	}
+	if got := Postgres.AddColumn("teams", col, refs); got != `ALTER TABLE "teams" ADD "org_id" integer NOT NULL` {
+		t.Errorf("AddColumn() on another table = %s", got)
+	}
+}
+
+func TestReferences(t *testing.T) {
+	ref := dbml.NewRef(dbml.ManyToOne).From("public", "posts", "author_id").To("public", "users", "id").
+		WithOnDelete(dbml.SetNull).WithOnUpdate(dbml.Restrict)
+
+	tests := map[Dialect]string{
+		Postgres: `REFERENCES "users" ("id") ON DELETE SET NULL ON UPDATE RESTRICT`,
+		MariaDB:  "REFERENCES `users` (`id`) ON DELETE SET NULL ON UPDATE RESTRICT",
+		MSSQL:    "REFERENCES [users] ([id]) ON DELETE SET NULL ON UPDATE NO ACTION",
+	}
+	for d, want := range tests {
+		if got := d.References(ref); got != want {
+			t.Errorf("%s.References() = %s, want %s", d, got, want)
+		}
+	}
+}
+
+func TestEnums(t *testing.T) {
+	enum := dbml.NewEnum("posts_status", "draft", "it's").WithSchema("public")
+	if got := Postgres.CreateEnum(enum); got != `CREATE TYPE "posts_status" AS ENUM ('draft', 'it''s')` {
+		t.Errorf("CreateEnum() = %s", got)
+	}
+	if got := Postgres.AddEnumValue("posts_status", "archived"); got != `ALTER TYPE "posts_status" ADD VALUE 'archived'` {
+		t.Errorf("AddEnumValue() = %s", got)
+	}
+	if got := Postgres.DropEnum("posts_status"); got != `DROP TYPE "posts_status"` {
+		t.Errorf("DropEnum() = %s", got)
+	}
+
+	project := dbml.NewProject("p").AddEnum(enum)
+	table := dbml.NewTable("posts").WithSchema("public").
+		AddColumn(dbml.NewColumn("id", "integer")).
+		AddColumn(dbml.NewColumn("status", "posts_status"))
+	if got := TableEnums(project, table); len(got) != 1 || got[0] != enum {
+		t.Errorf("TableEnums() = %v", got)
+	}
+}
+
+func TestDropIndex(t *testing.T) {
+	if got := Postgres.DropIndex("users", "idx"); got != `DROP INDEX "idx"` {
+		t.Errorf("Postgres DropIndex() = %s", got)
+	}
+	if got := MariaDB.DropIndex("users", "idx"); got != "DROP INDEX `idx` ON `users`" {
+		t.Errorf("MariaDB DropIndex() = %s", got)
+	}
+}
+
+func TestAlterColumn(t *testing.T) {
+	from := dbml.NewColumn("age", "integer").WithNull()
+	to := dbml.NewColumn("age", "bigint").WithDefault("0")
+
+	tests := []struct {
+		dialect Dialect
+		want    []string
+	}{
+		{Postgres, []string{
+			`ALTER TABLE "users" ALTER COLUMN "age" TYPE bigint`,
+			`ALTER TABLE "users" ALTER COLUMN "age" SET NOT NULL`,
+			`ALTER TABLE "users" ALTER COLUMN "age" SET DEFAULT 0`,
+		}},
+		{MariaDB, []string{"ALTER TABLE `users` MODIFY COLUMN `age` bigint NOT NULL DEFAULT 0"}},
+		{MSSQL, []string{"ALTER TABLE [users] ALTER COLUMN [age] bigint NOT NULL"}},
+	}
+	for _, tt := range tests {
+		want := to
+		if tt.dialect == MSSQL {
+			want = dbml.NewColumn("age", "bigint")
+		}
+		if got, err := tt.dialect.AlterColumn("users", from, want); err != nil || !slices.Equal(got, tt.want) {
+			t.Errorf("%s AlterColumn() = %q, %v, want %q", tt.dialect, got, err, tt.want)
+		}
+	}
+
+	if _, err := MSSQL.AlterColumn("users", from, to); err == nil || !strings.Contains(err.Error(), "default of users.age") {
+		t.Errorf("MSSQL AlterColumn() of a default error = %v", err)
+	}
+	if _, err := SQLite.AlterColumn("users", from, to); err == nil || !strings.Contains(err.Error(), "cannot alter users.age") {
+		t.Errorf("SQLite AlterColumn() error = %v", err)
+	}
+	if got, err := SQLite.AlterColumn("users", from, from); got != nil || err != nil {
+		t.Errorf("AlterColumn() on unchanged column = %q, %v, want nil", got, err)
+	}
+}
+
+func TestCreateIndex(t *testing.T) {
+	idx := dbml.NewIndex("org_id", "email").WithName("uq_members").WithUnique().WithType("btree")
+	partial := IndexOptions{Where: "deleted_at IS NULL"}
+
+	tests := map[Dialect]string{
+		Postgres: `CREATE UNIQUE INDEX "uq_members" ON "members" USING btree ("org_id", "email") WHERE deleted_at IS NULL`,
+		SQLite:   `CREATE UNIQUE INDEX "uq_members" ON "members" ("org_id", "email") WHERE deleted_at IS NULL`,
+		MSSQL:    `CREATE UNIQUE INDEX [uq_members] ON [members] ([org_id], [email]) WHERE deleted_at IS NULL`,
+	}
+	for d, want := range tests {
+		if got, err := d.CreateIndex("members", idx, partial); err != nil || got != want {
+			t.Errorf("%s.CreateIndex() = %s, %v, want %s", d, got, err, want)
+		}
+	}
+	if _, err := MariaDB.CreateIndex("members", idx, partial); err == nil || !strings.Contains(err.Error(), "partial index uq_members") {
+		t.Errorf("MariaDB.CreateIndex() error = %v", err)
+	}
+	table := dbml.NewTable("members").AddIndex(idx)
+	if _, err := MariaDB.CreateIndexes(table, map[string]IndexOptions{"uq_members": partial}); err == nil {
+		t.Error("MariaDB.CreateIndexes() should fail for a partial index")
+	}
+	if got, err := MariaDB.CreateIndexes(table, nil); err != nil || len(got) != 1 || strings.Contains(got[0], "WHERE") {
+		t.Errorf("MariaDB.CreateIndexes() without options = %q, %v", got, err)
+	}
+
+	trgm := dbml.NewIndex("name", "org_id").WithType("gin")
+	trgmOptions := IndexOptions{Where: "deleted_at IS NULL", OpClasses: map[string]string{"name": "gin_trgm_ops"}}
+	if got, want := mustCreateIndex(t, Postgres, "users", trgm, trgmOptions), `CREATE INDEX "idx_users_name_org_id" ON "users" USING gin ("name" gin_trgm_ops, "org_id") WHERE deleted_at IS NULL`; got != want {
+		t.Errorf("CreateIndex() = %s, want %s", got, want)
+	}
+	if got, want := mustCreateIndex(t, SQLite, "users", trgm, trgmOptions), `CREATE INDEX "idx_users_name_org_id" ON "users" ("name", "org_id") WHERE deleted_at IS NULL`; got != want {
+		t.Errorf("SQLite CreateIndex() = %s, want %s", got, want)
+	}
+}
+
+func mustCreateIndex(t *testing.T, d Dialect, table string, idx *dbml.Index, options IndexOptions) string {
+	t.Helper()
+	stmt, err := d.CreateIndex(table, idx, options)
+	if err != nil {
+		t.Fatalf("%s.CreateIndex() failed: %v", d, err)
+	}
+	return stmt
+}
+
+func TestIndexName(t *testing.T) {
+	if got := IndexName("users", dbml.NewIndex("a", "b")); got != "idx_users_a_b" {
+		t.Errorf("IndexName() = %s", got)
+	}
+	if got := IndexName("users", dbml.NewIndex("a").WithName("custom")); got != "custom" {
+		t.Errorf("IndexName() = %s", got)
+	}
+}
@@ -0,0 +1,350 @@
+package scanner
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/zoobzio/atom"
+)
+
+// errMultidimensional is returned when an array column holds nested arrays.
+var errMultidimensional = errors.New("multidimensional arrays are not supported")
+
+// arrayTimeLayouts are the layouts of timestamps inside PostgreSQL array literals.
+var arrayTimeLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02 15:04:05.999999999Z07:00:00",
+	"2006-01-02 15:04:05.999999999Z07:00",
+	"2006-01-02 15:04:05.999999999Z07",
+	"2006-01-02 15:04:05.999999999",
+	"2006-01-02",
+}
+
+// IsArray reports whether values of t are stored as native PostgreSQL arrays: slices of
+// strings, numbers, booleans or time.Time, or pointers to them. Pointers are unwrapped
+// once, and elements may be pointers, where nil is stored as a NULL element.
+// []byte, slices of registered types, and types implementing driver.Valuer or
+// sql.Scanner are not arrays.
+func IsArray(t reflect.Type) bool {
+	_, ok := arrayElem(t)
+	return ok
+}
+
+// arrayElem returns the element type of an array type with pointers removed.
+func arrayElem(t reflect.Type) (reflect.Type, bool) {
+	if t == nil {
+		return nil, false
+	}
+	if t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Slice || convertsItself(t) {
+		return nil, false
+	}
+	elem := t.Elem()
+	if elem.Kind() == reflect.Uint8 {
+		return nil, false
+	}
+	if elem.Kind() == reflect.Pointer {
+		elem = elem.Elem()
+	}
+	if elem == timeType {
+		return elem, true
+	}
+	if _, ok := customTable(elem); ok || convertsItself(elem) {
+		return nil, false
+	}
+	if _, ok := scalarToTable(elem); !ok {
+		return nil, false
+	}
+	return elem, true
+}
+
+// arrayTable maps an array type to the atom slice table that holds it.
+func arrayTable(t reflect.Type) (atom.Table, bool) {
+	elem, ok := arrayElem(t)
+	if !ok {
+		return "", false
+	}
+	base, _ := scalarToTable(elem)
+	switch base {
+	case atom.TableStrings:
+		return atom.TableStringSlices, true
+	case atom.TableInts:
+		return atom.TableIntSlices, true
+	case atom.TableUints:
+		return atom.TableUintSlices, true
+	case atom.TableFloats:
+		return atom.TableFloatSlices, true
+	case atom.TableBools:
+		return atom.TableBoolSlices, true
+	default:
+		return atom.TableTimeSlices, true
+	}
+}
+
+// FormatArray renders a slice as a PostgreSQL array literal such as {"a","b"}.
+// A nil slice returns false, for binding as NULL.
+func FormatArray(v reflect.Value) (string, bool) {
+	if v.IsNil() {
+		return "", false
+	}
+	var b strings.Builder
+	b.WriteByte('{')
+	for i := range v.Len() {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		elem := v.Index(i)
+		if elem.Kind() == reflect.Pointer {
+			if elem.IsNil() {
+				b.WriteString("NULL")
+				continue
+			}
+			elem = elem.Elem()
+		}
+		formatArrayElem(&b, elem)
+	}
+	b.WriteByte('}')
+	return b.String(), true
+}
+
+// formatArrayElem writes a single array element.
+func formatArrayElem(b *strings.Builder, v reflect.Value) {
+	if v.Type() == timeType {
+		b.WriteByte('"')
+		b.WriteString(v.Interface().(time.Time).Format(time.RFC3339Nano))
+		b.WriteByte('"')
+		return
+	}
+	switch v.Kind() {
+	case reflect.String:
+		quoteArrayElem(b, v.String())
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		b.WriteString(strconv.FormatInt(v.Int(), 10))
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		b.WriteString(strconv.FormatUint(v.Uint(), 10))
+	case reflect.Float32, reflect.Float64:
+		f := v.Float()
+		switch {
+		case math.IsNaN(f):
+			b.WriteString("NaN")
+		case math.IsInf(f, 1):
+			b.WriteString("Infinity")
+		case math.IsInf(f, -1):
+			b.WriteString("-Infinity")
+		default:
+			b.WriteString(strconv.FormatFloat(f, 'g', -1, v.Type().Bits()))
+		}
+	case reflect.Bool:
+		if v.Bool() {
+			b.WriteByte('t')
+		} else {
+			b.WriteByte('f')
+		}
+	}
+}
+
+// quoteArrayElem writes s as a double-quoted array element, escaping quotes and backslashes.
+func quoteArrayElem(b *strings.Builder, s string) {
+	b.WriteByte('"')
+	for i := 0; i < len(s); i++ {
+		if s[i] == '"' || s[i] == '\\' {
+			b.WriteByte('\\')
+		}
+		b.WriteByte(s[i])
+	}
+	b.WriteByte('"')
+}
+
+// parseArray splits a one-dimensional PostgreSQL array literal into its elements.
+// NULL elements are returned as nil.
+func parseArray(data []byte) ([]*string, error) {
+	s := string(data)
+	// Arrays with a lower bound other than 1 carry a dimension prefix such as [0:2]=
+	if strings.HasPrefix(s, "[") {
+		i := strings.IndexByte(s, '=')
+		if i == -1 {
+			return nil, fmt.Errorf("malformed array literal %q", s)
+		}
+		s = s[i+1:]
+	}
+	if len(s) < 2 || s[0] != '{' || s[len(s)-1] != '}' {
+		return nil, fmt.Errorf("malformed array literal %q", s)
+	}
+	body := s[1 : len(s)-1]
+	elems := []*string{}
+	if strings.TrimSpace(body) == "" {
+		return elems, nil
+	}
+
+	for i := 0; ; {
+		for i < len(body) && body[i] == ' ' {
+			i++
+		}
+		if i < len(body) && body[i] == '{' {
+			return nil, errMultidimensional
+		}
+
+		var (
+			elem   strings.Builder
+			quoted bool
+		)
+		if i < len(body) && body[i] == '"' {
+			quoted = true
+			i++
+			for ; i < len(body) && body[i] != '"'; i++ {
+				if body[i] == '\\' && i+1 < len(body) {
+					i++
+				}
+				elem.WriteByte(body[i])
+			}
+			if i == len(body) {
+				return nil, fmt.Errorf("malformed array literal %q: unterminated quote", s)
+			}
+			i++
+		} else {
+			for ; i < len(body) && body[i] != ','; i++ {
+				if body[i] == '\\' && i+1 < len(body) {
+					i++
+				}
+				elem.WriteByte(body[i])
+			}
+		}
+
+		value := elem.String()
+		if !quoted {
+			value = strings.TrimSpace(value)
+		}
+		if !quoted && strings.EqualFold(value, "NULL") {
+			elems = append(elems, nil)
+		} else {
+			elems = append(elems, &value)
+		}
+
+		for i < len(body) && body[i] == ' ' {
+			i++
+		}
+		if i == len(body) {
+			return elems, nil
+		}
+		if body[i] != ',' {
+			return nil, fmt.Errorf("malformed array literal %q", s)
+		}
+		i++
+	}
+}
+
+// DecodeArray parses a PostgreSQL array literal into a new slice of type t.
+// NULL elements become nil for pointer elements and the zero value otherwise.
+func DecodeArray(t reflect.Type, data []byte) (reflect.Value, error) {
+	elems, err := parseArray(data)
+	if err != nil {
+		return reflect.Value{}, err
+	}
+	out := reflect.MakeSlice(t, len(elems), len(elems))
+	elemType := t.Elem()
+	for i, elem := range elems {
+		if elem == nil {
+			continue
+		}
+		target := out.Index(i)
+		if elemType.Kind() == reflect.Pointer {
+			target.Set(reflect.New(elemType.Elem()))
+			target = target.Elem()
+		}
+		if err := decodeArrayElem(target, *elem); err != nil {
+			return reflect.Value{}, fmt.Errorf("array element %d: %w", i, err)
+		}
+	}
+	return out, nil
+}
+
+// decodeArrayElem parses s into v according to v's kind.
+func decodeArrayElem(v reflect.Value, s string) error {
+	if v.Type() == timeType {
+		for _, layout := range arrayTimeLayouts {
+			if t, err := time.Parse(layout, s); err == nil {
+				v.Set(reflect.ValueOf(t))
+				return nil
+			}
+		}
+		return fmt.Errorf("cannot parse %q as a timestamp", s)
+	}
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(s)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(s, 10, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseUint(s, 10, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetUint(n)
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(s, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetFloat(f)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(s)
+		if err != nil {
+			return err
+		}
+		v.SetBool(b)
+	default:
+		return fmt.Errorf("unsupported array element type %s", v.Type())
+	}
+	return nil
+}
+
+// assignArray decodes an array column into the atom's slice table for plan.
+// NULL leaves the field unset.
+func assignArray(a *atom.Atom, plan *scanFieldPlan, data []byte) error {
+	if data == nil {
+		return nil
+	}
+	var err error
+	switch plan.table {
+	case atom.TableStringSlices:
+		err = setArray(&a.StringSlices, plan.fieldName, data)
+	case atom.TableIntSlices:
+		err = setArray(&a.IntSlices, plan.fieldName, data)
+	case atom.TableUintSlices:
+		err = setArray(&a.UintSlices, plan.fieldName, data)
+	case atom.TableFloatSlices:
+		err = setArray(&a.FloatSlices, plan.fieldName, data)
+	case atom.TableBoolSlices:
+		err = setArray(&a.BoolSlices, plan.fieldName, data)
+	case atom.TableTimeSlices:
+		err = setArray(&a.TimeSlices, plan.fieldName, data)
+	}
+	if err != nil {
+		return fmt.Errorf("decoding array column %q: %w", plan.column, err)
+	}
+	return nil
+}
+
+// setArray decodes data as []E and stores it in table under name.
+func setArray[E any](table *map[string][]E, name string, data []byte) error {
+	v, err := DecodeArray(reflect.TypeFor[[]E](), data)
+	if err != nil {
+		return err
+	}
+	if *table == nil {
+		*table = make(map[string][]E)
+	}
+	(*table)[name] = v.Interface().([]E)
+	return nil
+}
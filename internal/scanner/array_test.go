@@ -0,0 +1,148 @@
+package scanner
+
+import (
+	"database/sql"
+	"errors"
+	"math"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestIsArray(t *testing.T) {
+	type tag string
+	tests := []struct {
+		typ  reflect.Type
+		want bool
+	}{
+		{reflect.TypeFor[[]string](), true},
+		{reflect.TypeFor[*[]int64](), true},
+		{reflect.TypeFor[[]tag](), true},
+		{reflect.TypeFor[[]*float64](), true},
+		{reflect.TypeFor[[]bool](), true},
+		{reflect.TypeFor[[]time.Time](), true},
+		{reflect.TypeFor[[]byte](), false},
+		{reflect.TypeFor[[][]string](), false},
+		{reflect.TypeFor[[]sql.NullString](), false},
+		{reflect.TypeFor[[]struct{ A int }](), false},
+		{reflect.TypeFor[string](), false},
+		{nil, false},
+	}
+	for _, tt := range tests {
+		if got := IsArray(tt.typ); got != tt.want {
+			t.Errorf("IsArray(%v) = %v, want %v", tt.typ, got, tt.want)
+		}
+	}
+}
+
+func TestFormatArray(t *testing.T) {
+	one := 1.5
+	ts := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	tests := []struct {
+		value any
+		want  string
+	}{
+		{[]string{"a", `say "hi"`, `back\slash`, ""}, `{"a","say \"hi\"","back\\slash",""}`},
+		{[]int{1, -2, 3}, `{1,-2,3}`},
+		{[]uint8{}, `{}`},
+		{[]*float64{&one, nil}, `{1.5,NULL}`},
+		{[]float64{math.Inf(1), math.NaN()}, `{Infinity,NaN}`},
+		{[]bool{true, false}, `{t,f}`},
+		{[]time.Time{ts}, `{"2024-01-02T03:04:05Z"}`},
+	}
+	for _, tt := range tests {
+		got, ok := FormatArray(reflect.ValueOf(tt.value))
+		if !ok || got != tt.want {
+			t.Errorf("FormatArray(%v) = %q, %v, want %q", tt.value, got, ok, tt.want)
+		}
+	}
+
+	if _, ok := FormatArray(reflect.ValueOf([]string(nil))); ok {
+		t.Error("FormatArray(nil) should report NULL")
+	}
+}
+
+func TestDecodeArray(t *testing.T) {
+	got, err := DecodeArray(reflect.TypeFor[[]string](), []byte(`{plain,"say \"hi\"","a,b", spaced ,NULL,"NULL"}`))
+	if err != nil {
+		t.Fatalf("DecodeArray() error = %v", err)
+	}
+	want := []string{"plain", `say "hi"`, "a,b", "spaced", "", "NULL"}
+	if !reflect.DeepEqual(got.Interface(), want) {
+		t.Errorf("DecodeArray() = %q, want %q", got.Interface(), want)
+	}
+
+	ptrs, err := DecodeArray(reflect.TypeFor[[]*int32](), []byte(`[0:1]={7,NULL}`))
+	if err != nil {
+		t.Fatalf("DecodeArray() error = %v", err)
+	}
+	values := ptrs.Interface().([]*int32)
+	if len(values) != 2 || *values[0] != 7 || values[1] != nil {
+		t.Errorf("DecodeArray() = %v", values)
+	}
+
+	times, err := DecodeArray(reflect.TypeFor[[]time.Time](), []byte(`{"2024-01-02 03:04:05.5+00","2024-01-02 03:04:05+05:30"}`))
+	if err != nil {
+		t.Fatalf("DecodeArray() error = %v", err)
+	}
+	ts := times.Interface().([]time.Time)
+	if !ts[0].Equal(time.Date(2024, 1, 2, 3, 4, 5, 5e8, time.UTC)) || !ts[1].Equal(time.Date(2024, 1, 1, 21, 34, 5, 0, time.UTC)) {
+		t.Errorf("DecodeArray() = %v", ts)
+	}
+
+	empty, err := DecodeArray(reflect.TypeFor[[]bool](), []byte(`{}`))
+	if err != nil || empty.Len() != 0 || empty.IsNil() {
+		t.Errorf("DecodeArray({}) = %v, %v", empty, err)
+	}
+
+	if _, err := DecodeArray(reflect.TypeFor[[]int](), []byte(`{{1,2},{3,4}}`)); !errors.Is(err, errMultidimensional) {
+		t.Errorf("DecodeArray(multidimensional) error = %v", err)
+	}
+	for _, bad := range []string{`1,2`, `{"open}`, `{1,x}`, `{1 2}`} {
+		if _, err := DecodeArray(reflect.TypeFor[[]int](), []byte(bad)); err == nil {
+			t.Errorf("DecodeArray(%q) expected error", bad)
+		}
+	}
+}
+
+func TestScan_ArrayColumn(t *testing.T) {
+	type post struct {
+		ID     int64      `db:"id"`
+		Tags   []string   `db:"tags"`
+		Scores *[]float64 `db:"scores"`
+		Flags  []bool     `db:"flags"`
+	}
+
+	s, err := New(buildMetadata[post]())
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	mock := &mockColScanner{
+		columns: []string{"id", "tags", "scores", "flags"},
+		rows: [][]any{
+			{int64(1), []byte(`{go,sql}`), []byte(`{1.5,2}`), []byte(nil)},
+		},
+	}
+	result, err := s.Scan(mock)
+	if err != nil {
+		t.Fatalf("Scan() error = %v", err)
+	}
+	if !reflect.DeepEqual(result.StringSlices["Tags"], []string{"go", "sql"}) {
+		t.Errorf("Tags = %v", result.StringSlices["Tags"])
+	}
+	if !reflect.DeepEqual(result.FloatSlices["Scores"], []float64{1.5, 2}) {
+		t.Errorf("Scores = %v", result.FloatSlices["Scores"])
+	}
+	if _, ok := result.BoolSlices["Flags"]; ok {
+		t.Error("NULL array column should be unset")
+	}
+
+	bad := &mockColScanner{
+		columns: []string{"tags"},
+		rows:    [][]any{{[]byte(`not an array`)}},
+	}
+	if _, err := s.Scan(bad); err == nil {
+		t.Error("expected error for malformed array column")
+	}
+}
@@ -0,0 +1,210 @@
+package scanner
+
+import (
+	"bytes"
+	"database/sql"
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"reflect"
+
+	"github.com/zoobzio/atom"
+)
+
+var (
+	rawMessageType = reflect.TypeFor[json.RawMessage]()
+	valuerType     = reflect.TypeFor[driver.Valuer]()
+	sqlScannerType = reflect.TypeFor[sql.Scanner]()
+)
+
+// IsJSON reports whether values of t are stored as JSON documents: structs, maps,
+// slices of structs or maps, and json.RawMessage. Pointers are unwrapped once.
+// time.Time and types implementing driver.Valuer or sql.Scanner convert themselves
+// and are not JSON.
+func IsJSON(t reflect.Type) bool {
+	if t == nil {
+		return false
+	}
+	if t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t == rawMessageType {
+		return true
+	}
+	if t == timeType || convertsItself(t) {
+		return false
+	}
+	switch t.Kind() {
+	case reflect.Struct, reflect.Map:
+		return true
+	case reflect.Slice:
+		elem := t.Elem()
+		for elem.Kind() == reflect.Pointer {
+			elem = elem.Elem()
+		}
+		return (elem.Kind() == reflect.Struct && elem != timeType) || elem.Kind() == reflect.Map
+	}
+	return false
+}
+
+// convertsItself reports whether t implements driver.Valuer or sql.Scanner.
+func convertsItself(t reflect.Type) bool {
+	return t.Implements(valuerType) || t.Implements(sqlScannerType) ||
+		reflect.PointerTo(t).Implements(valuerType) || reflect.PointerTo(t).Implements(sqlScannerType)
+}
+
+// assignJSON decodes a JSON column into the atom under name.
+// Objects become nested atoms keyed by their JSON keys, arrays of objects become nested
+// slices, and arrays of strings, numbers or booleans become typed slices. Numbers are
+// stored as ints when integral and floats otherwise. NULL, JSON null, empty arrays and
+// arrays of mixed types leave the field unset.
+func assignJSON(a *atom.Atom, name string, data []byte) error {
+	if data == nil {
+		return nil
+	}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var v any
+	if err := dec.Decode(&v); err != nil {
+		return fmt.Errorf("decoding JSON column %q: %w", name, err)
+	}
+	setJSONValue(a, name, v)
+	return nil
+}
+
+// jsonObject converts a decoded JSON object to an Atom.
+func jsonObject(obj map[string]any) atom.Atom {
+	var a atom.Atom
+	for key, v := range obj {
+		setJSONValue(&a, key, v)
+	}
+	return a
+}
+
+// setJSONValue stores a decoded JSON value in the atom table matching its type.
+func setJSONValue(a *atom.Atom, key string, v any) {
+	switch v := v.(type) {
+	case string:
+		if a.Strings == nil {
+			a.Strings = make(map[string]string)
+		}
+		a.Strings[key] = v
+	case bool:
+		if a.Bools == nil {
+			a.Bools = make(map[string]bool)
+		}
+		a.Bools[key] = v
+	case json.Number:
+		if i, err := v.Int64(); err == nil {
+			if a.Ints == nil {
+				a.Ints = make(map[string]int64)
+			}
+			a.Ints[key] = i
+			return
+		}
+		if f, err := v.Float64(); err == nil {
+			if a.Floats == nil {
+				a.Floats = make(map[string]float64)
+			}
+			a.Floats[key] = f
+		}
+	case map[string]any:
+		if a.Nested == nil {
+			a.Nested = make(map[string]atom.Atom)
+		}
+		a.Nested[key] = jsonObject(v)
+	case []any:
+		setJSONArray(a, key, v)
+	}
+}
+
+// setJSONArray stores a decoded JSON array whose elements share a type.
+func setJSONArray(a *atom.Atom, key string, arr []any) {
+	if len(arr) == 0 {
+		return
+	}
+	switch arr[0].(type) {
+	case string:
+		if values, ok := sameType[string](arr); ok {
+			if a.StringSlices == nil {
+				a.StringSlices = make(map[string][]string)
+			}
+			a.StringSlices[key] = values
+		}
+	case bool:
+		if values, ok := sameType[bool](arr); ok {
+			if a.BoolSlices == nil {
+				a.BoolSlices = make(map[string][]bool)
+			}
+			a.BoolSlices[key] = values
+		}
+	case json.Number:
+		numbers, ok := sameType[json.Number](arr)
+		if !ok {
+			return
+		}
+		if ints, ok := jsonInts(numbers); ok {
+			if a.IntSlices == nil {
+				a.IntSlices = make(map[string][]int64)
+			}
+			a.IntSlices[key] = ints
+			return
+		}
+		if floats, ok := jsonFloats(numbers); ok {
+			if a.FloatSlices == nil {
+				a.FloatSlices = make(map[string][]float64)
+			}
+			a.FloatSlices[key] = floats
+		}
+	case map[string]any:
+		objects, ok := sameType[map[string]any](arr)
+		if !ok {
+			return
+		}
+		nested := make([]atom.Atom, len(objects))
+		for i, obj := range objects {
+			nested[i] = jsonObject(obj)
+		}
+		if a.NestedSlices == nil {
+			a.NestedSlices = make(map[string][]atom.Atom)
+		}
+		a.NestedSlices[key] = nested
+	}
+}
+
+// sameType returns the elements of arr as E, or false if any element is not an E.
+func sameType[E any](arr []any) ([]E, bool) {
+	values := make([]E, len(arr))
+	for i, v := range arr {
+		e, ok := v.(E)
+		if !ok {
+			return nil, false
+		}
+		values[i] = e
+	}
+	return values, true
+}
+
+func jsonInts(numbers []json.Number) ([]int64, bool) {
+	ints := make([]int64, len(numbers))
+	for i, n := range numbers {
+		v, err := n.Int64()
+		if err != nil {
+			return nil, false
+		}
+		ints[i] = v
+	}
+	return ints, true
+}
+
+func jsonFloats(numbers []json.Number) ([]float64, bool) {
+	floats := make([]float64, len(numbers))
+	for i, n := range numbers {
+		v, err := n.Float64()
+		if err != nil {
+			return nil, false
+		}
+		floats[i] = v
+	}
+	return floats, true
+}
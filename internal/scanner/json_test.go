@@ -0,0 +1,131 @@
+package scanner
+
+import (
+	"database/sql"
+	"encoding/json"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/zoobzio/atom"
+)
+
+func TestIsJSON(t *testing.T) {
+	type settings struct{ Theme string }
+	tests := []struct {
+		typ  reflect.Type
+		want bool
+	}{
+		{reflect.TypeFor[settings](), true},
+		{reflect.TypeFor[*settings](), true},
+		{reflect.TypeFor[map[string]any](), true},
+		{reflect.TypeFor[[]settings](), true},
+		{reflect.TypeFor[[]*settings](), true},
+		{reflect.TypeFor[json.RawMessage](), true},
+		{reflect.TypeFor[string](), false},
+		{reflect.TypeFor[[]string](), false},
+		{reflect.TypeFor[[]byte](), false},
+		{reflect.TypeFor[time.Time](), false},
+		{reflect.TypeFor[[]time.Time](), false},
+		{reflect.TypeFor[sql.NullString](), false},
+		{nil, false},
+	}
+	for _, tt := range tests {
+		if got := IsJSON(tt.typ); got != tt.want {
+			t.Errorf("IsJSON(%v) = %v, want %v", tt.typ, got, tt.want)
+		}
+	}
+}
+
+func TestAssignJSON(t *testing.T) {
+	var a atom.Atom
+	doc := `{"theme":"dark","size":12,"ratio":1.5,"beta":true,"tags":["a","b"],"scores":[1,2.5],` +
+		`"owner":{"name":"ada"},"items":[{"sku":"x"}],"mixed":[1,"a"],"empty":[],"gone":null}`
+	if err := assignJSON(&a, "Settings", []byte(doc)); err != nil {
+		t.Fatalf("assignJSON() error = %v", err)
+	}
+
+	settings, ok := a.Nested["Settings"]
+	if !ok {
+		t.Fatalf("Nested[Settings] missing: %+v", a)
+	}
+	if settings.Strings["theme"] != "dark" || settings.Ints["size"] != 12 || settings.Floats["ratio"] != 1.5 || !settings.Bools["beta"] {
+		t.Errorf("unexpected scalars: %+v", settings)
+	}
+	if !reflect.DeepEqual(settings.StringSlices["tags"], []string{"a", "b"}) {
+		t.Errorf("tags = %v", settings.StringSlices["tags"])
+	}
+	if !reflect.DeepEqual(settings.FloatSlices["scores"], []float64{1, 2.5}) {
+		t.Errorf("scores = %v", settings.FloatSlices["scores"])
+	}
+	if settings.Nested["owner"].Strings["name"] != "ada" {
+		t.Errorf("owner = %+v", settings.Nested["owner"])
+	}
+	if items := settings.NestedSlices["items"]; len(items) != 1 || items[0].Strings["sku"] != "x" {
+		t.Errorf("items = %+v", items)
+	}
+	for _, key := range []string{"mixed", "empty", "gone"} {
+		if _, ok := settings.IntSlices[key]; ok {
+			t.Errorf("%s should be unset", key)
+		}
+		if _, ok := settings.Strings[key]; ok {
+			t.Errorf("%s should be unset", key)
+		}
+	}
+
+	var list atom.Atom
+	if err := assignJSON(&list, "IDs", []byte(`[1,2,3]`)); err != nil {
+		t.Fatalf("assignJSON() error = %v", err)
+	}
+	if !reflect.DeepEqual(list.IntSlices["IDs"], []int64{1, 2, 3}) {
+		t.Errorf("IDs = %v", list.IntSlices["IDs"])
+	}
+
+	var null atom.Atom
+	if err := assignJSON(&null, "Settings", nil); err != nil || null.Nested != nil {
+		t.Errorf("assignJSON(NULL) = %+v, %v", null, err)
+	}
+
+	if err := assignJSON(&atom.Atom{}, "Settings", []byte(`{`)); err == nil {
+		t.Error("expected error for malformed JSON")
+	}
+}
+
+func TestScan_JSONColumn(t *testing.T) {
+	type settings struct{ Theme string }
+	type doc struct {
+		ID       int64          `db:"id"`
+		Settings settings       `db:"settings"`
+		Labels   map[string]any `db:"labels"`
+	}
+
+	s, err := New(buildMetadata[doc]())
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	mock := &mockColScanner{
+		columns: []string{"id", "settings", "labels"},
+		rows: [][]any{
+			{int64(1), []byte(`{"theme":"dark"}`), []byte(nil)},
+		},
+	}
+	result, err := s.Scan(mock)
+	if err != nil {
+		t.Fatalf("Scan() error = %v", err)
+	}
+	if result.Nested["Settings"].Strings["theme"] != "dark" {
+		t.Errorf("Settings = %+v", result.Nested["Settings"])
+	}
+	if _, ok := result.Nested["Labels"]; ok {
+		t.Error("NULL JSON column should be unset")
+	}
+
+	bad := &mockColScanner{
+		columns: []string{"settings"},
+		rows:    [][]any{{[]byte(`not json`)}},
+	}
+	if _, err := s.Scan(bad); err == nil {
+		t.Error("expected error for malformed JSON column")
+	}
+}
@@ -5,8 +5,11 @@ package scanner
 import (
 	"database/sql"
 	"fmt"
+	"maps"
 	"reflect"
+	"slices"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/zoobzio/atom"
@@ -35,55 +38,103 @@ type scanFieldPlan struct {
 	table     atom.Table
 	path      []string
 	nullable  bool
+	json      bool // Column holds a JSON document, decoded by assignJSON
+	array     bool // Column holds a PostgreSQL array, decoded by assignArray
 }
 
 var timeType = reflect.TypeFor[time.Time]()
 
+// customTables maps registered custom types to the table of their driver representation.
+var customTables = struct {
+	sync.RWMutex
+	byType map[reflect.Type]atom.Table
+}{byType: make(map[reflect.Type]atom.Table)}
+
+// RegisterTable maps a custom Go type to the atom table its scanned values are stored in.
+// It takes precedence over the built-in mapping, so types such as [16]byte UUIDs can be
+// stored as strings. Scanners built before registration are not affected.
+func RegisterTable(t reflect.Type, table atom.Table) {
+	customTables.Lock()
+	defer customTables.Unlock()
+	customTables.byType[t] = table
+}
+
+func customTable(t reflect.Type) (atom.Table, bool) {
+	customTables.RLock()
+	defer customTables.RUnlock()
+	table, ok := customTables.byType[t]
+	return table, ok
+}
+
 // New creates a Scanner from sentinel metadata.
 // The metadata provides struct field information including db tags for column mapping.
 func New(metadata sentinel.Metadata) (*Scanner, error) {
+	return NewWithFields(metadata, metadata.Fields)
+}
+
+// NewWithFields creates a Scanner for spec that maps columns with fields instead of the
+// fields of spec. Fields named by a dotted path, such as Address.Street, are columns of a
+// nested struct and are scanned into the nested atom of each struct on the path.
+func NewWithFields(spec sentinel.Metadata, fields []sentinel.FieldMetadata) (*Scanner, error) {
 	s := &Scanner{
-		spec:     metadata,
+		spec:     spec,
 		byColumn: make(map[string]*scanFieldPlan),
 		tableSet: make(map[atom.Table]int),
 	}
 
-	if err := s.buildPlans(metadata.Fields, nil); err != nil {
+	if err := s.buildPlans(fields); err != nil {
 		return nil, err
 	}
 
 	return s, nil
 }
 
-// buildPlans recursively builds scan plans from metadata fields.
-func (s *Scanner) buildPlans(fields []sentinel.FieldMetadata, path []string) error {
+// buildPlans builds scan plans from metadata fields.
+func (s *Scanner) buildPlans(fields []sentinel.FieldMetadata) error {
 	for _, field := range fields {
 		dbTag, hasDB := field.Tags["db"]
 		if !hasDB || dbTag == "" || dbTag == "-" {
 			continue
 		}
 
+		var path []string
+		fieldName := field.Name
+		if i := strings.LastIndex(fieldName, "."); i >= 0 {
+			path = strings.Split(fieldName[:i], ".")
+			fieldName = fieldName[i+1:]
+		}
+
 		table, nullable := fieldToTable(field.ReflectType)
+		isArray := false
 		if table == "" {
-			// Skip unsupported types (slices, maps, nested structs without db tags)
+			table, isArray = arrayTable(field.ReflectType)
+			nullable = isArray && field.ReflectType.Kind() == reflect.Pointer
+		}
+		isJSON := table == "" && IsJSON(field.ReflectType)
+		if table == "" && !isJSON {
+			// Skip unsupported types (channels, funcs, nested slices)
 			continue
 		}
 
 		// Check for column name collision
 		if existing, ok := s.byColumn[dbTag]; ok {
 			return fmt.Errorf("column %q maps to multiple fields: %s and %s",
-				dbTag, fieldPath(existing.path, existing.fieldName), fieldPath(path, field.Name))
+				dbTag, fieldPath(existing.path, existing.fieldName), fieldPath(path, fieldName))
 		}
 
 		plan := &scanFieldPlan{
-			fieldName: field.Name,
+			fieldName: fieldName,
 			column:    dbTag,
 			table:     table,
 			nullable:  nullable,
 			path:      path,
+			json:      isJSON,
+			array:     isArray,
 		}
 		s.byColumn[dbTag] = plan
-		s.tableSet[table]++
+		if !isJSON && !isArray && path == nil {
+			s.tableSet[table]++
+		}
 	}
 
 	return nil
@@ -100,10 +151,20 @@ func fieldPath(path []string, fieldName string) string {
 // fieldToTable maps a reflect.Type to its atom.Table.
 // Returns the table and whether the type is nullable (pointer).
 func fieldToTable(t reflect.Type) (atom.Table, bool) {
+	// Registered custom types
+	if table, ok := customTable(t); ok {
+		return table, false
+	}
+
 	// Handle pointer types
 	if t.Kind() == reflect.Ptr {
 		elemType := t.Elem()
 
+		// Pointer to a registered custom type
+		if table, ok := customTable(elemType); ok {
+			return pointerTable(table), true
+		}
+
 		// Pointer to []byte
 		if elemType.Kind() == reflect.Slice && elemType.Elem().Kind() == reflect.Uint8 {
 			return atom.TableBytePtrs, true
@@ -192,7 +253,7 @@ func (s *Scanner) Scan(cs ColScanner) (*atom.Atom, error) {
 		return nil, fmt.Errorf("scanning row: %w", err)
 	}
 
-	return s.buildAtom(plans, dests), nil
+	return s.buildAtom(plans, dests)
 }
 
 // ScanAll reads all rows into Atoms.
@@ -210,7 +271,11 @@ func (s *Scanner) ScanAll(cs ColScanner, next func() bool) ([]*atom.Atom, error)
 		if err := cs.Scan(dests...); err != nil {
 			return nil, fmt.Errorf("scanning row: %w", err)
 		}
-		atoms = append(atoms, s.buildAtom(plans, dests))
+		a, err := s.buildAtom(plans, dests)
+		if err != nil {
+			return nil, err
+		}
+		atoms = append(atoms, a)
 		// Reset destinations for next row
 		resetDests(dests)
 	}
@@ -237,6 +302,10 @@ func (s *Scanner) prepareScan(cols []string) (plans []*scanFieldPlan, dests []an
 			continue
 		}
 
+		if plan.json || plan.array {
+			dests[i] = new([]byte)
+			continue
+		}
 		dests[i] = makeScanDest(plan.table, plan.nullable)
 	}
 
@@ -320,20 +389,77 @@ func resetDests(dests []any) {
 }
 
 // buildAtom constructs an Atom from scanned destinations.
-func (s *Scanner) buildAtom(plans []*scanFieldPlan, dests []any) *atom.Atom {
+func (s *Scanner) buildAtom(plans []*scanFieldPlan, dests []any) (*atom.Atom, error) {
 	result := allocateAtom(s.tableSet)
 	result.Spec = s.spec
 
+	// Atoms of nested structs, keyed by their dotted field path
+	var nested map[string]*atom.Atom
+
 	for i, plan := range plans {
 		if plan == nil {
 			continue
 		}
 
+		target := result
+		if plan.path != nil {
+			if nested == nil {
+				nested = make(map[string]*atom.Atom)
+			}
+			key := strings.Join(plan.path, ".")
+			if target = nested[key]; target == nil {
+				target = &atom.Atom{}
+				nested[key] = target
+			}
+		}
+
 		dest := dests[i]
-		assignValue(result, plan, dest)
+		if plan.json {
+			if err := assignJSON(target, plan.fieldName, *dest.(*[]byte)); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		if plan.array {
+			if err := assignArray(target, plan, *dest.(*[]byte)); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		assignValue(target, plan, dest)
+	}
+
+	if nested != nil {
+		attachNested(result, nested)
+	}
+
+	return result, nil
+}
+
+// attachNested stores nested atoms in the Nested table of their parents. Atom.Nested holds
+// values, so the deepest atoms are attached first, creating intermediate atoms as needed.
+func attachNested(root *atom.Atom, nested map[string]*atom.Atom) {
+	for _, key := range slices.Collect(maps.Keys(nested)) {
+		for i := strings.LastIndex(key, "."); i >= 0; i = strings.LastIndex(key[:i], ".") {
+			if nested[key[:i]] == nil {
+				nested[key[:i]] = &atom.Atom{}
+			}
+		}
 	}
 
-	return result
+	keys := slices.SortedFunc(maps.Keys(nested), func(a, b string) int {
+		return strings.Count(b, ".") - strings.Count(a, ".")
+	})
+	for _, key := range keys {
+		parent, name := root, key
+		if i := strings.LastIndex(key, "."); i >= 0 {
+			parent, name = nested[key[:i]], key[i+1:]
+		}
+		if parent.Nested == nil {
+			parent.Nested = make(map[string]atom.Atom)
+		}
+		parent.Nested[name] = *nested[key]
+	}
 }
 
 // allocateAtom pre-allocates maps for an atom based on expected table usage.
@@ -1173,3 +1173,63 @@ func TestNew_SkipsEmptyDBTag(t *testing.T) {
 		t.Error("expected plan for 'id' column")
 	}
 }
+
+func TestNewWithFields_Nested(t *testing.T) {
+	fields := []sentinel.FieldMetadata{
+		{Name: "ID", ReflectType: reflect.TypeFor[int64](), Tags: map[string]string{"db": "id"}},
+		{Name: "Address.Street", ReflectType: reflect.TypeFor[string](), Tags: map[string]string{"db": "addr_street"}},
+		{Name: "Address.Geo.Lat", ReflectType: reflect.TypeFor[*float64](), Tags: map[string]string{"db": "addr_geo_lat"}},
+	}
+	spec := sentinel.Metadata{TypeName: "place"}
+	s, err := NewWithFields(spec, fields)
+	if err != nil {
+		t.Fatalf("NewWithFields() error = %v", err)
+	}
+
+	mock := &mockColScanner{
+		columns: []string{"id", "addr_street", "addr_geo_lat"},
+		rows:    [][]any{{int64(1), "Main St", 51.5}},
+	}
+	result, err := s.Scan(mock)
+	if err != nil {
+		t.Fatalf("Scan() error = %v", err)
+	}
+
+	if result.Spec.TypeName != "place" || result.Ints["ID"] != 1 {
+		t.Errorf("Scan() top level = %+v", result)
+	}
+	address, ok := result.Nested["Address"]
+	if !ok || address.Strings["Street"] != "Main St" {
+		t.Fatalf("Nested[Address] = %+v", result.Nested)
+	}
+	if lat := address.Nested["Geo"].FloatPtrs["Lat"]; lat == nil || *lat != 51.5 {
+		t.Errorf("Nested[Address].Nested[Geo] = %+v", address.Nested)
+	}
+
+	_, err = NewWithFields(spec, append(fields, sentinel.FieldMetadata{
+		Name: "Billing.Street", ReflectType: reflect.TypeFor[string](), Tags: map[string]string{"db": "addr_street"},
+	}))
+	if err == nil || err.Error() != `column "addr_street" maps to multiple fields: Address.Street and Billing.Street` {
+		t.Errorf("NewWithFields() error = %v, want column collision", err)
+	}
+}
+
+func TestRegisterTable(t *testing.T) {
+	type registeredID [16]byte
+	typ := reflect.TypeFor[registeredID]()
+
+	if tbl, _ := fieldToTable(typ); tbl != atom.TableBytes {
+		t.Fatalf("fieldToTable() before registration = %v, want %v", tbl, atom.TableBytes)
+	}
+
+	RegisterTable(typ, atom.TableStrings)
+
+	tbl, nullable := fieldToTable(typ)
+	if tbl != atom.TableStrings || nullable {
+		t.Errorf("fieldToTable(registeredID) = %v, %v, want %v, false", tbl, nullable, atom.TableStrings)
+	}
+	tbl, nullable = fieldToTable(reflect.PointerTo(typ))
+	if tbl != atom.TableStringPtrs || !nullable {
+		t.Errorf("fieldToTable(*registeredID) = %v, %v, want %v, true", tbl, nullable, atom.TableStringPtrs)
+	}
+}
@@ -0,0 +1,68 @@
+package soy
+
+import (
+	"fmt"
+	"regexp"
+
+	"github.com/zoobzio/astql"
+)
+
+// opJSONPath is the stand-in operator for JSON path comparisons. WhereJSONPath renders
+// a group of two conditions on the field, "(field soy_json_path :key AND field
+// soy_json_path:<op> :param)", so both params are ASTQL params that are validated and
+// namespaced like any other. The extensionRenderer rewrites the group as
+// "(field ->> :key) <op> :param".
+const opJSONPath = "soy_json_path"
+
+// jsonPathComparisons are the operators that can compare the value at a JSON key.
+var jsonPathComparisons = map[string]bool{
+	"=": true, "!=": true, "<": true, "<=": true, ">": true, ">=": true,
+	"LIKE": true, "NOT LIKE": true, "ILIKE": true, "NOT ILIKE": true,
+}
+
+var jsonPathStandIn = regexp.MustCompile(`\(((?:[a-z]\.)?"(?:[^"]|"")*") ` + opJSONPath + ` (:[A-Za-z_][A-Za-z0-9_]*) AND (?:[a-z]\.)?"(?:[^"]|"")*" ` + opJSONPath + `:(=|!=|<=|<|>=|>|LIKE|NOT LIKE|ILIKE|NOT ILIKE) (:[A-Za-z_][A-Za-z0-9_]*)\)`)
+
+// expandJSONPath rewrites the JSON path stand-ins in rendered SQL.
+func expandJSONPath(sql string) string {
+	return jsonPathStandIn.ReplaceAllString(sql, "($1 ->> $2) $3 $4")
+}
+
+// whereJSONPathImpl adds a comparison of the text value at the key in path of the JSON
+// field with param.
+func whereJSONPathImpl(instance *astql.ASTQL, builder *astql.Builder, field, path, operator, param string) (*astql.Builder, error) {
+	if _, err := validateFieldOperator(instance, field, "->>"); err != nil {
+		return builder, err
+	}
+	if !jsonPathComparisons[operator] {
+		return builder, newOperatorUsageError(operator, fmt.Sprintf("operator %q cannot compare a JSON path value", operator))
+	}
+
+	f, err := instance.TryF(field)
+	if err != nil {
+		return builder, newFieldError(field, err)
+	}
+
+	k, err := instance.TryP(path)
+	if err != nil {
+		return builder, newParamError(path, err)
+	}
+
+	p, err := instance.TryP(param)
+	if err != nil {
+		return builder, newParamError(param, err)
+	}
+
+	key, err := instance.TryC(f, opJSONPath, k)
+	if err != nil {
+		return builder, newConditionError(err)
+	}
+	comparison, err := instance.TryC(f, astql.Operator(opJSONPath+":"+operator), p)
+	if err != nil {
+		return builder, newConditionError(err)
+	}
+	condition, err := instance.TryAnd(key, comparison)
+	if err != nil {
+		return builder, newConditionError(err)
+	}
+	return builder.Where(condition), nil
+}
@@ -0,0 +1,81 @@
+package migrate
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/zoobzio/soy/internal/ddl"
+)
+
+// createTableSQL renders the tracking table DDL. It is safe to run on every start.
+func (m *Migrator) createTableSQL() string {
+	t := m.dialect.Quote(m.table)
+	switch m.dialect {
+	case ddl.MariaDB:
+		return "CREATE TABLE IF NOT EXISTS " + t + " (version BIGINT NOT NULL PRIMARY KEY, name VARCHAR(255) NOT NULL, checksum CHAR(64) NOT NULL, applied_at DATETIME(6) NOT NULL)"
+	case ddl.SQLite:
+		return "CREATE TABLE IF NOT EXISTS " + t + " (version INTEGER NOT NULL PRIMARY KEY, name TEXT NOT NULL, checksum TEXT NOT NULL, applied_at TIMESTAMP NOT NULL)"
+	case ddl.MSSQL:
+		return "IF OBJECT_ID(N" + ddl.SQLString(m.table) + ", N'U') IS NULL CREATE TABLE " + t + " (version BIGINT NOT NULL PRIMARY KEY, name NVARCHAR(255) NOT NULL, checksum CHAR(64) NOT NULL, applied_at DATETIME2 NOT NULL)"
+	default:
+		return "CREATE TABLE IF NOT EXISTS " + t + " (version BIGINT NOT NULL PRIMARY KEY, name TEXT NOT NULL, checksum TEXT NOT NULL, applied_at TIMESTAMPTZ NOT NULL)"
+	}
+}
+
+// tableExistsSQL counts tracking tables with the bound name; bind with Rebind.
+func (m *Migrator) tableExistsSQL() string {
+	switch m.dialect {
+	case ddl.MariaDB:
+		return "SELECT COUNT(*) FROM information_schema.TABLES WHERE TABLE_SCHEMA = DATABASE() AND TABLE_NAME = ?"
+	case ddl.SQLite:
+		return "SELECT COUNT(*) FROM sqlite_master WHERE type = 'table' AND name = ?"
+	case ddl.MSSQL:
+		return "SELECT COUNT(*) FROM INFORMATION_SCHEMA.TABLES WHERE TABLE_SCHEMA = SCHEMA_NAME() AND TABLE_NAME = ?"
+	default:
+		return "SELECT COUNT(*) FROM information_schema.tables WHERE table_schema = current_schema() AND table_name = ?"
+	}
+}
+
+func (m *Migrator) selectSQL() string {
+	return "SELECT version, checksum, applied_at FROM " + m.dialect.Quote(m.table) + " ORDER BY version"
+}
+
+func (m *Migrator) insertSQL() string {
+	return "INSERT INTO " + m.dialect.Quote(m.table) + " (version, name, checksum, applied_at) VALUES (?, ?, ?, ?)"
+}
+
+func (m *Migrator) deleteSQL() string {
+	return "DELETE FROM " + m.dialect.Quote(m.table) + " WHERE version = ?"
+}
+
+// lock takes the dialect's session-level migration lock on conn and returns its release.
+// PostgreSQL uses an advisory lock keyed by the tracking table name, MariaDB GET_LOCK and
+// SQL Server sp_getapplock. SQLite serializes writers itself and takes no lock.
+func (m *Migrator) lock(ctx context.Context, conn *sqlx.Conn) (func(), error) {
+	var lockSQL, unlockSQL string
+	var key any = m.table
+	switch m.dialect {
+	case ddl.Postgres:
+		h := fnv.New64a()
+		_, _ = h.Write([]byte("soy/migrate:" + m.table))
+		key = int64(h.Sum64()) //nolint:gosec // wrapping is fine for a lock key
+		lockSQL, unlockSQL = "SELECT pg_advisory_lock(?)", "SELECT pg_advisory_unlock(?)"
+	case ddl.MariaDB:
+		lockSQL, unlockSQL = "SELECT GET_LOCK(?, -1)", "SELECT RELEASE_LOCK(?)"
+	case ddl.MSSQL:
+		lockSQL = "EXEC sp_getapplock @Resource = ?, @LockMode = 'Exclusive', @LockOwner = 'Session', @LockTimeout = -1"
+		unlockSQL = "EXEC sp_releaseapplock @Resource = ?, @LockOwner = 'Session'"
+	default:
+		return func() {}, nil
+	}
+
+	if _, err := conn.ExecContext(ctx, m.db.Rebind(lockSQL), key); err != nil {
+		return nil, fmt.Errorf("migrate: failed to acquire lock: %w", err)
+	}
+	return func() {
+		// Use a fresh context so a cancelled run still releases the lock.
+		_, _ = conn.ExecContext(context.Background(), m.db.Rebind(unlockSQL), key)
+	}, nil
+}
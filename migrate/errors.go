@@ -0,0 +1,64 @@
+package migrate
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Sentinel errors for the migrate package.
+var (
+	// ErrNoDownMigration is returned when rolling back a migration without a down file.
+	ErrNoDownMigration = errors.New("migrate: migration has no down SQL")
+
+	// ErrUnknownVersion is returned when rolling back a version that has no migration file.
+	ErrUnknownVersion = errors.New("migrate: applied version has no migration file")
+
+	// ErrNoChanges is returned by Scaffold when the schema matches the last snapshot.
+	ErrNoChanges = errors.New("migrate: schema has not changed since the last snapshot")
+)
+
+// ChecksumError is returned when an applied migration's up SQL no longer matches
+// the checksum recorded when it ran.
+type ChecksumError struct {
+	Version int64
+	Name    string
+}
+
+func (e *ChecksumError) Error() string {
+	return fmt.Sprintf("migrate: migration %d (%s) changed after it was applied", e.Version, e.Name)
+}
+
+// Is implements errors.Is for ChecksumError.
+func (e *ChecksumError) Is(target error) bool {
+	_, ok := target.(*ChecksumError)
+	return ok
+}
+
+// ErrChecksumMismatch is returned when an applied migration file was modified.
+var ErrChecksumMismatch = &ChecksumError{}
+
+// MigrationError is returned when a migration fails to execute or record.
+// The migration's transaction is rolled back.
+type MigrationError struct {
+	Version   int64
+	Name      string
+	Direction string // "up" or "down"
+	Err       error
+}
+
+func (e *MigrationError) Error() string {
+	return fmt.Sprintf("migrate: %s migration %d (%s) failed: %v", e.Direction, e.Version, e.Name, e.Err)
+}
+
+func (e *MigrationError) Unwrap() error {
+	return e.Err
+}
+
+// Is implements errors.Is for MigrationError.
+func (e *MigrationError) Is(target error) bool {
+	_, ok := target.(*MigrationError)
+	return ok
+}
+
+// ErrMigrationFailed is returned when any migration fails.
+var ErrMigrationFailed = &MigrationError{}
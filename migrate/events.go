@@ -0,0 +1,31 @@
+package migrate
+
+import "github.com/zoobzio/capitan"
+
+// Migration signals.
+// Failures also carry soy.DurationMsKey and soy.ErrorKey; completions carry soy.DurationMsKey.
+var (
+	// MigrationStarted is emitted before a migration runs.
+	// Fields: VersionKey, MigrationKey, DirectionKey.
+	MigrationStarted = capitan.NewSignal("db.migration.started", "Migration started")
+
+	// MigrationCompleted is emitted after a migration is applied or rolled back and recorded.
+	// Fields: VersionKey, MigrationKey, DirectionKey, DurationMsKey.
+	MigrationCompleted = capitan.NewSignal("db.migration.completed", "Migration completed successfully")
+
+	// MigrationFailed is emitted when a migration fails; its transaction is rolled back.
+	// Fields: VersionKey, MigrationKey, DirectionKey, DurationMsKey, ErrorKey.
+	MigrationFailed = capitan.NewSignal("db.migration.failed", "Migration failed with error")
+)
+
+// Migration field keys.
+var (
+	// VersionKey contains the migration version.
+	VersionKey = capitan.NewInt64Key("migration_version")
+
+	// MigrationKey contains the migration name.
+	MigrationKey = capitan.NewStringKey("migration")
+
+	// DirectionKey is "up" or "down".
+	DirectionKey = capitan.NewStringKey("direction")
+)
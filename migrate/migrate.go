@@ -0,0 +1,425 @@
+// Package migrate applies versioned SQL migrations for soy-managed schemas.
+//
+// Migrations are plain SQL files read from an fs.FS, so they can be embedded in the binary:
+//
+//	migrations/
+//	    0001_create_users.up.sql
+//	    0001_create_users.down.sql
+//	    0002_add_user_age.up.sql
+//	    0002_add_user_age.down.sql
+//
+// Each file is executed as a single statement batch inside its own transaction, and the
+// applied version is recorded with a checksum of its up SQL in a tracking table. Runners
+// on PostgreSQL, MariaDB and SQL Server take a session lock first, so concurrent deploys
+// apply each migration once. On MariaDB, files with several statements need the driver's
+// multiStatements option.
+//
+// Example:
+//
+//	//go:embed migrations/*.sql
+//	var files embed.FS
+//
+//	sub, _ := fs.Sub(files, "migrations")
+//	m, err := migrate.New(db, postgres.New(), sub)
+//	if err != nil {
+//	    return err
+//	}
+//	applied, err := m.Up(ctx)
+package migrate
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"math"
+	"regexp"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/zoobzio/astql"
+	"github.com/zoobzio/capitan"
+	"github.com/zoobzio/soy"
+	"github.com/zoobzio/soy/internal/ddl"
+)
+
+// DefaultTable is the name of the table that records applied migrations.
+const DefaultTable = "soy_migrations"
+
+// DefaultSnapshotFile is the file, relative to the migrations FS, that holds the
+// DBML snapshot used by Scaffold.
+const DefaultSnapshotFile = "schema.snapshot.json"
+
+// migrationFile matches migration file names: <version>_<name>.<up|down>.sql.
+var migrationFile = regexp.MustCompile(`^(\d+)_([A-Za-z0-9_\-]+)\.(up|down)\.sql$`)
+
+// Migration is a single versioned schema change.
+type Migration struct {
+	Version int64
+	Name    string
+	Up      string
+	Down    string // Empty if the migration cannot be rolled back
+}
+
+// Checksum returns the SHA-256 of the up SQL, which is recorded when the migration is applied.
+func (m Migration) Checksum() string {
+	sum := sha256.Sum256([]byte(m.Up))
+	return hex.EncodeToString(sum[:])
+}
+
+// Load reads migrations from the root of fsys, ordered by version.
+// Files that do not follow the <version>_<name>.<up|down>.sql pattern are ignored.
+func Load(fsys fs.FS) ([]Migration, error) {
+	entries, err := fs.ReadDir(fsys, ".")
+	if err != nil {
+		return nil, fmt.Errorf("migrate: failed to read migrations: %w", err)
+	}
+
+	byVersion := make(map[int64]*Migration)
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		match := migrationFile.FindStringSubmatch(entry.Name())
+		if match == nil {
+			continue
+		}
+
+		version, err := strconv.ParseInt(match[1], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("migrate: invalid version in %q: %w", entry.Name(), err)
+		}
+		content, err := fs.ReadFile(fsys, entry.Name())
+		if err != nil {
+			return nil, fmt.Errorf("migrate: failed to read %q: %w", entry.Name(), err)
+		}
+
+		m, ok := byVersion[version]
+		if !ok {
+			m = &Migration{Version: version, Name: match[2]}
+			byVersion[version] = m
+		}
+		if m.Name != match[2] {
+			return nil, fmt.Errorf("migrate: version %d is used by %q and %q", version, m.Name, match[2])
+		}
+		if match[3] == "up" {
+			m.Up = string(content)
+		} else {
+			m.Down = string(content)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, m := range byVersion {
+		if m.Up == "" {
+			return nil, fmt.Errorf("migrate: version %d (%s) has no up migration", m.Version, m.Name)
+		}
+		migrations = append(migrations, *m)
+	}
+	slices.SortFunc(migrations, func(a, b Migration) int {
+		switch {
+		case a.Version < b.Version:
+			return -1
+		case a.Version > b.Version:
+			return 1
+		default:
+			return 0
+		}
+	})
+	return migrations, nil
+}
+
+// Status is a migration together with its state in the database.
+type Status struct {
+	Migration
+	Applied   bool
+	AppliedAt time.Time
+	Modified  bool // The file changed after it was applied
+}
+
+// Migrator applies and rolls back migrations against one database.
+type Migrator struct {
+	db           *sqlx.DB
+	dialect      ddl.Dialect
+	fsys         fs.FS
+	migrations   []Migration
+	table        string
+	snapshotFile string
+	dryRun       io.Writer
+}
+
+// New loads the migrations in fsys and returns a Migrator for db.
+// The renderer selects the dialect for the tracking table, locking and scaffolded SQL.
+func New(db *sqlx.DB, renderer astql.Renderer, fsys fs.FS) (*Migrator, error) {
+	if db == nil {
+		return nil, errors.New("migrate: db cannot be nil")
+	}
+	if renderer == nil {
+		return nil, soy.ErrNilRenderer
+	}
+	migrations, err := Load(fsys)
+	if err != nil {
+		return nil, err
+	}
+	return &Migrator{
+		db:           db,
+		dialect:      ddl.Of(renderer),
+		fsys:         fsys,
+		migrations:   migrations,
+		table:        DefaultTable,
+		snapshotFile: DefaultSnapshotFile,
+	}, nil
+}
+
+// TrackingTable sets the table that records applied migrations. Defaults to DefaultTable.
+func (m *Migrator) TrackingTable(name string) {
+	m.table = name
+}
+
+// SnapshotFile sets the snapshot file read by Scaffold. Defaults to DefaultSnapshotFile.
+func (m *Migrator) SnapshotFile(name string) {
+	m.snapshotFile = name
+}
+
+// DryRun makes Up, UpTo and Down write the SQL they would execute to w instead of running it.
+// Nothing is locked, created or recorded. Pass nil to execute again.
+func (m *Migrator) DryRun(w io.Writer) {
+	m.dryRun = w
+}
+
+// Migrations returns the loaded migrations, ordered by version.
+func (m *Migrator) Migrations() []Migration {
+	return slices.Clone(m.migrations)
+}
+
+// Status reports which migrations are applied and whether any changed since.
+func (m *Migrator) Status(ctx context.Context) ([]Status, error) {
+	applied, err := m.readApplied(ctx, m.db, true)
+	if err != nil {
+		return nil, err
+	}
+	statuses := make([]Status, len(m.migrations))
+	for i, mig := range m.migrations {
+		statuses[i] = Status{Migration: mig}
+		if rec, ok := applied[mig.Version]; ok {
+			statuses[i].Applied = true
+			statuses[i].AppliedAt = rec.AppliedAt
+			statuses[i].Modified = rec.Checksum != mig.Checksum()
+		}
+	}
+	return statuses, nil
+}
+
+// Up applies every pending migration in version order and returns the ones applied.
+func (m *Migrator) Up(ctx context.Context) ([]Migration, error) {
+	return m.UpTo(ctx, math.MaxInt64)
+}
+
+// UpTo applies pending migrations up to and including version.
+// Applied migrations whose files changed are rejected with a *ChecksumError.
+func (m *Migrator) UpTo(ctx context.Context, version int64) ([]Migration, error) {
+	return m.run(ctx, directionUp, func(applied map[int64]record) ([]Migration, error) {
+		if err := m.verifyChecksums(applied); err != nil {
+			return nil, err
+		}
+		var pending []Migration
+		for _, mig := range m.migrations {
+			if _, ok := applied[mig.Version]; !ok && mig.Version <= version {
+				pending = append(pending, mig)
+			}
+		}
+		return pending, nil
+	})
+}
+
+// Down rolls back the most recently applied steps migrations, newest first,
+// and returns the ones rolled back. steps must be at least 1.
+func (m *Migrator) Down(ctx context.Context, steps int) ([]Migration, error) {
+	if steps < 1 {
+		return nil, fmt.Errorf("migrate: steps must be at least 1, got %d", steps)
+	}
+	return m.run(ctx, directionDown, func(applied map[int64]record) ([]Migration, error) {
+		if err := m.verifyChecksums(applied); err != nil {
+			return nil, err
+		}
+		versions := make([]int64, 0, len(applied))
+		for v := range applied {
+			versions = append(versions, v)
+		}
+		slices.Sort(versions)
+		slices.Reverse(versions)
+
+		var rollback []Migration
+		for _, v := range versions[:min(steps, len(versions))] {
+			idx := slices.IndexFunc(m.migrations, func(mig Migration) bool { return mig.Version == v })
+			if idx < 0 {
+				return nil, fmt.Errorf("%w: version %d", ErrUnknownVersion, v)
+			}
+			if m.migrations[idx].Down == "" {
+				return nil, fmt.Errorf("%w: version %d (%s)", ErrNoDownMigration, v, m.migrations[idx].Name)
+			}
+			rollback = append(rollback, m.migrations[idx])
+		}
+		return rollback, nil
+	})
+}
+
+const (
+	directionUp   = "up"
+	directionDown = "down"
+)
+
+// record is a row of the tracking table.
+type record struct {
+	Version   int64     `db:"version"`
+	Checksum  string    `db:"checksum"`
+	AppliedAt time.Time `db:"applied_at"`
+}
+
+// run plans and executes migrations in one direction. Outside dry-run mode the whole
+// run holds the migration lock on a dedicated connection.
+func (m *Migrator) run(ctx context.Context, direction string, plan func(map[int64]record) ([]Migration, error)) ([]Migration, error) {
+	if m.dryRun != nil {
+		applied, err := m.readApplied(ctx, m.db, true)
+		if err != nil {
+			return nil, err
+		}
+		migrations, err := plan(applied)
+		if err != nil {
+			return nil, err
+		}
+		for _, mig := range migrations {
+			sql := mig.Up
+			if direction == directionDown {
+				sql = mig.Down
+			}
+			if _, err := fmt.Fprintf(m.dryRun, "-- %d_%s (%s)\n%s\n\n", mig.Version, mig.Name, direction, sql); err != nil {
+				return nil, fmt.Errorf("migrate: dry run output failed: %w", err)
+			}
+		}
+		return migrations, nil
+	}
+
+	conn, err := m.db.Connx(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("migrate: failed to acquire connection: %w", err)
+	}
+	defer func() { _ = conn.Close() }()
+
+	unlock, err := m.lock(ctx, conn)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	if _, err := conn.ExecContext(ctx, m.createTableSQL()); err != nil {
+		return nil, fmt.Errorf("migrate: failed to create tracking table: %w", err)
+	}
+	applied, err := m.readApplied(ctx, conn, false)
+	if err != nil {
+		return nil, err
+	}
+	migrations, err := plan(applied)
+	if err != nil {
+		return nil, err
+	}
+
+	for i, mig := range migrations {
+		if err := m.apply(ctx, conn, mig, direction); err != nil {
+			return migrations[:i], err
+		}
+	}
+	return migrations, nil
+}
+
+// apply executes one migration and updates the tracking table in the same transaction.
+func (m *Migrator) apply(ctx context.Context, conn *sqlx.Conn, mig Migration, direction string) error {
+	capitan.Debug(ctx, MigrationStarted,
+		VersionKey.Field(mig.Version),
+		MigrationKey.Field(mig.Name),
+		DirectionKey.Field(direction),
+	)
+	startTime := time.Now()
+
+	fail := func(err error) error {
+		capitan.Error(ctx, MigrationFailed,
+			VersionKey.Field(mig.Version),
+			MigrationKey.Field(mig.Name),
+			DirectionKey.Field(direction),
+			soy.DurationMsKey.Field(time.Since(startTime).Milliseconds()),
+			soy.ErrorKey.Field(err.Error()),
+		)
+		return &MigrationError{Version: mig.Version, Name: mig.Name, Direction: direction, Err: err}
+	}
+
+	tx, err := conn.BeginTxx(ctx, nil)
+	if err != nil {
+		return fail(err)
+	}
+
+	sql, track := mig.Up, m.db.Rebind(m.insertSQL())
+	args := []any{mig.Version, mig.Name, mig.Checksum(), time.Now().UTC()}
+	if direction == directionDown {
+		sql, track = mig.Down, m.db.Rebind(m.deleteSQL())
+		args = []any{mig.Version}
+	}
+
+	if _, err := tx.ExecContext(ctx, sql); err != nil {
+		_ = tx.Rollback()
+		return fail(err)
+	}
+	if _, err := tx.ExecContext(ctx, track, args...); err != nil {
+		_ = tx.Rollback()
+		return fail(err)
+	}
+	if err := tx.Commit(); err != nil {
+		return fail(err)
+	}
+
+	capitan.Info(ctx, MigrationCompleted,
+		VersionKey.Field(mig.Version),
+		MigrationKey.Field(mig.Name),
+		DirectionKey.Field(direction),
+		soy.DurationMsKey.Field(time.Since(startTime).Milliseconds()),
+	)
+	return nil
+}
+
+// verifyChecksums rejects applied migrations whose up SQL changed since they ran.
+func (m *Migrator) verifyChecksums(applied map[int64]record) error {
+	for _, mig := range m.migrations {
+		if rec, ok := applied[mig.Version]; ok && rec.Checksum != mig.Checksum() {
+			return &ChecksumError{Version: mig.Version, Name: mig.Name}
+		}
+	}
+	return nil
+}
+
+// readApplied loads the tracking table. With allowMissing, a missing table means
+// nothing is applied yet; this keeps Status and dry runs free of side effects.
+func (m *Migrator) readApplied(ctx context.Context, q sqlx.QueryerContext, allowMissing bool) (map[int64]record, error) {
+	if allowMissing {
+		var count int
+		if err := sqlx.GetContext(ctx, q, &count, m.db.Rebind(m.tableExistsSQL()), m.table); err != nil {
+			return nil, fmt.Errorf("migrate: failed to check tracking table: %w", err)
+		}
+		if count == 0 {
+			return map[int64]record{}, nil
+		}
+	}
+
+	var records []record
+	if err := sqlx.SelectContext(ctx, q, &records, m.selectSQL()); err != nil {
+		return nil, fmt.Errorf("migrate: failed to read tracking table: %w", err)
+	}
+	applied := make(map[int64]record, len(records))
+	for _, rec := range records {
+		applied[rec.Version] = rec
+	}
+	return applied, nil
+}
@@ -0,0 +1,308 @@
+package migrate
+
+import (
+	"bytes"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/zoobzio/astql/postgres"
+)
+
+// fakeDriver is a database/sql driver that keeps the tracking table in memory
+// and records every statement it executes.
+type fakeDriver struct {
+	mu      sync.Mutex
+	exists  bool
+	applied map[int64]string // version -> checksum
+	execs   []string
+}
+
+func (d *fakeDriver) Open(_ string) (driver.Conn, error) { return &fakeConn{d}, nil }
+
+func (d *fakeDriver) statements() []string {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return slices.Clone(d.execs)
+}
+
+type fakeConn struct{ d *fakeDriver }
+
+func (c *fakeConn) Prepare(query string) (driver.Stmt, error) {
+	return &fakeStmt{d: c.d, query: query}, nil
+}
+func (*fakeConn) Close() error              { return nil }
+func (*fakeConn) Begin() (driver.Tx, error) { return fakeTx{}, nil }
+
+type fakeTx struct{}
+
+func (fakeTx) Commit() error   { return nil }
+func (fakeTx) Rollback() error { return nil }
+
+type fakeStmt struct {
+	d     *fakeDriver
+	query string
+}
+
+func (*fakeStmt) Close() error  { return nil }
+func (*fakeStmt) NumInput() int { return -1 }
+
+func (s *fakeStmt) Exec(args []driver.Value) (driver.Result, error) {
+	s.d.mu.Lock()
+	defer s.d.mu.Unlock()
+	s.d.execs = append(s.d.execs, s.query)
+
+	switch {
+	case strings.Contains(s.query, "FAIL"):
+		return nil, errors.New("syntax error")
+	case strings.HasPrefix(s.query, "CREATE TABLE IF NOT EXISTS \"soy_migrations\""):
+		s.d.exists = true
+	case strings.HasPrefix(s.query, "INSERT INTO \"soy_migrations\""):
+		s.d.applied[args[0].(int64)] = args[2].(string)
+	case strings.HasPrefix(s.query, "DELETE FROM \"soy_migrations\""):
+		delete(s.d.applied, args[0].(int64))
+	}
+	return driver.RowsAffected(1), nil
+}
+
+func (s *fakeStmt) Query(_ []driver.Value) (driver.Rows, error) {
+	s.d.mu.Lock()
+	defer s.d.mu.Unlock()
+
+	if strings.Contains(s.query, "COUNT(*)") {
+		count := int64(0)
+		if s.d.exists {
+			count = 1
+		}
+		return &fakeRows{cols: []string{"count"}, data: [][]driver.Value{{count}}}, nil
+	}
+
+	rows := &fakeRows{cols: []string{"version", "checksum", "applied_at"}}
+	for version, checksum := range s.d.applied {
+		rows.data = append(rows.data, []driver.Value{version, checksum, time.Now()})
+	}
+	return rows, nil
+}
+
+type fakeRows struct {
+	cols []string
+	data [][]driver.Value
+}
+
+func (r *fakeRows) Columns() []string { return r.cols }
+func (*fakeRows) Close() error        { return nil }
+func (r *fakeRows) Next(dest []driver.Value) error {
+	if len(r.data) == 0 {
+		return io.EOF
+	}
+	copy(dest, r.data[0])
+	r.data = r.data[1:]
+	return nil
+}
+
+var fakeDriverSeq atomic.Int64
+
+func newFakeDB(t *testing.T) (*sqlx.DB, *fakeDriver) {
+	t.Helper()
+	drv := &fakeDriver{applied: map[int64]string{}}
+	name := fmt.Sprintf("soy_migrate_%d", fakeDriverSeq.Add(1))
+	sql.Register(name, drv)
+	sqlx.BindDriver(name, sqlx.DOLLAR)
+
+	db, err := sqlx.Open(name, "")
+	if err != nil {
+		t.Fatalf("sqlx.Open() failed: %v", err)
+	}
+	t.Cleanup(func() { _ = db.Close() })
+	return db, drv
+}
+
+func testMigrations() fstest.MapFS {
+	return fstest.MapFS{
+		"0001_create_users.up.sql":   {Data: []byte("CREATE TABLE users (id SERIAL PRIMARY KEY)")},
+		"0001_create_users.down.sql": {Data: []byte("DROP TABLE users")},
+		"0002_add_age.up.sql":        {Data: []byte("ALTER TABLE users ADD age INTEGER")},
+		"0002_add_age.down.sql":      {Data: []byte("ALTER TABLE users DROP COLUMN age")},
+		"0010_seed.up.sql":           {Data: []byte("INSERT INTO users DEFAULT VALUES")},
+		"README.md":                  {Data: []byte("ignored")},
+	}
+}
+
+func TestLoad(t *testing.T) {
+	migrations, err := Load(testMigrations())
+	if err != nil {
+		t.Fatalf("Load() failed: %v", err)
+	}
+
+	var versions []int64
+	for _, m := range migrations {
+		versions = append(versions, m.Version)
+	}
+	if !slices.Equal(versions, []int64{1, 2, 10}) {
+		t.Errorf("versions = %v, want [1 2 10]", versions)
+	}
+	if migrations[0].Name != "create_users" || migrations[0].Down != "DROP TABLE users" {
+		t.Errorf("unexpected migration: %+v", migrations[0])
+	}
+	if migrations[2].Down != "" {
+		t.Error("seed migration should have no down SQL")
+	}
+}
+
+func TestLoad_Errors(t *testing.T) {
+	tests := map[string]fstest.MapFS{
+		"down without up": {
+			"0001_a.down.sql": {Data: []byte("DROP TABLE a")},
+		},
+		"duplicate version": {
+			"0001_a.up.sql": {Data: []byte("SELECT 1")},
+			"0001_b.up.sql": {Data: []byte("SELECT 2")},
+		},
+	}
+	for name, fsys := range tests {
+		t.Run(name, func(t *testing.T) {
+			if _, err := Load(fsys); err == nil {
+				t.Error("expected error")
+			}
+		})
+	}
+}
+
+func TestMigrator_UpAndDown(t *testing.T) {
+	db, drv := newFakeDB(t)
+	m, err := New(db, postgres.New(), testMigrations())
+	if err != nil {
+		t.Fatalf("New() failed: %v", err)
+	}
+	ctx := t.Context()
+
+	applied, err := m.UpTo(ctx, 2)
+	if err != nil {
+		t.Fatalf("UpTo() failed: %v", err)
+	}
+	if len(applied) != 2 {
+		t.Fatalf("applied %d migrations, want 2", len(applied))
+	}
+	execs := drv.statements()
+	if !strings.HasPrefix(execs[0], "SELECT pg_advisory_lock(") {
+		t.Errorf("first statement should take the lock, got %q", execs[0])
+	}
+	if last := execs[len(execs)-1]; !strings.HasPrefix(last, "SELECT pg_advisory_unlock(") {
+		t.Errorf("last statement should release the lock, got %q", last)
+	}
+
+	applied, err = m.Up(ctx)
+	if err != nil {
+		t.Fatalf("Up() failed: %v", err)
+	}
+	if len(applied) != 1 || applied[0].Version != 10 {
+		t.Fatalf("Up() applied %+v, want only version 10", applied)
+	}
+
+	for _, steps := range []int{0, -1} {
+		before := len(drv.statements())
+		if _, err := m.Down(ctx, steps); err == nil || !strings.Contains(err.Error(), "steps must be at least 1") {
+			t.Errorf("Down(%d) error = %v", steps, err)
+		}
+		if len(drv.statements()) != before {
+			t.Errorf("Down(%d) should fail before taking the lock", steps)
+		}
+	}
+
+	// Version 10 has no down migration.
+	if _, err := m.Down(ctx, 1); !errors.Is(err, ErrNoDownMigration) {
+		t.Fatalf("Down() error = %v, want ErrNoDownMigration", err)
+	}
+
+	delete(drv.applied, 10)
+	rolledBack, err := m.Down(ctx, 5)
+	if err != nil {
+		t.Fatalf("Down() failed: %v", err)
+	}
+	if len(rolledBack) != 2 || rolledBack[0].Version != 2 || rolledBack[1].Version != 1 {
+		t.Errorf("Down() rolled back %+v, want versions 2 then 1", rolledBack)
+	}
+	if len(drv.applied) != 0 {
+		t.Errorf("tracking table should be empty, has %v", drv.applied)
+	}
+}
+
+func TestMigrator_ChecksumMismatch(t *testing.T) {
+	db, drv := newFakeDB(t)
+	drv.exists = true
+	drv.applied[1] = "stale"
+
+	m, err := New(db, postgres.New(), testMigrations())
+	if err != nil {
+		t.Fatalf("New() failed: %v", err)
+	}
+
+	statuses, err := m.Status(t.Context())
+	if err != nil {
+		t.Fatalf("Status() failed: %v", err)
+	}
+	if !statuses[0].Applied || !statuses[0].Modified || statuses[1].Applied {
+		t.Errorf("unexpected statuses: %+v", statuses)
+	}
+
+	if _, err := m.Up(t.Context()); !errors.Is(err, ErrChecksumMismatch) {
+		t.Errorf("Up() error = %v, want ErrChecksumMismatch", err)
+	}
+}
+
+func TestMigrator_Failure(t *testing.T) {
+	db, drv := newFakeDB(t)
+	fsys := testMigrations()
+	fsys["0002_add_age.up.sql"] = &fstest.MapFile{Data: []byte("FAIL")}
+
+	m, err := New(db, postgres.New(), fsys)
+	if err != nil {
+		t.Fatalf("New() failed: %v", err)
+	}
+
+	applied, err := m.Up(t.Context())
+	var migErr *MigrationError
+	if !errors.As(err, &migErr) || migErr.Version != 2 || migErr.Direction != "up" {
+		t.Fatalf("Up() error = %v, want MigrationError for version 2", err)
+	}
+	if len(applied) != 1 {
+		t.Errorf("applied %d migrations before the failure, want 1", len(applied))
+	}
+	if _, ok := drv.applied[2]; ok {
+		t.Error("failed migration must not be recorded")
+	}
+}
+
+func TestMigrator_DryRun(t *testing.T) {
+	db, drv := newFakeDB(t)
+	m, err := New(db, postgres.New(), testMigrations())
+	if err != nil {
+		t.Fatalf("New() failed: %v", err)
+	}
+
+	var out bytes.Buffer
+	m.DryRun(&out)
+	planned, err := m.Up(t.Context())
+	if err != nil {
+		t.Fatalf("Up() failed: %v", err)
+	}
+	if len(planned) != 3 {
+		t.Errorf("planned %d migrations, want 3", len(planned))
+	}
+	if !strings.Contains(out.String(), "-- 1_create_users (up)\nCREATE TABLE users") {
+		t.Errorf("unexpected dry run output:\n%s", out.String())
+	}
+	if execs := drv.statements(); len(execs) != 0 {
+		t.Errorf("dry run executed statements: %v", execs)
+	}
+}
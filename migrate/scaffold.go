@@ -0,0 +1,420 @@
+package migrate
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/zoobzio/dbml"
+	"github.com/zoobzio/soy"
+	"github.com/zoobzio/soy/internal/ddl"
+)
+
+// Model is a source of struct-derived schema. *soy.Soy[T] satisfies it.
+type Model interface {
+	DBML() (*dbml.Project, error)
+	IndexOptions() (map[string]soy.IndexOptions, error)
+}
+
+// schema is a DBML project with the options of its indexes that DBML cannot express,
+// keyed by table name and then index name. It is what a snapshot holds.
+type schema struct {
+	Project *dbml.Project
+	Indexes map[string]map[string]ddl.IndexOptions
+}
+
+func newSchema() *schema {
+	return &schema{Project: dbml.NewProject("soy"), Indexes: make(map[string]map[string]ddl.IndexOptions)}
+}
+
+// readSchema decodes a snapshot. Snapshots that hold only a DBML project have no index options.
+func readSchema(data []byte) (*schema, error) {
+	var s schema
+	if err := json.Unmarshal(data, &s); err != nil {
+		return nil, err
+	}
+	if s.Project == nil {
+		s.Project = dbml.NewProject("soy")
+		if err := s.Project.FromJSON(data); err != nil {
+			return nil, err
+		}
+	}
+	if s.Indexes == nil {
+		s.Indexes = make(map[string]map[string]ddl.IndexOptions)
+	}
+	return &s, nil
+}
+
+// Scaffold is a generated migration: the SQL that moves the schema from the last
+// snapshot to the current models and back, plus the snapshot to save with it.
+// The SQL is a starting point and should be reviewed before it is committed.
+type Scaffold struct {
+	Version      int64
+	Name         string
+	Up           string
+	Down         string
+	Snapshot     []byte // The current schema as DBML JSON, with the options of its indexes
+	SnapshotFile string
+}
+
+// Files returns the scaffold's file names and contents, relative to the migrations directory.
+func (s *Scaffold) Files() map[string][]byte {
+	base := fmt.Sprintf("%04d_%s", s.Version, s.Name)
+	return map[string][]byte{
+		base + ".up.sql":   []byte(s.Up),
+		base + ".down.sql": []byte(s.Down),
+		s.SnapshotFile:     s.Snapshot,
+	}
+}
+
+// Write writes the migration files and the updated snapshot into dir,
+// which should be the directory the migrations FS is built from.
+func (s *Scaffold) Write(dir string) error {
+	for name, content := range s.Files() {
+		if err := os.WriteFile(filepath.Join(dir, name), content, 0o644); err != nil { //nolint:gosec // migration files are source code
+			return fmt.Errorf("migrate: failed to write %s: %w", name, err)
+		}
+	}
+	return nil
+}
+
+// Scaffold diffs the schema of models against the last snapshot in the migrations FS
+// and returns the next migration. Without a snapshot every table is new.
+// Returns ErrNoChanges if the schema is unchanged.
+//
+// Example:
+//
+//	scaffold, err := m.Scaffold("add_posts", users, posts)
+//	if errors.Is(err, migrate.ErrNoChanges) {
+//	    return nil
+//	}
+//	err = scaffold.Write("migrations")
+func (m *Migrator) Scaffold(name string, models ...Model) (*Scaffold, error) {
+	if !migrationFile.MatchString("1_" + name + ".up.sql") {
+		return nil, fmt.Errorf("migrate: invalid migration name %q", name)
+	}
+
+	current := newSchema()
+	for _, model := range models {
+		project, err := model.DBML()
+		if err != nil {
+			return nil, err
+		}
+		options, err := model.IndexOptions()
+		if err != nil {
+			return nil, err
+		}
+		for key, table := range project.Tables {
+			if _, dup := current.Project.Tables[key]; dup {
+				return nil, fmt.Errorf("migrate: table %s is defined by more than one model", table.Name)
+			}
+			current.Project.Tables[key] = table
+			for _, idx := range table.Indexes {
+				name := ddl.IndexName(table.Name, idx)
+				if opts, ok := options[name]; ok {
+					if current.Indexes[table.Name] == nil {
+						current.Indexes[table.Name] = make(map[string]ddl.IndexOptions)
+					}
+					current.Indexes[table.Name][name] = opts
+				}
+			}
+		}
+		current.Project.Refs = append(current.Project.Refs, project.Refs...)
+		for key, enum := range project.Enums {
+			current.Project.Enums[key] = enum
+		}
+	}
+
+	previous := newSchema()
+	data, err := fs.ReadFile(m.fsys, m.snapshotFile)
+	switch {
+	case errors.Is(err, fs.ErrNotExist):
+	case err != nil:
+		return nil, fmt.Errorf("migrate: failed to read snapshot: %w", err)
+	default:
+		if previous, err = readSchema(data); err != nil {
+			return nil, fmt.Errorf("migrate: invalid snapshot %s: %w", m.snapshotFile, err)
+		}
+	}
+
+	up, err := diffSchema(m.dialect, previous, current)
+	if err != nil {
+		return nil, fmt.Errorf("migrate: %w", err)
+	}
+	if len(up) == 0 {
+		return nil, ErrNoChanges
+	}
+	down, err := diffSchema(m.dialect, current, previous)
+	if err != nil {
+		return nil, fmt.Errorf("migrate: %w", err)
+	}
+
+	snapshot, err := json.MarshalIndent(current, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("migrate: failed to encode snapshot: %w", err)
+	}
+
+	var version int64 = 1
+	if n := len(m.migrations); n > 0 {
+		version = m.migrations[n-1].Version + 1
+	}
+
+	return &Scaffold{
+		Version:      version,
+		Name:         name,
+		Up:           joinStatements(up),
+		Down:         joinStatements(down),
+		Snapshot:     snapshot,
+		SnapshotFile: m.snapshotFile,
+	}, nil
+}
+
+// diffSchema returns the statements that turn schema from into schema to.
+// New tables are created before existing ones change, and removed tables are dropped
+// last; both follow foreign key order. Enum types are created and extended before the
+// tables that use them, and dropped after. It fails for changes the dialect cannot render.
+func diffSchema(d ddl.Dialect, from, to *schema) ([]string, error) {
+	fromTables, toTables := tablesByName(from.Project), tablesByName(to.Project)
+
+	var created, changed, dropped []*dbml.Table
+	for name, table := range toTables {
+		if _, ok := fromTables[name]; ok {
+			changed = append(changed, table)
+		} else {
+			created = append(created, table)
+		}
+	}
+	for name, table := range fromTables {
+		if _, ok := toTables[name]; !ok {
+			dropped = append(dropped, table)
+		}
+	}
+
+	var stmts []string
+	for _, key := range slices.Sorted(maps.Keys(to.Project.Enums)) {
+		if prev, ok := from.Project.Enums[key]; ok {
+			stmts = append(stmts, diffEnum(d, prev, to.Project.Enums[key])...)
+		} else {
+			stmts = append(stmts, d.CreateEnum(to.Project.Enums[key]))
+		}
+	}
+	for _, table := range orderByReferences(created, to.Project.Refs) {
+		indexes, err := d.CreateIndexes(table, to.Indexes[table.Name])
+		if err != nil {
+			return nil, err
+		}
+		stmts = append(stmts, d.CreateTable(table, to.Project.Refs))
+		stmts = append(stmts, indexes...)
+	}
+	slices.SortFunc(changed, func(a, b *dbml.Table) int { return strings.Compare(a.Name, b.Name) })
+	for _, table := range changed {
+		diff, err := diffTable(d, fromTables[table.Name], table, from, to)
+		if err != nil {
+			return nil, err
+		}
+		stmts = append(stmts, diff...)
+	}
+	dropped = orderByReferences(dropped, from.Project.Refs)
+	slices.Reverse(dropped)
+	for _, table := range dropped {
+		stmts = append(stmts, d.DropTable(table.Name))
+	}
+	for _, key := range slices.Sorted(maps.Keys(from.Project.Enums)) {
+		if _, ok := to.Project.Enums[key]; !ok {
+			stmts = append(stmts, d.DropEnum(from.Project.Enums[key].Name))
+		}
+	}
+	return stmts, nil
+}
+
+// diffEnum returns the statements that turn an existing enum type from into to.
+// New values are added; PostgreSQL cannot remove enum values, so removals are left
+// as a TODO.
+func diffEnum(d ddl.Dialect, from, to *dbml.Enum) []string {
+	var stmts []string
+	for _, value := range to.Values {
+		if !slices.Contains(from.Values, value) {
+			stmts = append(stmts, d.AddEnumValue(to.Name, value))
+		}
+	}
+	for _, value := range from.Values {
+		if !slices.Contains(to.Values, value) {
+			stmts = append(stmts, fmt.Sprintf("-- TODO: remove value %s from enum %s; PostgreSQL requires recreating the type", ddl.SQLString(value), to.Name))
+		}
+	}
+	return stmts
+}
+
+// diffTable returns the statements that turn an existing table from into to.
+// fromSchema and toSchema are the schemas the tables belong to.
+func diffTable(d ddl.Dialect, from, to *dbml.Table, fromSchema, toSchema *schema) ([]string, error) {
+	fromRefs, toRefs := fromSchema.Project.Refs, toSchema.Project.Refs
+	fromIndexes, err := indexesByName(d, from, fromSchema.Indexes[from.Name])
+	if err != nil {
+		return nil, err
+	}
+	toIndexes, err := indexesByName(d, to, toSchema.Indexes[to.Name])
+	if err != nil {
+		return nil, err
+	}
+	fromColumns := make(map[string]*dbml.Column, len(from.Columns))
+	for _, col := range from.Columns {
+		fromColumns[col.Name] = col
+	}
+	toColumns := make(map[string]*dbml.Column, len(to.Columns))
+	for _, col := range to.Columns {
+		toColumns[col.Name] = col
+	}
+
+	var stmts []string
+	for _, name := range sortedKeys(fromIndexes) {
+		if toIndexes[name] != fromIndexes[name] {
+			stmts = append(stmts, d.DropIndex(from.Name, name))
+		}
+	}
+	for _, col := range from.Columns {
+		if _, ok := toColumns[col.Name]; !ok {
+			stmts = append(stmts, d.DropColumn(to.Name, col.Name))
+		}
+	}
+	for _, col := range to.Columns {
+		prev, ok := fromColumns[col.Name]
+		if !ok {
+			stmts = append(stmts, d.AddColumn(to.Name, col, toRefs))
+			continue
+		}
+		alter, err := d.AlterColumn(to.Name, prev, col)
+		if err != nil {
+			return nil, err
+		}
+		stmts = append(stmts, alter...)
+		if constraintsChanged(prev, col) ||
+			refString(ddl.ColumnRef(fromRefs, from.Name, col.Name)) != refString(ddl.ColumnRef(toRefs, to.Name, col.Name)) {
+			stmts = append(stmts, fmt.Sprintf("-- TODO: review constraint or comment changes on %s.%s", to.Name, col.Name))
+		}
+	}
+	for _, name := range sortedKeys(toIndexes) {
+		if fromIndexes[name] != toIndexes[name] {
+			stmts = append(stmts, toIndexes[name])
+		}
+	}
+	return stmts, nil
+}
+
+// constraintsChanged reports changes that AlterColumn does not render.
+func constraintsChanged(from, to *dbml.Column) bool {
+	return from.Settings.PrimaryKey != to.Settings.PrimaryKey ||
+		from.Settings.Unique != to.Settings.Unique ||
+		stringValue(from.Settings.Check) != stringValue(to.Settings.Check) ||
+		stringValue(from.Note) != stringValue(to.Note)
+}
+
+func tablesByName(project *dbml.Project) map[string]*dbml.Table {
+	tables := make(map[string]*dbml.Table, len(project.Tables))
+	for _, table := range project.Tables {
+		tables[table.Name] = table
+	}
+	return tables
+}
+
+// indexesByName maps index names to their CREATE INDEX statements, so a changed
+// index is detected by comparing statements.
+func indexesByName(d ddl.Dialect, table *dbml.Table, options map[string]ddl.IndexOptions) (map[string]string, error) {
+	indexes := make(map[string]string, len(table.Indexes))
+	for _, idx := range table.Indexes {
+		name := ddl.IndexName(table.Name, idx)
+		stmt, err := d.CreateIndex(table.Name, idx, options[name])
+		if err != nil {
+			return nil, err
+		}
+		if stmt != "" {
+			indexes[name] = stmt
+		}
+	}
+	return indexes, nil
+}
+
+// orderByReferences sorts tables by name, then moves each table after the tables it references.
+func orderByReferences(tables []*dbml.Table, refs []*dbml.Ref) []*dbml.Table {
+	slices.SortFunc(tables, func(a, b *dbml.Table) int { return strings.Compare(a.Name, b.Name) })
+
+	pending := make(map[string]*dbml.Table, len(tables))
+	for _, table := range tables {
+		pending[table.Name] = table
+	}
+
+	ordered := make([]*dbml.Table, 0, len(tables))
+	var visit func(table *dbml.Table)
+	visit = func(table *dbml.Table) {
+		if _, ok := pending[table.Name]; !ok {
+			return
+		}
+		delete(pending, table.Name)
+		for _, ref := range refs {
+			if ref.Left == nil || ref.Right == nil || ref.Left.Table != table.Name {
+				continue
+			}
+			if dep, ok := pending[ref.Right.Table]; ok {
+				visit(dep)
+			}
+		}
+		ordered = append(ordered, table)
+	}
+	for _, table := range tables {
+		visit(table)
+	}
+	return ordered
+}
+
+// joinStatements renders statements as a SQL script. Comment lines and statements
+// that already end in a semicolon are left as they are.
+func joinStatements(stmts []string) string {
+	var sb strings.Builder
+	for _, stmt := range stmts {
+		sb.WriteString(stmt)
+		switch {
+		case strings.HasSuffix(stmt, ";\n"):
+		case strings.HasPrefix(stmt, "--"):
+			sb.WriteString("\n")
+		default:
+			sb.WriteString(";\n")
+		}
+	}
+	return sb.String()
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	slices.Sort(keys)
+	return keys
+}
+
+func refString(ref *dbml.Ref) string {
+	if ref == nil {
+		return ""
+	}
+	s := fmt.Sprintf("%s %s.%s(%s)", ref.Type, ref.Right.Schema, ref.Right.Table, strings.Join(ref.Right.Columns, ","))
+	if ref.OnDelete != nil {
+		s += " delete:" + string(*ref.OnDelete)
+	}
+	if ref.OnUpdate != nil {
+		s += " update:" + string(*ref.OnUpdate)
+	}
+	return s
+}
+
+func stringValue(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
@@ -0,0 +1,362 @@
+package migrate
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/zoobzio/astql"
+	"github.com/zoobzio/astql/mariadb"
+	"github.com/zoobzio/astql/mssql"
+	"github.com/zoobzio/astql/postgres"
+	"github.com/zoobzio/astql/sqlite"
+	"github.com/zoobzio/soy"
+)
+
+type scaffoldUserV1 struct {
+	ID    int    `db:"id" type:"serial" constraints:"primary_key"`
+	Email string `db:"email" type:"text" constraints:"not_null,unique"`
+	Name  string `db:"name" type:"text"`
+}
+
+type scaffoldUserV2 struct {
+	ID    int    `db:"id" type:"serial" constraints:"primary_key"`
+	Email string `db:"email" type:"varchar(255)" constraints:"not_null,unique"`
+	Age   *int   `db:"age" type:"integer" index:"idx_users_age"`
+}
+
+type scaffoldPost struct {
+	ID     int `db:"id" type:"serial" constraints:"primary_key"`
+	UserID int `db:"user_id" type:"integer" constraints:"not_null" references:"users(id) on_delete:cascade"`
+}
+
+type scaffoldPostV2 struct {
+	ID       int  `db:"id" type:"serial" constraints:"primary_key"`
+	UserID   int  `db:"user_id" type:"integer" constraints:"not_null" references:"users(id) on_delete:restrict"`
+	EditorID *int `db:"editor_id" type:"integer" references:"users(id) on_delete:set_null"`
+}
+
+type scaffoldSession struct {
+	ID        int     `db:"id" type:"serial" constraints:"primary_key"`
+	Token     string  `db:"token" type:"text" index:"idx_sessions_live,where:revoked_at IS NULL"`
+	RevokedAt *string `db:"revoked_at" type:"text"`
+}
+
+type scaffoldSessionV2 struct {
+	ID        int     `db:"id" type:"serial" constraints:"primary_key"`
+	Token     string  `db:"token" type:"text" index:"idx_sessions_live,where:revoked_at IS NULL AND token <> ''"`
+	RevokedAt *string `db:"revoked_at" type:"text"`
+}
+
+type scaffoldTaskV1 struct {
+	ID     int    `db:"id" type:"integer" constraints:"primary_key"`
+	Status string `db:"status" type:"text"`
+}
+
+type scaffoldTaskV2 struct {
+	ID     int    `db:"id" type:"integer" constraints:"primary_key"`
+	Status string `db:"status" type:"text" default:"'open'"`
+}
+
+type scaffoldArticleV1 struct {
+	ID     int    `db:"id" type:"serial" constraints:"primary_key"`
+	Status string `db:"status" enum:"draft,published"`
+}
+
+type scaffoldArticleV2 struct {
+	ID     int    `db:"id" type:"serial" constraints:"primary_key"`
+	Status string `db:"status" enum:"draft,published,archived"`
+}
+
+func newModel[T any](t *testing.T, table string) Model {
+	t.Helper()
+	s, err := soy.New[T](&sqlx.DB{}, table, postgres.New())
+	if err != nil {
+		t.Fatalf("soy.New() failed: %v", err)
+	}
+	return s
+}
+
+func TestScaffold(t *testing.T) {
+	db, _ := newFakeDB(t)
+	fsys := fstest.MapFS{}
+
+	m, err := New(db, postgres.New(), fsys)
+	if err != nil {
+		t.Fatalf("New() failed: %v", err)
+	}
+	first, err := m.Scaffold("create_users", newModel[scaffoldUserV1](t, "users"))
+	if err != nil {
+		t.Fatalf("Scaffold() failed: %v", err)
+	}
+	if first.Version != 1 || !strings.HasPrefix(first.Up, `CREATE TABLE "users"`) {
+		t.Errorf("unexpected first scaffold: version %d\n%s", first.Version, first.Up)
+	}
+	if first.Down != "DROP TABLE \"users\";\n" {
+		t.Errorf("unexpected first down:\n%s", first.Down)
+	}
+
+	for name, content := range first.Files() {
+		fsys[name] = &fstest.MapFile{Data: content}
+	}
+	m, err = New(db, postgres.New(), fsys)
+	if err != nil {
+		t.Fatalf("New() failed: %v", err)
+	}
+
+	if _, err := m.Scaffold("noop", newModel[scaffoldUserV1](t, "users")); !errors.Is(err, ErrNoChanges) {
+		t.Errorf("Scaffold() error = %v, want ErrNoChanges", err)
+	}
+
+	second, err := m.Scaffold("add_posts", newModel[scaffoldUserV2](t, "users"), newModel[scaffoldPost](t, "posts"))
+	if err != nil {
+		t.Fatalf("Scaffold() failed: %v", err)
+	}
+	if second.Version != 2 {
+		t.Errorf("Version = %d, want 2", second.Version)
+	}
+
+	wantUp := []string{
+		`CREATE TABLE "posts"`,
+		`FOREIGN KEY ("user_id") REFERENCES "users" ("id") ON DELETE CASCADE`,
+		`ALTER TABLE "users" DROP COLUMN "name";`,
+		`ALTER TABLE "users" ADD "age" integer;`,
+		`ALTER TABLE "users" ALTER COLUMN "email" TYPE varchar(255);`,
+		`CREATE INDEX "idx_users_age" ON "users" ("age");`,
+	}
+	for _, part := range wantUp {
+		if !strings.Contains(second.Up, part) {
+			t.Errorf("up missing %q:\n%s", part, second.Up)
+		}
+	}
+
+	wantDown := []string{
+		`DROP INDEX "idx_users_age";`,
+		`ALTER TABLE "users" DROP COLUMN "age";`,
+		`ALTER TABLE "users" ADD "name" text;`,
+		`ALTER TABLE "users" ALTER COLUMN "email" TYPE text;`,
+		`DROP TABLE "posts";`,
+	}
+	for _, part := range wantDown {
+		if !strings.Contains(second.Down, part) {
+			t.Errorf("down missing %q:\n%s", part, second.Down)
+		}
+	}
+	if strings.Index(second.Down, `DROP INDEX "idx_users_age"`) > strings.Index(second.Down, `DROP COLUMN "age"`) {
+		t.Errorf("index must be dropped before its column:\n%s", second.Down)
+	}
+}
+
+func TestScaffold_References(t *testing.T) {
+	db, _ := newFakeDB(t)
+	fsys := fstest.MapFS{}
+
+	m, err := New(db, postgres.New(), fsys)
+	if err != nil {
+		t.Fatalf("New() failed: %v", err)
+	}
+	first, err := m.Scaffold("init", newModel[scaffoldUserV1](t, "users"), newModel[scaffoldPost](t, "posts"))
+	if err != nil {
+		t.Fatalf("Scaffold() failed: %v", err)
+	}
+	for name, content := range first.Files() {
+		fsys[name] = &fstest.MapFile{Data: content}
+	}
+	m, err = New(db, postgres.New(), fsys)
+	if err != nil {
+		t.Fatalf("New() failed: %v", err)
+	}
+
+	second, err := m.Scaffold("post_editors", newModel[scaffoldUserV1](t, "users"), newModel[scaffoldPostV2](t, "posts"))
+	if err != nil {
+		t.Fatalf("Scaffold() failed: %v", err)
+	}
+	wantUp := []string{
+		`ALTER TABLE "posts" ADD "editor_id" integer REFERENCES "users" ("id") ON DELETE SET NULL;`,
+		`-- TODO: review constraint or comment changes on posts.user_id`,
+	}
+	for _, part := range wantUp {
+		if !strings.Contains(second.Up, part) {
+			t.Errorf("up missing %q:\n%s", part, second.Up)
+		}
+	}
+}
+
+func TestScaffold_Enums(t *testing.T) {
+	db, _ := newFakeDB(t)
+	fsys := fstest.MapFS{}
+
+	m, err := New(db, postgres.New(), fsys)
+	if err != nil {
+		t.Fatalf("New() failed: %v", err)
+	}
+	first, err := m.Scaffold("create_articles", newModel[scaffoldArticleV1](t, "articles"))
+	if err != nil {
+		t.Fatalf("Scaffold() failed: %v", err)
+	}
+	if !strings.HasPrefix(first.Up, `CREATE TYPE "articles_status" AS ENUM ('draft', 'published');`+"\nCREATE TABLE") {
+		t.Errorf("enum type must be created before its table:\n%s", first.Up)
+	}
+	if first.Down != "DROP TABLE \"articles\";\nDROP TYPE \"articles_status\";\n" {
+		t.Errorf("unexpected down:\n%s", first.Down)
+	}
+
+	for name, content := range first.Files() {
+		fsys[name] = &fstest.MapFile{Data: content}
+	}
+	m, err = New(db, postgres.New(), fsys)
+	if err != nil {
+		t.Fatalf("New() failed: %v", err)
+	}
+
+	second, err := m.Scaffold("archive_articles", newModel[scaffoldArticleV2](t, "articles"))
+	if err != nil {
+		t.Fatalf("Scaffold() failed: %v", err)
+	}
+	if second.Up != "ALTER TYPE \"articles_status\" ADD VALUE 'archived';\n" {
+		t.Errorf("unexpected up:\n%s", second.Up)
+	}
+	if !strings.Contains(second.Down, "-- TODO: remove value 'archived' from enum articles_status") {
+		t.Errorf("unexpected down:\n%s", second.Down)
+	}
+}
+
+func TestScaffold_IndexOptions(t *testing.T) {
+	db, _ := newFakeDB(t)
+	fsys := fstest.MapFS{}
+
+	m, err := New(db, postgres.New(), fsys)
+	if err != nil {
+		t.Fatalf("New() failed: %v", err)
+	}
+	first, err := m.Scaffold("create_sessions", newModel[scaffoldSession](t, "sessions"))
+	if err != nil {
+		t.Fatalf("Scaffold() failed: %v", err)
+	}
+	if !strings.Contains(first.Up, `CREATE INDEX "idx_sessions_live" ON "sessions" ("token") WHERE revoked_at IS NULL;`) {
+		t.Errorf("unexpected up:\n%s", first.Up)
+	}
+	if !strings.Contains(string(first.Snapshot), "revoked_at IS NULL") {
+		t.Errorf("snapshot is missing the index predicate:\n%s", first.Snapshot)
+	}
+
+	for name, content := range first.Files() {
+		fsys[name] = &fstest.MapFile{Data: content}
+	}
+	m, err = New(db, postgres.New(), fsys)
+	if err != nil {
+		t.Fatalf("New() failed: %v", err)
+	}
+	if _, err := m.Scaffold("noop", newModel[scaffoldSession](t, "sessions")); !errors.Is(err, ErrNoChanges) {
+		t.Errorf("Scaffold() error = %v, want ErrNoChanges", err)
+	}
+
+	second, err := m.Scaffold("narrow_sessions", newModel[scaffoldSessionV2](t, "sessions"))
+	if err != nil {
+		t.Fatalf("Scaffold() failed: %v", err)
+	}
+	if !strings.Contains(second.Up, `DROP INDEX "idx_sessions_live";`) ||
+		!strings.Contains(second.Up, `WHERE revoked_at IS NULL AND token <> '';`) {
+		t.Errorf("a changed predicate should recreate the index:\n%s", second.Up)
+	}
+
+	t.Run("DBML-only snapshots have no index options", func(t *testing.T) {
+		project, err := newModel[scaffoldUserV1](t, "users").DBML()
+		if err != nil {
+			t.Fatalf("DBML() failed: %v", err)
+		}
+		data, err := project.ToJSON()
+		if err != nil {
+			t.Fatalf("ToJSON() failed: %v", err)
+		}
+		previous, err := readSchema(data)
+		if err != nil {
+			t.Fatalf("readSchema() failed: %v", err)
+		}
+		if len(previous.Project.Tables) != 1 || len(previous.Indexes) != 0 {
+			t.Errorf("readSchema() = %+v", previous)
+		}
+	})
+}
+
+func TestScaffold_InvalidName(t *testing.T) {
+	db, _ := newFakeDB(t)
+	m, err := New(db, postgres.New(), fstest.MapFS{})
+	if err != nil {
+		t.Fatalf("New() failed: %v", err)
+	}
+	if _, err := m.Scaffold("add posts", newModel[scaffoldPost](t, "posts")); err == nil {
+		t.Error("expected error for name with spaces")
+	}
+}
+
+func TestScaffold_Unsupported(t *testing.T) {
+	db, _ := newFakeDB(t)
+
+	t.Run("MariaDB partial index", func(t *testing.T) {
+		m, err := New(db, mariadb.New(), fstest.MapFS{})
+		if err != nil {
+			t.Fatalf("New() failed: %v", err)
+		}
+		if _, err := m.Scaffold("add_sessions", newModel[scaffoldSession](t, "sessions")); err == nil || !strings.Contains(err.Error(), "partial index idx_sessions_live") {
+			t.Errorf("Scaffold() error = %v", err)
+		}
+	})
+
+	// scaffoldChange scaffolds from, saves it, then scaffolds to against it.
+	scaffoldChange := func(t *testing.T, renderer astql.Renderer, from, to Model) error {
+		t.Helper()
+		fsys := fstest.MapFS{}
+		m, err := New(db, renderer, fsys)
+		if err != nil {
+			t.Fatalf("New() failed: %v", err)
+		}
+		first, err := m.Scaffold("create_tasks", from)
+		if err != nil {
+			t.Fatalf("Scaffold() failed: %v", err)
+		}
+		for name, content := range first.Files() {
+			fsys[name] = &fstest.MapFile{Data: content}
+		}
+		if m, err = New(db, renderer, fsys); err != nil {
+			t.Fatalf("New() failed: %v", err)
+		}
+		_, err = m.Scaffold("change_tasks", to)
+		return err
+	}
+
+	t.Run("SQL Server default change", func(t *testing.T) {
+		err := scaffoldChange(t, mssql.New(), newModel[scaffoldTaskV1](t, "tasks"), newModel[scaffoldTaskV2](t, "tasks"))
+		if err == nil || !strings.Contains(err.Error(), "cannot change the default of tasks.status") {
+			t.Errorf("Scaffold() error = %v", err)
+		}
+	})
+
+	t.Run("SQLite column change", func(t *testing.T) {
+		err := scaffoldChange(t, sqlite.New(), newModel[scaffoldUserV1](t, "tasks"), newModel[scaffoldUserV2](t, "tasks"))
+		if err == nil || !strings.Contains(err.Error(), "cannot alter tasks.email") {
+			t.Errorf("Scaffold() error = %v", err)
+		}
+	})
+}
+
+func TestOrderByReferences(t *testing.T) {
+	db, _ := newFakeDB(t)
+	m, err := New(db, postgres.New(), fstest.MapFS{})
+	if err != nil {
+		t.Fatalf("New() failed: %v", err)
+	}
+	// posts sorts before users but references it.
+	s, err := m.Scaffold("init", newModel[scaffoldPost](t, "posts"), newModel[scaffoldUserV1](t, "users"))
+	if err != nil {
+		t.Fatalf("Scaffold() failed: %v", err)
+	}
+	if strings.Index(s.Up, `CREATE TABLE "users"`) > strings.Index(s.Up, `CREATE TABLE "posts"`) {
+		t.Errorf("users must be created before posts:\n%s", s.Up)
+	}
+	if strings.Index(s.Down, `DROP TABLE "posts"`) > strings.Index(s.Down, `DROP TABLE "users"`) {
+		t.Errorf("posts must be dropped before users:\n%s", s.Down)
+	}
+}
@@ -0,0 +1,286 @@
+package soy
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"slices"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/zoobzio/astql"
+)
+
+// DistanceMetric is a pgvector distance operator.
+// Smaller distances are nearer for every metric.
+type DistanceMetric string
+
+// Distance metric constants.
+const (
+	DistanceL2           DistanceMetric = "<->"
+	DistanceInnerProduct DistanceMetric = "<#>" // Negative inner product
+	DistanceCosine       DistanceMetric = "<=>"
+	DistanceL1           DistanceMetric = "<+>"
+)
+
+// distanceMetrics are the supported distance metrics. Their ASTQL operators are the
+// vector operators of operatorMap.
+var distanceMetrics = []DistanceMetric{DistanceL2, DistanceInnerProduct, DistanceCosine, DistanceL1}
+
+// distanceOperator returns the ASTQL operator of a distance metric.
+func distanceOperator(metric DistanceMetric) (astql.Operator, error) {
+	if !slices.Contains(distanceMetrics, metric) {
+		return "", newOperatorUsageError(string(metric), fmt.Sprintf("invalid distance metric %q, supported: <->, <#>, <=>, <+>", metric))
+	}
+	return operatorMap[string(metric)], nil
+}
+
+// nearestDistanceAlias is the result column that Nearest selects the distance as.
+const nearestDistanceAlias = "soy_distance"
+
+// opVectorWithin is the stand-in operator for distance thresholds. It renders as
+// "field soy_within:<metric>:<vector param> :max_param" and is expanded by the
+// extensionRenderer to "field <metric> :vector_param <= :max_param".
+const opVectorWithin = "soy_within"
+
+var vectorWithin = regexp.MustCompile(`((?:[a-z]\.)?"(?:[^"]|"")*") soy_within:(<->|<#>|<=>|<\+>):([A-Za-z_][A-Za-z0-9_]*) (:[A-Za-z_][A-Za-z0-9_]*)`)
+
+// expandVectorWithin rewrites the distance threshold stand-ins in rendered SQL.
+func expandVectorWithin(sql string) string {
+	return vectorWithin.ReplaceAllString(sql, "$1 $2 :$3 <= $4")
+}
+
+// Scored is a record found by a nearest-neighbour search, with its distance from the
+// query vector.
+type Scored[T any] struct {
+	Record   *T
+	Distance float64
+}
+
+// vectorSetting is a pgvector setting applied with SET LOCAL before a search.
+type vectorSetting struct {
+	name  string
+	value int
+}
+
+// Nearest builds a k-nearest-neighbour search over a vector column (PostgreSQL with pgvector).
+// It is created by Query.Nearest and keeps the query's conditions and selected fields.
+type Nearest[T any] struct {
+	query    *Query[T]
+	field    string
+	param    string
+	metric   DistanceMetric
+	settings []vectorSetting
+	noScan   bool // skips OnScan, for callers that run it once per merged record
+	err      error
+}
+
+// Nearest finds the k records whose vector field is nearest to the vector in vectorParam,
+// nearest first, with their distances. The query's conditions filter the candidates, and
+// any ordering it has breaks ties. The param takes a Vector, HalfVector or SparseVector
+// matching the column type.
+//
+// Example:
+//
+//	results, err := soy.Query().
+//	    Where("category", "=", "category").
+//	    Nearest("embedding", "query_vec", soy.DistanceCosine, 10).
+//	    Exec(ctx, map[string]any{"category": "docs", "query_vec": soy.Vector(embedding)})
+//	// SELECT *, "embedding" <=> :query_vec AS "soy_distance" FROM ...
+//	// WHERE "category" = :category ORDER BY "embedding" <=> :query_vec ASC LIMIT 10
+//	for _, r := range results {
+//	    fmt.Println(r.Record.Title, r.Distance)
+//	}
+func (qb *Query[T]) Nearest(field, vectorParam string, metric DistanceMetric, k int) *Nearest[T] {
+	nb := &Nearest[T]{query: qb.Clone(), field: field, param: vectorParam, metric: metric}
+	if nb.query.err != nil {
+		nb.err = nb.query.err
+		return nb
+	}
+	nb.query.builder, nb.err = nearestImpl(nb.query.instance, nb.query.builder, field, vectorParam, metric, k)
+	return nb
+}
+
+// nearestImpl selects the distance of field from the vector in param, orders by it and
+// limits the results to k.
+func nearestImpl(instance *astql.ASTQL, builder *astql.Builder, field, param string, metric DistanceMetric, k int) (*astql.Builder, error) {
+	op, err := distanceOperator(metric)
+	if err != nil {
+		return builder, err
+	}
+	if schema := schemaOf(instance); schema != nil && schema.dialect != dialectPostgres {
+		return builder, newFieldUsageError(field, "nearest-neighbour search requires PostgreSQL")
+	}
+	if k < 1 {
+		return builder, fmt.Errorf("nearest-neighbour search needs k of at least 1, got %d", k)
+	}
+
+	f, err := instance.TryF(field)
+	if err != nil {
+		return builder, newFieldError(field, err)
+	}
+
+	p, err := instance.TryP(param)
+	if err != nil {
+		return builder, newParamError(param, err)
+	}
+
+	builder = builder.
+		SelectBinaryExpr(f, op, p, nearestDistanceAlias).
+		OrderByExpr(f, op, p, astql.ASC).
+		Limit(k)
+	return orderByFirst(builder), nil
+}
+
+// orderByFirst moves the last ORDER BY entry of builder before the others, so a
+// ranking orders first and existing orderings break its ties.
+func orderByFirst(builder *astql.Builder) *astql.Builder {
+	ast := builder.GetAST()
+	if n := len(ast.Ordering); n > 1 {
+		last := ast.Ordering[n-1]
+		copy(ast.Ordering[1:], ast.Ordering[:n-1])
+		ast.Ordering[0] = last
+	}
+	return builder
+}
+
+// MaxDistance keeps only records within the distance in maxParam of the query vector.
+//
+// Example:
+//
+//	.Nearest("embedding", "query_vec", soy.DistanceCosine, 10).
+//	MaxDistance("max_distance")
+//	// WHERE "embedding" <=> :query_vec <= :max_distance
+func (nb *Nearest[T]) MaxDistance(maxParam string) *Nearest[T] {
+	if nb.err != nil {
+		return nb
+	}
+	nb.query.builder, nb.err = maxDistanceImpl(nb.query.instance, nb.query.builder, nb.field, nb.param, nb.metric, maxParam)
+	return nb
+}
+
+// maxDistanceImpl adds a WHERE condition that the distance of field from the vector in
+// param is at most the value of maxParam.
+func maxDistanceImpl(instance *astql.ASTQL, builder *astql.Builder, field, param string, metric DistanceMetric, maxParam string) (*astql.Builder, error) {
+	f, err := instance.TryF(field)
+	if err != nil {
+		return builder, newFieldError(field, err)
+	}
+
+	p, err := instance.TryP(maxParam)
+	if err != nil {
+		return builder, newParamError(maxParam, err)
+	}
+
+	op := astql.Operator(fmt.Sprintf("%s:%s:%s", opVectorWithin, metric, param))
+	condition, err := instance.TryC(f, op, p)
+	if err != nil {
+		return builder, newConditionError(err)
+	}
+	return builder.Where(condition), nil
+}
+
+// EfSearch sets hnsw.ef_search, the size of the candidate list of HNSW index scans,
+// for this search. Larger values trade speed for recall; it must be at least k to
+// return k results.
+func (nb *Nearest[T]) EfSearch(n int) *Nearest[T] {
+	return nb.set("hnsw.ef_search", n)
+}
+
+// Probes sets ivfflat.probes, the number of lists IVFFlat index scans visit, for this
+// search. Larger values trade speed for recall.
+func (nb *Nearest[T]) Probes(n int) *Nearest[T] {
+	return nb.set("ivfflat.probes", n)
+}
+
+// set records a setting to apply with SET LOCAL, replacing an earlier value.
+func (nb *Nearest[T]) set(name string, value int) *Nearest[T] {
+	if nb.err != nil {
+		return nb
+	}
+	if value < 1 {
+		nb.err = fmt.Errorf("%s must be at least 1, got %d", name, value)
+		return nb
+	}
+	nb.settings = slices.DeleteFunc(nb.settings, func(s vectorSetting) bool { return s.name == name })
+	nb.settings = append(nb.settings, vectorSetting{name: name, value: value})
+	return nb
+}
+
+// Exec runs the search and returns the records nearest first, with their distances.
+// Settings are applied with SET LOCAL, so when the Soy was created with a *sqlx.DB a
+// search with settings runs in its own transaction.
+func (nb *Nearest[T]) Exec(ctx context.Context, params map[string]any) ([]Scored[T], error) {
+	return nb.exec(ctx, nb.query.soy.execer(), params)
+}
+
+// ExecTx runs the search within a transaction. Settings stay in effect until the
+// transaction ends.
+func (nb *Nearest[T]) ExecTx(ctx context.Context, tx *sqlx.Tx, params map[string]any) ([]Scored[T], error) {
+	return nb.exec(ctx, tx, params)
+}
+
+// exec is the internal execution method used by both Exec and ExecTx.
+func (nb *Nearest[T]) exec(ctx context.Context, execer sqlx.ExtContext, params map[string]any) ([]Scored[T], error) {
+	params = nb.query.soy.bindParams(params)
+	if nb.err != nil {
+		return nil, fmt.Errorf("nearest query has errors: %w", nb.err)
+	}
+
+	result, err := nb.query.renderFor(params)
+	if err != nil {
+		return nil, err
+	}
+
+	// SET LOCAL only lasts for a transaction, so a bare connection pool needs one
+	if beginner, ok := execer.(txBeginner); ok && len(nb.settings) > 0 {
+		tx, err := beginner.BeginTxx(ctx, nil)
+		if err != nil {
+			return nil, fmt.Errorf("failed to begin nearest-neighbour search transaction: %w", err)
+		}
+		defer func() { _ = tx.Rollback() }()
+
+		scored, err := nb.search(ctx, tx, result.SQL, params)
+		if err != nil {
+			return nil, err
+		}
+		if err := tx.Commit(); err != nil {
+			return nil, fmt.Errorf("failed to commit nearest-neighbour search transaction: %w", err)
+		}
+		return scored, nil
+	}
+	return nb.search(ctx, execer, result.SQL, params)
+}
+
+// search applies the settings and runs the rendered search.
+func (nb *Nearest[T]) search(ctx context.Context, execer sqlx.ExtContext, sql string, params map[string]any) ([]Scored[T], error) {
+	for _, s := range nb.settings {
+		if _, err := execer.ExecContext(ctx, fmt.Sprintf("SET LOCAL %s = %d", s.name, s.value)); err != nil {
+			return nil, fmt.Errorf("failed to set %s: %w", s.name, err)
+		}
+	}
+	var onScan func(context.Context, *T) error
+	if !nb.noScan {
+		onScan = func(ctx context.Context, result *T) error {
+			return nb.query.soy.callOnScan(ctx, result)
+		}
+	}
+	return execScoredRows[T](ctx, execer, nb.query.soy.statements(), dialectOf(nb.query.soy.renderer()), sql, params, nb.query.soy.getTableName(), "QUERY_NEAREST", nearestDistanceAlias, onScan)
+}
+
+// Render builds and renders the search to SQL with parameter placeholders.
+// Settings are not part of the rendered SQL.
+func (nb *Nearest[T]) Render() (*astql.QueryResult, error) {
+	if nb.err != nil {
+		return nil, fmt.Errorf("nearest query has errors: %w", nb.err)
+	}
+	return nb.query.Render()
+}
+
+// MustRender is like Render but panics on error.
+func (nb *Nearest[T]) MustRender() *astql.QueryResult {
+	result, err := nb.Render()
+	if err != nil {
+		panic(err)
+	}
+	return result
+}
@@ -0,0 +1,200 @@
+package soy
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/zoobzio/astql/postgres"
+	"github.com/zoobzio/astql/sqlite"
+)
+
+type nearestTestDoc struct {
+	ID        int    `db:"id" type:"serial" constraints:"primarykey"`
+	Category  string `db:"category" type:"text"`
+	Embedding Vector `db:"embedding" type:"vector(3)"`
+}
+
+// nearestDriver is a database/sql driver that records the statements it executes and
+// returns fixed rows for every query, or the rows of respond when it is set. Exec fails
+// with the error of execErr when it returns one.
+type nearestDriver struct {
+	mu      sync.Mutex
+	log     []string
+	cols    []string
+	data    [][]driver.Value
+	respond func(query string) ([]string, [][]driver.Value)
+	execErr func(args []driver.Value) error
+}
+
+func (d *nearestDriver) record(s string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.log = append(d.log, s)
+}
+
+func (d *nearestDriver) Open(_ string) (driver.Conn, error) { return nearestConn{d}, nil }
+
+type nearestConn struct{ driver *nearestDriver }
+
+func (c nearestConn) Prepare(query string) (driver.Stmt, error) {
+	return nearestStmt{driver: c.driver, query: query}, nil
+}
+func (nearestConn) Close() error { return nil }
+func (c nearestConn) Begin() (driver.Tx, error) {
+	c.driver.record("BEGIN")
+	return nearestTx(c), nil
+}
+
+type nearestTx struct{ driver *nearestDriver }
+
+func (t nearestTx) Commit() error   { t.driver.record("COMMIT"); return nil }
+func (t nearestTx) Rollback() error { t.driver.record("ROLLBACK"); return nil }
+
+type nearestStmt struct {
+	driver *nearestDriver
+	query  string
+}
+
+func (nearestStmt) Close() error  { return nil }
+func (nearestStmt) NumInput() int { return -1 }
+func (s nearestStmt) Exec(args []driver.Value) (driver.Result, error) {
+	s.driver.record(s.query)
+	if s.driver.execErr != nil {
+		if err := s.driver.execErr(args); err != nil {
+			return nil, err
+		}
+	}
+	return driver.RowsAffected(0), nil
+}
+func (s nearestStmt) Query(args []driver.Value) (driver.Rows, error) {
+	s.driver.record(fmt.Sprintf("QUERY %v", args))
+	if s.driver.respond != nil {
+		cols, data := s.driver.respond(s.query)
+		return &verifyRows{cols: cols, data: data}, nil
+	}
+	return &verifyRows{cols: s.driver.cols, data: slices.Clone(s.driver.data)}, nil
+}
+
+func TestNearest_Render(t *testing.T) {
+	s, err := New[nearestTestDoc](nil, "docs", postgres.New())
+	if err != nil {
+		t.Fatalf("New() failed: %v", err)
+	}
+
+	result, err := s.Query().
+		Where("category", "=", "category").
+		Nearest("embedding", "q", DistanceCosine, 5).
+		MaxDistance("max_distance").
+		EfSearch(40).
+		Render()
+	if err != nil {
+		t.Fatalf("Render() failed: %v", err)
+	}
+	for _, want := range []string{
+		`"embedding" <=> :q AS "soy_distance"`,
+		`"category" = :category AND "embedding" <=> :q <= :max_distance`,
+		`ORDER BY "embedding" <=> :q ASC LIMIT 5`,
+	} {
+		if !strings.Contains(result.SQL, want) {
+			t.Errorf("SQL missing %q:\n%s", want, result.SQL)
+		}
+	}
+	for _, param := range []string{"category", "q", "max_distance"} {
+		if !slices.Contains(result.RequiredParams, param) {
+			t.Errorf("RequiredParams = %v, missing %q", result.RequiredParams, param)
+		}
+	}
+
+	ordered := s.Query().OrderBy("id", "desc").Nearest("embedding", "q", DistanceL2, 3).MustRender()
+	if !strings.Contains(ordered.SQL, `ORDER BY "embedding" <-> :q ASC, "id" DESC`) {
+		t.Errorf("existing ordering should break distance ties: %s", ordered.SQL)
+	}
+
+	base := s.Query().Where("category", "=", "category")
+	base.Nearest("embedding", "q", DistanceL2, 3)
+	if sql := base.MustRender().SQL; strings.Contains(sql, "<->") {
+		t.Errorf("Nearest() modified the query it was built from: %s", sql)
+	}
+
+	t.Run("invalid", func(t *testing.T) {
+		_, err := s.Query().Nearest("embedding", "q", "<>", 5).Render()
+		if !errors.Is(err, ErrInvalidOperator) {
+			t.Errorf("expected ErrInvalidOperator, got %v", err)
+		}
+		if _, err := s.Query().Nearest("missing", "q", DistanceL2, 5).Render(); !errors.Is(err, ErrInvalidField) {
+			t.Errorf("expected ErrInvalidField, got %v", err)
+		}
+		if _, err := s.Query().Nearest("embedding", "q", DistanceL2, 0).Render(); err == nil {
+			t.Error("expected error for k of 0")
+		}
+		if _, err := s.Query().Nearest("embedding", "q", DistanceL2, 5).Probes(0).Render(); err == nil {
+			t.Error("expected error for probes of 0")
+		}
+
+		lite, err := New[nearestTestDoc](nil, "docs", sqlite.New())
+		if err != nil {
+			t.Fatalf("New() failed: %v", err)
+		}
+		if _, err := lite.Query().Nearest("embedding", "q", DistanceL2, 5).Render(); err == nil || !strings.Contains(err.Error(), "requires PostgreSQL") {
+			t.Errorf("expected PostgreSQL error, got %v", err)
+		}
+	})
+}
+
+func TestNearest_Exec(t *testing.T) {
+	drv := &nearestDriver{
+		cols: []string{"id", "category", "embedding", "soy_distance"},
+		data: [][]driver.Value{
+			{int64(1), "docs", "[1,0,0]", 0.0},
+			{int64(2), "docs", "[0,1,0]", 0.25},
+		},
+	}
+	name := fmt.Sprintf("soy_nearest_%d", verifyDriverSeq.Add(1))
+	sql.Register(name, drv)
+	db, err := sqlx.Open(name, "")
+	if err != nil {
+		t.Fatalf("sqlx.Open() failed: %v", err)
+	}
+	t.Cleanup(func() { _ = db.Close() })
+
+	s, err := New[nearestTestDoc](db, "docs", postgres.New())
+	if err != nil {
+		t.Fatalf("New() failed: %v", err)
+	}
+
+	results, err := s.Query().
+		Nearest("embedding", "q", DistanceCosine, 2).
+		EfSearch(40).
+		Probes(4).
+		EfSearch(80).
+		Exec(t.Context(), map[string]any{"q": Vector{1, 0, 0}})
+	if err != nil {
+		t.Fatalf("Exec() error = %v", err)
+	}
+	if len(results) != 2 || results[1].Record.ID != 2 || results[1].Distance != 0.25 {
+		t.Fatalf("Exec() = %+v", results)
+	}
+	if got := results[0].Record.Embedding; !slices.Equal(got, Vector{1, 0, 0}) {
+		t.Errorf("Embedding = %v", got)
+	}
+
+	want := []string{"BEGIN", "SET LOCAL ivfflat.probes = 4", "SET LOCAL hnsw.ef_search = 80", "QUERY [[1,0,0] [1,0,0]]", "COMMIT"}
+	if !slices.Equal(drv.log, want) {
+		t.Errorf("statements = %q, want %q", drv.log, want)
+	}
+
+	drv.log = nil
+	if _, err := s.Query().Nearest("embedding", "q", DistanceCosine, 2).Exec(t.Context(), map[string]any{"q": Vector{1, 0, 0}}); err != nil {
+		t.Fatalf("Exec() error = %v", err)
+	}
+	if slices.Contains(drv.log, "BEGIN") {
+		t.Errorf("search without settings began a transaction: %q", drv.log)
+	}
+}
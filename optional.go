@@ -0,0 +1,179 @@
+package soy
+
+import (
+	"reflect"
+	"slices"
+
+	"github.com/zoobzio/astql"
+)
+
+// optionalCondition is a WHERE condition, or an AND/OR group of conditions, that is
+// applied at execution time only for the members whose params were supplied.
+type optionalCondition struct {
+	conditions []Condition
+	items      []astql.ConditionItem // built from conditions when added
+	group      astql.ConditionItem   // all items combined, used when every param is present
+	or         bool
+}
+
+// optionalWhere tracks optional conditions and the SkipMissingParams mode for a builder.
+// Optional conditions are kept out of the astql builder and merged into a copy of it
+// when the query is rendered.
+type optionalWhere struct {
+	conditions  []optionalCondition
+	skipMissing bool
+}
+
+// add validates the conditions and records them as one optional entry.
+func (o *optionalWhere) add(instance *astql.ASTQL, or bool, conditions ...Condition) error {
+	if len(conditions) == 0 {
+		return nil
+	}
+
+	items := instance.ConditionItems()
+	for _, cond := range conditions {
+		item, err := buildConditionWithInstance(instance, cond)
+		if err != nil {
+			return err
+		}
+		items = append(items, item)
+	}
+
+	group, err := combineConditions(instance, items, or)
+	if err != nil {
+		return err
+	}
+
+	o.conditions = append(o.conditions, optionalCondition{
+		conditions: slices.Clone(conditions),
+		items:      items,
+		group:      group,
+		or:         or,
+	})
+	return nil
+}
+
+// active reports whether rendering depends on the params supplied at execution time.
+func (o optionalWhere) active() bool {
+	return len(o.conditions) > 0
+}
+
+// paramNames returns the params referenced by optional conditions.
+func (o optionalWhere) paramNames() []string {
+	var names []string
+	for _, oc := range o.conditions {
+		for _, cond := range oc.conditions {
+			switch {
+			case cond.isNull:
+			case cond.isBetween:
+				names = append(names, cond.lowParam, cond.highParam)
+			default:
+				names = append(names, cond.param)
+			}
+		}
+	}
+	return names
+}
+
+// clone returns an independent copy of the optional state.
+func (o optionalWhere) clone() optionalWhere {
+	return optionalWhere{
+		conditions:  slices.Clone(o.conditions),
+		skipMissing: o.skipMissing,
+	}
+}
+
+// applyAll returns a copy of builder with every optional condition included,
+// as if all params were supplied. Used for Render and set operations.
+func (o optionalWhere) applyAll(builder *astql.Builder) *astql.Builder {
+	if !o.active() {
+		return builder
+	}
+	b := cloneBuilder(builder)
+	for _, oc := range o.conditions {
+		b = b.Where(oc.group)
+	}
+	return b
+}
+
+// apply returns a copy of builder with the optional conditions whose params are
+// present and non-nil in params. Members of a group are dropped individually.
+func (o optionalWhere) apply(instance *astql.ASTQL, builder *astql.Builder, params map[string]any) (*astql.Builder, error) {
+	if !o.active() {
+		return builder, nil
+	}
+
+	b := cloneBuilder(builder)
+	for _, oc := range o.conditions {
+		items := instance.ConditionItems()
+		for i, cond := range oc.conditions {
+			if conditionParamsPresent(cond, params) {
+				items = append(items, oc.items[i])
+			}
+		}
+
+		switch len(items) {
+		case 0:
+			continue
+		case len(oc.items):
+			b = b.Where(oc.group)
+		default:
+			group, err := combineConditions(instance, items, oc.or)
+			if err != nil {
+				return nil, err
+			}
+			b = b.Where(group)
+		}
+	}
+	return b, nil
+}
+
+// combineConditions joins items with AND or OR. A single item is returned as is.
+func combineConditions(instance *astql.ASTQL, items []astql.ConditionItem, or bool) (astql.ConditionItem, error) {
+	if len(items) == 1 {
+		return items[0], nil
+	}
+
+	var (
+		group astql.ConditionItem
+		err   error
+	)
+	if or {
+		group, err = instance.TryOr(items...)
+	} else {
+		group, err = instance.TryAnd(items...)
+	}
+	if err != nil {
+		return nil, newConditionError(err)
+	}
+	return group, nil
+}
+
+// conditionParamsPresent reports whether every param referenced by cond is supplied.
+// IS NULL and IS NOT NULL conditions have no params and are always kept.
+func conditionParamsPresent(cond Condition, params map[string]any) bool {
+	switch {
+	case cond.isNull:
+		return true
+	case cond.isBetween:
+		return paramPresent(params, cond.lowParam) && paramPresent(params, cond.highParam)
+	default:
+		return paramPresent(params, cond.param)
+	}
+}
+
+// paramPresent reports whether name is in params with a non-nil value.
+// Typed nil pointers, slices and maps count as missing.
+func paramPresent(params map[string]any, name string) bool {
+	value, ok := params[name]
+	if !ok || value == nil {
+		return false
+	}
+	v := reflect.ValueOf(value)
+	switch v.Kind() {
+	case reflect.Pointer, reflect.Map, reflect.Slice, reflect.Interface:
+		return !v.IsNil()
+	default:
+		return true
+	}
+}
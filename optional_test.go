@@ -0,0 +1,159 @@
+package soy
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/zoobzio/astql/postgres"
+)
+
+func newOptionalTestSoy(t *testing.T) *Soy[batchTestUser] {
+	t.Helper()
+	s, err := New[batchTestUser](&sqlx.DB{}, "users", postgres.New())
+	if err != nil {
+		t.Fatalf("New() failed: %v", err)
+	}
+	return s
+}
+
+func TestQuery_WhereOptional(t *testing.T) {
+	s := newOptionalTestSoy(t)
+	q := s.Query().
+		Where("email", "=", "email").
+		WhereOptional("name", "=", "name").
+		WhereOptional("age", ">=", "min_age")
+
+	t.Run("missing params drop conditions", func(t *testing.T) {
+		result, err := q.renderFor(map[string]any{"email": "a@example.com", "name": "A"})
+		if err != nil {
+			t.Fatalf("renderFor() failed: %v", err)
+		}
+		if !strings.Contains(result.SQL, `"email" = :email`) || !strings.Contains(result.SQL, `"name" = :name`) {
+			t.Errorf("expected email and name conditions: %s", result.SQL)
+		}
+		if strings.Contains(result.SQL, "min_age") {
+			t.Errorf("age condition should be dropped: %s", result.SQL)
+		}
+	})
+
+	t.Run("nil and typed nil count as missing", func(t *testing.T) {
+		var name *string
+		result, err := q.renderFor(map[string]any{"email": "a@example.com", "name": name, "min_age": nil})
+		if err != nil {
+			t.Fatalf("renderFor() failed: %v", err)
+		}
+		if strings.Contains(result.SQL, ":name") || strings.Contains(result.SQL, ":min_age") {
+			t.Errorf("nil params should drop conditions: %s", result.SQL)
+		}
+	})
+
+	t.Run("render includes every optional condition", func(t *testing.T) {
+		result := q.MustRender()
+		for _, param := range []string{"email", "name", "min_age"} {
+			if !strings.Contains(result.SQL, ":"+param) {
+				t.Errorf("Render() missing %s: %s", param, result.SQL)
+			}
+		}
+	})
+
+	t.Run("invalid field is reported", func(t *testing.T) {
+		if _, err := s.Query().WhereOptional("missing", "=", "x").Render(); err == nil {
+			t.Error("expected error for invalid field")
+		}
+	})
+}
+
+func TestQuery_SkipMissingParams(t *testing.T) {
+	s := newOptionalTestSoy(t)
+	q := s.Query().
+		Where("email", "=", "email").
+		SkipMissingParams().
+		Where("name", "=", "name").
+		WhereOr(C("age", "=", "age_a"), C("age", "=", "age_b")).
+		WhereBetween("id", "min_id", "max_id").
+		WhereNull("age")
+
+	result, err := q.renderFor(map[string]any{"email": "a@example.com", "age_b": 30, "min_id": 1})
+	if err != nil {
+		t.Fatalf("renderFor() failed: %v", err)
+	}
+
+	if !strings.Contains(result.SQL, `"email" = :email`) {
+		t.Errorf("conditions added before SkipMissingParams must be kept: %s", result.SQL)
+	}
+	if strings.Contains(result.SQL, ":name") {
+		t.Errorf("name condition should be dropped: %s", result.SQL)
+	}
+	if !strings.Contains(result.SQL, ":age_b") || strings.Contains(result.SQL, ":age_a") {
+		t.Errorf("OR group should keep only supplied members: %s", result.SQL)
+	}
+	if strings.Contains(result.SQL, "BETWEEN") {
+		t.Errorf("BETWEEN needs both params: %s", result.SQL)
+	}
+	if !strings.Contains(result.SQL, `"age" IS NULL`) {
+		t.Errorf("conditions without params must be kept: %s", result.SQL)
+	}
+}
+
+func TestSelect_WhereOptional(t *testing.T) {
+	s := newOptionalTestSoy(t)
+	sb := s.Select().Where("id", "=", "id").WhereOptional("email", "=", "email")
+
+	result, err := sb.renderFor(map[string]any{"id": 1})
+	if err != nil {
+		t.Fatalf("renderFor() failed: %v", err)
+	}
+	if strings.Contains(result.SQL, ":email") {
+		t.Errorf("email condition should be dropped: %s", result.SQL)
+	}
+
+	clone := sb.Clone()
+	if len(clone.optional.conditions) != 1 {
+		t.Errorf("Clone() should copy optional conditions")
+	}
+}
+
+func TestAggregate_SkipMissingParams(t *testing.T) {
+	s := newOptionalTestSoy(t)
+	ab := s.Count().SkipMissingParams().Where("name", "=", "name")
+
+	builder, err := ab.agg.optional.apply(ab.agg.instance, ab.agg.builder, map[string]any{})
+	if err != nil {
+		t.Fatalf("apply() failed: %v", err)
+	}
+	result, err := builder.Render(postgres.New())
+	if err != nil {
+		t.Fatalf("Render() failed: %v", err)
+	}
+	if strings.Contains(result.SQL, "WHERE") {
+		t.Errorf("expected no WHERE clause: %s", result.SQL)
+	}
+	if !strings.Contains(ab.MustRender().SQL, ":name") {
+		t.Error("Render() should include optional conditions")
+	}
+}
+
+func TestOptional_CompileRejected(t *testing.T) {
+	s := newOptionalTestSoy(t)
+
+	if _, err := s.Query().WhereOptional("name", "=", "name").Compile(); err == nil {
+		t.Error("expected Query.Compile() to reject optional conditions")
+	}
+	if _, err := s.Select().WhereOptional("name", "=", "name").Compile(); err == nil {
+		t.Error("expected Select.Compile() to reject optional conditions")
+	}
+	if _, err := s.Count().WhereOptional("name", "=", "name").Compile(); err == nil {
+		t.Error("expected Aggregate.Compile() to reject optional conditions")
+	}
+}
+
+func TestOptional_SetOperationsIncludeConditions(t *testing.T) {
+	s := newOptionalTestSoy(t)
+	compound := s.Query().WhereOptional("name", "=", "a").Union(s.Query().WhereOptional("name", "=", "b"))
+
+	result := compound.MustRender()
+	if !strings.Contains(result.SQL, "_a") || !strings.Contains(result.SQL, "_b") {
+		t.Errorf("set operations should keep optional conditions: %s", result.SQL)
+	}
+}
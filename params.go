@@ -0,0 +1,39 @@
+package soy
+
+import (
+	"slices"
+)
+
+// validateParams compares params with the params a rendered statement requires.
+// Every missing name is reported at once in a MissingParamsError. In strict mode,
+// keys that are neither required nor listed in allowed are reported in an
+// UnknownParamsError; the tenant and scope params soy binds itself are never unknown.
+// Nil values count as supplied; they bind as NULL.
+func validateParams(required []string, params map[string]any, strict bool, allowed ...string) error {
+	var missing []string
+	for _, name := range required {
+		if _, ok := params[name]; !ok && !slices.Contains(missing, name) {
+			missing = append(missing, name)
+		}
+	}
+	if len(missing) > 0 {
+		slices.Sort(missing)
+		return &MissingParamsError{Names: missing}
+	}
+
+	if !strict {
+		return nil
+	}
+
+	var unknown []string
+	for name := range params {
+		if !slices.Contains(required, name) && !slices.Contains(allowed, name) && !implicitParam(name) {
+			unknown = append(unknown, name)
+		}
+	}
+	if len(unknown) > 0 {
+		slices.Sort(unknown)
+		return &UnknownParamsError{Names: unknown}
+	}
+	return nil
+}
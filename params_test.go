@@ -0,0 +1,122 @@
+package soy
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/zoobzio/astql/postgres"
+)
+
+func TestValidateParams(t *testing.T) {
+	tests := []struct {
+		name        string
+		required    []string
+		params      map[string]any
+		strict      bool
+		allowed     []string
+		wantMissing []string
+		wantUnknown []string
+	}{
+		{"all present", []string{"a", "b"}, map[string]any{"a": 1, "b": 2}, false, nil, nil, nil},
+		{"nil counts as present", []string{"a"}, map[string]any{"a": nil}, false, nil, nil, nil},
+		{"reports every missing name", []string{"b", "a", "c"}, map[string]any{"c": 1}, false, nil, []string{"a", "b"}, nil},
+		{"extra keys allowed by default", []string{"a"}, map[string]any{"a": 1, "x": 2}, false, nil, nil, nil},
+		{"strict rejects extra keys", []string{"a"}, map[string]any{"a": 1, "z": 2, "x": 3}, true, nil, nil, []string{"x", "z"}},
+		{"strict honours allowed keys", []string{"a"}, map[string]any{"a": 1, "x": 2}, true, []string{"x"}, nil, nil},
+		{"missing reported before unknown", []string{"a"}, map[string]any{"x": 1}, true, nil, []string{"a"}, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateParams(tt.required, tt.params, tt.strict, tt.allowed...)
+
+			var missing *MissingParamsError
+			var unknown *UnknownParamsError
+			switch {
+			case tt.wantMissing != nil:
+				if !errors.As(err, &missing) || !reflect.DeepEqual(missing.Names, tt.wantMissing) {
+					t.Errorf("validateParams() = %v, want missing %v", err, tt.wantMissing)
+				}
+			case tt.wantUnknown != nil:
+				if !errors.As(err, &unknown) || !reflect.DeepEqual(unknown.Names, tt.wantUnknown) {
+					t.Errorf("validateParams() = %v, want unknown %v", err, tt.wantUnknown)
+				}
+			case err != nil:
+				t.Errorf("validateParams() unexpected error: %v", err)
+			}
+		})
+	}
+}
+
+func TestParamsValidation_BeforeExecution(t *testing.T) {
+	// The zero sqlx.DB cannot execute, so reaching the driver would fail differently
+	s, err := New[batchTestUser](&sqlx.DB{}, "users", postgres.New())
+	if err != nil {
+		t.Fatalf("New() failed: %v", err)
+	}
+
+	t.Run("query", func(t *testing.T) {
+		_, err := s.Query().Where("name", "=", "name").Where("age", ">", "min_age").Exec(t.Context(), map[string]any{})
+		var missing *MissingParamsError
+		if !errors.As(err, &missing) || len(missing.Names) != 2 {
+			t.Errorf("expected both params reported, got %v", err)
+		}
+	})
+
+	t.Run("delete", func(t *testing.T) {
+		_, err := s.Remove().Where("id", "=", "user_id").Exec(t.Context(), map[string]any{"id": 1})
+		if !errors.Is(err, ErrMissingParams) {
+			t.Errorf("expected ErrMissingParams, got %v", err)
+		}
+	})
+
+	t.Run("strict mode", func(t *testing.T) {
+		strict, err := New[batchTestUser](&sqlx.DB{}, "users", postgres.New())
+		if err != nil {
+			t.Fatalf("New() failed: %v", err)
+		}
+		strict.StrictParams(true)
+
+		_, err = strict.Count().Where("name", "=", "name").Exec(t.Context(), map[string]any{"name": "A", "nmae": "B"})
+		if !errors.Is(err, ErrUnknownParams) {
+			t.Errorf("expected ErrUnknownParams, got %v", err)
+		}
+	})
+
+	t.Run("strict mode allows optional params", func(t *testing.T) {
+		strict, err := New[batchTestUser](&sqlx.DB{}, "users", postgres.New())
+		if err != nil {
+			t.Fatalf("New() failed: %v", err)
+		}
+		strict.StrictParams(true)
+
+		_, err = strict.Query().WhereOptional("name", "=", "name").renderFor(map[string]any{"name": nil})
+		if err != nil {
+			t.Errorf("optional params should not be unknown: %v", err)
+		}
+	})
+
+	t.Run("batch reports each index", func(t *testing.T) {
+		_, err := s.Modify().
+			Set("name", "new_name").
+			Where("age", ">", "min_age").
+			ExecBatch(t.Context(), []map[string]any{
+				{"new_name": "A", "min_age": 1},
+				{"new_name": "B"},
+				{"min_age": 3},
+			})
+
+		var bErr *BatchError
+		if !errors.As(err, &bErr) {
+			t.Fatalf("expected BatchError, got %v", err)
+		}
+		if len(bErr.Errors) != 2 || bErr.Errors[0].Index != 1 || bErr.Errors[1].Index != 2 {
+			t.Errorf("unexpected index errors: %v", bErr.Errors)
+		}
+		if !errors.Is(err, ErrMissingParams) {
+			t.Error("expected per-index errors to match ErrMissingParams")
+		}
+	})
+}
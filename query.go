@@ -15,8 +15,10 @@ import (
 type Query[T any] struct {
 	instance *astql.ASTQL
 	builder  *astql.Builder
-	soy      soyExecutor // interface for execution
-	err      error       // stores first error encountered during building
+	soy      soyExecutor   // interface for execution
+	optional optionalWhere // conditions applied only when their params are supplied
+	scopes   scopeFilter   // scopes left out with Unscoped
+	err      error         // stores first error encountered during building
 }
 
 // Fields specifies which fields to select. If not called, selects all fields (*).
@@ -46,6 +48,10 @@ func (qb *Query[T]) Where(field, operator, param string) *Query[T] {
 	if qb.err != nil {
 		return qb
 	}
+	if qb.optional.skipMissing {
+		qb.err = qb.optional.add(qb.instance, false, C(field, operator, param))
+		return qb
+	}
 	qb.builder, qb.err = whereImpl(qb.instance, qb.builder, field, operator, param)
 	return qb
 }
@@ -62,6 +68,10 @@ func (qb *Query[T]) WhereAnd(conditions ...Condition) *Query[T] {
 	if qb.err != nil {
 		return qb
 	}
+	if qb.optional.skipMissing {
+		qb.err = qb.optional.add(qb.instance, false, conditions...)
+		return qb
+	}
 	qb.builder, qb.err = whereAndImpl(qb.instance, qb.builder, conditions...)
 	return qb
 }
@@ -78,6 +88,10 @@ func (qb *Query[T]) WhereOr(conditions ...Condition) *Query[T] {
 	if qb.err != nil {
 		return qb
 	}
+	if qb.optional.skipMissing {
+		qb.err = qb.optional.add(qb.instance, true, conditions...)
+		return qb
+	}
 	qb.builder, qb.err = whereOrImpl(qb.instance, qb.builder, conditions...)
 	return qb
 }
@@ -111,6 +125,10 @@ func (qb *Query[T]) WhereBetween(field, lowParam, highParam string) *Query[T] {
 	if qb.err != nil {
 		return qb
 	}
+	if qb.optional.skipMissing {
+		qb.err = qb.optional.add(qb.instance, false, Between(field, lowParam, highParam))
+		return qb
+	}
 	qb.builder, qb.err = whereBetweenImpl(qb.instance, qb.builder, field, lowParam, highParam)
 	return qb
 }
@@ -126,6 +144,10 @@ func (qb *Query[T]) WhereNotBetween(field, lowParam, highParam string) *Query[T]
 	if qb.err != nil {
 		return qb
 	}
+	if qb.optional.skipMissing {
+		qb.err = qb.optional.add(qb.instance, false, NotBetween(field, lowParam, highParam))
+		return qb
+	}
 	qb.builder, qb.err = whereNotBetweenImpl(qb.instance, qb.builder, field, lowParam, highParam)
 	return qb
 }
@@ -145,6 +167,56 @@ func (qb *Query[T]) WhereFields(leftField, operator, rightField string) *Query[T
 	return qb
 }
 
+// WhereOptional adds a WHERE condition that is only applied when param is present
+// and non-nil in the params passed to Exec. Otherwise the condition is left out
+// and the query is rendered without it.
+//
+// Example:
+//
+//	soy.Query().
+//	    WhereOptional("status", "=", "status").
+//	    WhereOptional("age", ">=", "min_age")
+//	// params: map[string]any{"status": "active"} filters on status only
+func (qb *Query[T]) WhereOptional(field, operator, param string) *Query[T] {
+	if qb.err != nil {
+		return qb
+	}
+	qb.err = qb.optional.add(qb.instance, false, C(field, operator, param))
+	return qb
+}
+
+// SkipMissingParams switches the builder to optional-filter mode. Conditions added
+// afterwards with Where, WhereAnd, WhereOr, WhereBetween or WhereNotBetween behave like
+// WhereOptional: any condition whose params are missing or nil at execution time is
+// removed, and members of AND/OR groups are removed individually. Conditions added
+// before the call, and conditions without params, are always applied.
+//
+// Example:
+//
+//	soy.Query().
+//	    Where("tenant_id", "=", "tenant_id").
+//	    SkipMissingParams().
+//	    Where("status", "=", "status").
+//	    WhereBetween("age", "min_age", "max_age")
+func (qb *Query[T]) SkipMissingParams() *Query[T] {
+	qb.optional.skipMissing = true
+	return qb
+}
+
+// Unscoped leaves the named scopes registered with AddScope out of the query, or every
+// scope when no names are given. Tenant scoping from ForTenant always applies.
+//
+// Example:
+//
+//	soy.Query().Unscoped("live").Where("id", "=", "id")
+func (qb *Query[T]) Unscoped(names ...string) *Query[T] {
+	if qb.err != nil {
+		return qb
+	}
+	qb.err = qb.scopes.exclude(qb.soy, names)
+	return qb
+}
+
 // OrderBy adds an ORDER BY clause.
 // Direction must be "ASC" or "DESC" (case-insensitive).
 // Multiple calls add additional sort fields.
@@ -493,6 +565,201 @@ func (qb *Query[T]) SelectSqrt(field, alias string) *Query[T] {
 	return qb
 }
 
+// --- Array Expression Methods (PostgreSQL) ---
+
+// SelectArrayLength adds array_length(field, 1) AS alias to the SELECT clause.
+// The field must be an array column. Empty arrays have a NULL length.
+//
+// Example:
+//
+//	.SelectArrayLength("tags", "tag_count")  // SELECT array_length("tags", 1) AS "tag_count"
+func (qb *Query[T]) SelectArrayLength(field, alias string) *Query[T] {
+	if qb.err != nil {
+		return qb
+	}
+	qb.builder, qb.err = selectArrayFuncImpl(qb.instance, qb.builder, castArrayLength, field, alias)
+	return qb
+}
+
+// SelectUnnest adds unnest(field) AS alias to the SELECT clause, returning a row per element.
+// The field must be an array column.
+//
+// Example:
+//
+//	.SelectUnnest("tags", "tag")  // SELECT unnest("tags") AS "tag"
+func (qb *Query[T]) SelectUnnest(field, alias string) *Query[T] {
+	if qb.err != nil {
+		return qb
+	}
+	qb.builder, qb.err = selectArrayFuncImpl(qb.instance, qb.builder, castUnnest, field, alias)
+	return qb
+}
+
+// --- Full-Text Search Methods (PostgreSQL) ---
+
+// WhereSearch adds a full-text match of field against the web search query in param.
+// Quoted phrases, OR and -word are supported, as in websearch_to_tsquery. config is the
+// text search configuration, such as "english". An empty config uses the configuration
+// of a generated tsvector column, or "english". tsvector columns are matched directly.
+//
+// Example:
+//
+//	.WhereSearch("body", "q", "english")
+//	// WHERE to_tsvector('english', "body") @@ websearch_to_tsquery('english', :q)
+func (qb *Query[T]) WhereSearch(field, param, config string) *Query[T] {
+	if qb.err != nil {
+		return qb
+	}
+	qb.builder, qb.err = whereSearchImpl(qb.instance, qb.builder, field, param, config)
+	return qb
+}
+
+// SelectSearchRank adds the ts_rank of field against the web search query in param AS
+// alias to the SELECT clause. Text columns are ranked with the "english" configuration;
+// use a tsvector column to rank with another.
+//
+// Example:
+//
+//	.SelectSearchRank("search", "q", "rank")
+//	// SELECT ts_rank("search", websearch_to_tsquery('english', :q)) AS "rank"
+func (qb *Query[T]) SelectSearchRank(field, param, alias string) *Query[T] {
+	if qb.err != nil {
+		return qb
+	}
+	qb.builder, qb.err = selectSearchRankImpl(qb.instance, qb.builder, field, param, alias)
+	return qb
+}
+
+// OrderBySearchRank orders by the ts_rank of field against the web search query in param.
+// Direction must be "asc" or "desc" (case insensitive).
+//
+// Example:
+//
+//	.OrderBySearchRank("search", "q", "desc")
+func (qb *Query[T]) OrderBySearchRank(field, param, direction string) *Query[T] {
+	if qb.err != nil {
+		return qb
+	}
+	qb.builder, qb.err = orderBySearchRankImpl(qb.instance, qb.builder, field, param, direction)
+	return qb
+}
+
+// --- JSON Path Methods (PostgreSQL) ---
+
+// WhereJSONPath compares the text value at a key of the JSON field with param. The
+// key is the value of the path param, and operator is one of =, !=, <, <=, >, >=, LIKE,
+// NOT LIKE, ILIKE or NOT ILIKE. The value is text, so compare it with a string param.
+//
+// Example:
+//
+//	.WhereJSONPath("metadata", "key", "=", "value")
+//	// WHERE ("metadata" ->> :key) = :value
+func (qb *Query[T]) WhereJSONPath(field, path, operator, param string) *Query[T] {
+	if qb.err != nil {
+		return qb
+	}
+	qb.builder, qb.err = whereJSONPathImpl(qb.instance, qb.builder, field, path, operator, param)
+	return qb
+}
+
+// --- Trigram Similarity Methods (PostgreSQL with pg_trgm) ---
+
+// WhereSimilar adds a trigram similarity match of field against the text in param.
+// With an empty threshold it renders the % operator, which matches above the
+// pg_trgm.similarity_threshold setting (0.3 by default) and can use a trigram index.
+// Otherwise the similarity must be at least the value of the threshold param.
+//
+// Example:
+//
+//	.WhereSimilar("name", "q", "")
+//	// WHERE "name" % :q
+//	.WhereSimilar("name", "q", "min")
+//	// WHERE similarity("name", :q) >= :min
+func (qb *Query[T]) WhereSimilar(field, param, threshold string) *Query[T] {
+	if qb.err != nil {
+		return qb
+	}
+	qb.builder, qb.err = whereSimilarImpl(qb.instance, qb.builder, field, param, threshold, false)
+	return qb
+}
+
+// WhereWordSimilar is like WhereSimilar but matches the text in param against the most
+// similar run of words in field, so a short query can match a long value.
+//
+// Example:
+//
+//	.WhereWordSimilar("title", "q", "")
+//	// WHERE "title" %> :q
+//	.WhereWordSimilar("title", "q", "min")
+//	// WHERE word_similarity(:q, "title") >= :min
+func (qb *Query[T]) WhereWordSimilar(field, param, threshold string) *Query[T] {
+	if qb.err != nil {
+		return qb
+	}
+	qb.builder, qb.err = whereSimilarImpl(qb.instance, qb.builder, field, param, threshold, true)
+	return qb
+}
+
+// SelectSimilarity adds the trigram similarity of field and the text in param AS alias
+// to the SELECT clause. Similarity ranges from 0 to 1.
+//
+// Example:
+//
+//	.SelectSimilarity("name", "q", "score")
+//	// SELECT similarity("name", :q) AS "score"
+func (qb *Query[T]) SelectSimilarity(field, param, alias string) *Query[T] {
+	if qb.err != nil {
+		return qb
+	}
+	qb.builder, qb.err = selectSimilarityImpl(qb.instance, qb.builder, field, param, alias, false)
+	return qb
+}
+
+// SelectWordSimilarity adds the word similarity of the text in param within field AS
+// alias to the SELECT clause.
+//
+// Example:
+//
+//	.SelectWordSimilarity("title", "q", "score")
+//	// SELECT word_similarity(:q, "title") AS "score"
+func (qb *Query[T]) SelectWordSimilarity(field, param, alias string) *Query[T] {
+	if qb.err != nil {
+		return qb
+	}
+	qb.builder, qb.err = selectSimilarityImpl(qb.instance, qb.builder, field, param, alias, true)
+	return qb
+}
+
+// OrderBySimilarity orders by the trigram distance between field and the text in param,
+// so "asc" puts the most similar first. Direction must be "asc" or "desc" (case insensitive).
+//
+// Example:
+//
+//	.OrderBySimilarity("name", "q", "asc")
+//	// ORDER BY "name" <-> :q ASC
+func (qb *Query[T]) OrderBySimilarity(field, param, direction string) *Query[T] {
+	if qb.err != nil {
+		return qb
+	}
+	qb.builder, qb.err = orderBySimilarityImpl(qb.instance, qb.builder, field, param, direction, false)
+	return qb
+}
+
+// OrderByWordSimilarity orders by the word similarity distance of the text in param
+// within field, so "asc" puts the best match first.
+//
+// Example:
+//
+//	.OrderByWordSimilarity("title", "q", "asc")
+//	// ORDER BY "title" <->> :q ASC
+func (qb *Query[T]) OrderByWordSimilarity(field, param, direction string) *Query[T] {
+	if qb.err != nil {
+		return qb
+	}
+	qb.builder, qb.err = orderBySimilarityImpl(qb.instance, qb.builder, field, param, direction, true)
+	return qb
+}
+
 // --- Cast Expression Methods ---
 
 // SelectCast adds CAST(field AS type) AS alias to the SELECT clause.
@@ -1049,36 +1316,62 @@ func (qb *Query[T]) ExecTxAtom(ctx context.Context, tx *sqlx.Tx, params map[stri
 
 // execAtom is the internal atom execution method used by both ExecAtom and ExecTxAtom.
 func (qb *Query[T]) execAtom(ctx context.Context, execer sqlx.ExtContext, params map[string]any) ([]*atom.Atom, error) {
+	params = qb.soy.bindParams(params)
 	if qb.err != nil {
 		return nil, fmt.Errorf("query builder has errors: %w", qb.err)
 	}
 
-	result, err := qb.builder.Render(qb.soy.renderer())
+	result, err := qb.renderFor(params)
 	if err != nil {
-		return nil, fmt.Errorf("failed to render SELECT query: %w", err)
+		return nil, err
 	}
 
-	return execAtomMultipleRows(ctx, execer, qb.soy.atomScanner(), result.SQL, params, qb.soy.getTableName(), "QUERY")
+	return execAtomMultipleRows(ctx, execer, qb.soy.statements(), dialectOf(qb.soy.renderer()), qb.soy.atomScanner(), result.SQL, params, qb.soy.getTableName(), "QUERY")
 }
 
 // exec is the internal execution method used by both Exec and ExecTx.
 func (qb *Query[T]) exec(ctx context.Context, execer sqlx.ExtContext, params map[string]any) ([]*T, error) {
+	params = qb.soy.bindParams(params)
 	if qb.err != nil {
 		return nil, fmt.Errorf("query has errors: %w", qb.err)
 	}
 
-	result, err := qb.builder.Render(qb.soy.renderer())
+	result, err := qb.renderFor(params)
 	if err != nil {
-		return nil, fmt.Errorf("failed to render SELECT query: %w", err)
+		return nil, err
 	}
 
-	return execMultipleRows[T](ctx, execer, result.SQL, params, qb.soy.getTableName(), "QUERY", func(ctx context.Context, result *T) error {
+	return execMultipleRows[T](ctx, execer, qb.soy.statements(), dialectOf(qb.soy.renderer()), result.SQL, params, qb.soy.getTableName(), "QUERY", func(ctx context.Context, result *T) error {
 		return qb.soy.callOnScan(ctx, result)
 	})
 }
 
+// Clone returns an independent copy of the builder, including any stored error.
+// Use it to derive request-specific variants from a shared base query without
+// mutating the base.
+//
+// Example:
+//
+//	active := soy.Query().Where("status", "=", "status")
+//	// per request
+//	users, err := active.Clone().
+//	    Where("age", ">=", "min_age").
+//	    Limit(10).
+//	    Exec(ctx, params)
+func (qb *Query[T]) Clone() *Query[T] {
+	return &Query[T]{
+		instance: qb.instance,
+		builder:  cloneBuilder(qb.builder),
+		soy:      qb.soy,
+		optional: qb.optional.clone(),
+		scopes:   qb.scopes,
+		err:      qb.err,
+	}
+}
+
 // Render builds and renders the query to SQL with parameter placeholders.
 // Returns the SQL string and list of required parameters.
+// Optional conditions are rendered as if all of their params were supplied.
 // Useful for inspection/debugging before execution.
 func (qb *Query[T]) Render() (*astql.QueryResult, error) {
 	// Check for  errors first
@@ -1086,10 +1379,38 @@ func (qb *Query[T]) Render() (*astql.QueryResult, error) {
 		return nil, fmt.Errorf("query  has errors: %w", qb.err)
 	}
 
-	result, err := qb.builder.Render(qb.soy.renderer())
+	result, err := qb.withConditions().Render(qb.soy.renderer())
+	if err != nil {
+		return nil, fmt.Errorf("failed to render SELECT query: %w", err)
+	}
+	return result, nil
+}
+
+// withConditions returns a copy of the builder with its scopes and every optional
+// condition applied, as rendered by Render and set operations.
+func (qb *Query[T]) withConditions() *astql.Builder {
+	return qb.optional.applyAll(qb.scopes.apply(qb.soy, qb.builder))
+}
+
+// renderFor renders the query for execution with params, leaving out optional
+// conditions whose params are missing, and validates params against the result.
+func (qb *Query[T]) renderFor(params map[string]any) (*astql.QueryResult, error) {
+	builder, err := qb.optional.apply(qb.instance, qb.scopes.apply(qb.soy, qb.builder), params)
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := builder.Render(qb.soy.renderer())
 	if err != nil {
 		return nil, fmt.Errorf("failed to render SELECT query: %w", err)
 	}
+
+	if err := validateParams(result.RequiredParams, params, qb.soy.strictParams(), qb.optional.paramNames()...); err != nil {
+		return nil, err
+	}
+	if err := validateEnumParams(qb.instance, builder, params); err != nil {
+		return nil, err
+	}
 	return result, nil
 }
 
@@ -1127,17 +1448,17 @@ func (qb *Query[T]) Union(other *Query[T]) *Compound[T] {
 			err:      qb.err,
 		}
 	}
-	if other.err != nil {
+	if err := compoundOperandErr(qb.soy, other); err != nil {
 		return &Compound[T]{
 			instance: qb.instance,
 			soy:      qb.soy,
-			err:      other.err,
+			err:      err,
 		}
 	}
 
 	return &Compound[T]{
 		instance: qb.instance,
-		builder:  qb.builder.Union(other.builder),
+		builder:  qb.withConditions().Union(other.withConditions()),
 		soy:      qb.soy,
 	}
 }
@@ -1152,17 +1473,17 @@ func (qb *Query[T]) UnionAll(other *Query[T]) *Compound[T] {
 			err:      qb.err,
 		}
 	}
-	if other.err != nil {
+	if err := compoundOperandErr(qb.soy, other); err != nil {
 		return &Compound[T]{
 			instance: qb.instance,
 			soy:      qb.soy,
-			err:      other.err,
+			err:      err,
 		}
 	}
 
 	return &Compound[T]{
 		instance: qb.instance,
-		builder:  qb.builder.UnionAll(other.builder),
+		builder:  qb.withConditions().UnionAll(other.withConditions()),
 		soy:      qb.soy,
 	}
 }
@@ -1177,17 +1498,17 @@ func (qb *Query[T]) Intersect(other *Query[T]) *Compound[T] {
 			err:      qb.err,
 		}
 	}
-	if other.err != nil {
+	if err := compoundOperandErr(qb.soy, other); err != nil {
 		return &Compound[T]{
 			instance: qb.instance,
 			soy:      qb.soy,
-			err:      other.err,
+			err:      err,
 		}
 	}
 
 	return &Compound[T]{
 		instance: qb.instance,
-		builder:  qb.builder.Intersect(other.builder),
+		builder:  qb.withConditions().Intersect(other.withConditions()),
 		soy:      qb.soy,
 	}
 }
@@ -1202,17 +1523,17 @@ func (qb *Query[T]) IntersectAll(other *Query[T]) *Compound[T] {
 			err:      qb.err,
 		}
 	}
-	if other.err != nil {
+	if err := compoundOperandErr(qb.soy, other); err != nil {
 		return &Compound[T]{
 			instance: qb.instance,
 			soy:      qb.soy,
-			err:      other.err,
+			err:      err,
 		}
 	}
 
 	return &Compound[T]{
 		instance: qb.instance,
-		builder:  qb.builder.IntersectAll(other.builder),
+		builder:  qb.withConditions().IntersectAll(other.withConditions()),
 		soy:      qb.soy,
 	}
 }
@@ -1227,17 +1548,17 @@ func (qb *Query[T]) Except(other *Query[T]) *Compound[T] {
 			err:      qb.err,
 		}
 	}
-	if other.err != nil {
+	if err := compoundOperandErr(qb.soy, other); err != nil {
 		return &Compound[T]{
 			instance: qb.instance,
 			soy:      qb.soy,
-			err:      other.err,
+			err:      err,
 		}
 	}
 
 	return &Compound[T]{
 		instance: qb.instance,
-		builder:  qb.builder.Except(other.builder),
+		builder:  qb.withConditions().Except(other.withConditions()),
 		soy:      qb.soy,
 	}
 }
@@ -1252,17 +1573,17 @@ func (qb *Query[T]) ExceptAll(other *Query[T]) *Compound[T] {
 			err:      qb.err,
 		}
 	}
-	if other.err != nil {
+	if err := compoundOperandErr(qb.soy, other); err != nil {
 		return &Compound[T]{
 			instance: qb.instance,
 			soy:      qb.soy,
-			err:      other.err,
+			err:      err,
 		}
 	}
 
 	return &Compound[T]{
 		instance: qb.instance,
-		builder:  qb.builder.ExceptAll(other.builder),
+		builder:  qb.withConditions().ExceptAll(other.withConditions()),
 		soy:      qb.soy,
 	}
 }
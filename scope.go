@@ -0,0 +1,275 @@
+package soy
+
+import (
+	"fmt"
+	"maps"
+	"reflect"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/zoobzio/astql"
+)
+
+// scopeParamPrefix starts the params that bind the values of named scopes.
+const scopeParamPrefix = "__scope_"
+
+// scopeName matches valid scope names, which become part of param names.
+var scopeName = regexp.MustCompile(`^[a-z_][a-z0-9_]*$`)
+
+// namedScope is a default filter registered with AddScope.
+type namedScope struct {
+	name       string
+	conditions []Condition         // with params renamed to scope params
+	item       astql.ConditionItem // the conditions combined with AND
+	params     map[string]any      // scope param name to value
+}
+
+// AddScope registers a named default filter. Its conditions are combined with AND and
+// added to the WHERE clause of every Select, Query, Aggregate, Update and Delete of the
+// instance when it is rendered, so Render shows them. A builder opts out with Unscoped.
+//
+// Condition params take their values from params and are bound on every execution as
+// __scope_<name>_<param>, so they never collide with the params of a query. Every param
+// of the conditions needs a value, and every value must be used.
+//
+// Register scopes before building queries; AddScope is not safe for concurrent use with
+// builders of the instance. Instances returned by ForTenant keep the scopes registered
+// before ForTenant is called.
+//
+// Example:
+//
+//	err := soy.AddScope("live", map[string]any{"deleted": "deleted"},
+//	    soy.C("status", "!=", "deleted"),
+//	    soy.Null("archived_at"),
+//	)
+//	// SELECT ... WHERE ("status" != :__scope_live_deleted AND "archived_at" IS NULL)
+func (c *Soy[T]) AddScope(name string, params map[string]any, conditions ...Condition) error {
+	if !scopeName.MatchString(name) {
+		return fmt.Errorf("soy: invalid scope name %q: use lowercase letters, digits and underscores", name)
+	}
+	if c.scope(name) != nil {
+		return fmt.Errorf("soy: scope %q is already registered", name)
+	}
+	if len(conditions) == 0 {
+		return fmt.Errorf("soy: scope %q has no conditions", name)
+	}
+
+	bound := make(map[string]any, len(params))
+	renamed := make([]Condition, len(conditions))
+	items := c.instance.ConditionItems()
+	for i, cond := range conditions {
+		for _, param := range conditionParams(cond) {
+			value, ok := params[param]
+			if !ok {
+				return newParamUsageError(param, fmt.Sprintf("scope %q has no value for param %q", name, param))
+			}
+			bound[scopeParam(name, param)] = value
+		}
+
+		renamed[i] = renameConditionParams(cond, func(param string) string {
+			return scopeParam(name, param)
+		})
+		item, err := buildConditionWithInstance(c.instance, renamed[i])
+		if err != nil {
+			return err
+		}
+		items = append(items, item)
+	}
+	for param := range params {
+		if _, ok := bound[scopeParam(name, param)]; !ok {
+			return newParamUsageError(param, fmt.Sprintf("scope %q does not use param %q", name, param))
+		}
+	}
+
+	item, err := combineConditions(c.instance, items, false)
+	if err != nil {
+		return err
+	}
+
+	// Clip so instances copied by ForTenant never share appends
+	c.scopes = append(slices.Clip(c.scopes), &namedScope{name: name, conditions: renamed, item: item, params: bound})
+	return nil
+}
+
+// scope returns the scope registered under name, or nil.
+func (c *Soy[T]) scope(name string) *namedScope {
+	for _, s := range c.scopes {
+		if s.name == name {
+			return s
+		}
+	}
+	return nil
+}
+
+// namedScopes returns the scopes registered with AddScope, in registration order.
+func (c *Soy[T]) namedScopes() []*namedScope {
+	return c.scopes
+}
+
+// sameScopes reports whether a and b bind the same scope params, so the params of one
+// can be bound for queries built from the other.
+func sameScopes(a, b []*namedScope) bool {
+	return slices.EqualFunc(a, b, func(x, y *namedScope) bool {
+		return x == y || x.name == y.name && reflect.DeepEqual(x.params, y.params)
+	})
+}
+
+// bindParams returns params with the tenant and the values of every scope bound.
+// Scope params the statement does not use are ignored by strict validation.
+func (c *Soy[T]) bindParams(params map[string]any) map[string]any {
+	if len(c.scopes) > 0 {
+		scoped := make(map[string]any, len(params))
+		maps.Copy(scoped, params)
+		for _, s := range c.scopes {
+			maps.Copy(scoped, s.params)
+		}
+		params = scoped
+	}
+	return c.tenantScope.params(params)
+}
+
+// bindBatchParams binds the tenant and scope values in every entry of a batch.
+func (c *Soy[T]) bindBatchParams(batchParams []map[string]any) []map[string]any {
+	if c.tenantScope == nil && len(c.scopes) == 0 {
+		return batchParams
+	}
+	bound := make([]map[string]any, len(batchParams))
+	for i, params := range batchParams {
+		bound[i] = c.bindParams(params)
+	}
+	return bound
+}
+
+// bindCompoundParams binds the tenant and scope values for each query of a compound,
+// which namespaces their params as q<n>_<param>.
+func (c *Soy[T]) bindCompoundParams(params map[string]any, required []string) map[string]any {
+	implicit := c.bindParams(nil)
+	if len(implicit) == 0 {
+		return params
+	}
+	bound := make(map[string]any, len(params)+len(required))
+	maps.Copy(bound, params)
+	for _, param := range required {
+		if !strings.HasPrefix(param, "q") {
+			continue
+		}
+		_, name, ok := strings.Cut(param, "_")
+		if value, implicitParam := implicit[name]; ok && implicitParam {
+			bound[param] = value
+		}
+	}
+	return bound
+}
+
+// scopeParam returns the param that binds param of the scope name.
+func scopeParam(name, param string) string {
+	return scopeParamPrefix + name + "_" + param
+}
+
+// implicitParam reports whether name is bound by soy rather than by the caller.
+func implicitParam(name string) bool {
+	return name == tenantParam || strings.HasPrefix(name, scopeParamPrefix)
+}
+
+// conditionParams returns the params a condition references.
+func conditionParams(cond Condition) []string {
+	switch {
+	case cond.isNull:
+		return nil
+	case cond.isBetween:
+		return []string{cond.lowParam, cond.highParam}
+	default:
+		return []string{cond.param}
+	}
+}
+
+// renameConditionParams returns cond with every param passed through rename.
+func renameConditionParams(cond Condition, rename func(string) string) Condition {
+	switch {
+	case cond.isNull:
+	case cond.isBetween:
+		cond.lowParam = rename(cond.lowParam)
+		cond.highParam = rename(cond.highParam)
+	default:
+		cond.param = rename(cond.param)
+	}
+	return cond
+}
+
+// scopeFilter records the scopes a builder opted out of with Unscoped.
+type scopeFilter struct {
+	excluded []string
+	all      bool
+}
+
+// exclude opts out of the named scopes, or of every scope when no names are given.
+func (f *scopeFilter) exclude(soy soyExecutor, names []string) error {
+	if len(names) == 0 {
+		f.all = true
+		return nil
+	}
+	for _, name := range names {
+		if !slices.ContainsFunc(soy.namedScopes(), func(s *namedScope) bool { return s.name == name }) {
+			return fmt.Errorf("unknown scope %q", name)
+		}
+	}
+	f.excluded = append(slices.Clip(f.excluded), names...)
+	return nil
+}
+
+// active returns the scopes of soy that apply to the builder.
+func (f scopeFilter) active(soy soyExecutor) []*namedScope {
+	if f.all {
+		return nil
+	}
+	var active []*namedScope
+	for _, s := range soy.namedScopes() {
+		if !slices.Contains(f.excluded, s.name) {
+			active = append(active, s)
+		}
+	}
+	return active
+}
+
+// apply returns a copy of builder with the conditions of every active scope, or
+// builder itself when none apply.
+func (f scopeFilter) apply(soy soyExecutor, builder *astql.Builder) *astql.Builder {
+	active := f.active(soy)
+	if len(active) == 0 {
+		return builder
+	}
+	b := cloneBuilder(builder)
+	for _, s := range active {
+		b = b.Where(s.item)
+	}
+	return b
+}
+
+// items returns the conditions of every active scope.
+func (f scopeFilter) items(soy soyExecutor) []astql.ConditionItem {
+	var items []astql.ConditionItem
+	for _, s := range f.active(soy) {
+		items = append(items, s.item)
+	}
+	return items
+}
+
+// batchShape returns shape with the conditions of every active scope recorded as keys.
+func (f scopeFilter) batchShape(soy soyExecutor, shape batchShape) batchShape {
+	active := f.active(soy)
+	if len(active) == 0 {
+		return shape
+	}
+	shape = shape.clone()
+	for _, s := range active {
+		for _, cond := range s.conditions {
+			if cond.isNull || cond.isBetween {
+				shape.complex = true
+				continue
+			}
+			shape.addKey(cond.field, cond.operator, cond.param)
+		}
+	}
+	return shape
+}
@@ -0,0 +1,249 @@
+package soy
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/zoobzio/astql/postgres"
+)
+
+type scopeTestPost struct {
+	ID        int        `db:"id" type:"serial" constraints:"primarykey"`
+	Status    string     `db:"status" type:"text"`
+	Published bool       `db:"published" type:"boolean"`
+	DeletedAt *time.Time `db:"deleted_at" type:"timestamptz"`
+}
+
+func newScopeTestPosts(t *testing.T) *Soy[scopeTestPost] {
+	t.Helper()
+	s, err := New[scopeTestPost](&sqlx.DB{}, "posts", postgres.New())
+	if err != nil {
+		t.Fatalf("New() failed: %v", err)
+	}
+	if err := s.AddScope("live", map[string]any{"deleted": "deleted"}, C("status", "!=", "deleted"), Null("deleted_at")); err != nil {
+		t.Fatalf("AddScope() failed: %v", err)
+	}
+	if err := s.AddScope("published", map[string]any{"published": true}, C("published", "=", "published")); err != nil {
+		t.Fatalf("AddScope() failed: %v", err)
+	}
+	return s
+}
+
+func TestAddScope_Errors(t *testing.T) {
+	s := newScopeTestPosts(t)
+
+	tests := []struct {
+		name       string
+		scope      string
+		params     map[string]any
+		conditions []Condition
+		want       error
+	}{
+		{"invalid name", "Live-Posts", nil, []Condition{Null("deleted_at")}, nil},
+		{"duplicate name", "live", nil, []Condition{Null("deleted_at")}, nil},
+		{"no conditions", "empty", nil, nil, nil},
+		{"missing value", "draft", nil, []Condition{C("status", "=", "draft")}, ErrInvalidParam},
+		{"unused value", "draft", map[string]any{"draft": "draft", "extra": 1}, []Condition{C("status", "=", "draft")}, ErrInvalidParam},
+		{"unknown field", "draft", map[string]any{"draft": "draft"}, []Condition{C("state", "=", "draft")}, ErrInvalidField},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := s.AddScope(tt.scope, tt.params, tt.conditions...)
+			if err == nil {
+				t.Fatal("expected error")
+			}
+			if tt.want != nil && !errors.Is(err, tt.want) {
+				t.Errorf("expected %v, got %v", tt.want, err)
+			}
+		})
+	}
+
+	if len(s.namedScopes()) != 2 {
+		t.Errorf("failed registrations changed the scopes: %d", len(s.namedScopes()))
+	}
+}
+
+func TestAddScope_Render(t *testing.T) {
+	s := newScopeTestPosts(t)
+
+	const scopes = `("status" != :__scope_live_deleted AND "deleted_at" IS NULL)) AND "published" = :__scope_published_published`
+	tests := []struct {
+		name   string
+		render func() (string, error)
+		want   string
+	}{
+		{"query", func() (string, error) {
+			return sqlOf(s.Query().Where("id", "=", "id").Render())
+		}, `WHERE (("id" = :id AND ` + scopes + `)`},
+		{"select", func() (string, error) {
+			return sqlOf(s.Select().Where("id", "=", "id").Render())
+		}, scopes},
+		{"count", func() (string, error) {
+			return sqlOf(s.Count().Render())
+		}, `WHERE (("status" != :__scope_live_deleted AND "deleted_at" IS NULL) AND "published" = :__scope_published_published)`},
+		{"update", func() (string, error) {
+			return sqlOf(s.Modify().Set("status", "status").Where("id", "=", "id").Render())
+		}, scopes},
+		{"delete", func() (string, error) {
+			return sqlOf(s.Remove().Where("id", "=", "id").Render())
+		}, scopes},
+		{"compound", func() (string, error) {
+			return sqlOf(s.Query().Where("id", "=", "a").Union(s.Query().Unscoped("published")).Render())
+		}, `UNION (SELECT * FROM "posts" WHERE ("status" != :q1___scope_live_deleted AND "deleted_at" IS NULL))`},
+		{"unscoped by name", func() (string, error) {
+			return sqlOf(s.Query().Where("id", "=", "id").Unscoped("live").Render())
+		}, `WHERE ("id" = :id AND "published" = :__scope_published_published)`},
+		{"unscoped entirely", func() (string, error) {
+			return sqlOf(s.Remove().Unscoped().Where("id", "=", "id").Render())
+		}, `DELETE FROM "posts" WHERE "id" = :id`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sql, err := tt.render()
+			if err != nil {
+				t.Fatalf("Render() failed: %v", err)
+			}
+			if !strings.Contains(sql, tt.want) {
+				t.Errorf("SQL missing %q:\n%s", tt.want, sql)
+			}
+		})
+	}
+
+	t.Run("unknown scope", func(t *testing.T) {
+		if _, err := s.Query().Unscoped("drafts").Render(); err == nil || !strings.Contains(err.Error(), `unknown scope "drafts"`) {
+			t.Errorf("Unscoped() error = %v", err)
+		}
+	})
+
+	t.Run("compound operands of other instances", func(t *testing.T) {
+		if _, err := s.Query().Union(newScopeTestPosts(t).Query()).Render(); err != nil {
+			t.Errorf("Render() with the same scopes failed: %v", err)
+		}
+		other := newScopeTestPosts(t)
+		if err := other.AddScope("mine", map[string]any{"min": 1}, C("id", ">=", "min")); err != nil {
+			t.Fatalf("AddScope() failed: %v", err)
+		}
+		if _, err := s.Query().Union(other.Query()).Render(); err == nil || !strings.Contains(err.Error(), "different named scopes") {
+			t.Errorf("Union() of an extra scope error = %v", err)
+		}
+		drafts, err := New[scopeTestPost](&sqlx.DB{}, "posts", postgres.New())
+		if err != nil {
+			t.Fatalf("New() failed: %v", err)
+		}
+		if err := drafts.AddScope("live", map[string]any{"deleted": "draft"}, C("status", "!=", "deleted"), Null("deleted_at")); err != nil {
+			t.Fatalf("AddScope() failed: %v", err)
+		}
+		if err := drafts.AddScope("published", map[string]any{"published": true}, C("published", "=", "published")); err != nil {
+			t.Fatalf("AddScope() failed: %v", err)
+		}
+		if _, err := s.Query().Union(drafts.Query()).Render(); err == nil || !strings.Contains(err.Error(), "different named scopes") {
+			t.Errorf("Union() of other scope values error = %v", err)
+		}
+	})
+
+	t.Run("clones keep the opt-out", func(t *testing.T) {
+		q := s.Query().Unscoped("live")
+		sql, err := sqlOf(q.Clone().Unscoped("published").Render())
+		if err != nil || strings.Contains(sql, "__scope_") {
+			t.Errorf("clone SQL = %s, %v", sql, err)
+		}
+		sql, err = sqlOf(q.Render())
+		if err != nil || !strings.Contains(sql, "__scope_published") || strings.Contains(sql, "__scope_live") {
+			t.Errorf("original SQL = %s, %v", sql, err)
+		}
+	})
+
+	t.Run("scopes combine with tenant scoping", func(t *testing.T) {
+		if err := s.AddScope("mine", map[string]any{"min": 1}, C("id", ">=", "min")); err != nil {
+			t.Fatalf("AddScope() failed: %v", err)
+		}
+		scoped, err := s.ForTenant("status", "acme")
+		if err != nil {
+			t.Fatalf("ForTenant() failed: %v", err)
+		}
+		if err := scoped.AddScope("extra", nil, Null("deleted_at")); err != nil {
+			t.Fatalf("AddScope() failed: %v", err)
+		}
+		if s.scope("extra") != nil {
+			t.Error("scope added to a tenant copy leaked into the original")
+		}
+		sql, err := sqlOf(scoped.Query().Unscoped("live", "published").Render())
+		if err != nil {
+			t.Fatalf("Render() failed: %v", err)
+		}
+		want := `WHERE (("status" = :__tenant AND "id" >= :__scope_mine_min) AND "deleted_at" IS NULL)`
+		if !strings.Contains(sql, want) {
+			t.Errorf("SQL missing %q:\n%s", want, sql)
+		}
+	})
+}
+
+func TestAddScope_Params(t *testing.T) {
+	s := newScopeTestPosts(t)
+	s.StrictParams(true)
+
+	params := s.bindParams(map[string]any{"id": 1, "__scope_live_deleted": "other"})
+	if params["__scope_live_deleted"] != "deleted" || params["__scope_published_published"] != true || params["id"] != 1 {
+		t.Errorf("bindParams() = %v", params)
+	}
+
+	t.Run("strict mode ignores params of left out scopes", func(t *testing.T) {
+		if _, err := s.Query().Where("id", "=", "id").Unscoped("published").renderFor(params); err != nil {
+			t.Errorf("renderFor() failed: %v", err)
+		}
+		if _, err := s.Query().Where("id", "=", "id").renderFor(s.bindParams(map[string]any{"id": 1, "extra": 2})); !errors.Is(err, ErrUnknownParams) {
+			t.Errorf("expected ErrUnknownParams, got %v", err)
+		}
+	})
+
+	t.Run("compound operands", func(t *testing.T) {
+		required := []string{"q0_id", "q0___scope_live_deleted", "q1___scope_published_published"}
+		bound := s.bindCompoundParams(map[string]any{"q0_id": 1}, required)
+		if bound["q0___scope_live_deleted"] != "deleted" || bound["q1___scope_published_published"] != true || bound["q0_id"] != 1 {
+			t.Errorf("bindCompoundParams() = %v", bound)
+		}
+	})
+
+	t.Run("batches", func(t *testing.T) {
+		batch := s.bindBatchParams([]map[string]any{{"id": 1}, {"id": 2}})
+		if batch[1]["__scope_live_deleted"] != "deleted" {
+			t.Errorf("bindBatchParams() = %v", batch)
+		}
+	})
+}
+
+func TestAddScope_Batch(t *testing.T) {
+	s := newScopeTestPosts(t)
+
+	update := s.Modify().Set("status", "status").Where("id", "=", "id").Unscoped("live")
+	shape := update.scopes.batchShape(update.soy, update.batch)
+	sql, ok := shape.renderSetUpdate(dialectPostgres, "posts", s.getMetadata(), 2)
+	if !ok || !strings.Contains(sql, `"posts"."published" = "soy_batch"."__scope_published_published"`) {
+		t.Errorf("renderSetUpdate() = %s, %v", sql, ok)
+	}
+	if len(update.batch.keys) != 1 {
+		t.Errorf("scopes were recorded on the builder's shape: %v", update.batch.keys)
+	}
+
+	remove := s.Remove().Where("id", "=", "id")
+	if shape := remove.scopes.batchShape(remove.soy, remove.batch); shape.setBased() {
+		t.Error("IS NULL scope should need the row-by-row fallback")
+	}
+
+	t.Run("fallback select", func(t *testing.T) {
+		builder, err := update.buildFallbackSelect()
+		if err != nil {
+			t.Fatalf("buildFallbackSelect() failed: %v", err)
+		}
+		result, err := builder.Render(postgres.New())
+		if err != nil {
+			t.Fatalf("Render() failed: %v", err)
+		}
+		if !strings.Contains(result.SQL, `WHERE ("id" = :id AND "published" = :__scope_published_published)`) {
+			t.Errorf("fallback SQL = %s", result.SQL)
+		}
+	})
+}
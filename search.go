@@ -0,0 +1,230 @@
+package soy
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/zoobzio/astql"
+	"github.com/zoobzio/sentinel"
+	"github.com/zoobzio/soy/internal/ddl"
+)
+
+// defaultSearchConfig is the text search configuration used when none is given.
+const defaultSearchConfig = "english"
+
+// Stand-in operators for full-text search. They render as "field op :param" and are
+// expanded by the extensionRenderer, with the search configuration after the colon.
+// The _vector variants apply to tsvector columns, which are not passed to to_tsvector.
+const (
+	opSearch           = "soy_search"
+	opSearchVector     = "soy_search_vector"
+	opSearchRank       = "soy_search_rank"
+	opSearchRankVector = "soy_search_rank_vector"
+)
+
+// searchFuncs are the expressions that replace each search stand-in, formatted with the
+// field, the quoted configuration and the param.
+var searchFuncs = map[string]string{
+	opSearch:           "to_tsvector(%[2]s, %[1]s) @@ websearch_to_tsquery(%[2]s, %[3]s)",
+	opSearchVector:     "%[1]s @@ websearch_to_tsquery(%[2]s, %[3]s)",
+	opSearchRank:       "ts_rank(to_tsvector(%[2]s, %[1]s), websearch_to_tsquery(%[2]s, %[3]s))",
+	opSearchRankVector: "ts_rank(%[1]s, websearch_to_tsquery(%[2]s, %[3]s))",
+}
+
+var (
+	searchConfigName = regexp.MustCompile(`^[a-z_][a-z0-9_]*(\.[a-z_][a-z0-9_]*)?$`)
+	searchStandIn    = regexp.MustCompile(`((?:[a-z]\.)?"(?:[^"]|"")*") (soy_search[a-z_]*):([a-z0-9_.]+) (:[A-Za-z_][A-Za-z0-9_]*)`)
+	generatedConfig  = regexp.MustCompile(`to_tsvector\('([^']+)'`)
+)
+
+// expandSearch rewrites the search stand-ins in rendered SQL.
+func expandSearch(sql string) string {
+	return searchStandIn.ReplaceAllStringFunc(sql, func(match string) string {
+		parts := searchStandIn.FindStringSubmatch(match)
+		format, ok := searchFuncs[parts[2]]
+		if !ok {
+			return match
+		}
+		return fmt.Sprintf(format, parts[1], ddl.SQLString(parts[3]), parts[4])
+	})
+}
+
+// searchOperator returns the stand-in operator that searches field with config, or ranks
+// field against the query when rank is set. An empty config uses the configuration of a
+// generated tsvector column, or defaultSearchConfig.
+func searchOperator(instance *astql.ASTQL, field, config string, rank bool) (astql.Operator, error) {
+	schema := schemaOf(instance)
+	if schema != nil && schema.dialect != dialectPostgres {
+		return "", newFieldUsageError(field, "full-text search requires PostgreSQL")
+	}
+
+	vector := false
+	if schema != nil {
+		column := columnName(field)
+		vector = schema.isSearchVector(column)
+		if config == "" {
+			config = schema.searchConfig(column)
+		}
+	}
+	if config == "" {
+		config = defaultSearchConfig
+	}
+	if !searchConfigName.MatchString(config) {
+		return "", newFieldUsageError(field, fmt.Sprintf("invalid text search configuration %q", config))
+	}
+
+	op := opSearch
+	switch {
+	case rank && vector:
+		op = opSearchRankVector
+	case rank:
+		op = opSearchRank
+	case vector:
+		op = opSearchVector
+	}
+	return astql.Operator(op + ":" + config), nil
+}
+
+// whereSearchImpl adds a full-text match of field against the query in param.
+func whereSearchImpl(instance *astql.ASTQL, builder *astql.Builder, field, param, config string) (*astql.Builder, error) {
+	op, err := searchOperator(instance, field, config, false)
+	if err != nil {
+		return builder, err
+	}
+
+	f, err := instance.TryF(field)
+	if err != nil {
+		return builder, newFieldError(field, err)
+	}
+
+	p, err := instance.TryP(param)
+	if err != nil {
+		return builder, newParamError(param, err)
+	}
+
+	condition, err := instance.TryC(f, op, p)
+	if err != nil {
+		return builder, newConditionError(err)
+	}
+	return builder.Where(condition), nil
+}
+
+// selectSearchRankImpl adds the ts_rank of field against the query in param AS alias to
+// the SELECT clause.
+func selectSearchRankImpl(instance *astql.ASTQL, builder *astql.Builder, field, param, alias string) (*astql.Builder, error) {
+	op, err := searchOperator(instance, field, "", true)
+	if err != nil {
+		return builder, err
+	}
+
+	f, err := instance.TryF(field)
+	if err != nil {
+		return builder, newFieldError(field, err)
+	}
+
+	p, err := instance.TryP(param)
+	if err != nil {
+		return builder, newParamError(param, err)
+	}
+
+	return builder.SelectBinaryExpr(f, op, p, alias), nil
+}
+
+// orderBySearchRankImpl orders by the ts_rank of field against the query in param.
+func orderBySearchRankImpl(instance *astql.ASTQL, builder *astql.Builder, field, param, direction string) (*astql.Builder, error) {
+	astqlDir, err := validateDirection(direction)
+	if err != nil {
+		return builder, err
+	}
+
+	op, err := searchOperator(instance, field, "", true)
+	if err != nil {
+		return builder, err
+	}
+
+	f, err := instance.TryF(field)
+	if err != nil {
+		return builder, newFieldError(field, err)
+	}
+
+	p, err := instance.TryP(param)
+	if err != nil {
+		return builder, newParamError(param, err)
+	}
+
+	return builder.OrderByExpr(f, op, p, astqlDir), nil
+}
+
+// tsvectorSpec is a parsed tsvector tag.
+type tsvectorSpec struct {
+	config  string
+	columns []string
+}
+
+// parseTSVectorTag parses a tsvector tag such as "title,body,config:english".
+// Entries are source columns, except config:, which sets the text search configuration.
+func parseTSVectorTag(tag string) (tsvectorSpec, error) {
+	spec := tsvectorSpec{config: defaultSearchConfig}
+	for _, entry := range strings.Split(tag, ",") {
+		entry = strings.TrimSpace(entry)
+		if config, ok := strings.CutPrefix(entry, "config:"); ok {
+			if !searchConfigName.MatchString(config) {
+				return tsvectorSpec{}, fmt.Errorf("invalid text search configuration %q in tsvector tag", config)
+			}
+			spec.config = config
+			continue
+		}
+		if entry == "" {
+			return tsvectorSpec{}, fmt.Errorf("empty column in tsvector tag %q", tag)
+		}
+		spec.columns = append(spec.columns, entry)
+	}
+	if len(spec.columns) == 0 {
+		return tsvectorSpec{}, fmt.Errorf("tsvector tag %q has no source columns", tag)
+	}
+	return spec, nil
+}
+
+// validate checks that the dialect has tsvector columns and that every source column is
+// a column of metadata.
+func (s tsvectorSpec) validate(d dialect, metadata sentinel.Metadata) error {
+	if d != dialectPostgres {
+		return fmt.Errorf("tsvector columns require PostgreSQL")
+	}
+	columns := columnFields(metadata)
+	for _, column := range s.columns {
+		if _, ok := columns[column]; !ok {
+			return fmt.Errorf("tsvector source %q is not a column", column)
+		}
+	}
+	return nil
+}
+
+// columnType returns the type of a tsvector column generated from its source columns.
+// NULL sources are treated as empty so one missing column does not empty the vector.
+func (s tsvectorSpec) columnType(d dialect) string {
+	sources := make([]string, len(s.columns))
+	for i, column := range s.columns {
+		sources[i] = "coalesce(" + d.quote(column) + ", '')"
+	}
+	return fmt.Sprintf("tsvector GENERATED ALWAYS AS (to_tsvector(%s, %s)) STORED",
+		ddl.SQLString(s.config), strings.Join(sources, " || ' ' || "))
+}
+
+// isSearchVector reports whether column is a tsvector column.
+func (s *instanceSchema) isSearchVector(column string) bool {
+	return strings.HasPrefix(strings.ToLower(s.columnTypes[column]), "tsvector")
+}
+
+// searchConfig returns the text search configuration of a generated tsvector column,
+// or "" for other columns.
+func (s *instanceSchema) searchConfig(column string) string {
+	if !s.isSearchVector(column) {
+		return ""
+	}
+	if match := generatedConfig.FindStringSubmatch(s.columnTypes[column]); match != nil {
+		return match[1]
+	}
+	return ""
+}
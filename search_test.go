@@ -0,0 +1,149 @@
+package soy
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/zoobzio/astql/postgres"
+	"github.com/zoobzio/astql/sqlite"
+)
+
+type searchTestArticle struct {
+	ID     int     `db:"id" type:"serial" constraints:"primarykey"`
+	Title  string  `db:"title" type:"text"`
+	Body   *string `db:"body" type:"text"`
+	Search string  `db:"search" tsvector:"title,body,config:simple"`
+}
+
+func TestParseTSVectorTag(t *testing.T) {
+	spec, err := parseTSVectorTag("title, body")
+	if err != nil {
+		t.Fatalf("parseTSVectorTag() error = %v", err)
+	}
+	if spec.config != "english" || strings.Join(spec.columns, ",") != "title,body" {
+		t.Errorf("parseTSVectorTag() = %+v", spec)
+	}
+
+	for _, bad := range []string{"", "config:english", "title,,body", "title,config:english'"} {
+		if _, err := parseTSVectorTag(bad); err == nil {
+			t.Errorf("parseTSVectorTag(%q) expected error", bad)
+		}
+	}
+}
+
+func TestSearchDDL(t *testing.T) {
+	s, err := New[searchTestArticle](nil, "articles", postgres.New())
+	if err != nil {
+		t.Fatalf("New() failed: %v", err)
+	}
+
+	sql, err := s.CreateTableSQL()
+	if err != nil {
+		t.Fatalf("CreateTableSQL() error = %v", err)
+	}
+	want := `"search" tsvector GENERATED ALWAYS AS (to_tsvector('simple', coalesce("title", '') || ' ' || coalesce("body", ''))) STORED,`
+	if !strings.Contains(sql, want) {
+		t.Errorf("CreateTableSQL() missing %q:\n%s", want, sql)
+	}
+
+	indexes, err := s.CreateIndexesSQL()
+	if err != nil {
+		t.Fatalf("CreateIndexesSQL() error = %v", err)
+	}
+	if len(indexes) != 1 || !strings.Contains(indexes[0], `USING gin ("search")`) {
+		t.Errorf("CreateIndexesSQL() = %q", indexes)
+	}
+
+	result, err := s.Insert().Render()
+	if err != nil {
+		t.Fatalf("Render() failed: %v", err)
+	}
+	if strings.Contains(result.SQL, ":search") || !strings.Contains(result.SQL, `RETURNING "id", "title", "body", "search"`) {
+		t.Errorf("Insert() writes the generated column: %s", result.SQL)
+	}
+	if columns := s.Insert().insertColumns(true); strings.Join(columns, ",") != "id,title,body" {
+		t.Errorf("insertColumns(true) = %q", columns)
+	}
+
+	t.Run("invalid tags", func(t *testing.T) {
+		type unknownSource struct {
+			ID     int    `db:"id"`
+			Search string `db:"search" tsvector:"title"`
+		}
+		if _, err := New[unknownSource](nil, "bad", postgres.New()); err == nil || !strings.Contains(err.Error(), `tsvector source "title" is not a column`) {
+			t.Errorf("New() error = %v", err)
+		}
+		if _, err := New[searchTestArticle](nil, "articles", sqlite.New()); err == nil || !strings.Contains(err.Error(), "require PostgreSQL") {
+			t.Errorf("New(sqlite) error = %v", err)
+		}
+	})
+}
+
+func TestSearchQueries(t *testing.T) {
+	s, err := New[searchTestArticle](nil, "articles", postgres.New())
+	if err != nil {
+		t.Fatalf("New() failed: %v", err)
+	}
+
+	result, err := s.Query().
+		WhereSearch("body", "q", "english").
+		SelectSearchRank("title", "q", "rank").
+		OrderBySearchRank("title", "q", "desc").
+		Render()
+	if err != nil {
+		t.Fatalf("Render() failed: %v", err)
+	}
+	for _, want := range []string{
+		`to_tsvector('english', "body") @@ websearch_to_tsquery('english', :q)`,
+		`ts_rank(to_tsvector('english', "title"), websearch_to_tsquery('english', :q)) AS "rank"`,
+		`ORDER BY ts_rank(to_tsvector('english', "title"), websearch_to_tsquery('english', :q)) DESC`,
+	} {
+		if !strings.Contains(result.SQL, want) {
+			t.Errorf("Query SQL missing %q:\n%s", want, result.SQL)
+		}
+	}
+
+	result, err = s.Select().
+		WhereSearch("search", "q", "").
+		OrderBySearchRank("search", "q", "DESC").
+		Render()
+	if err != nil {
+		t.Fatalf("Render() failed: %v", err)
+	}
+	for _, want := range []string{
+		`WHERE "search" @@ websearch_to_tsquery('simple', :q)`,
+		`ORDER BY ts_rank("search", websearch_to_tsquery('simple', :q)) DESC`,
+	} {
+		if !strings.Contains(result.SQL, want) {
+			t.Errorf("Select SQL missing %q:\n%s", want, result.SQL)
+		}
+	}
+
+	count, err := s.Count().WhereSearch("search", "q", "").Render()
+	if err != nil {
+		t.Fatalf("Count Render() failed: %v", err)
+	}
+	if !strings.Contains(count.SQL, `"search" @@ websearch_to_tsquery('simple', :q)`) {
+		t.Errorf("Count SQL = %s", count.SQL)
+	}
+
+	t.Run("invalid", func(t *testing.T) {
+		_, err := s.Query().WhereSearch("body", "q", "english'; DROP").Render()
+		var vErr *ValidationError
+		if !errors.As(err, &vErr) || !strings.Contains(err.Error(), "invalid text search configuration") {
+			t.Errorf("expected invalid configuration error, got %v", err)
+		}
+		if _, err := s.Query().OrderBySearchRank("body", "q", "up").Render(); !errors.Is(err, ErrInvalidDirection) {
+			t.Errorf("expected ErrInvalidDirection, got %v", err)
+		}
+
+		plain, err := New[extensionTestNote](nil, "notes", sqlite.New())
+		if err != nil {
+			t.Fatalf("New() failed: %v", err)
+		}
+		if _, err := plain.Query().WhereSearch("tags", "q", "english").Render(); err == nil || !strings.Contains(err.Error(), "requires PostgreSQL") {
+			t.Errorf("expected PostgreSQL error, got %v", err)
+		}
+	})
+}
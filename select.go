@@ -24,6 +24,13 @@ type soyExecutor interface {
 	getInstance() *astql.ASTQL
 	callOnScan(ctx context.Context, result any) error
 	callOnRecord(ctx context.Context, record any) error
+	statements() *stmtCache
+	strictParams() bool
+	tenant() *tenantScope
+	namedScopes() []*namedScope
+	bindParams(params map[string]any) map[string]any
+	bindBatchParams(batchParams []map[string]any) []map[string]any
+	bindCompoundParams(params map[string]any, required []string) map[string]any
 }
 
 // Select provides a focused API for building SELECT queries that return a single record.
@@ -32,8 +39,10 @@ type soyExecutor interface {
 type Select[T any] struct {
 	instance *astql.ASTQL
 	builder  *astql.Builder
-	soy      soyExecutor // interface for execution
-	err      error       // stores first error encountered during building
+	soy      soyExecutor   // interface for execution
+	optional optionalWhere // conditions applied only when their params are supplied
+	scopes   scopeFilter   // scopes left out with Unscoped
+	err      error         // stores first error encountered during building
 }
 
 // Condition represents a WHERE condition with string-based components.
@@ -70,6 +79,10 @@ func (sb *Select[T]) Where(field, operator, param string) *Select[T] {
 	if sb.err != nil {
 		return sb
 	}
+	if sb.optional.skipMissing {
+		sb.err = sb.optional.add(sb.instance, false, C(field, operator, param))
+		return sb
+	}
 	sb.builder, sb.err = whereImpl(sb.instance, sb.builder, field, operator, param)
 	return sb
 }
@@ -86,6 +99,10 @@ func (sb *Select[T]) WhereAnd(conditions ...Condition) *Select[T] {
 	if sb.err != nil {
 		return sb
 	}
+	if sb.optional.skipMissing {
+		sb.err = sb.optional.add(sb.instance, false, conditions...)
+		return sb
+	}
 	sb.builder, sb.err = whereAndImpl(sb.instance, sb.builder, conditions...)
 	return sb
 }
@@ -102,6 +119,10 @@ func (sb *Select[T]) WhereOr(conditions ...Condition) *Select[T] {
 	if sb.err != nil {
 		return sb
 	}
+	if sb.optional.skipMissing {
+		sb.err = sb.optional.add(sb.instance, true, conditions...)
+		return sb
+	}
 	sb.builder, sb.err = whereOrImpl(sb.instance, sb.builder, conditions...)
 	return sb
 }
@@ -135,6 +156,10 @@ func (sb *Select[T]) WhereBetween(field, lowParam, highParam string) *Select[T]
 	if sb.err != nil {
 		return sb
 	}
+	if sb.optional.skipMissing {
+		sb.err = sb.optional.add(sb.instance, false, Between(field, lowParam, highParam))
+		return sb
+	}
 	sb.builder, sb.err = whereBetweenImpl(sb.instance, sb.builder, field, lowParam, highParam)
 	return sb
 }
@@ -150,6 +175,10 @@ func (sb *Select[T]) WhereNotBetween(field, lowParam, highParam string) *Select[
 	if sb.err != nil {
 		return sb
 	}
+	if sb.optional.skipMissing {
+		sb.err = sb.optional.add(sb.instance, false, NotBetween(field, lowParam, highParam))
+		return sb
+	}
 	sb.builder, sb.err = whereNotBetweenImpl(sb.instance, sb.builder, field, lowParam, highParam)
 	return sb
 }
@@ -169,6 +198,56 @@ func (sb *Select[T]) WhereFields(leftField, operator, rightField string) *Select
 	return sb
 }
 
+// WhereOptional adds a WHERE condition that is only applied when param is present
+// and non-nil in the params passed to Exec. Otherwise the condition is left out
+// and the query is rendered without it.
+//
+// Example:
+//
+//	soy.Select().
+//	    WhereOptional("status", "=", "status").
+//	    WhereOptional("age", ">=", "min_age")
+//	// params: map[string]any{"status": "active"} filters on status only
+func (sb *Select[T]) WhereOptional(field, operator, param string) *Select[T] {
+	if sb.err != nil {
+		return sb
+	}
+	sb.err = sb.optional.add(sb.instance, false, C(field, operator, param))
+	return sb
+}
+
+// SkipMissingParams switches the builder to optional-filter mode. Conditions added
+// afterwards with Where, WhereAnd, WhereOr, WhereBetween or WhereNotBetween behave like
+// WhereOptional: any condition whose params are missing or nil at execution time is
+// removed, and members of AND/OR groups are removed individually. Conditions added
+// before the call, and conditions without params, are always applied.
+//
+// Example:
+//
+//	soy.Select().
+//	    Where("tenant_id", "=", "tenant_id").
+//	    SkipMissingParams().
+//	    Where("status", "=", "status").
+//	    WhereBetween("age", "min_age", "max_age")
+func (sb *Select[T]) SkipMissingParams() *Select[T] {
+	sb.optional.skipMissing = true
+	return sb
+}
+
+// Unscoped leaves the named scopes registered with AddScope out of the query, or every
+// scope when no names are given. Tenant scoping from ForTenant always applies.
+//
+// Example:
+//
+//	soy.Select().Unscoped("live").Where("id", "=", "id")
+func (sb *Select[T]) Unscoped(names ...string) *Select[T] {
+	if sb.err != nil {
+		return sb
+	}
+	sb.err = sb.scopes.exclude(sb.soy, names)
+	return sb
+}
+
 // OrderBy adds an ORDER BY clause.
 // Direction must be "asc" or "desc" (case insensitive).
 func (sb *Select[T]) OrderBy(field string, direction string) *Select[T] {
@@ -504,6 +583,201 @@ func (sb *Select[T]) SelectSqrt(field, alias string) *Select[T] {
 	return sb
 }
 
+// --- Array Expression Methods (PostgreSQL) ---
+
+// SelectArrayLength adds array_length(field, 1) AS alias to the SELECT clause.
+// The field must be an array column. Empty arrays have a NULL length.
+//
+// Example:
+//
+//	.SelectArrayLength("tags", "tag_count")  // SELECT array_length("tags", 1) AS "tag_count"
+func (sb *Select[T]) SelectArrayLength(field, alias string) *Select[T] {
+	if sb.err != nil {
+		return sb
+	}
+	sb.builder, sb.err = selectArrayFuncImpl(sb.instance, sb.builder, castArrayLength, field, alias)
+	return sb
+}
+
+// SelectUnnest adds unnest(field) AS alias to the SELECT clause, returning a row per element.
+// The field must be an array column.
+//
+// Example:
+//
+//	.SelectUnnest("tags", "tag")  // SELECT unnest("tags") AS "tag"
+func (sb *Select[T]) SelectUnnest(field, alias string) *Select[T] {
+	if sb.err != nil {
+		return sb
+	}
+	sb.builder, sb.err = selectArrayFuncImpl(sb.instance, sb.builder, castUnnest, field, alias)
+	return sb
+}
+
+// --- Full-Text Search Methods (PostgreSQL) ---
+
+// WhereSearch adds a full-text match of field against the web search query in param.
+// Quoted phrases, OR and -word are supported, as in websearch_to_tsquery. config is the
+// text search configuration, such as "english". An empty config uses the configuration
+// of a generated tsvector column, or "english". tsvector columns are matched directly.
+//
+// Example:
+//
+//	.WhereSearch("body", "q", "english")
+//	// WHERE to_tsvector('english', "body") @@ websearch_to_tsquery('english', :q)
+func (sb *Select[T]) WhereSearch(field, param, config string) *Select[T] {
+	if sb.err != nil {
+		return sb
+	}
+	sb.builder, sb.err = whereSearchImpl(sb.instance, sb.builder, field, param, config)
+	return sb
+}
+
+// SelectSearchRank adds the ts_rank of field against the web search query in param AS
+// alias to the SELECT clause. Text columns are ranked with the "english" configuration;
+// use a tsvector column to rank with another.
+//
+// Example:
+//
+//	.SelectSearchRank("search", "q", "rank")
+//	// SELECT ts_rank("search", websearch_to_tsquery('english', :q)) AS "rank"
+func (sb *Select[T]) SelectSearchRank(field, param, alias string) *Select[T] {
+	if sb.err != nil {
+		return sb
+	}
+	sb.builder, sb.err = selectSearchRankImpl(sb.instance, sb.builder, field, param, alias)
+	return sb
+}
+
+// OrderBySearchRank orders by the ts_rank of field against the web search query in param.
+// Direction must be "asc" or "desc" (case insensitive).
+//
+// Example:
+//
+//	.OrderBySearchRank("search", "q", "desc")
+func (sb *Select[T]) OrderBySearchRank(field, param, direction string) *Select[T] {
+	if sb.err != nil {
+		return sb
+	}
+	sb.builder, sb.err = orderBySearchRankImpl(sb.instance, sb.builder, field, param, direction)
+	return sb
+}
+
+// --- JSON Path Methods (PostgreSQL) ---
+
+// WhereJSONPath compares the text value at a key of the JSON field with param. The
+// key is the value of the path param, and operator is one of =, !=, <, <=, >, >=, LIKE,
+// NOT LIKE, ILIKE or NOT ILIKE. The value is text, so compare it with a string param.
+//
+// Example:
+//
+//	.WhereJSONPath("metadata", "key", "=", "value")
+//	// WHERE ("metadata" ->> :key) = :value
+func (sb *Select[T]) WhereJSONPath(field, path, operator, param string) *Select[T] {
+	if sb.err != nil {
+		return sb
+	}
+	sb.builder, sb.err = whereJSONPathImpl(sb.instance, sb.builder, field, path, operator, param)
+	return sb
+}
+
+// --- Trigram Similarity Methods (PostgreSQL with pg_trgm) ---
+
+// WhereSimilar adds a trigram similarity match of field against the text in param.
+// With an empty threshold it renders the % operator, which matches above the
+// pg_trgm.similarity_threshold setting (0.3 by default) and can use a trigram index.
+// Otherwise the similarity must be at least the value of the threshold param.
+//
+// Example:
+//
+//	.WhereSimilar("name", "q", "")
+//	// WHERE "name" % :q
+//	.WhereSimilar("name", "q", "min")
+//	// WHERE similarity("name", :q) >= :min
+func (sb *Select[T]) WhereSimilar(field, param, threshold string) *Select[T] {
+	if sb.err != nil {
+		return sb
+	}
+	sb.builder, sb.err = whereSimilarImpl(sb.instance, sb.builder, field, param, threshold, false)
+	return sb
+}
+
+// WhereWordSimilar is like WhereSimilar but matches the text in param against the most
+// similar run of words in field, so a short query can match a long value.
+//
+// Example:
+//
+//	.WhereWordSimilar("title", "q", "")
+//	// WHERE "title" %> :q
+//	.WhereWordSimilar("title", "q", "min")
+//	// WHERE word_similarity(:q, "title") >= :min
+func (sb *Select[T]) WhereWordSimilar(field, param, threshold string) *Select[T] {
+	if sb.err != nil {
+		return sb
+	}
+	sb.builder, sb.err = whereSimilarImpl(sb.instance, sb.builder, field, param, threshold, true)
+	return sb
+}
+
+// SelectSimilarity adds the trigram similarity of field and the text in param AS alias
+// to the SELECT clause. Similarity ranges from 0 to 1.
+//
+// Example:
+//
+//	.SelectSimilarity("name", "q", "score")
+//	// SELECT similarity("name", :q) AS "score"
+func (sb *Select[T]) SelectSimilarity(field, param, alias string) *Select[T] {
+	if sb.err != nil {
+		return sb
+	}
+	sb.builder, sb.err = selectSimilarityImpl(sb.instance, sb.builder, field, param, alias, false)
+	return sb
+}
+
+// SelectWordSimilarity adds the word similarity of the text in param within field AS
+// alias to the SELECT clause.
+//
+// Example:
+//
+//	.SelectWordSimilarity("title", "q", "score")
+//	// SELECT word_similarity(:q, "title") AS "score"
+func (sb *Select[T]) SelectWordSimilarity(field, param, alias string) *Select[T] {
+	if sb.err != nil {
+		return sb
+	}
+	sb.builder, sb.err = selectSimilarityImpl(sb.instance, sb.builder, field, param, alias, true)
+	return sb
+}
+
+// OrderBySimilarity orders by the trigram distance between field and the text in param,
+// so "asc" puts the most similar first. Direction must be "asc" or "desc" (case insensitive).
+//
+// Example:
+//
+//	.OrderBySimilarity("name", "q", "asc")
+//	// ORDER BY "name" <-> :q ASC
+func (sb *Select[T]) OrderBySimilarity(field, param, direction string) *Select[T] {
+	if sb.err != nil {
+		return sb
+	}
+	sb.builder, sb.err = orderBySimilarityImpl(sb.instance, sb.builder, field, param, direction, false)
+	return sb
+}
+
+// OrderByWordSimilarity orders by the word similarity distance of the text in param
+// within field, so "asc" puts the best match first.
+//
+// Example:
+//
+//	.OrderByWordSimilarity("title", "q", "asc")
+//	// ORDER BY "title" <->> :q ASC
+func (sb *Select[T]) OrderByWordSimilarity(field, param, direction string) *Select[T] {
+	if sb.err != nil {
+		return sb
+	}
+	sb.builder, sb.err = orderBySimilarityImpl(sb.instance, sb.builder, field, param, direction, true)
+	return sb
+}
+
 // --- Cast Expression Methods ---
 
 // SelectCast adds CAST(field AS type) AS alias to the SELECT clause.
@@ -1001,21 +1275,69 @@ func (sb *Select[T]) SelectMaxOver(field string) *SelectWindowBuilder[T] {
 
 // SelectWindowBuilder is now defined in window.go
 
+// Clone returns an independent copy of the builder, including any stored error.
+// Use it to derive request-specific variants from a shared base query without
+// mutating the base.
+//
+// Example:
+//
+//	base := soy.Select().Where("status", "=", "status")
+//	byEmail := base.Clone().Where("email", "=", "email")
+func (sb *Select[T]) Clone() *Select[T] {
+	return &Select[T]{
+		instance: sb.instance,
+		builder:  cloneBuilder(sb.builder),
+		soy:      sb.soy,
+		optional: sb.optional.clone(),
+		scopes:   sb.scopes,
+		err:      sb.err,
+	}
+}
+
 // Render builds and renders the query to SQL with parameter placeholders.
 // Returns the SQL string and list of required parameters for sqlx execution.
+// Optional conditions are rendered as if all of their params were supplied.
 func (sb *Select[T]) Render() (*astql.QueryResult, error) {
 	// Check for  errors first
 	if sb.err != nil {
 		return nil, newBuilderError("select", sb.err)
 	}
 
-	result, err := sb.builder.Render(sb.soy.renderer())
+	result, err := sb.withConditions().Render(sb.soy.renderer())
 	if err != nil {
 		return nil, newRenderError("SELECT", err)
 	}
 	return result, nil
 }
 
+// withConditions returns a copy of the builder with its scopes and every optional
+// condition applied, as rendered by Render and set operations.
+func (sb *Select[T]) withConditions() *astql.Builder {
+	return sb.optional.applyAll(sb.scopes.apply(sb.soy, sb.builder))
+}
+
+// renderFor renders the query for execution with params, leaving out optional
+// conditions whose params are missing, and validates params against the result.
+func (sb *Select[T]) renderFor(params map[string]any) (*astql.QueryResult, error) {
+	builder, err := sb.optional.apply(sb.instance, sb.scopes.apply(sb.soy, sb.builder), params)
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := builder.Render(sb.soy.renderer())
+	if err != nil {
+		return nil, newRenderError("SELECT", err)
+	}
+
+	if err := validateParams(result.RequiredParams, params, sb.soy.strictParams(), sb.optional.paramNames()...); err != nil {
+		return nil, err
+	}
+	if err := validateEnumParams(sb.instance, builder, params); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
 // MustRender is like Render but panics on error.
 // This is intentionally preserved for cases where panicking is desired (e.g., tests, initialization).
 func (sb *Select[T]) MustRender() *astql.QueryResult {
@@ -1090,43 +1412,50 @@ func (sb *Select[T]) ExecTxAtom(ctx context.Context, tx *sqlx.Tx, params map[str
 
 // execAtom is the internal atom execution method used by both ExecAtom and ExecTxAtom.
 func (sb *Select[T]) execAtom(ctx context.Context, execer sqlx.ExtContext, params map[string]any) (*atom.Atom, error) {
+	params = sb.soy.bindParams(params)
 	if sb.err != nil {
 		return nil, newBuilderError("select", sb.err)
 	}
 
-	result, err := sb.Render()
+	result, err := sb.renderFor(params)
 	if err != nil {
-		return nil, err // already wrapped by Render
+		return nil, err // already wrapped by renderFor
 	}
 
-	return execAtomSingleRow(ctx, execer, sb.soy.atomScanner(), result.SQL, params, sb.soy.getTableName(), "SELECT")
+	return execAtomSingleRow(ctx, execer, sb.soy.statements(), dialectOf(sb.soy.renderer()), sb.soy.atomScanner(), result.SQL, params, sb.soy.getTableName(), "SELECT")
 }
 
 // exec is the internal execution method used by both Exec and ExecTx.
 func (sb *Select[T]) exec(ctx context.Context, execer sqlx.ExtContext, params map[string]any) (*T, error) {
+	params = sb.soy.bindParams(params)
 	// Check for  errors first
 	if sb.err != nil {
 		return nil, newBuilderError("select", sb.err)
 	}
 
 	// Render the query
-	result, err := sb.Render()
+	result, err := sb.renderFor(params)
 	if err != nil {
-		return nil, err // already wrapped by Render
+		return nil, err // already wrapped by renderFor
 	}
 
+	return sb.execSQL(ctx, execer, result.SQL, params)
+}
+
+// execSQL executes already-rendered SELECT SQL and scans exactly one row.
+func (sb *Select[T]) execSQL(ctx context.Context, execer sqlx.ExtContext, query string, params map[string]any) (*T, error) {
 	// Emit query started event
 	tableName := sb.soy.getTableName()
 	capitan.Debug(ctx, QueryStarted,
 		TableKey.Field(tableName),
 		OperationKey.Field("SELECT"),
-		SQLKey.Field(result.SQL),
+		SQLKey.Field(query),
 	)
 
 	startTime := time.Now()
 
 	// Execute named query
-	rows, err := sqlx.NamedQueryContext(ctx, execer, result.SQL, params)
+	rows, err := sb.soy.statements().namedQuery(ctx, execer, dialectOf(sb.soy.renderer()), query, params)
 	if err != nil {
 		durationMs := time.Since(startTime).Milliseconds()
 		capitan.Error(ctx, QueryFailed,
@@ -1153,7 +1482,7 @@ func (sb *Select[T]) exec(ctx context.Context, execer sqlx.ExtContext, params ma
 
 	// Scan the single row
 	var record T
-	if err := rows.StructScan(&record); err != nil {
+	if err := scanStruct(dialectOf(sb.soy.renderer()), rows, &record); err != nil {
 		durationMs := time.Since(startTime).Milliseconds()
 		capitan.Error(ctx, QueryFailed,
 			TableKey.Field(tableName),
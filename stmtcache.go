@@ -0,0 +1,256 @@
+package soy
+
+import (
+	"container/list"
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"net"
+	"strings"
+	"sync"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/zoobzio/capitan"
+)
+
+// stmtCache holds prepared named statements for a Soy instance, keyed by rendered SQL.
+// Statements are prepared against the Soy's *sqlx.DB; transactions receive a tx-bound
+// copy of a statement already in the cache and never prepare new entries. Least recently used statements are closed once the
+// cache exceeds its capacity.
+//
+// A nil *stmtCache is valid and executes every query unprepared.
+type stmtCache struct {
+	mu        sync.Mutex
+	db        *sqlx.DB
+	tableName string
+	capacity  int
+	entries   map[string]*list.Element
+	order     *list.List // front is most recently used
+}
+
+// stmtCacheEntry is a single cached statement.
+type stmtCacheEntry struct {
+	query string
+	stmt  *sqlx.NamedStmt
+}
+
+// newStmtCache creates a statement cache for db holding at most capacity statements.
+func newStmtCache(db *sqlx.DB, tableName string, capacity int) *stmtCache {
+	return &stmtCache{
+		db:        db,
+		tableName: tableName,
+		capacity:  capacity,
+		entries:   make(map[string]*list.Element),
+		order:     list.New(),
+	}
+}
+
+// namedQuery executes a named query, using a cached prepared statement when possible.
+// Params of registered, array and JSON-stored types are encoded first for the dialect d.
+func (sc *stmtCache) namedQuery(ctx context.Context, execer sqlx.ExtContext, d dialect, query string, arg any) (*sqlx.Rows, error) {
+	arg, err := encodeArg(d, arg)
+	if err != nil {
+		return nil, err
+	}
+
+	stmt, ok := sc.statement(ctx, execer, query)
+	if !ok {
+		return sqlx.NamedQueryContext(ctx, execer, query, arg)
+	}
+
+	rows, err := stmt.QueryxContext(ctx, arg)
+	if err != nil && sc.handleError(query, err) {
+		return sqlx.NamedQueryContext(ctx, execer, query, arg)
+	}
+	return rows, err
+}
+
+// namedExec executes a named statement, using a cached prepared statement when possible.
+// Params of registered, array and JSON-stored types are encoded first for the dialect d.
+func (sc *stmtCache) namedExec(ctx context.Context, execer sqlx.ExtContext, d dialect, query string, arg any) (sql.Result, error) {
+	arg, err := encodeArg(d, arg)
+	if err != nil {
+		return nil, err
+	}
+
+	stmt, ok := sc.statement(ctx, execer, query)
+	if !ok {
+		return sqlx.NamedExecContext(ctx, execer, query, arg)
+	}
+
+	res, err := stmt.ExecContext(ctx, arg)
+	if err != nil && sc.handleError(query, err) {
+		return sqlx.NamedExecContext(ctx, execer, query, arg)
+	}
+	return res, err
+}
+
+// statement returns the prepared statement for query bound to execer.
+// It reports false when execer is neither the cached *sqlx.DB nor a transaction,
+// or when preparing fails; callers then execute the query unprepared.
+func (sc *stmtCache) statement(ctx context.Context, execer sqlx.ExtContext, query string) (*sqlx.NamedStmt, bool) {
+	if sc == nil {
+		return nil, false
+	}
+
+	var tx *sqlx.Tx
+	switch e := execer.(type) {
+	case *sqlx.DB:
+		if e != sc.db {
+			return nil, false
+		}
+	case *sqlx.Tx:
+		tx = e
+	default:
+		return nil, false
+	}
+
+	stmt := sc.lookup(ctx, query)
+	if stmt == nil && tx != nil {
+		// Preparing on the pool would need a second connection while the transaction
+		// holds one; the statement is cached on its next execution outside a transaction
+		return nil, false
+	}
+	if stmt == nil {
+		prepared, err := sc.db.PrepareNamedContext(ctx, query)
+		if err != nil {
+			sc.handleError(query, err)
+			return nil, false
+		}
+		stmt = sc.store(ctx, query, prepared)
+	}
+
+	// Statements prepared on the pool are re-prepared on the transaction's connection
+	if tx != nil {
+		return tx.NamedStmtContext(ctx, stmt), true
+	}
+	return stmt, true
+}
+
+// lookup returns the cached statement for query and marks it most recently used.
+func (sc *stmtCache) lookup(ctx context.Context, query string) *sqlx.NamedStmt {
+	sc.mu.Lock()
+	elem, ok := sc.entries[query]
+	if ok {
+		sc.order.MoveToFront(elem)
+	}
+	sc.mu.Unlock()
+
+	if !ok {
+		capitan.Debug(ctx, StatementCacheMiss,
+			TableKey.Field(sc.tableName),
+			SQLKey.Field(query),
+		)
+		return nil
+	}
+
+	capitan.Debug(ctx, StatementCacheHit,
+		TableKey.Field(sc.tableName),
+		SQLKey.Field(query),
+	)
+	entry, _ := elem.Value.(*stmtCacheEntry)
+	return entry.stmt
+}
+
+// store caches a freshly prepared statement, evicting the least recently used
+// statements beyond capacity. If another goroutine cached the same query first,
+// the duplicate is closed and the existing statement is returned.
+func (sc *stmtCache) store(ctx context.Context, query string, stmt *sqlx.NamedStmt) *sqlx.NamedStmt {
+	sc.mu.Lock()
+	if elem, ok := sc.entries[query]; ok {
+		sc.order.MoveToFront(elem)
+		sc.mu.Unlock()
+		_ = stmt.Close()
+		entry, _ := elem.Value.(*stmtCacheEntry)
+		return entry.stmt
+	}
+
+	sc.entries[query] = sc.order.PushFront(&stmtCacheEntry{query: query, stmt: stmt})
+
+	var evicted []*stmtCacheEntry
+	for sc.order.Len() > sc.capacity {
+		oldest := sc.order.Back()
+		sc.order.Remove(oldest)
+		entry, _ := oldest.Value.(*stmtCacheEntry)
+		delete(sc.entries, entry.query)
+		evicted = append(evicted, entry)
+	}
+	sc.mu.Unlock()
+
+	for _, entry := range evicted {
+		_ = entry.stmt.Close()
+		capitan.Debug(ctx, StatementCacheEvicted,
+			TableKey.Field(sc.tableName),
+			SQLKey.Field(entry.query),
+		)
+	}
+
+	return stmt
+}
+
+// handleError invalidates cached statements after a failure.
+// Connection errors purge the whole cache, since statements are tied to server sessions.
+// A statement closed by a concurrent eviction is dropped and reported as retryable.
+func (sc *stmtCache) handleError(query string, err error) bool {
+	switch {
+	case isConnectionError(err):
+		sc.clear()
+		return false
+	case strings.Contains(err.Error(), "statement is closed"):
+		sc.remove(query)
+		return true
+	default:
+		return false
+	}
+}
+
+// remove drops query from the cache without closing its statement.
+func (sc *stmtCache) remove(query string) {
+	sc.mu.Lock()
+	defer sc.mu.Unlock()
+	if elem, ok := sc.entries[query]; ok {
+		sc.order.Remove(elem)
+		delete(sc.entries, query)
+	}
+}
+
+// clear closes and drops every cached statement.
+func (sc *stmtCache) clear() {
+	if sc == nil {
+		return
+	}
+
+	sc.mu.Lock()
+	entries := make([]*stmtCacheEntry, 0, sc.order.Len())
+	for elem := sc.order.Front(); elem != nil; elem = elem.Next() {
+		entry, _ := elem.Value.(*stmtCacheEntry)
+		entries = append(entries, entry)
+	}
+	sc.entries = make(map[string]*list.Element)
+	sc.order.Init()
+	sc.mu.Unlock()
+
+	for _, entry := range entries {
+		_ = entry.stmt.Close()
+	}
+}
+
+// size returns the number of cached statements.
+func (sc *stmtCache) size() int {
+	if sc == nil {
+		return 0
+	}
+	sc.mu.Lock()
+	defer sc.mu.Unlock()
+	return sc.order.Len()
+}
+
+// isConnectionError reports whether err indicates a broken database connection.
+func isConnectionError(err error) bool {
+	if errors.Is(err, driver.ErrBadConn) || errors.Is(err, sql.ErrConnDone) {
+		return true
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr)
+}
@@ -0,0 +1,300 @@
+package soy
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/zoobzio/astql/postgres"
+)
+
+// stmtCacheDriver is a minimal database/sql driver that counts prepared statements.
+type stmtCacheDriver struct {
+	prepares atomic.Int64
+	closes   atomic.Int64
+}
+
+func (d *stmtCacheDriver) Open(_ string) (driver.Conn, error) {
+	return &stmtCacheConn{driver: d}, nil
+}
+
+type stmtCacheConn struct {
+	driver *stmtCacheDriver
+}
+
+func (c *stmtCacheConn) Prepare(_ string) (driver.Stmt, error) {
+	c.driver.prepares.Add(1)
+	return &stmtCacheStmt{driver: c.driver}, nil
+}
+
+func (*stmtCacheConn) Close() error { return nil }
+
+func (*stmtCacheConn) Begin() (driver.Tx, error) { return stmtCacheTx{}, nil }
+
+type stmtCacheStmt struct {
+	driver *stmtCacheDriver
+}
+
+func (s *stmtCacheStmt) Close() error {
+	s.driver.closes.Add(1)
+	return nil
+}
+
+func (*stmtCacheStmt) NumInput() int { return -1 }
+
+func (*stmtCacheStmt) Exec(_ []driver.Value) (driver.Result, error) {
+	return driver.RowsAffected(1), nil
+}
+
+func (*stmtCacheStmt) Query(_ []driver.Value) (driver.Rows, error) {
+	return stmtCacheRows{}, nil
+}
+
+type stmtCacheTx struct{}
+
+func (stmtCacheTx) Commit() error   { return nil }
+func (stmtCacheTx) Rollback() error { return nil }
+
+type stmtCacheRows struct{}
+
+func (stmtCacheRows) Columns() []string           { return []string{"id"} }
+func (stmtCacheRows) Close() error                { return nil }
+func (stmtCacheRows) Next(_ []driver.Value) error { return io.EOF }
+
+var stmtCacheDriverSeq atomic.Int64
+
+// newStmtCacheDB registers a fresh counting driver and opens a database on it.
+func newStmtCacheDB(t *testing.T) (*sqlx.DB, *stmtCacheDriver) {
+	t.Helper()
+	drv := &stmtCacheDriver{}
+	name := fmt.Sprintf("soy_stmtcache_%d", stmtCacheDriverSeq.Add(1))
+	sql.Register(name, drv)
+	sqlx.BindDriver(name, sqlx.DOLLAR)
+
+	db, err := sqlx.Open(name, "")
+	if err != nil {
+		t.Fatalf("sqlx.Open() failed: %v", err)
+	}
+	db.SetMaxOpenConns(1)
+	t.Cleanup(func() { _ = db.Close() })
+	return db, drv
+}
+
+func TestStmtCache_Reuse(t *testing.T) {
+	db, drv := newStmtCacheDB(t)
+	cache := newStmtCache(db, "users", 2)
+	params := map[string]any{"id": 1}
+
+	for range 3 {
+		if _, err := cache.namedExec(t.Context(), db, dialectPostgres, `DELETE FROM "users" WHERE "id" = :id`, params); err != nil {
+			t.Fatalf("namedExec() failed: %v", err)
+		}
+	}
+
+	if got := drv.prepares.Load(); got != 1 {
+		t.Errorf("prepares = %d, want 1", got)
+	}
+	if cache.size() != 1 {
+		t.Errorf("size = %d, want 1", cache.size())
+	}
+}
+
+func TestStmtCache_LRUEviction(t *testing.T) {
+	db, drv := newStmtCacheDB(t)
+	cache := newStmtCache(db, "users", 2)
+	params := map[string]any{"id": 1}
+
+	queries := []string{
+		`SELECT "id" FROM "users" WHERE "id" = :id`,
+		`SELECT "id" FROM "users" WHERE "id" > :id`,
+	}
+	for _, query := range queries {
+		rows, err := cache.namedQuery(t.Context(), db, dialectPostgres, query, params)
+		if err != nil {
+			t.Fatalf("namedQuery() failed: %v", err)
+		}
+		_ = rows.Close()
+	}
+
+	// Touch the first query so the second becomes least recently used
+	rows, err := cache.namedQuery(t.Context(), db, dialectPostgres, queries[0], params)
+	if err != nil {
+		t.Fatalf("namedQuery() failed: %v", err)
+	}
+	_ = rows.Close()
+
+	rows, err = cache.namedQuery(t.Context(), db, dialectPostgres, `SELECT "id" FROM "users" WHERE "id" < :id`, params)
+	if err != nil {
+		t.Fatalf("namedQuery() failed: %v", err)
+	}
+	_ = rows.Close()
+
+	if cache.size() != 2 {
+		t.Fatalf("size = %d, want 2", cache.size())
+	}
+	if _, ok := cache.entries[queries[1]]; ok {
+		t.Error("least recently used statement should be evicted")
+	}
+	if _, ok := cache.entries[queries[0]]; !ok {
+		t.Error("recently used statement should be kept")
+	}
+	if got := drv.closes.Load(); got != 1 {
+		t.Errorf("closes = %d, want 1", got)
+	}
+}
+
+func TestStmtCache_Transaction(t *testing.T) {
+	db, drv := newStmtCacheDB(t)
+	db.SetMaxOpenConns(2)
+	cache := newStmtCache(db, "users", 4)
+	query := `UPDATE "users" SET "name" = :name WHERE "id" = :id`
+	params := map[string]any{"name": "A", "id": 1}
+
+	tx, err := db.Beginx()
+	if err != nil {
+		t.Fatalf("Beginx() failed: %v", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	// A miss inside a transaction executes unprepared and leaves the cache untouched
+	if _, err := cache.namedExec(t.Context(), tx, dialectPostgres, query, params); err != nil {
+		t.Fatalf("namedExec() in tx failed: %v", err)
+	}
+	if cache.size() != 0 {
+		t.Errorf("size = %d, want 0 after tx miss", cache.size())
+	}
+
+	// Once cached on the pool, the transaction reuses the statement
+	if _, err := cache.namedExec(t.Context(), db, dialectPostgres, query, params); err != nil {
+		t.Fatalf("namedExec() failed: %v", err)
+	}
+	before := drv.prepares.Load()
+	if _, err := cache.namedExec(t.Context(), tx, dialectPostgres, query, params); err != nil {
+		t.Fatalf("namedExec() in tx failed: %v", err)
+	}
+	if cache.size() != 1 {
+		t.Errorf("size = %d, want 1", cache.size())
+	}
+	if got := drv.prepares.Load() - before; got > 1 {
+		t.Errorf("tx re-prepared %d statements, want at most 1", got)
+	}
+}
+
+func TestStmtCache_Invalidation(t *testing.T) {
+	db, drv := newStmtCacheDB(t)
+	cache := newStmtCache(db, "users", 4)
+	params := map[string]any{"id": 1}
+
+	for _, query := range []string{`DELETE FROM "users" WHERE "id" = :id`, `DELETE FROM "users" WHERE "id" > :id`} {
+		if _, err := cache.namedExec(t.Context(), db, dialectPostgres, query, params); err != nil {
+			t.Fatalf("namedExec() failed: %v", err)
+		}
+	}
+
+	cache.handleError("", &net.OpError{Op: "read", Err: errors.New("connection reset")})
+
+	if cache.size() != 0 {
+		t.Errorf("size = %d, want 0 after connection error", cache.size())
+	}
+	if got := drv.closes.Load(); got != 2 {
+		t.Errorf("closes = %d, want 2", got)
+	}
+}
+
+func TestStmtCache_ConnectionErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"bad conn", driver.ErrBadConn, true},
+		{"conn done", fmt.Errorf("exec: %w", sql.ErrConnDone), true},
+		{"net error", &net.OpError{Op: "dial", Err: errors.New("refused")}, true},
+		{"query error", errors.New("syntax error"), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isConnectionError(tt.err); got != tt.want {
+				t.Errorf("isConnectionError() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestStmtCache_Concurrent(t *testing.T) {
+	db, _ := newStmtCacheDB(t)
+	db.SetMaxOpenConns(4)
+	cache := newStmtCache(db, "users", 2)
+
+	var wg sync.WaitGroup
+	for i := range 8 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			query := fmt.Sprintf(`DELETE FROM "users" WHERE "id" = :id AND %d = %d`, i%3, i%3)
+			if _, err := cache.namedExec(t.Context(), db, dialectPostgres, query, map[string]any{"id": i}); err != nil {
+				t.Errorf("namedExec() failed: %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if cache.size() > 2 {
+		t.Errorf("size = %d, exceeds capacity", cache.size())
+	}
+}
+
+func TestSoy_EnableStatementCache(t *testing.T) {
+	t.Run("requires a database", func(t *testing.T) {
+		s, err := New[batchTestUser](nil, "users", postgres.New())
+		if err != nil {
+			t.Fatalf("New() failed: %v", err)
+		}
+		if err := s.EnableStatementCache(10); err == nil {
+			t.Error("expected error without *sqlx.DB")
+		}
+	})
+
+	t.Run("rejects non-positive capacity", func(t *testing.T) {
+		db, _ := newStmtCacheDB(t)
+		s, err := New[batchTestUser](db, "users", postgres.New())
+		if err != nil {
+			t.Fatalf("New() failed: %v", err)
+		}
+		if err := s.EnableStatementCache(0); err == nil {
+			t.Error("expected error for zero capacity")
+		}
+	})
+
+	t.Run("routes executions through the cache", func(t *testing.T) {
+		db, drv := newStmtCacheDB(t)
+		s, err := New[batchTestUser](db, "users", postgres.New())
+		if err != nil {
+			t.Fatalf("New() failed: %v", err)
+		}
+		if err := s.EnableStatementCache(10); err != nil {
+			t.Fatalf("EnableStatementCache() failed: %v", err)
+		}
+
+		for range 3 {
+			if _, err := s.Remove().Where("id", "=", "user_id").Exec(t.Context(), map[string]any{"user_id": 1}); err != nil {
+				t.Fatalf("Exec() failed: %v", err)
+			}
+		}
+		if got := drv.prepares.Load(); got != 1 {
+			t.Errorf("prepares = %d, want 1", got)
+		}
+
+		s.ClearStatementCache()
+		if s.statements().size() != 0 {
+			t.Error("ClearStatementCache() should empty the cache")
+		}
+	})
+}
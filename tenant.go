@@ -0,0 +1,179 @@
+package soy
+
+import (
+	"fmt"
+	"maps"
+	"reflect"
+	"slices"
+
+	"github.com/zoobzio/astql"
+)
+
+// tenantParam is the param that binds the tenant of a scoped instance.
+const tenantParam = "__tenant"
+
+// tenantScope is the tenant a scoped Soy instance is restricted to.
+// Its methods are safe to call on a nil scope, which leaves everything unscoped.
+type tenantScope struct {
+	column string
+	value  any
+	stamp  reflect.Value // value converted to the type of the column's field
+	index  []int         // index of the column's field in T
+}
+
+// ForTenant returns a copy of the instance scoped to one tenant, identified by value in
+// the tenant column field. Every Select, Query, Aggregate, Update and Delete built from
+// the copy matches "field = :__tenant", and compound queries, nearest-neighbour and
+// hybrid searches inherit that from their queries. The param is bound on every
+// execution, overriding any __tenant key in the params map.
+//
+// Inserts stamp the tenant on each record after the OnRecord callback, and ExecAtom
+// binds it to the column param. Set, SetExpr and DoUpdate Set reject the tenant column,
+// and OnConflict must include it, so an upsert never updates another tenant's row.
+//
+// Register OnScan and OnRecord callbacks before scoping; the copy keeps the callbacks,
+// settings and statement cache the instance has when ForTenant is called. Instance()
+// returns the unscoped ASTQL instance.
+//
+// Example:
+//
+//	orders, err := soy.ForTenant("tenant_id", tenantID)
+//	if err != nil {
+//	    return err
+//	}
+//	pending, err := orders.Query().
+//	    Where("status", "=", "status").
+//	    Exec(ctx, map[string]any{"status": "pending"})
+//	// SELECT ... WHERE "tenant_id" = :__tenant AND "status" = :status
+func (c *Soy[T]) ForTenant(field string, value any) (*Soy[T], error) {
+	if c.tenantScope != nil {
+		return nil, fmt.Errorf("soy: instance is already scoped to a tenant in %q", c.tenantScope.column)
+	}
+	if value == nil {
+		return nil, fmt.Errorf("soy: tenant value for %q is nil", field)
+	}
+
+	target, ok := columnFields(c.metadata)[field]
+	if !ok {
+		return nil, newFieldUsageError(field, fmt.Sprintf("tenant column %q is not a column of %s", field, c.tableName))
+	}
+	if generatedColumn(target) {
+		return nil, newFieldUsageError(field, fmt.Sprintf("tenant column %q is generated", field))
+	}
+
+	fieldType := reflect.TypeFor[T]().FieldByIndex(target.Index).Type
+	stamp := reflect.ValueOf(value)
+	switch {
+	case stamp.Type().ConvertibleTo(fieldType):
+		stamp = stamp.Convert(fieldType)
+	case fieldType.Kind() == reflect.Pointer && stamp.Type().ConvertibleTo(fieldType.Elem()):
+		ptr := reflect.New(fieldType.Elem())
+		ptr.Elem().Set(stamp.Convert(fieldType.Elem()))
+		stamp = ptr
+	default:
+		return nil, newFieldUsageError(field, fmt.Sprintf("tenant value of type %T does not fit field %s of type %s", value, target.Name, fieldType))
+	}
+
+	scoped := *c
+	scoped.tenantScope = &tenantScope{column: field, value: value, stamp: stamp, index: target.Index}
+	return &scoped, nil
+}
+
+// tenant returns the tenant scope of the instance, or nil when it is unscoped.
+func (c *Soy[T]) tenant() *tenantScope {
+	return c.tenantScope
+}
+
+// condition returns the condition that restricts a builder to the tenant, or nil.
+func (s *tenantScope) condition(instance *astql.ASTQL) (astql.ConditionItem, error) {
+	if s == nil {
+		return nil, nil
+	}
+	f, err := instance.TryF(s.column)
+	if err != nil {
+		return nil, newFieldError(s.column, err)
+	}
+	p, err := instance.TryP(tenantParam)
+	if err != nil {
+		return nil, newParamError(tenantParam, err)
+	}
+	condition, err := instance.TryC(f, astql.EQ, p)
+	if err != nil {
+		return nil, newConditionError(err)
+	}
+	return condition, nil
+}
+
+// params returns a copy of params with the tenant bound, or params itself when unscoped.
+func (s *tenantScope) params(params map[string]any) map[string]any {
+	if s == nil {
+		return params
+	}
+	scoped := make(map[string]any, len(params)+1)
+	maps.Copy(scoped, params)
+	scoped[tenantParam] = s.value
+	return scoped
+}
+
+// insertParams binds the tenant to the tenant column param of an INSERT.
+func (s *tenantScope) insertParams(params map[string]any) map[string]any {
+	if s == nil {
+		return params
+	}
+	scoped := make(map[string]any, len(params)+1)
+	maps.Copy(scoped, params)
+	scoped[s.column] = s.value
+	return scoped
+}
+
+// stampRecord sets the tenant column field of record, a pointer to the instance's model.
+func (s *tenantScope) stampRecord(record reflect.Value) {
+	if s == nil {
+		return
+	}
+	record.Elem().FieldByIndex(s.index).Set(s.stamp)
+}
+
+// checkSet rejects assignments to the tenant column.
+func (s *tenantScope) checkSet(field string) error {
+	if s == nil || field != s.column {
+		return nil
+	}
+	return newFieldUsageError(field, fmt.Sprintf("tenant column %q of a scoped instance cannot be set", field))
+}
+
+// checkConflict rejects ON CONFLICT targets that do not include the tenant column, since
+// the conflicting row could belong to another tenant.
+func (s *tenantScope) checkConflict(columns []string) error {
+	if s == nil || slices.Contains(columns, s.column) {
+		return nil
+	}
+	return newFieldUsageError(s.column, fmt.Sprintf("conflict columns of a scoped instance must include the tenant column %q", s.column))
+}
+
+// checkUpdate rejects DO UPDATE on MariaDB, whose ON DUPLICATE KEY UPDATE fires on any
+// unique key rather than the conflict columns, so the updated row could belong to another tenant.
+func (s *tenantScope) checkUpdate(d dialect) error {
+	if s == nil || d != dialectMariaDB {
+		return nil
+	}
+	return newFieldUsageError(s.column, "DO UPDATE of a scoped instance is not supported on MariaDB, whose ON DUPLICATE KEY UPDATE can match a row of another tenant")
+}
+
+// sameTenant reports whether two scopes restrict to the same tenant.
+func sameTenant(a, b *tenantScope) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return a.column == b.column && reflect.DeepEqual(a.value, b.value)
+}
+
+// scopeBuilder restricts builder to the tenant of a scoped instance. It returns the
+// tenant condition, which is nil when the instance is unscoped.
+func (c *Soy[T]) scopeBuilder(builder *astql.Builder) (*astql.Builder, astql.ConditionItem, error) {
+	condition, err := c.tenantScope.condition(c.instance)
+	if err != nil || condition == nil {
+		return builder, nil, err
+	}
+	return builder.Where(condition), condition, nil
+}
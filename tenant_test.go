@@ -0,0 +1,297 @@
+package soy
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/zoobzio/astql"
+	"github.com/zoobzio/astql/mariadb"
+	"github.com/zoobzio/astql/postgres"
+)
+
+type tenantTestOrder struct {
+	ID       int     `db:"id" type:"serial" constraints:"primarykey"`
+	TenantID string  `db:"tenant_id" type:"text" index:"orders_tenant_status,unique"`
+	Status   string  `db:"status" type:"text" index:"orders_tenant_status,unique"`
+	Note     *string `db:"note" type:"text"`
+}
+
+func newTenantTestOrders(t *testing.T) (*Soy[tenantTestOrder], *Soy[tenantTestOrder]) {
+	t.Helper()
+	s, err := New[tenantTestOrder](&sqlx.DB{}, "orders", postgres.New())
+	if err != nil {
+		t.Fatalf("New() failed: %v", err)
+	}
+	scoped, err := s.ForTenant("tenant_id", "acme")
+	if err != nil {
+		t.Fatalf("ForTenant() failed: %v", err)
+	}
+	return s, scoped
+}
+
+func TestForTenant_Errors(t *testing.T) {
+	s, scoped := newTenantTestOrders(t)
+
+	if _, err := s.ForTenant("org_id", "acme"); !errors.Is(err, ErrInvalidField) {
+		t.Errorf("expected ErrInvalidField for unknown column, got %v", err)
+	}
+	if _, err := s.ForTenant("tenant_id", nil); err == nil {
+		t.Error("expected error for nil tenant")
+	}
+	if _, err := s.ForTenant("tenant_id", 42.5); !errors.Is(err, ErrInvalidField) {
+		t.Errorf("expected ErrInvalidField for mismatched type, got %v", err)
+	}
+	if _, err := scoped.ForTenant("tenant_id", "other"); err == nil || !strings.Contains(err.Error(), "already scoped") {
+		t.Errorf("expected already scoped error, got %v", err)
+	}
+	if _, err := s.ForTenant("note", "acme"); err != nil {
+		t.Errorf("ForTenant() on a pointer field failed: %v", err)
+	}
+}
+
+func TestForTenant_Render(t *testing.T) {
+	s, scoped := newTenantTestOrders(t)
+
+	tests := []struct {
+		name   string
+		render func() (string, error)
+		want   string
+	}{
+		{"query", func() (string, error) {
+			r, err := scoped.Query().Where("status", "=", "status").Render()
+			return sqlOf(r, err)
+		}, `WHERE ("tenant_id" = :__tenant AND "status" = :status)`},
+		{"select", func() (string, error) {
+			r, err := scoped.Select().Where("id", "=", "id").Render()
+			return sqlOf(r, err)
+		}, `WHERE ("tenant_id" = :__tenant AND "id" = :id)`},
+		{"count", func() (string, error) {
+			r, err := scoped.Count().Render()
+			return sqlOf(r, err)
+		}, `SELECT COUNT(*) FROM "orders" WHERE "tenant_id" = :__tenant`},
+		{"max", func() (string, error) {
+			r, err := scoped.Max("id").Render()
+			return sqlOf(r, err)
+		}, `WHERE "tenant_id" = :__tenant`},
+		{"compound", func() (string, error) {
+			r, err := scoped.Query().Where("status", "=", "a").Union(scoped.Query().Where("status", "=", "b")).Render()
+			return sqlOf(r, err)
+		}, `WHERE ("tenant_id" = :q1___tenant AND "status" = :q1_b)`},
+		{"update", func() (string, error) {
+			r, err := scoped.Modify().Set("status", "status").Where("id", "=", "id").Render()
+			return sqlOf(r, err)
+		}, `WHERE ("tenant_id" = :__tenant AND "id" = :id)`},
+		{"delete", func() (string, error) {
+			r, err := scoped.Remove().Where("id", "=", "id").Render()
+			return sqlOf(r, err)
+		}, `DELETE FROM "orders" WHERE ("tenant_id" = :__tenant AND "id" = :id)`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sql, err := tt.render()
+			if err != nil {
+				t.Fatalf("Render() failed: %v", err)
+			}
+			if !strings.Contains(sql, tt.want) {
+				t.Errorf("SQL missing %q:\n%s", tt.want, sql)
+			}
+		})
+	}
+
+	t.Run("original instance is unscoped", func(t *testing.T) {
+		result, err := s.Query().Render()
+		if err != nil {
+			t.Fatalf("Render() failed: %v", err)
+		}
+		if strings.Contains(result.SQL, "__tenant") {
+			t.Errorf("unscoped SQL = %s", result.SQL)
+		}
+	})
+
+	t.Run("set-based batches key on the tenant", func(t *testing.T) {
+		update := scoped.Modify().Set("status", "status").Where("id", "=", "id")
+		sql, ok := update.batch.renderSetUpdate(dialectPostgres, "orders", scoped.getMetadata(), 2)
+		if !ok || !strings.Contains(sql, `"orders"."tenant_id" = "soy_batch"."__tenant"`) {
+			t.Errorf("renderSetUpdate() = %s, %v", sql, ok)
+		}
+		remove := scoped.Remove().Where("id", "=", "id")
+		sql, ok = remove.batch.renderSetDelete(dialectPostgres, "orders", 2)
+		if !ok || !strings.Contains(sql, `("tenant_id" = :__tenant_1 AND "id" = :id_1)`) {
+			t.Errorf("renderSetDelete() = %s, %v", sql, ok)
+		}
+	})
+}
+
+func sqlOf(result *astql.QueryResult, err error) (string, error) {
+	if err != nil {
+		return "", err
+	}
+	return result.SQL, nil
+}
+
+func TestForTenant_Params(t *testing.T) {
+	_, scoped := newTenantTestOrders(t)
+	scoped.StrictParams(true)
+
+	params := scoped.tenant().params(map[string]any{"status": "open", tenantParam: "other"})
+	if params[tenantParam] != "acme" || params["status"] != "open" {
+		t.Errorf("params() = %v", params)
+	}
+	if _, err := scoped.Query().Where("status", "=", "status").renderFor(params); err != nil {
+		t.Errorf("renderFor() with scoped params failed: %v", err)
+	}
+
+	compound := scoped.bindCompoundParams(map[string]any{"q0_a": 1}, []string{"q0___tenant", "q0_a", "q1___tenant"})
+	if compound["q0___tenant"] != "acme" || compound["q1___tenant"] != "acme" || compound["q0_a"] != 1 {
+		t.Errorf("bindCompoundParams() = %v", compound)
+	}
+
+	batch := scoped.bindBatchParams([]map[string]any{{"id": 1}, {"id": 2}})
+	if batch[0][tenantParam] != "acme" || batch[1][tenantParam] != "acme" {
+		t.Errorf("bindBatchParams() = %v", batch)
+	}
+
+	if got := scoped.tenant().insertParams(map[string]any{"tenant_id": "other"}); got["tenant_id"] != "acme" {
+		t.Errorf("insertParams() = %v", got)
+	}
+
+	var unscoped *tenantScope
+	original := map[string]any{"status": "open"}
+	if got := unscoped.params(original); len(got) != 1 {
+		t.Errorf("nil scope params() = %v", got)
+	}
+
+	t.Run("update without WHERE is still rejected", func(t *testing.T) {
+		_, err := scoped.Modify().Set("status", "status").Exec(context.Background(), map[string]any{"status": "open"})
+		if err == nil || !strings.Contains(err.Error(), "requires at least one WHERE") {
+			t.Errorf("expected WHERE error, got %v", err)
+		}
+		_, err = scoped.Remove().Exec(context.Background(), nil)
+		if err == nil || !strings.Contains(err.Error(), "requires at least one WHERE") {
+			t.Errorf("expected WHERE error, got %v", err)
+		}
+	})
+}
+
+func TestForTenant_Writes(t *testing.T) {
+	s, scoped := newTenantTestOrders(t)
+
+	t.Run("records are stamped after OnRecord", func(t *testing.T) {
+		s.OnRecord(func(_ context.Context, record *tenantTestOrder) error {
+			record.TenantID = "evil"
+			return nil
+		})
+		stamped, err := s.ForTenant("tenant_id", "acme")
+		if err != nil {
+			t.Fatalf("ForTenant() failed: %v", err)
+		}
+		order := &tenantTestOrder{TenantID: "other"}
+		if err := stamped.callOnRecord(context.Background(), order); err != nil {
+			t.Fatalf("callOnRecord() failed: %v", err)
+		}
+		if order.TenantID != "acme" {
+			t.Errorf("TenantID = %q, want acme", order.TenantID)
+		}
+
+		notes, err := New[tenantTestOrder](nil, "orders", postgres.New())
+		if err != nil {
+			t.Fatalf("New() failed: %v", err)
+		}
+		byNote, err := notes.ForTenant("note", "acme")
+		if err != nil {
+			t.Fatalf("ForTenant() failed: %v", err)
+		}
+		if err := byNote.callOnRecord(context.Background(), order); err != nil || order.Note == nil || *order.Note != "acme" {
+			t.Errorf("pointer tenant not stamped: %v, %v", order.Note, err)
+		}
+	})
+
+	t.Run("tenant column cannot be set", func(t *testing.T) {
+		if _, err := scoped.Modify().Set("tenant_id", "t").Where("id", "=", "id").Render(); !errors.Is(err, ErrInvalidField) {
+			t.Errorf("Set() error = %v", err)
+		}
+		if _, err := scoped.Modify().SetExpr("tenant_id", "+", "t").Where("id", "=", "id").Render(); !errors.Is(err, ErrInvalidField) {
+			t.Errorf("SetExpr() error = %v", err)
+		}
+		if _, err := scoped.InsertFull().OnConflict("id", "tenant_id").DoUpdate().Set("tenant_id", "tenant_id").Build().Render(); !errors.Is(err, ErrInvalidField) {
+			t.Errorf("DoUpdate Set() error = %v", err)
+		}
+		if _, err := s.Modify().Set("tenant_id", "t").Where("id", "=", "id").Render(); err != nil {
+			t.Errorf("unscoped Set() error = %v", err)
+		}
+	})
+
+	t.Run("conflict columns must include the tenant", func(t *testing.T) {
+		if _, err := scoped.InsertFull().OnConflict("id").DoNothing().Render(); err == nil || !strings.Contains(err.Error(), "must include the tenant column") {
+			t.Errorf("OnConflict() error = %v", err)
+		}
+		if _, err := scoped.InsertFull().OnConflict("tenant_id", "id").DoNothing().Render(); err != nil {
+			t.Errorf("OnConflict() with tenant error = %v", err)
+		}
+	})
+
+	t.Run("compound queries must share the scope", func(t *testing.T) {
+		if _, err := scoped.Query().Union(s.Query()).Render(); err == nil || !strings.Contains(err.Error(), "different tenant scopes") {
+			t.Errorf("Union() error = %v", err)
+		}
+		other, err := s.ForTenant("tenant_id", "globex")
+		if err != nil {
+			t.Fatalf("ForTenant() failed: %v", err)
+		}
+		if _, err := scoped.Query().Union(scoped.Query()).Except(other.Query()).Render(); err == nil {
+			t.Error("expected error combining different tenants")
+		}
+		same, err := s.ForTenant("tenant_id", "acme")
+		if err != nil {
+			t.Fatalf("ForTenant() failed: %v", err)
+		}
+		if _, err := scoped.Query().Union(same.Query()).Render(); err != nil {
+			t.Errorf("Union() of the same tenant error = %v", err)
+		}
+	})
+}
+
+func TestForTenant_MariaDBUpsert(t *testing.T) {
+	drv := &nearestDriver{cols: []string{"id", "tenant_id", "status", "note"}}
+	name := fmt.Sprintf("soy_tenant_%d", verifyDriverSeq.Add(1))
+	sql.Register(name, drv)
+	db, err := sqlx.Open(name, "")
+	if err != nil {
+		t.Fatalf("sqlx.Open() failed: %v", err)
+	}
+	t.Cleanup(func() { _ = db.Close() })
+
+	s, err := New[tenantTestOrder](db, "orders", mariadb.New())
+	if err != nil {
+		t.Fatalf("New() failed: %v", err)
+	}
+	scoped, err := s.ForTenant("tenant_id", "acme")
+	if err != nil {
+		t.Fatalf("ForTenant() failed: %v", err)
+	}
+
+	// ON DUPLICATE KEY UPDATE would overwrite a row of another tenant that shares any
+	// unique key, so the upsert must never reach the database.
+	upsert := scoped.Insert().OnConflict("tenant_id", "status").DoUpdate().Set("note", "note").Build()
+	if _, err := upsert.Exec(t.Context(), &tenantTestOrder{Status: "open"}); !errors.Is(err, ErrInvalidField) {
+		t.Errorf("Exec() error = %v", err)
+	}
+	if _, err := upsert.ExecBatchUpsert(t.Context(), []*tenantTestOrder{{Status: "open"}}); !errors.Is(err, ErrInvalidField) {
+		t.Errorf("ExecBatchUpsert() error = %v", err)
+	}
+	if len(drv.log) != 0 {
+		t.Errorf("statements reached the database: %q", drv.log)
+	}
+
+	if _, err := scoped.Insert().OnConflict("tenant_id", "status").DoNothing().Render(); err != nil {
+		t.Errorf("DoNothing() error = %v", err)
+	}
+	if _, err := s.Insert().OnConflict("status", "tenant_id").DoUpdate().Set("note", "note").Build().Render(); err != nil {
+		t.Errorf("unscoped DoUpdate() error = %v", err)
+	}
+}
@@ -39,6 +39,28 @@ type TestUserExtended struct {
 	Metadata  *string    `db:"metadata" type:"jsonb"`
 }
 
+// TestUserMetadata is the JSON document stored in test_users_extended.metadata.
+type TestUserMetadata struct {
+	Role        string   `json:"role"`
+	Permissions []string `json:"permissions"`
+}
+
+// TestUserWithMetadata maps test_users_extended with a structured JSONB column.
+type TestUserWithMetadata struct {
+	ID       int               `db:"id" type:"serial" constraints:"primarykey"`
+	Email    string            `db:"email" type:"text" constraints:"notnull,unique"`
+	Name     string            `db:"name" type:"text" constraints:"notnull"`
+	Metadata *TestUserMetadata `db:"metadata" type:"jsonb"`
+}
+
+// TestUserWithTags maps test_users_extended with a native TEXT[] column.
+type TestUserWithTags struct {
+	ID    int      `db:"id" type:"serial" constraints:"primarykey"`
+	Email string   `db:"email" type:"text" constraints:"notnull,unique"`
+	Name  string   `db:"name" type:"text" constraints:"notnull"`
+	Tags  []string `db:"tags"`
+}
+
 // TestVectorWithPgvector is a model for pgvector tests.
 type TestVectorWithPgvector struct {
 	ID        int    `db:"id" type:"serial" constraints:"primarykey"`
@@ -152,7 +174,8 @@ func createAllTables(db *sqlx.DB) error {
 			is_active BOOLEAN,
 			created_at TIMESTAMPTZ DEFAULT NOW(),
 			updated_at TIMESTAMPTZ,
-			metadata JSONB
+			metadata JSONB,
+			tags TEXT[]
 		)`,
 		`CREATE TABLE IF NOT EXISTS test_vectors (
 			id SERIAL PRIMARY KEY,
@@ -0,0 +1,145 @@
+package integration
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"testing"
+
+	"github.com/zoobzio/astql/postgres"
+	"github.com/zoobzio/soy"
+)
+
+// copyTestUsers returns n distinct users for COPY tests.
+func copyTestUsers(n int) []*TestUser {
+	users := make([]*TestUser, n)
+	for i := range users {
+		users[i] = &TestUser{
+			Email: fmt.Sprintf("copy%d@example.com", i),
+			Name:  fmt.Sprintf("Copy User %d", i),
+			Age:   intPtr(20 + i%50),
+		}
+	}
+	return users
+}
+
+func TestCopy_Integration(t *testing.T) {
+	db := getTestDB(t)
+
+	c, err := soy.New[TestUser](db, "test_users", postgres.New())
+	if err != nil {
+		t.Fatalf("New() failed: %v", err)
+	}
+
+	ctx := context.Background()
+
+	t.Run("copy rows", func(t *testing.T) {
+		truncateTestTable(t, db)
+
+		const n = 1000
+		copied, err := c.Insert().Copy(ctx, slices.Values(copyTestUsers(n)))
+		if err != nil {
+			t.Fatalf("Copy() failed: %v", err)
+		}
+		if copied != n {
+			t.Errorf("expected %d rows copied, got %d", n, copied)
+		}
+
+		count, err := c.Count().Exec(ctx, nil)
+		if err != nil {
+			t.Fatalf("Count().Exec() failed: %v", err)
+		}
+		if count != n {
+			t.Errorf("expected count %d, got %v", n, count)
+		}
+
+		user, err := c.Select().
+			Where("email", "=", "email").
+			Exec(ctx, map[string]any{"email": "copy999@example.com"})
+		if err != nil {
+			t.Fatalf("Select().Exec() failed: %v", err)
+		}
+		if user.Name != "Copy User 999" || user.Age == nil || *user.Age != 69 {
+			t.Errorf("unexpected copied row: %+v", user)
+		}
+	})
+
+	t.Run("failed copy is rolled back", func(t *testing.T) {
+		truncateTestTable(t, db)
+
+		// The duplicate email violates the unique constraint when COPY flushes
+		users := copyTestUsers(100)
+		users[50].Email = users[10].Email
+
+		if _, err := c.Insert().Copy(ctx, slices.Values(users)); err == nil {
+			t.Fatal("expected error for duplicate email")
+		}
+
+		count, err := c.Count().Exec(ctx, nil)
+		if err != nil {
+			t.Fatalf("Count().Exec() failed: %v", err)
+		}
+		if count != 0 {
+			t.Errorf("expected failed COPY to leave no rows, got %v", count)
+		}
+	})
+
+	t.Run("nil record aborts the copy", func(t *testing.T) {
+		truncateTestTable(t, db)
+
+		users := copyTestUsers(10)
+		users[5] = nil
+
+		if _, err := c.Insert().Copy(ctx, slices.Values(users)); err == nil {
+			t.Fatal("expected error for nil record")
+		}
+
+		count, err := c.Count().Exec(ctx, nil)
+		if err != nil {
+			t.Fatalf("Count().Exec() failed: %v", err)
+		}
+		if count != 0 {
+			t.Errorf("expected aborted COPY to leave no rows, got %v", count)
+		}
+	})
+
+	t.Run("copy within transaction", func(t *testing.T) {
+		truncateTestTable(t, db)
+
+		tx, err := db.BeginTxx(ctx, nil)
+		if err != nil {
+			t.Fatalf("BeginTxx() failed: %v", err)
+		}
+
+		copied, err := c.Insert().CopyTx(ctx, tx, slices.Values(copyTestUsers(25)))
+		if err != nil {
+			tx.Rollback()
+			t.Fatalf("CopyTx() failed: %v", err)
+		}
+		if copied != 25 {
+			t.Errorf("expected 25 rows copied, got %d", copied)
+		}
+
+		// Rows are not visible outside the transaction until it commits
+		count, err := c.Count().Exec(ctx, nil)
+		if err != nil {
+			tx.Rollback()
+			t.Fatalf("Count().Exec() failed: %v", err)
+		}
+		if count != 0 {
+			t.Errorf("expected uncommitted rows to be invisible, got %v", count)
+		}
+
+		if err := tx.Rollback(); err != nil {
+			t.Fatalf("Rollback() failed: %v", err)
+		}
+
+		count, err = c.Count().Exec(ctx, nil)
+		if err != nil {
+			t.Fatalf("Count().Exec() failed: %v", err)
+		}
+		if count != 0 {
+			t.Errorf("expected rolled back COPY to leave no rows, got %v", count)
+		}
+	})
+}
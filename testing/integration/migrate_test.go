@@ -0,0 +1,84 @@
+package integration
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"testing/fstest"
+
+	"github.com/zoobzio/astql/postgres"
+	"github.com/zoobzio/soy/migrate"
+)
+
+func TestMigrate_UpDown(t *testing.T) {
+	db := getTestDB(t)
+	ctx := context.Background()
+
+	fsys := fstest.MapFS{
+		"0001_create_items.up.sql":   {Data: []byte("CREATE TABLE migrate_items (id SERIAL PRIMARY KEY, name TEXT NOT NULL)")},
+		"0001_create_items.down.sql": {Data: []byte("DROP TABLE migrate_items")},
+		"0002_add_price.up.sql":      {Data: []byte("ALTER TABLE migrate_items ADD price NUMERIC; CREATE INDEX idx_migrate_items_name ON migrate_items (name)")},
+		"0002_add_price.down.sql":    {Data: []byte("DROP INDEX idx_migrate_items_name; ALTER TABLE migrate_items DROP COLUMN price")},
+	}
+
+	m, err := migrate.New(db, postgres.New(), fsys)
+	if err != nil {
+		t.Fatalf("New() failed: %v", err)
+	}
+	m.TrackingTable("migrate_test_versions")
+	t.Cleanup(func() {
+		_, _ = db.Exec("DROP TABLE IF EXISTS migrate_items")
+		_, _ = db.Exec("DROP TABLE IF EXISTS migrate_test_versions")
+	})
+
+	applied, err := m.Up(ctx)
+	if err != nil {
+		t.Fatalf("Up() failed: %v", err)
+	}
+	if len(applied) != 2 {
+		t.Fatalf("applied %d migrations, want 2", len(applied))
+	}
+	if _, err := db.Exec("INSERT INTO migrate_items (name, price) VALUES ('a', 1.5)"); err != nil {
+		t.Fatalf("migrated table unusable: %v", err)
+	}
+
+	applied, err = m.Up(ctx)
+	if err != nil || len(applied) != 0 {
+		t.Fatalf("second Up() = %d, %v; want nothing applied", len(applied), err)
+	}
+
+	statuses, err := m.Status(ctx)
+	if err != nil {
+		t.Fatalf("Status() failed: %v", err)
+	}
+	for _, s := range statuses {
+		if !s.Applied || s.Modified {
+			t.Errorf("unexpected status: %+v", s)
+		}
+	}
+
+	if _, err := m.Down(ctx, 2); err != nil {
+		t.Fatalf("Down() failed: %v", err)
+	}
+	var exists bool
+	if err := db.Get(&exists, "SELECT EXISTS (SELECT 1 FROM information_schema.tables WHERE table_name = 'migrate_items')"); err != nil {
+		t.Fatalf("existence check failed: %v", err)
+	}
+	if exists {
+		t.Error("migrate_items should be dropped")
+	}
+
+	if _, err := m.Up(ctx); err != nil {
+		t.Fatalf("Up() after rollback failed: %v", err)
+	}
+	edited, err := migrate.New(db, postgres.New(), fstest.MapFS{
+		"0001_create_items.up.sql": {Data: []byte("CREATE TABLE migrate_items (id INTEGER)")},
+	})
+	if err != nil {
+		t.Fatalf("New() failed: %v", err)
+	}
+	edited.TrackingTable("migrate_test_versions")
+	if _, err := edited.Up(ctx); !errors.Is(err, migrate.ErrChecksumMismatch) {
+		t.Errorf("Up() error = %v, want ErrChecksumMismatch", err)
+	}
+}
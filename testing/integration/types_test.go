@@ -208,6 +208,142 @@ func TestTypeCoverage_Integration(t *testing.T) {
 		}
 	})
 
+	t.Run("JSONB type - struct round trip", func(t *testing.T) {
+		truncateExtendedTestTable(t, db)
+
+		docs, err := soy.New[TestUserWithMetadata](db, "test_users_extended", postgres.New())
+		if err != nil {
+			t.Fatalf("New() failed: %v", err)
+		}
+
+		inserted, err := docs.Insert().Exec(ctx, &TestUserWithMetadata{
+			Email:    "structjson@example.com",
+			Name:     "Struct JSON User",
+			Metadata: &TestUserMetadata{Role: "admin", Permissions: []string{"read", "write"}},
+		})
+		if err != nil {
+			t.Fatalf("Insert().Exec() failed: %v", err)
+		}
+		if inserted.Metadata == nil || inserted.Metadata.Role != "admin" {
+			t.Errorf("unexpected inserted Metadata: %+v", inserted.Metadata)
+		}
+
+		fetched, err := docs.Query().
+			Where("metadata", "@>", "match").
+			Where("metadata", "?", "key").
+			Exec(ctx, map[string]any{
+				"match": map[string]any{"role": "admin"},
+				"key":   "permissions",
+			})
+		if err != nil {
+			t.Fatalf("Query().Exec() failed: %v", err)
+		}
+		if len(fetched) != 1 || len(fetched[0].Metadata.Permissions) != 2 {
+			t.Errorf("unexpected results: %+v", fetched)
+		}
+
+		atoms, err := docs.Query().ExecAtom(ctx, nil)
+		if err != nil {
+			t.Fatalf("Query().ExecAtom() failed: %v", err)
+		}
+		if len(atoms) != 1 || atoms[0].Nested["Metadata"].Strings["role"] != "admin" {
+			t.Errorf("unexpected atoms: %+v", atoms)
+		}
+	})
+
+	t.Run("array type - round trip", func(t *testing.T) {
+		truncateExtendedTestTable(t, db)
+
+		tagged, err := soy.New[TestUserWithTags](db, "test_users_extended", postgres.New())
+		if err != nil {
+			t.Fatalf("New() failed: %v", err)
+		}
+
+		inserted, err := tagged.Insert().Exec(ctx, &TestUserWithTags{
+			Email: "tags@example.com",
+			Name:  "Tagged User",
+			Tags:  []string{"go", "sql", `with "quotes"`},
+		})
+		if err != nil {
+			t.Fatalf("Insert().Exec() failed: %v", err)
+		}
+		if len(inserted.Tags) != 3 || inserted.Tags[2] != `with "quotes"` {
+			t.Errorf("unexpected inserted Tags: %q", inserted.Tags)
+		}
+
+		fetched, err := tagged.Query().
+			Where("id", "IN", "ids").
+			Where("tags", "@>", "required").
+			Exec(ctx, map[string]any{
+				"ids":      []int{inserted.ID, inserted.ID + 100},
+				"required": []string{"go"},
+			})
+		if err != nil {
+			t.Fatalf("Query().Exec() failed: %v", err)
+		}
+		if len(fetched) != 1 {
+			t.Fatalf("expected 1 result, got %d", len(fetched))
+		}
+
+		updated, err := tagged.Modify().
+			Set("tags", "tags").
+			Where("email", "LIKE ANY", "patterns").
+			Exec(ctx, map[string]any{
+				"tags":     []string{},
+				"patterns": []string{"tags@%", "none@%"},
+			})
+		if err != nil {
+			t.Fatalf("Modify().Exec() failed: %v", err)
+		}
+		if updated.Tags == nil || len(updated.Tags) != 0 {
+			t.Errorf("expected empty Tags, got %#v", updated.Tags)
+		}
+
+		atoms, err := tagged.Query().ExecAtom(ctx, nil)
+		if err != nil {
+			t.Fatalf("Query().ExecAtom() failed: %v", err)
+		}
+		if len(atoms) != 1 || atoms[0].StringSlices["Tags"] == nil {
+			t.Errorf("unexpected atoms: %+v", atoms)
+		}
+	})
+
+	t.Run("array functions", func(t *testing.T) {
+		truncateExtendedTestTable(t, db)
+
+		tagged, err := soy.New[TestUserWithTags](db, "test_users_extended", postgres.New())
+		if err != nil {
+			t.Fatalf("New() failed: %v", err)
+		}
+		if _, err := tagged.Insert().Exec(ctx, &TestUserWithTags{Email: "fn@example.com", Name: "Fn", Tags: []string{"a", "b"}}); err != nil {
+			t.Fatalf("Insert().Exec() failed: %v", err)
+		}
+
+		counts, err := tagged.Query().
+			Fields("id").
+			SelectArrayLength("tags", "tag_count").
+			ExecAtom(ctx, nil)
+		if err != nil {
+			t.Fatalf("SelectArrayLength ExecAtom() failed: %v", err)
+		}
+		if len(counts) != 1 {
+			t.Fatalf("expected 1 row, got %d", len(counts))
+		}
+
+		rows, err := db.QueryxContext(ctx, tagged.Query().Fields("id").SelectUnnest("tags", "tag").MustRender().SQL)
+		if err != nil {
+			t.Fatalf("unnest query failed: %v", err)
+		}
+		defer rows.Close()
+		n := 0
+		for rows.Next() {
+			n++
+		}
+		if n != 2 {
+			t.Errorf("expected 2 unnested rows, got %d", n)
+		}
+	})
+
 	t.Run("update timestamp", func(t *testing.T) {
 		truncateExtendedTestTable(t, db)
 
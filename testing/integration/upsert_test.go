@@ -0,0 +1,302 @@
+package integration
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"testing"
+
+	_ "github.com/go-sql-driver/mysql"
+	"github.com/jmoiron/sqlx"
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/testcontainers/testcontainers-go"
+	"github.com/testcontainers/testcontainers-go/wait"
+	"github.com/zoobzio/astql"
+	"github.com/zoobzio/astql/mariadb"
+	"github.com/zoobzio/astql/sqlite"
+	"github.com/zoobzio/soy"
+)
+
+// openSQLiteDB opens a file-backed SQLite database with the test_users table.
+func openSQLiteDB(t *testing.T) *sqlx.DB {
+	t.Helper()
+	db, err := sqlx.Connect("sqlite3", filepath.Join(t.TempDir(), "soy.db"))
+	if err != nil {
+		t.Fatalf("failed to open sqlite database: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	if _, err := db.Exec(`CREATE TABLE test_users (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		email TEXT NOT NULL UNIQUE,
+		name TEXT NOT NULL,
+		age INTEGER,
+		created_at TIMESTAMP
+	)`); err != nil {
+		t.Fatalf("failed to create sqlite table: %v", err)
+	}
+	return db
+}
+
+// openMariaDB starts a MariaDB container with the test_users table.
+func openMariaDB(t *testing.T) *sqlx.DB {
+	t.Helper()
+	ctx := context.Background()
+
+	container, err := testcontainers.GenericContainer(ctx, testcontainers.GenericContainerRequest{
+		ContainerRequest: testcontainers.ContainerRequest{
+			Image:        "mariadb:11",
+			ExposedPorts: []string{"3306/tcp"},
+			Env: map[string]string{
+				"MARIADB_ROOT_PASSWORD": "test",
+				"MARIADB_DATABASE":      "testdb",
+			},
+			// The entrypoint starts a temporary server to initialize the database first
+			WaitingFor: wait.ForLog("ready for connections").WithOccurrence(2),
+		},
+		Started: true,
+	})
+	testcontainers.CleanupContainer(t, container)
+	if err != nil {
+		t.Fatalf("failed to start mariadb container: %v", err)
+	}
+
+	host, err := container.Host(ctx)
+	if err != nil {
+		t.Fatalf("failed to get mariadb host: %v", err)
+	}
+	port, err := container.MappedPort(ctx, "3306/tcp")
+	if err != nil {
+		t.Fatalf("failed to get mariadb port: %v", err)
+	}
+
+	db, err := sqlx.Connect("mysql", fmt.Sprintf("root:test@tcp(%s:%s)/testdb?parseTime=true", host, port.Port()))
+	if err != nil {
+		t.Fatalf("failed to connect to mariadb: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	if _, err := db.Exec(`CREATE TABLE test_users (
+		id INT AUTO_INCREMENT PRIMARY KEY,
+		email VARCHAR(255) NOT NULL UNIQUE,
+		name TEXT NOT NULL,
+		age INT,
+		created_at TIMESTAMP NULL
+	)`); err != nil {
+		t.Fatalf("failed to create mariadb table: %v", err)
+	}
+	return db
+}
+
+func TestBatchUpsert_SQLite(t *testing.T) {
+	testBatchUpsert(t, openSQLiteDB(t), sqlite.New())
+}
+
+func TestBatchUpsert_MariaDB(t *testing.T) {
+	db := openMariaDB(t)
+	testBatchUpsert(t, db, mariadb.New())
+
+	c, err := soy.New[TestUser](db, "test_users", mariadb.New())
+	if err != nil {
+		t.Fatalf("New() failed: %v", err)
+	}
+	ctx := context.Background()
+
+	t.Run("unchanged rows are skipped", func(t *testing.T) {
+		truncateUpsertTable(t, db)
+		seedUpsertUsers(t, c)
+
+		result, err := c.Insert().
+			OnConflict("email").
+			DoUpdate().
+			Set("name", "name").
+			Build().
+			ExecBatchUpsert(ctx, []*TestUser{
+				{Email: "alice@example.com", Name: "Alice"},
+				{Email: "bob@example.com", Name: "Robert"},
+			})
+		if err != nil {
+			t.Fatalf("ExecBatchUpsert() failed: %v", err)
+		}
+		if result.Inserted != 0 || result.Updated != 1 || result.Skipped != 1 {
+			t.Errorf("expected 0 inserted, 1 updated, 1 skipped, got %+v", result)
+		}
+	})
+
+	t.Run("conflict columns must be a unique key", func(t *testing.T) {
+		truncateUpsertTable(t, db)
+
+		_, err := c.Insert().
+			OnConflict("name").
+			DoUpdate().
+			Set("age", "age").
+			Build().
+			ExecBatchUpsert(ctx, []*TestUser{{Email: "alice@example.com", Name: "Alice"}})
+		if !errors.Is(err, soy.ErrInvalidField) {
+			t.Errorf("expected ErrInvalidField, got %v", err)
+		}
+		assertUpsertCount(t, c, 0)
+	})
+}
+
+// testBatchUpsert checks the counts reported by ExecBatchUpsert, which SQLite and MariaDB
+// derive from a count of existing keys, against the rows actually written.
+func testBatchUpsert(t *testing.T, db *sqlx.DB, renderer astql.Renderer) {
+	t.Helper()
+
+	c, err := soy.New[TestUser](db, "test_users", renderer)
+	if err != nil {
+		t.Fatalf("New() failed: %v", err)
+	}
+	ctx := context.Background()
+
+	t.Run("DO UPDATE counts inserted and updated rows", func(t *testing.T) {
+		truncateUpsertTable(t, db)
+		seedUpsertUsers(t, c)
+
+		result, err := c.Insert().
+			OnConflict("email").
+			DoUpdate().
+			Set("name", "name").
+			Set("age", "age").
+			Build().
+			ExecBatchUpsert(ctx, []*TestUser{
+				{Email: "alice@example.com", Name: "Alice Updated", Age: intPtr(31)},
+				{Email: "bob@example.com", Name: "Bob Updated", Age: intPtr(41)},
+				{Email: "carol@example.com", Name: "Carol", Age: intPtr(50)},
+				{Email: "dave@example.com", Name: "Dave", Age: intPtr(60)},
+			})
+		if err != nil {
+			t.Fatalf("ExecBatchUpsert() failed: %v", err)
+		}
+		if result.Inserted != 2 || result.Updated != 2 || result.Skipped != 0 {
+			t.Errorf("expected 2 inserted, 2 updated, 0 skipped, got %+v", result)
+		}
+
+		users, err := c.Query().OrderBy("email", "asc").Exec(ctx, nil)
+		if err != nil {
+			t.Fatalf("Query().Exec() failed: %v", err)
+		}
+		if len(users) != 4 {
+			t.Fatalf("expected 4 users, got %d", len(users))
+		}
+		if users[0].Name != "Alice Updated" || users[0].Age == nil || *users[0].Age != 31 {
+			t.Errorf("expected alice to be updated, got %+v", users[0])
+		}
+		if users[3].Name != "Dave" {
+			t.Errorf("expected dave to be inserted, got %+v", users[3])
+		}
+	})
+
+	t.Run("DO NOTHING skips existing rows", func(t *testing.T) {
+		truncateUpsertTable(t, db)
+		seedUpsertUsers(t, c)
+
+		result, err := c.Insert().
+			OnConflict("email").
+			DoNothing().
+			ExecBatchUpsert(ctx, []*TestUser{
+				{Email: "alice@example.com", Name: "Alice Updated"},
+				{Email: "carol@example.com", Name: "Carol"},
+			})
+		if err != nil {
+			t.Fatalf("ExecBatchUpsert() failed: %v", err)
+		}
+		if result.Inserted != 1 || result.Updated != 0 || result.Skipped != 1 {
+			t.Errorf("expected 1 inserted, 0 updated, 1 skipped, got %+v", result)
+		}
+
+		alice, err := c.Select().
+			Where("email", "=", "email").
+			Exec(ctx, map[string]any{"email": "alice@example.com"})
+		if err != nil {
+			t.Fatalf("Select().Exec() failed: %v", err)
+		}
+		if alice.Name != "Alice" {
+			t.Errorf("expected alice to be left unchanged, got %q", alice.Name)
+		}
+		assertUpsertCount(t, c, 3)
+	})
+
+	t.Run("repeated conflict key writes nothing", func(t *testing.T) {
+		truncateUpsertTable(t, db)
+
+		_, err := c.Insert().
+			OnConflict("email").
+			DoUpdate().
+			Set("name", "name").
+			Build().
+			ExecBatchUpsert(ctx, []*TestUser{
+				{Email: "alice@example.com", Name: "Alice"},
+				{Email: "alice@example.com", Name: "Alice Again"},
+			})
+		if err == nil {
+			t.Fatal("expected error for repeated conflict key")
+		}
+		assertUpsertCount(t, c, 0)
+	})
+
+	t.Run("within transaction", func(t *testing.T) {
+		truncateUpsertTable(t, db)
+		seedUpsertUsers(t, c)
+
+		tx, err := db.BeginTxx(ctx, nil)
+		if err != nil {
+			t.Fatalf("BeginTxx() failed: %v", err)
+		}
+		defer tx.Rollback()
+
+		result, err := c.Insert().
+			OnConflict("email").
+			DoUpdate().
+			Set("name", "name").
+			Build().
+			ExecBatchUpsertTx(ctx, tx, []*TestUser{
+				{Email: "bob@example.com", Name: "Bob Updated"},
+				{Email: "carol@example.com", Name: "Carol"},
+			})
+		if err != nil {
+			t.Fatalf("ExecBatchUpsertTx() failed: %v", err)
+		}
+		if result.Inserted != 1 || result.Updated != 1 {
+			t.Errorf("expected 1 inserted, 1 updated, got %+v", result)
+		}
+		if err := tx.Commit(); err != nil {
+			t.Fatalf("Commit() failed: %v", err)
+		}
+		assertUpsertCount(t, c, 3)
+	})
+}
+
+// truncateUpsertTable clears test_users on databases without TRUNCATE ... RESTART IDENTITY.
+func truncateUpsertTable(t *testing.T, db *sqlx.DB) {
+	t.Helper()
+	if _, err := db.Exec(`DELETE FROM test_users`); err != nil {
+		t.Fatalf("failed to clear table: %v", err)
+	}
+}
+
+// seedUpsertUsers inserts alice and bob.
+func seedUpsertUsers(t *testing.T, c *soy.Soy[TestUser]) {
+	t.Helper()
+	_, err := c.Insert().ExecBatch(context.Background(), []*TestUser{
+		{Email: "alice@example.com", Name: "Alice", Age: intPtr(30)},
+		{Email: "bob@example.com", Name: "Bob", Age: intPtr(40)},
+	})
+	if err != nil {
+		t.Fatalf("failed to seed users: %v", err)
+	}
+}
+
+// assertUpsertCount checks the number of rows in test_users.
+func assertUpsertCount(t *testing.T, c *soy.Soy[TestUser], want int) {
+	t.Helper()
+	count, err := c.Count().Exec(context.Background(), nil)
+	if err != nil {
+		t.Fatalf("Count().Exec() failed: %v", err)
+	}
+	if int(count) != want {
+		t.Errorf("expected %d rows, got %v", want, count)
+	}
+}
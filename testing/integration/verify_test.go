@@ -0,0 +1,68 @@
+package integration
+
+import (
+	"context"
+	"testing"
+
+	"github.com/zoobzio/astql/postgres"
+	"github.com/zoobzio/soy"
+)
+
+func TestVerify_MatchingTables(t *testing.T) {
+	db := getTestDB(t)
+	ctx := context.Background()
+
+	users, err := soy.New[TestUser](db, "test_users", postgres.New())
+	if err != nil {
+		t.Fatalf("New() failed: %v", err)
+	}
+	report, err := users.Verify(ctx)
+	if err != nil {
+		t.Fatalf("Verify() failed: %v", err)
+	}
+	if report.HasDrift() {
+		t.Errorf("test_users: unexpected drift: %s", report)
+	}
+
+	extended, err := soy.New[TestUserExtended](db, "test_users_extended", postgres.New())
+	if err != nil {
+		t.Fatalf("New() failed: %v", err)
+	}
+	report, err = extended.Verify(ctx)
+	if err != nil {
+		t.Fatalf("Verify() failed: %v", err)
+	}
+	if report.HasDrift() {
+		t.Errorf("test_users_extended: unexpected drift: %s", report)
+	}
+}
+
+func TestVerify_DetectsDrift(t *testing.T) {
+	db := getTestDB(t)
+	ctx := context.Background()
+
+	// TestUserExtended declares columns that test_users lacks.
+	c, err := soy.New[TestUserExtended](db, "test_users", postgres.New())
+	if err != nil {
+		t.Fatalf("New() failed: %v", err)
+	}
+	report, err := c.Verify(ctx)
+	if err != nil {
+		t.Fatalf("Verify() failed: %v", err)
+	}
+	if len(report.MissingColumns) != 3 {
+		t.Errorf("MissingColumns = %v, want is_active, updated_at, metadata", report.MissingColumns)
+	}
+
+	missing, err := soy.New[TestUser](db, "no_such_table", postgres.New())
+	if err != nil {
+		t.Fatalf("New() failed: %v", err)
+	}
+	report, err = missing.Verify(ctx)
+	if err != nil {
+		t.Fatalf("Verify() failed: %v", err)
+	}
+	if !report.TableMissing {
+		t.Errorf("expected TableMissing, got %s", report)
+	}
+}
@@ -0,0 +1,168 @@
+package soy
+
+import (
+	"fmt"
+	"regexp"
+
+	"github.com/zoobzio/astql"
+)
+
+// Stand-in operators for trigram similarity (pg_trgm). They render as
+// "field op :param" and are expanded by the extensionRenderer. The _within variants
+// compare against a threshold: they render as a group of two conditions on the field,
+// "(field op :param AND field soy_similarity_threshold :threshold)", so both params are
+// ASTQL params that are validated and namespaced like any other.
+const (
+	opSimilarity           = "soy_similarity"
+	opWordSimilarity       = "soy_word_similarity"
+	opSimilarityWithin     = "soy_similarity_within"
+	opWordSimilarityWithin = "soy_word_similarity_within"
+	opSimilarityThreshold  = "soy_similarity_threshold"
+)
+
+// similarityFuncs are the expressions that replace each similarity stand-in, formatted
+// with the field and the query param, and the threshold param for the _within variants.
+// word_similarity takes the query first: it finds the query among the words of the field.
+var similarityFuncs = map[string]string{
+	opSimilarity:           "similarity(%[1]s, %[2]s)",
+	opWordSimilarity:       "word_similarity(%[2]s, %[1]s)",
+	opSimilarityWithin:     "similarity(%[1]s, %[2]s) >= %[3]s",
+	opWordSimilarityWithin: "word_similarity(%[2]s, %[1]s) >= %[3]s",
+}
+
+var (
+	similarityStandIn = regexp.MustCompile(`((?:[a-z]\.)?"(?:[^"]|"")*") (soy_(?:word_)?similarity) (:[A-Za-z_][A-Za-z0-9_]*)`)
+	similarityWithin  = regexp.MustCompile(`\(((?:[a-z]\.)?"(?:[^"]|"")*") (soy_(?:word_)?similarity_within) (:[A-Za-z_][A-Za-z0-9_]*) AND (?:[a-z]\.)?"(?:[^"]|"")*" ` + opSimilarityThreshold + ` (:[A-Za-z_][A-Za-z0-9_]*)\)`)
+)
+
+// expandSimilarity rewrites the trigram similarity stand-ins in rendered SQL.
+func expandSimilarity(sql string) string {
+	sql = similarityWithin.ReplaceAllStringFunc(sql, func(match string) string {
+		parts := similarityWithin.FindStringSubmatch(match)
+		return fmt.Sprintf(similarityFuncs[parts[2]], parts[1], parts[3], parts[4])
+	})
+	return similarityStandIn.ReplaceAllStringFunc(sql, func(match string) string {
+		parts := similarityStandIn.FindStringSubmatch(match)
+		return fmt.Sprintf(similarityFuncs[parts[2]], parts[1], parts[3])
+	})
+}
+
+// trigramOperators are the pg_trgm operators, which require PostgreSQL. % is not listed
+// because it is also the modulo operator; on text it is the similarity match.
+var trigramOperators = map[string]bool{
+	"<%": true, "%>": true, "<<%": true, "%>>": true, "<<->": true, "<->>": true,
+}
+
+// requireTrigram checks that the instance's dialect has pg_trgm.
+func requireTrigram(instance *astql.ASTQL, field string) error {
+	if schema := schemaOf(instance); schema != nil && schema.dialect != dialectPostgres {
+		return newFieldUsageError(field, "trigram similarity requires PostgreSQL")
+	}
+	return nil
+}
+
+// whereSimilarImpl adds a trigram similarity match of field against the text in param.
+// Without a threshold it uses the % or %> operator, which a trigram index can serve;
+// otherwise it compares the similarity with the threshold param.
+func whereSimilarImpl(instance *astql.ASTQL, builder *astql.Builder, field, param, threshold string, word bool) (*astql.Builder, error) {
+	if err := requireTrigram(instance, field); err != nil {
+		return builder, err
+	}
+
+	f, err := instance.TryF(field)
+	if err != nil {
+		return builder, newFieldError(field, err)
+	}
+
+	p, err := instance.TryP(param)
+	if err != nil {
+		return builder, newParamError(param, err)
+	}
+
+	if threshold == "" {
+		op := astql.Operator("%")
+		if word {
+			op = "%>"
+		}
+		condition, err := instance.TryC(f, op, p)
+		if err != nil {
+			return builder, newConditionError(err)
+		}
+		return builder.Where(condition), nil
+	}
+
+	t, err := instance.TryP(threshold)
+	if err != nil {
+		return builder, newParamError(threshold, err)
+	}
+	op := astql.Operator(opSimilarityWithin)
+	if word {
+		op = opWordSimilarityWithin
+	}
+	match, err := instance.TryC(f, op, p)
+	if err != nil {
+		return builder, newConditionError(err)
+	}
+	within, err := instance.TryC(f, opSimilarityThreshold, t)
+	if err != nil {
+		return builder, newConditionError(err)
+	}
+	condition, err := instance.TryAnd(match, within)
+	if err != nil {
+		return builder, newConditionError(err)
+	}
+	return builder.Where(condition), nil
+}
+
+// selectSimilarityImpl adds the trigram similarity of field and the text in param AS
+// alias to the SELECT clause.
+func selectSimilarityImpl(instance *astql.ASTQL, builder *astql.Builder, field, param, alias string, word bool) (*astql.Builder, error) {
+	if err := requireTrigram(instance, field); err != nil {
+		return builder, err
+	}
+
+	f, err := instance.TryF(field)
+	if err != nil {
+		return builder, newFieldError(field, err)
+	}
+
+	p, err := instance.TryP(param)
+	if err != nil {
+		return builder, newParamError(param, err)
+	}
+
+	op := astql.Operator(opSimilarity)
+	if word {
+		op = opWordSimilarity
+	}
+	return builder.SelectBinaryExpr(f, op, p, alias), nil
+}
+
+// orderBySimilarityImpl orders by the trigram distance between field and the text in
+// param, using the <-> or <->> operator that a GiST trigram index can serve.
+func orderBySimilarityImpl(instance *astql.ASTQL, builder *astql.Builder, field, param, direction string, word bool) (*astql.Builder, error) {
+	astqlDir, err := validateDirection(direction)
+	if err != nil {
+		return builder, err
+	}
+
+	if err := requireTrigram(instance, field); err != nil {
+		return builder, err
+	}
+
+	f, err := instance.TryF(field)
+	if err != nil {
+		return builder, newFieldError(field, err)
+	}
+
+	p, err := instance.TryP(param)
+	if err != nil {
+		return builder, newParamError(param, err)
+	}
+
+	op := astql.Operator("<->")
+	if word {
+		op = "<->>"
+	}
+	return builder.OrderByExpr(f, op, p, astqlDir), nil
+}
@@ -0,0 +1,158 @@
+package soy
+
+import (
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/zoobzio/astql/postgres"
+	"github.com/zoobzio/astql/sqlite"
+)
+
+type trigramTestPerson struct {
+	ID   int    `db:"id" type:"serial" constraints:"primarykey"`
+	Name string `db:"name" type:"text" index:"true,type:gin,ops:gin_trgm_ops"`
+	Bio  string `db:"bio" type:"text"`
+}
+
+func TestExpandSimilarity(t *testing.T) {
+	tests := []struct {
+		sql  string
+		want string
+	}{
+		{`"name" soy_similarity :q AS "score"`, `similarity("name", :q) AS "score"`},
+		{`u."bio" soy_word_similarity :q`, `word_similarity(:q, u."bio")`},
+		{`("name" soy_similarity_within :q AND "name" soy_similarity_threshold :min)`, `similarity("name", :q) >= :min`},
+		{`(u."bio" soy_word_similarity_within :q1_q AND u."bio" soy_similarity_threshold :q1_min)`, `word_similarity(:q1_q, u."bio") >= :q1_min`},
+		{`"name" % :q`, `"name" % :q`},
+	}
+	for _, tt := range tests {
+		if got := expandSimilarity(tt.sql); got != tt.want {
+			t.Errorf("expandSimilarity(%s) = %s, want %s", tt.sql, got, tt.want)
+		}
+	}
+}
+
+func TestTrigramQueries(t *testing.T) {
+	s, err := New[trigramTestPerson](nil, "people", postgres.New())
+	if err != nil {
+		t.Fatalf("New() failed: %v", err)
+	}
+
+	result, err := s.Query().
+		WhereSimilar("name", "q", "").
+		WhereWordSimilar("bio", "q", "min").
+		SelectSimilarity("name", "q", "score").
+		OrderBySimilarity("name", "q", "asc").
+		Render()
+	if err != nil {
+		t.Fatalf("Render() failed: %v", err)
+	}
+	for _, want := range []string{
+		`"name" % :q`,
+		`word_similarity(:q, "bio") >= :min`,
+		`similarity("name", :q) AS "score"`,
+		`ORDER BY "name" <-> :q ASC`,
+	} {
+		if !strings.Contains(result.SQL, want) {
+			t.Errorf("Query SQL missing %q:\n%s", want, result.SQL)
+		}
+	}
+
+	result, err = s.Select().
+		WhereSimilar("name", "q", "min").
+		WhereWordSimilar("bio", "q", "").
+		SelectWordSimilarity("bio", "q", "score").
+		OrderByWordSimilarity("bio", "q", "asc").
+		Render()
+	if err != nil {
+		t.Fatalf("Render() failed: %v", err)
+	}
+	for _, want := range []string{
+		`similarity("name", :q) >= :min`,
+		`"bio" %> :q`,
+		`word_similarity(:q, "bio") AS "score"`,
+		`ORDER BY "bio" <->> :q ASC`,
+	} {
+		if !strings.Contains(result.SQL, want) {
+			t.Errorf("Select SQL missing %q:\n%s", want, result.SQL)
+		}
+	}
+
+	count, err := s.Count().WhereSimilar("name", "q", "").Render()
+	if err != nil {
+		t.Fatalf("Count Render() failed: %v", err)
+	}
+	if !strings.Contains(count.SQL, `"name" % :q`) {
+		t.Errorf("Count SQL = %s", count.SQL)
+	}
+
+	operator, err := s.Query().Where("bio", "%>>", "q").Render()
+	if err != nil {
+		t.Fatalf("Render() failed: %v", err)
+	}
+	if !strings.Contains(operator.SQL, `"bio" %>> :q`) {
+		t.Errorf("Where SQL = %s", operator.SQL)
+	}
+
+	t.Run("threshold params", func(t *testing.T) {
+		s.StrictParams(true)
+		t.Cleanup(func() { s.StrictParams(false) })
+
+		query := s.Query().WhereSimilar("name", "q", "min")
+		if _, err := query.renderFor(map[string]any{"q": "ann", "min": 0.4}); err != nil {
+			t.Errorf("strict renderFor() failed: %v", err)
+		}
+		if _, err := query.renderFor(map[string]any{"min": 0.4}); !errors.Is(err, ErrMissingParams) {
+			t.Errorf("expected ErrMissingParams for q, got %v", err)
+		}
+
+		compound, err := s.Query().WhereSimilar("name", "q", "min").
+			Union(s.Query().WhereWordSimilar("bio", "q", "min")).
+			Render()
+		if err != nil {
+			t.Fatalf("compound Render() failed: %v", err)
+		}
+		for _, want := range []string{`similarity("name", :q0_q) >= :q0_min`, `word_similarity(:q1_q, "bio") >= :q1_min`} {
+			if !strings.Contains(compound.SQL, want) {
+				t.Errorf("compound SQL missing %q:\n%s", want, compound.SQL)
+			}
+		}
+		for _, want := range []string{"q0_q", "q0_min", "q1_q", "q1_min"} {
+			if !slices.Contains(compound.RequiredParams, want) {
+				t.Errorf("RequiredParams() = %v, missing %s", compound.RequiredParams, want)
+			}
+		}
+	})
+
+	t.Run("index", func(t *testing.T) {
+		indexes, err := s.CreateIndexesSQL()
+		if err != nil {
+			t.Fatalf("CreateIndexesSQL() error = %v", err)
+		}
+		if len(indexes) != 1 || !strings.Contains(indexes[0], `USING gin ("name" gin_trgm_ops)`) {
+			t.Errorf("CreateIndexesSQL() = %q", indexes)
+		}
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		if _, err := s.Query().OrderBySimilarity("name", "q", "up").Render(); !errors.Is(err, ErrInvalidDirection) {
+			t.Errorf("expected ErrInvalidDirection, got %v", err)
+		}
+		if _, err := s.Query().WhereSimilar("name", "q", "bad-param").Render(); !errors.Is(err, ErrInvalidParam) {
+			t.Errorf("expected ErrInvalidParam, got %v", err)
+		}
+
+		plain, err := New[extensionTestNote](nil, "notes", sqlite.New())
+		if err != nil {
+			t.Fatalf("New() failed: %v", err)
+		}
+		if _, err := plain.Query().WhereSimilar("tags", "q", "").Render(); err == nil || !strings.Contains(err.Error(), "requires PostgreSQL") {
+			t.Errorf("expected PostgreSQL error, got %v", err)
+		}
+		if _, err := plain.Query().Where("tags", "<%", "q").Render(); err == nil || !strings.Contains(err.Error(), "requires PostgreSQL") {
+			t.Errorf("expected PostgreSQL error, got %v", err)
+		}
+	})
+}
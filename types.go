@@ -0,0 +1,158 @@
+package soy
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"time"
+)
+
+// columnTypes are a dialect's default column types for Go types without a type tag.
+type columnTypes struct {
+	text      string
+	integer   string
+	bigint    string
+	smallint  string
+	real      string
+	double    string
+	boolean   string
+	timestamp string
+	bytes     string
+	json      string
+	arrays    bool // Native T[] array columns
+}
+
+var dialectColumnTypes = map[dialect]columnTypes{
+	dialectPostgres: {
+		text:      "TEXT",
+		integer:   "INTEGER",
+		bigint:    "BIGINT",
+		smallint:  "SMALLINT",
+		real:      "REAL",
+		double:    "DOUBLE PRECISION",
+		boolean:   "BOOLEAN",
+		timestamp: "TIMESTAMPTZ",
+		bytes:     "BYTEA",
+		json:      "JSONB",
+		arrays:    true,
+	},
+	dialectMariaDB: {
+		text:      "TEXT",
+		integer:   "INT",
+		bigint:    "BIGINT",
+		smallint:  "SMALLINT",
+		real:      "FLOAT",
+		double:    "DOUBLE",
+		boolean:   "TINYINT(1)",
+		timestamp: "DATETIME(6)",
+		bytes:     "BLOB",
+		json:      "JSON",
+	},
+	// SQLite types only select a column affinity; these are the names its drivers
+	// recognize when converting values back to Go.
+	dialectSQLite: {
+		text:      "TEXT",
+		integer:   "INTEGER",
+		bigint:    "INTEGER",
+		smallint:  "INTEGER",
+		real:      "REAL",
+		double:    "REAL",
+		boolean:   "BOOLEAN",
+		timestamp: "DATETIME",
+		bytes:     "BLOB",
+		json:      "TEXT",
+	},
+	dialectMSSQL: {
+		text:      "NVARCHAR(MAX)",
+		integer:   "INT",
+		bigint:    "BIGINT",
+		smallint:  "SMALLINT",
+		real:      "REAL",
+		double:    "FLOAT",
+		boolean:   "BIT",
+		timestamp: "DATETIME2",
+		bytes:     "VARBINARY(MAX)",
+		json:      "NVARCHAR(MAX)",
+	},
+}
+
+var (
+	timeType       = reflect.TypeFor[time.Time]()
+	rawMessageType = reflect.TypeFor[json.RawMessage]()
+)
+
+// inferSQLType maps a Go type to the dialect's default column type.
+// Pointers are unwrapped, types added with RegisterType use their registered column type,
+// and other named types map by their underlying kind. Structs, maps and
+// slices of them are stored as JSON; slices of scalars become arrays where the dialect
+// has them. Other types return ErrUnmappableType and need an explicit type tag.
+func inferSQLType(d dialect, t reflect.Type) (string, error) {
+	types, ok := dialectColumnTypes[d]
+	if !ok {
+		types = dialectColumnTypes[dialectPostgres]
+	}
+	if t == nil {
+		return "", fmt.Errorf("%w: nil type", ErrUnmappableType)
+	}
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	if codec := registeredCodec(t); codec != nil {
+		sqlType := codec.sqlTypes.forDialect(d)
+		if sqlType == "" {
+			return "", fmt.Errorf("%w: no column type registered for %s in %s", ErrUnmappableType, t, d)
+		}
+		return sqlType, nil
+	}
+
+	switch t {
+	case timeType:
+		return types.timestamp, nil
+	case rawMessageType:
+		return types.json, nil
+	}
+
+	switch t.Kind() {
+	case reflect.String:
+		return types.text, nil
+	case reflect.Int, reflect.Int32, reflect.Uint, reflect.Uint32:
+		return types.integer, nil
+	case reflect.Int64, reflect.Uint64:
+		return types.bigint, nil
+	case reflect.Int16, reflect.Int8, reflect.Uint16, reflect.Uint8:
+		return types.smallint, nil
+	case reflect.Float32:
+		return types.real, nil
+	case reflect.Float64:
+		return types.double, nil
+	case reflect.Bool:
+		return types.boolean, nil
+	case reflect.Struct, reflect.Map:
+		return types.json, nil
+	case reflect.Slice:
+		elem := t.Elem()
+		if elem.Kind() == reflect.Uint8 {
+			return types.bytes, nil
+		}
+		for elem.Kind() == reflect.Pointer {
+			elem = elem.Elem()
+		}
+		if (elem.Kind() == reflect.Struct && elem != timeType) || elem.Kind() == reflect.Map {
+			return types.json, nil
+		}
+		if !types.arrays {
+			return "", fmt.Errorf("%w: %s has no array columns for %s", ErrUnmappableType, d, t)
+		}
+		if elem.Kind() == reflect.Slice && elem.Elem().Kind() != reflect.Uint8 {
+			return "", fmt.Errorf("%w: nested slice %s", ErrUnmappableType, t)
+		}
+		base, err := inferSQLType(d, elem)
+		if err != nil {
+			return "", err
+		}
+		return base + "[]", nil
+	default:
+		return "", fmt.Errorf("%w: %s", ErrUnmappableType, t)
+	}
+}
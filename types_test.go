@@ -0,0 +1,136 @@
+package soy
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/zoobzio/astql/mariadb"
+	"github.com/zoobzio/astql/mssql"
+)
+
+type typesTestStatus string
+
+func TestInferSQLType(t *testing.T) {
+	tests := []struct {
+		goType   reflect.Type
+		postgres string
+		mariadb  string
+		sqlite   string
+		mssql    string
+	}{
+		{reflect.TypeFor[string](), "TEXT", "TEXT", "TEXT", "NVARCHAR(MAX)"},
+		{reflect.TypeFor[*string](), "TEXT", "TEXT", "TEXT", "NVARCHAR(MAX)"},
+		{reflect.TypeFor[typesTestStatus](), "TEXT", "TEXT", "TEXT", "NVARCHAR(MAX)"},
+		{reflect.TypeFor[int](), "INTEGER", "INT", "INTEGER", "INT"},
+		{reflect.TypeFor[*int64](), "BIGINT", "BIGINT", "INTEGER", "BIGINT"},
+		{reflect.TypeFor[int16](), "SMALLINT", "SMALLINT", "INTEGER", "SMALLINT"},
+		{reflect.TypeFor[float64](), "DOUBLE PRECISION", "DOUBLE", "REAL", "FLOAT"},
+		{reflect.TypeFor[bool](), "BOOLEAN", "TINYINT(1)", "BOOLEAN", "BIT"},
+		{reflect.TypeFor[time.Time](), "TIMESTAMPTZ", "DATETIME(6)", "DATETIME", "DATETIME2"},
+		{reflect.TypeFor[time.Duration](), "BIGINT", "BIGINT", "INTEGER", "BIGINT"},
+		{reflect.TypeFor[[]byte](), "BYTEA", "BLOB", "BLOB", "VARBINARY(MAX)"},
+		{reflect.TypeFor[json.RawMessage](), "JSONB", "JSON", "TEXT", "NVARCHAR(MAX)"},
+		{reflect.TypeFor[map[string]any](), "JSONB", "JSON", "TEXT", "NVARCHAR(MAX)"},
+		{reflect.TypeFor[struct{ A int }](), "JSONB", "JSON", "TEXT", "NVARCHAR(MAX)"},
+		{reflect.TypeFor[[]struct{ A int }](), "JSONB", "JSON", "TEXT", "NVARCHAR(MAX)"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.goType.String(), func(t *testing.T) {
+			want := map[dialect]string{
+				dialectPostgres: tt.postgres,
+				dialectMariaDB:  tt.mariadb,
+				dialectSQLite:   tt.sqlite,
+				dialectMSSQL:    tt.mssql,
+			}
+			for d, expected := range want {
+				got, err := inferSQLType(d, tt.goType)
+				if err != nil {
+					t.Fatalf("inferSQLType(%s) failed: %v", d, err)
+				}
+				if got != expected {
+					t.Errorf("inferSQLType(%s) = %q, want %q", d, got, expected)
+				}
+			}
+		})
+	}
+}
+
+func TestInferSQLType_Arrays(t *testing.T) {
+	got, err := inferSQLType(dialectPostgres, reflect.TypeFor[[]string]())
+	if err != nil || got != "TEXT[]" {
+		t.Errorf("inferSQLType([]string) = %q, %v, want TEXT[]", got, err)
+	}
+	got, err = inferSQLType(dialectPostgres, reflect.TypeFor[[]*int]())
+	if err != nil || got != "INTEGER[]" {
+		t.Errorf("inferSQLType([]*int) = %q, %v, want INTEGER[]", got, err)
+	}
+
+	for _, d := range []dialect{dialectMariaDB, dialectSQLite, dialectMSSQL} {
+		if _, err := inferSQLType(d, reflect.TypeFor[[]string]()); !errors.Is(err, ErrUnmappableType) {
+			t.Errorf("inferSQLType(%s, []string) error = %v, want ErrUnmappableType", d, err)
+		}
+	}
+	if _, err := inferSQLType(dialectPostgres, reflect.TypeFor[[][]int]()); !errors.Is(err, ErrUnmappableType) {
+		t.Errorf("inferSQLType([][]int) error = %v, want ErrUnmappableType", err)
+	}
+}
+
+func TestInferSQLType_Unmappable(t *testing.T) {
+	for _, goType := range []reflect.Type{
+		reflect.TypeFor[[16]byte](),
+		reflect.TypeFor[any](),
+		reflect.TypeFor[complex128](),
+		reflect.TypeFor[chan int](),
+	} {
+		if _, err := inferSQLType(dialectPostgres, goType); !errors.Is(err, ErrUnmappableType) {
+			t.Errorf("inferSQLType(%s) error = %v, want ErrUnmappableType", goType, err)
+		}
+	}
+}
+
+func TestNew_DialectTypes(t *testing.T) {
+	type event struct {
+		ID        int64     `db:"id" type:"bigserial" constraints:"primary_key"`
+		Name      string    `db:"name" constraints:"not_null"`
+		Active    bool      `db:"active"`
+		CreatedAt time.Time `db:"created_at"`
+		Ignored   chan int  `db:"-"`
+	}
+
+	s, err := New[event](&sqlx.DB{}, "events", mssql.New())
+	if err != nil {
+		t.Fatalf("New() failed: %v", err)
+	}
+	project, err := s.DBML()
+	if err != nil {
+		t.Fatalf("DBML() failed: %v", err)
+	}
+	if project.DatabaseType == nil || *project.DatabaseType != "SQL Server" {
+		t.Errorf("DatabaseType = %v, want SQL Server", project.DatabaseType)
+	}
+	table, ok := project.Tables["dbo.events"]
+	if !ok {
+		t.Fatalf("dbo.events not found in %v", getTableKeys(project.Tables))
+	}
+	types := map[string]string{}
+	for _, col := range table.Columns {
+		types[col.Name] = col.Type
+	}
+	want := map[string]string{"id": "bigserial", "name": "NVARCHAR(MAX)", "active": "BIT", "created_at": "DATETIME2"}
+	if !reflect.DeepEqual(types, want) {
+		t.Errorf("column types = %v, want %v", types, want)
+	}
+
+	type tagged struct {
+		ID   int      `db:"id" type:"serial" constraints:"primary_key"`
+		Tags []string `db:"tags"`
+	}
+	if _, err := New[tagged](&sqlx.DB{}, "tagged", mariadb.New()); !errors.Is(err, ErrUnmappableType) {
+		t.Errorf("New() error = %v, want ErrUnmappableType", err)
+	}
+}
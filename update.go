@@ -3,6 +3,7 @@ package soy
 import (
 	"context"
 	"fmt"
+	"slices"
 	"time"
 
 	"github.com/jmoiron/sqlx"
@@ -19,6 +20,8 @@ type Update[T any] struct {
 	soy        soyExecutor           // interface for execution
 	hasWhere   bool                  // tracks if WHERE was called
 	whereItems []astql.ConditionItem // tracks WHERE conditions for fallback SELECT
+	batch      batchShape            // tracks structure for set-based ExecBatch
+	scopes     scopeFilter           // scopes left out with Unscoped
 	err        error                 // stores first error encountered during building
 }
 
@@ -36,6 +39,9 @@ func (ub *Update[T]) Set(field, param string) *Update[T] {
 	if ub.err != nil {
 		return ub
 	}
+	if ub.err = ub.soy.tenant().checkSet(field); ub.err != nil {
+		return ub
+	}
 
 	f, err := ub.instance.TryF(field)
 	if err != nil {
@@ -50,6 +56,7 @@ func (ub *Update[T]) Set(field, param string) *Update[T] {
 	}
 
 	ub.builder = ub.builder.Set(f, p)
+	ub.batch.addSet(field, param)
 	return ub
 }
 
@@ -68,7 +75,11 @@ func (ub *Update[T]) SetExpr(field, operator, param string) *Update[T] {
 	if ub.err != nil {
 		return ub
 	}
+	if ub.err = ub.soy.tenant().checkSet(field); ub.err != nil {
+		return ub
+	}
 	ub.builder, ub.err = setExprImpl(ub.instance, ub.builder, field, operator, param)
+	ub.batch.complex = true
 	return ub
 }
 
@@ -94,6 +105,7 @@ func (ub *Update[T]) Where(field, operator, param string) *Update[T] {
 	ub.builder = builder
 	ub.whereItems = append(ub.whereItems, cond)
 	ub.hasWhere = true
+	ub.batch.addKey(field, operator, param)
 	return ub
 }
 
@@ -133,6 +145,7 @@ func (ub *Update[T]) WhereAnd(conditions ...Condition) *Update[T] {
 	ub.builder = ub.builder.Where(andGroup)
 	ub.whereItems = append(ub.whereItems, andGroup)
 	ub.hasWhere = true
+	ub.batch.complex = true
 	return ub
 }
 
@@ -172,6 +185,7 @@ func (ub *Update[T]) WhereOr(conditions ...Condition) *Update[T] {
 	ub.builder = ub.builder.Where(orGroup)
 	ub.whereItems = append(ub.whereItems, orGroup)
 	ub.hasWhere = true
+	ub.batch.complex = true
 	return ub
 }
 
@@ -196,6 +210,7 @@ func (ub *Update[T]) WhereNull(field string) *Update[T] {
 	ub.builder = ub.builder.Where(condition)
 	ub.whereItems = append(ub.whereItems, condition)
 	ub.hasWhere = true
+	ub.batch.complex = true
 	return ub
 }
 
@@ -220,6 +235,7 @@ func (ub *Update[T]) WhereNotNull(field string) *Update[T] {
 	ub.builder = ub.builder.Where(condition)
 	ub.whereItems = append(ub.whereItems, condition)
 	ub.hasWhere = true
+	ub.batch.complex = true
 	return ub
 }
 
@@ -278,6 +294,22 @@ func (ub *Update[T]) whereBetweenHelper(field, lowParam, highParam string, negat
 	ub.builder = ub.builder.Where(condition)
 	ub.whereItems = append(ub.whereItems, condition)
 	ub.hasWhere = true
+	ub.batch.complex = true
+	return ub
+}
+
+// Unscoped leaves the named scopes registered with AddScope out of the update, or every
+// scope when no names are given. Scopes are not WHERE conditions, so the update still
+// needs one.
+//
+// Example:
+//
+//	soy.Modify().Unscoped("live").Where("id", "=", "id")
+func (ub *Update[T]) Unscoped(names ...string) *Update[T] {
+	if ub.err != nil {
+		return ub
+	}
+	ub.err = ub.scopes.exclude(ub.soy, names)
 	return ub
 }
 
@@ -313,7 +345,11 @@ func (ub *Update[T]) ExecTx(ctx context.Context, tx *sqlx.Tx, params map[string]
 
 // ExecBatch executes the UPDATE query for multiple parameter sets.
 // Returns the total number of rows affected.
-// Each parameter set is executed separately with the same WHERE clause.
+// When every WHERE condition is a Where(field, "=", param) and every assignment a Set,
+// PostgreSQL runs the whole batch as one UPDATE ... FROM (VALUES ...) statement.
+// Otherwise one prepared statement is executed per parameter set, in a transaction that
+// a failing set rolls back.
+// Failures are reported as a *BatchError identifying the failing indexes.
 //
 // Example:
 //
@@ -336,13 +372,34 @@ func (ub *Update[T]) ExecBatchTx(ctx context.Context, tx *sqlx.Tx, batchParams [
 }
 
 // execBatch is the internal batch execution method.
+// Updates keyed by "field = :param" conditions run as a single UPDATE ... FROM (VALUES ...)
+// on PostgreSQL, split into chunks within the parameter limit; everything else reuses one
+// prepared statement per entry.
 func (ub *Update[T]) execBatch(ctx context.Context, execer sqlx.ExtContext, batchParams []map[string]any) (int64, error) {
-	return executeBatch(ctx, execer, batchParams, ub.builder, ub.soy.renderer(), ub.soy.getTableName(), "UPDATE", ub.hasWhere, ub.err)
+	batchParams = ub.soy.bindBatchParams(batchParams)
+	if ub.err == nil && ub.hasWhere && len(batchParams) > 0 {
+		tableName := ub.soy.getTableName()
+		d := dialectOf(ub.soy.renderer())
+		batch := ub.scopes.batchShape(ub.soy, ub.batch)
+		if batch.setUpdate(d) {
+			params, err := batch.bindParams(d, "UPDATE", batchParams, ub.soy.strictParams(), enumBindingsOf(ub.instance, ub.builder))
+			if err != nil {
+				return 0, err
+			}
+			render := func(n int) string {
+				query, _ := batch.renderSetUpdate(d, tableName, ub.soy.getMetadata(), n)
+				return query
+			}
+			return executeSetBatch(ctx, execer, d, &batch, render, params, len(batchParams), tableName, "UPDATE")
+		}
+	}
+	return executeBatch(ctx, execer, batchParams, ub.scopes.apply(ub.soy, ub.builder), enumBindingsOf(ub.instance, ub.builder), ub.soy.renderer(), ub.soy.getTableName(), "UPDATE", ub.hasWhere, ub.err, ub.soy.strictParams())
 }
 
 // exec is the internal execution method used by both Exec and ExecTx.
 // It checks renderer capabilities and routes to the appropriate execution strategy.
 func (ub *Update[T]) exec(ctx context.Context, execer sqlx.ExtContext, params map[string]any) (*T, error) {
+	params = ub.soy.bindParams(params)
 	// Check for errors first
 	if ub.err != nil {
 		return nil, fmt.Errorf("update builder has errors: %w", ub.err)
@@ -353,34 +410,46 @@ func (ub *Update[T]) exec(ctx context.Context, execer sqlx.ExtContext, params ma
 		return nil, fmt.Errorf("UPDATE requires at least one WHERE condition to prevent accidental full-table update")
 	}
 
+	// Render the query
+	result, err := ub.scopes.apply(ub.soy, ub.builder).Render(ub.soy.renderer())
+	if err != nil {
+		return nil, fmt.Errorf("failed to render UPDATE query: %w", err)
+	}
+
+	if err := validateParams(result.RequiredParams, params, ub.soy.strictParams()); err != nil {
+		return nil, err
+	}
+	if err := validateEnumParams(ub.instance, ub.builder, params); err != nil {
+		return nil, err
+	}
+
 	// Check capabilities and route to appropriate execution strategy
 	caps := ub.soy.renderer().Capabilities()
 	if caps.ReturningOnUpdate {
-		return ub.execWithReturning(ctx, execer, params)
+		return ub.execWithReturning(ctx, execer, result.SQL, params)
 	}
-	return ub.execThenSelect(ctx, execer, params)
-}
 
-// execWithReturning executes UPDATE with RETURNING clause (PostgreSQL, SQLite, MSSQL).
-func (ub *Update[T]) execWithReturning(ctx context.Context, execer sqlx.ExtContext, params map[string]any) (*T, error) {
-	// Render the query
-	result, err := ub.builder.Render(ub.soy.renderer())
+	selectSQL, err := ub.renderFallbackSelect()
 	if err != nil {
-		return nil, fmt.Errorf("failed to render UPDATE query: %w", err)
+		return nil, err
 	}
+	return ub.execThenSelect(ctx, execer, result.SQL, selectSQL, params)
+}
 
+// execWithReturning executes UPDATE with RETURNING clause (PostgreSQL, SQLite, MSSQL).
+func (ub *Update[T]) execWithReturning(ctx context.Context, execer sqlx.ExtContext, query string, params map[string]any) (*T, error) {
 	// Emit query started event
 	tableName := ub.soy.getTableName()
 	capitan.Debug(ctx, QueryStarted,
 		TableKey.Field(tableName),
 		OperationKey.Field("UPDATE"),
-		SQLKey.Field(result.SQL),
+		SQLKey.Field(query),
 	)
 
 	startTime := time.Now()
 
 	// Execute named query with RETURNING
-	rows, err := sqlx.NamedQueryContext(ctx, execer, result.SQL, params)
+	rows, err := ub.soy.statements().namedQuery(ctx, execer, dialectOf(ub.soy.renderer()), query, params)
 	if err != nil {
 		durationMs := time.Since(startTime).Milliseconds()
 		capitan.Error(ctx, QueryFailed,
@@ -407,7 +476,7 @@ func (ub *Update[T]) execWithReturning(ctx context.Context, execer sqlx.ExtConte
 
 	// Scan the updated row
 	var updated T
-	if err := rows.StructScan(&updated); err != nil {
+	if err := scanStruct(dialectOf(ub.soy.renderer()), rows, &updated); err != nil {
 		durationMs := time.Since(startTime).Milliseconds()
 		capitan.Error(ctx, QueryFailed,
 			TableKey.Field(tableName),
@@ -447,24 +516,19 @@ func (ub *Update[T]) execWithReturning(ctx context.Context, execer sqlx.ExtConte
 }
 
 // execThenSelect executes UPDATE without RETURNING, then SELECTs the updated row (MariaDB fallback).
-func (ub *Update[T]) execThenSelect(ctx context.Context, execer sqlx.ExtContext, params map[string]any) (*T, error) {
-	// Render the UPDATE query (RETURNING will be omitted by renderer)
-	result, err := ub.builder.Render(ub.soy.renderer())
-	if err != nil {
-		return nil, fmt.Errorf("failed to render UPDATE query: %w", err)
-	}
-
+// The UPDATE SQL is rendered without RETURNING; selectSQL re-reads the row using the same WHERE conditions.
+func (ub *Update[T]) execThenSelect(ctx context.Context, execer sqlx.ExtContext, query, selectSQL string, params map[string]any) (*T, error) {
 	tableName := ub.soy.getTableName()
 	capitan.Debug(ctx, QueryStarted,
 		TableKey.Field(tableName),
 		OperationKey.Field("UPDATE"),
-		SQLKey.Field(result.SQL),
+		SQLKey.Field(query),
 	)
 
 	startTime := time.Now()
 
 	// Execute UPDATE without expecting rows back
-	res, err := sqlx.NamedExecContext(ctx, execer, result.SQL, params)
+	res, err := ub.soy.statements().namedExec(ctx, execer, dialectOf(ub.soy.renderer()), query, params)
 	if err != nil {
 		durationMs := time.Since(startTime).Milliseconds()
 		capitan.Error(ctx, QueryFailed,
@@ -511,24 +575,14 @@ func (ub *Update[T]) execThenSelect(ctx context.Context, execer sqlx.ExtContext,
 		return nil, fmt.Errorf("expected exactly one row updated, found %d", affected)
 	}
 
-	// Build and execute SELECT to fetch the updated row
-	selectBuilder, err := ub.buildFallbackSelect()
-	if err != nil {
-		return nil, fmt.Errorf("failed to build fallback SELECT: %w", err)
-	}
-
-	selectResult, err := selectBuilder.Render(ub.soy.renderer())
-	if err != nil {
-		return nil, fmt.Errorf("failed to render fallback SELECT: %w", err)
-	}
-
+	// Execute SELECT to fetch the updated row
 	capitan.Debug(ctx, QueryStarted,
 		TableKey.Field(tableName),
 		OperationKey.Field("SELECT (UPDATE fallback)"),
-		SQLKey.Field(selectResult.SQL),
+		SQLKey.Field(selectSQL),
 	)
 
-	rows, err := sqlx.NamedQueryContext(ctx, execer, selectResult.SQL, params)
+	rows, err := ub.soy.statements().namedQuery(ctx, execer, dialectOf(ub.soy.renderer()), selectSQL, params)
 	if err != nil {
 		durationMs := time.Since(startTime).Milliseconds()
 		capitan.Error(ctx, QueryFailed,
@@ -553,7 +607,7 @@ func (ub *Update[T]) execThenSelect(ctx context.Context, execer sqlx.ExtContext,
 	}
 
 	var updated T
-	if err := rows.StructScan(&updated); err != nil {
+	if err := scanStruct(dialectOf(ub.soy.renderer()), rows, &updated); err != nil {
 		durationMs := time.Since(startTime).Milliseconds()
 		capitan.Error(ctx, QueryFailed,
 			TableKey.Field(tableName),
@@ -580,6 +634,20 @@ func (ub *Update[T]) execThenSelect(ctx context.Context, execer sqlx.ExtContext,
 	return &updated, nil
 }
 
+// renderFallbackSelect builds and renders the SELECT used to re-read the updated row.
+func (ub *Update[T]) renderFallbackSelect() (string, error) {
+	selectBuilder, err := ub.buildFallbackSelect()
+	if err != nil {
+		return "", fmt.Errorf("failed to build fallback SELECT: %w", err)
+	}
+
+	selectResult, err := selectBuilder.Render(ub.soy.renderer())
+	if err != nil {
+		return "", fmt.Errorf("failed to render fallback SELECT: %w", err)
+	}
+	return selectResult.SQL, nil
+}
+
 // buildFallbackSelect builds a SELECT query using the same WHERE conditions as the UPDATE.
 func (ub *Update[T]) buildFallbackSelect() (*astql.Builder, error) {
 	tableName := ub.soy.getTableName()
@@ -606,14 +674,29 @@ func (ub *Update[T]) buildFallbackSelect() (*astql.Builder, error) {
 	}
 	builder = builder.Fields(fieldSlice...)
 
-	// Add stored WHERE conditions
-	for _, cond := range ub.whereItems {
+	// Add stored WHERE conditions and scopes
+	for _, cond := range append(slices.Clip(ub.whereItems), ub.scopes.items(ub.soy)...) {
 		builder = builder.Where(cond)
 	}
 
 	return builder, nil
 }
 
+// Clone returns an independent copy of the builder, including its WHERE tracking
+// and any stored error.
+func (ub *Update[T]) Clone() *Update[T] {
+	return &Update[T]{
+		instance:   ub.instance,
+		builder:    cloneBuilder(ub.builder),
+		soy:        ub.soy,
+		hasWhere:   ub.hasWhere,
+		whereItems: slices.Clone(ub.whereItems),
+		batch:      ub.batch.clone(),
+		scopes:     ub.scopes,
+		err:        ub.err,
+	}
+}
+
 // Render builds and renders the query to SQL with parameter placeholders.
 // Returns the SQL string and list of required parameters.
 // Useful for inspection/debugging before execution.
@@ -623,7 +706,7 @@ func (ub *Update[T]) Render() (*astql.QueryResult, error) {
 		return nil, fmt.Errorf("update  has errors: %w", ub.err)
 	}
 
-	result, err := ub.builder.Render(ub.soy.renderer())
+	result, err := ub.scopes.apply(ub.soy, ub.builder).Render(ub.soy.renderer())
 	if err != nil {
 		return nil, fmt.Errorf("failed to render UPDATE query: %w", err)
 	}
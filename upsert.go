@@ -0,0 +1,507 @@
+package soy
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/zoobzio/capitan"
+)
+
+// UpsertResult reports the outcome of a batch upsert.
+// Inserted counts new rows, Updated counts conflicting rows changed by DO UPDATE,
+// and Skipped counts conflicting rows left untouched (DO NOTHING, or unchanged rows on MariaDB).
+type UpsertResult struct {
+	Inserted int64
+	Updated  int64
+	Skipped  int64
+}
+
+// ExecBatchUpsert executes a multi-row upsert for the provided records.
+// OnConflict must be configured. The batch runs as one statement unless it needs more
+// params than the dialect allows (65535 on PostgreSQL, 2100 on SQL Server); it is then split
+// into chunks that run in one transaction, and the result adds up their counts.
+//
+// Each dialect uses its native set-based form:
+//   - PostgreSQL: INSERT ... ON CONFLICT ... RETURNING (xmax = 0)
+//   - SQLite: INSERT ... ON CONFLICT ...
+//   - MariaDB: INSERT ... ON DUPLICATE KEY UPDATE
+//   - SQL Server: MERGE ... OUTPUT $action
+//
+// DoUpdate Set params must name columns of T; each conflicting row is updated
+// with the value of that column from its incoming record.
+// Conflict keys should be unique within a batch; on SQLite and MariaDB, DoUpdate batches
+// with repeated conflict keys are rejected, since their counts could not be derived.
+//
+// SQLite and MariaDB cannot report per-row outcomes, so DoUpdate counts come from a count
+// of existing keys run as a separate statement before the write. Rows are written correctly
+// either way, but a concurrent writer that inserts or deletes a matching row between the two
+// statements makes Inserted and Updated misreport it; under MariaDB's default REPEATABLE READ
+// the count reads a snapshot, so this holds even inside the transaction. Use
+// ExecBatchUpsertTx with a SERIALIZABLE transaction when the counts must be exact.
+//
+// Example:
+//
+//	result, err := soy.Insert().
+//	    OnConflict("email").
+//	    DoUpdate().
+//	    Set("name", "name").
+//	    Build().
+//	    ExecBatchUpsert(ctx, users)
+func (cb *Create[T]) ExecBatchUpsert(ctx context.Context, records []*T) (*UpsertResult, error) {
+	return cb.execBatchUpsert(ctx, cb.soy.execer(), records)
+}
+
+// ExecBatchUpsertTx executes a multi-row upsert within a transaction.
+// On SQLite and MariaDB, DO UPDATE counts are derived from a pre-count of existing keys
+// that runs in the same transaction; ExecBatchUpsert begins one for it.
+//
+// Example:
+//
+//	tx, _ := db.BeginTxx(ctx, nil)
+//	defer tx.Rollback()
+//	result, err := soy.Insert().OnConflict("email").DoNothing().ExecBatchUpsertTx(ctx, tx, users)
+//	tx.Commit()
+func (cb *Create[T]) ExecBatchUpsertTx(ctx context.Context, tx *sqlx.Tx, records []*T) (*UpsertResult, error) {
+	return cb.execBatchUpsert(ctx, tx, records)
+}
+
+// execBatchUpsert is the internal batch upsert method used by ExecBatch, ExecBatchUpsert, and ExecBatchUpsertTx.
+// Batches that would exceed the dialect's parameter limit are split into chunks, and their
+// counts are added up. Chunks, and the pre-count of existing keys on SQLite and MariaDB,
+// run in one transaction when execer can begin one.
+func (cb *Create[T]) execBatchUpsert(ctx context.Context, execer sqlx.ExtContext, records []*T) (*UpsertResult, error) {
+	if cb.err != nil {
+		return nil, fmt.Errorf("create builder has errors: %w", cb.err)
+	}
+
+	if !cb.hasConflict {
+		return nil, fmt.Errorf("batch upsert requires OnConflict")
+	}
+
+	if len(records) == 0 {
+		return &UpsertResult{}, nil
+	}
+
+	columns, err := cb.upsertColumns()
+	if err != nil {
+		return nil, err
+	}
+	d := dialectOf(cb.soy.renderer())
+	size := max(d.maxParams()/len(columns), 1)
+
+	beginner, ok := execer.(txBeginner)
+	if !ok || (len(records) <= size && !cb.countsExisting(d)) {
+		return cb.execBatchUpsertChunks(ctx, execer, records, size)
+	}
+
+	tx, err := beginner.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin batch UPSERT transaction: %w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	result, err := cb.execBatchUpsertChunks(ctx, tx, records, size)
+	if err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit batch UPSERT transaction: %w", err)
+	}
+	return result, nil
+}
+
+// execBatchUpsertChunks upserts records in chunks of at most size records and adds up their counts.
+func (cb *Create[T]) execBatchUpsertChunks(ctx context.Context, execer sqlx.ExtContext, records []*T, size int) (*UpsertResult, error) {
+	total := &UpsertResult{}
+	keys := make(map[string]int)
+	start := 0
+	for chunk := range slices.Chunk(records, size) {
+		result, err := cb.execBatchUpsertChunk(ctx, execer, chunk, start, keys)
+		if err != nil {
+			if len(chunk) < len(records) {
+				// Record indexes in errors are relative to the chunk
+				return nil, fmt.Errorf("batch UPSERT of records %d to %d: %w", start, start+len(chunk)-1, err)
+			}
+			return nil, err
+		}
+		start += len(chunk)
+		total.Inserted += result.Inserted
+		total.Updated += result.Updated
+		total.Skipped += result.Skipped
+	}
+	return total, nil
+}
+
+// execBatchUpsertChunk executes a single upsert statement for records, which start at
+// index start of the batch. keys collects the conflict keys of the batch when existing
+// rows are counted.
+func (cb *Create[T]) execBatchUpsertChunk(ctx context.Context, execer sqlx.ExtContext, records []*T, start int, keys map[string]int) (*UpsertResult, error) {
+	query, params, err := cb.renderBatchUpsert(ctx, records)
+	if err != nil {
+		return nil, err
+	}
+
+	tableName := cb.soy.getTableName()
+	d := dialectOf(cb.soy.renderer())
+
+	// SQLite and MariaDB cannot report per-row outcomes, so count existing keys first
+	var existing int64
+	if cb.countsExisting(d) {
+		if err := cb.checkConflictKeys(params, len(records), start, keys); err != nil {
+			return nil, err
+		}
+		existing, err = cb.countExistingConflicts(ctx, execer, len(records), params)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	capitan.Debug(ctx, QueryStarted,
+		TableKey.Field(tableName),
+		OperationKey.Field("UPSERT_BATCH"),
+		SQLKey.Field(query),
+	)
+
+	startTime := time.Now()
+
+	var result *UpsertResult
+	switch d {
+	case dialectPostgres, dialectMSSQL:
+		result, err = cb.execBatchUpsertReturning(ctx, execer, d, query, params, int64(len(records)))
+	default:
+		result, err = cb.execBatchUpsertAffected(ctx, execer, d, query, params, int64(len(records)), existing)
+	}
+	if err != nil {
+		durationMs := time.Since(startTime).Milliseconds()
+		capitan.Error(ctx, QueryFailed,
+			TableKey.Field(tableName),
+			OperationKey.Field("UPSERT_BATCH"),
+			DurationMsKey.Field(durationMs),
+			ErrorKey.Field(err.Error()),
+		)
+		return nil, err
+	}
+
+	durationMs := time.Since(startTime).Milliseconds()
+	capitan.Info(ctx, QueryCompleted,
+		TableKey.Field(tableName),
+		OperationKey.Field("UPSERT_BATCH"),
+		DurationMsKey.Field(durationMs),
+		RowsAffectedKey.Field(result.Inserted+result.Updated),
+		RowsInsertedKey.Field(result.Inserted),
+		RowsUpdatedKey.Field(result.Updated),
+		RowsSkippedKey.Field(result.Skipped),
+	)
+
+	return result, nil
+}
+
+// execBatchUpsertReturning executes an upsert that reports one row per inserted or updated record.
+// PostgreSQL returns (xmax = 0), which is true for inserted rows; SQL Server returns the MERGE $action.
+func (cb *Create[T]) execBatchUpsertReturning(ctx context.Context, execer sqlx.ExtContext, d dialect, query string, params map[string]any, total int64) (*UpsertResult, error) {
+	rows, err := sqlx.NamedQueryContext(ctx, execer, query, params)
+	if err != nil {
+		return nil, fmt.Errorf("batch UPSERT failed: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	result := &UpsertResult{}
+	for rows.Next() {
+		var inserted bool
+		if d == dialectMSSQL {
+			var action string
+			if err := rows.Scan(&action); err != nil {
+				return nil, fmt.Errorf("failed to scan MERGE action: %w", err)
+			}
+			inserted = action == "INSERT"
+		} else if err := rows.Scan(&inserted); err != nil {
+			return nil, fmt.Errorf("failed to scan UPSERT result: %w", err)
+		}
+
+		if inserted {
+			result.Inserted++
+		} else {
+			result.Updated++
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("batch UPSERT failed: %w", err)
+	}
+
+	result.Skipped = total - result.Inserted - result.Updated
+	return result, nil
+}
+
+// execBatchUpsertAffected executes an upsert and derives counts from rows affected.
+// SQLite counts each inserted or updated row once. MariaDB counts inserts once,
+// updates twice, and unchanged rows not at all.
+func (cb *Create[T]) execBatchUpsertAffected(ctx context.Context, execer sqlx.ExtContext, d dialect, query string, params map[string]any, total, existing int64) (*UpsertResult, error) {
+	res, err := sqlx.NamedExecContext(ctx, execer, query, params)
+	if err != nil {
+		return nil, fmt.Errorf("batch UPSERT failed: %w", err)
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	result := &UpsertResult{}
+	if len(cb.updateFields) == 0 {
+		// DO NOTHING: every affected row is an insert
+		result.Inserted = affected
+	} else {
+		result.Inserted = max(total-existing, 0)
+		updated := max(affected-result.Inserted, 0)
+		if d == dialectMariaDB {
+			updated /= 2
+		}
+		result.Updated = updated
+	}
+
+	result.Skipped = total - result.Inserted - result.Updated
+	return result, nil
+}
+
+// checkUniqueKey rejects conflict columns on MariaDB that are not a unique key of the table.
+// ON DUPLICATE KEY UPDATE names no conflict target and fires on whichever unique key an
+// incoming row collides with, so other columns would be silently ignored.
+func (cb *Create[T]) checkUniqueKey(columns []string) error {
+	if dialectOf(cb.soy.renderer()) != dialectMariaDB {
+		return nil
+	}
+	keys, err := uniqueKeys(cb.soy.getMetadata())
+	if err != nil {
+		return err
+	}
+	sorted := slices.Sorted(slices.Values(columns))
+	for _, key := range keys {
+		if slices.Equal(slices.Sorted(slices.Values(key)), sorted) {
+			return nil
+		}
+	}
+	return newFieldUsageError(strings.Join(columns, ", "), fmt.Sprintf("conflict columns must be a primary or unique key of %s on MariaDB, whose ON DUPLICATE KEY UPDATE matches any unique key", cb.soy.getTableName()))
+}
+
+// countsExisting reports whether DO UPDATE counts are derived from a pre-count of existing keys,
+// which SQLite and MariaDB need because they cannot report per-row outcomes.
+func (cb *Create[T]) countsExisting(d dialect) bool {
+	return len(cb.updateFields) > 0 && (d == dialectSQLite || d == dialectMariaDB)
+}
+
+// checkConflictKeys rejects a record whose conflict key repeats an earlier record's, which
+// would be counted as an existing row once but updated twice. keys maps each conflict key
+// seen so far to the index of its record in the batch.
+func (cb *Create[T]) checkConflictKeys(params map[string]any, n, start int, keys map[string]int) error {
+	for i := range n {
+		var key strings.Builder
+		for _, col := range cb.conflictColumns {
+			value := params[fmt.Sprintf("%s_%d", col, i)]
+			fmt.Fprintf(&key, "%T:%v\x00", value, value)
+		}
+		if first, ok := keys[key.String()]; ok {
+			return fmt.Errorf("batch upsert records %d and %d have the same conflict key", first, start+i)
+		}
+		keys[key.String()] = start + i
+	}
+	return nil
+}
+
+// countExistingConflicts counts rows whose conflict keys match incoming records.
+// It reuses the indexed params of the batch so no extra values are bound.
+func (cb *Create[T]) countExistingConflicts(ctx context.Context, execer sqlx.ExtContext, n int, params map[string]any) (int64, error) {
+	query := cb.renderExistingCount(n)
+
+	rows, err := sqlx.NamedQueryContext(ctx, execer, query, params)
+	if err != nil {
+		return 0, fmt.Errorf("failed to count existing rows: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	var count int64
+	if rows.Next() {
+		if err := rows.Scan(&count); err != nil {
+			return 0, fmt.Errorf("failed to scan existing row count: %w", err)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return 0, fmt.Errorf("failed to count existing rows: %w", err)
+	}
+	return count, nil
+}
+
+// renderBatchUpsert renders the dialect-specific batch upsert for records.
+// Returns the SQL and the indexed params (column_index) for all records.
+func (cb *Create[T]) renderBatchUpsert(ctx context.Context, records []*T) (string, map[string]any, error) {
+	columns, err := cb.upsertColumns()
+	if err != nil {
+		return "", nil, err
+	}
+
+	builder, params, err := cb.buildBatchInsert(ctx, records, columns)
+	if err != nil {
+		return "", nil, err
+	}
+
+	d := dialectOf(cb.soy.renderer())
+	if d == dialectMSSQL {
+		return cb.renderBatchMerge(columns, len(records)), params, nil
+	}
+
+	result, err := builder.Render(cb.soy.renderer())
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to render batch INSERT query: %w", err)
+	}
+
+	var sql strings.Builder
+	sql.WriteString(result.SQL)
+
+	updates := cb.sortedUpdateFields()
+	switch d {
+	case dialectMariaDB:
+		// MariaDB has no DO NOTHING; a self-assignment leaves the row unchanged
+		sql.WriteString(" ON DUPLICATE KEY UPDATE ")
+		if len(updates) == 0 {
+			col := d.quote(cb.conflictColumns[0])
+			sql.WriteString(col + " = " + col)
+			break
+		}
+		assignments := make([]string, len(updates))
+		for i, field := range updates {
+			assignments[i] = fmt.Sprintf("%s = VALUES(%s)", d.quote(field), d.quote(cb.updateFields[field]))
+		}
+		sql.WriteString(strings.Join(assignments, ", "))
+	default:
+		sql.WriteString(" ON CONFLICT (" + d.quoteAll(cb.conflictColumns) + ") ")
+		if len(updates) == 0 {
+			sql.WriteString("DO NOTHING")
+		} else {
+			assignments := make([]string, len(updates))
+			for i, field := range updates {
+				assignments[i] = fmt.Sprintf("%s = excluded.%s", d.quote(field), d.quote(cb.updateFields[field]))
+			}
+			sql.WriteString("DO UPDATE SET " + strings.Join(assignments, ", "))
+		}
+		if d == dialectPostgres {
+			// xmax is zero only for freshly inserted tuples
+			sql.WriteString(" RETURNING (xmax = 0) AS " + d.quote("inserted"))
+		}
+	}
+
+	return sql.String(), params, nil
+}
+
+// renderBatchMerge renders a set-based MERGE for SQL Server.
+// Records are supplied as a VALUES source; OUTPUT $action reports INSERT or UPDATE per row.
+func (cb *Create[T]) renderBatchMerge(columns []string, n int) string {
+	d := dialectMSSQL
+
+	rows := make([]string, n)
+	for i := range n {
+		placeholders := make([]string, len(columns))
+		for j, col := range columns {
+			placeholders[j] = fmt.Sprintf(":%s_%d", col, i)
+		}
+		rows[i] = "(" + strings.Join(placeholders, ", ") + ")"
+	}
+
+	on := make([]string, len(cb.conflictColumns))
+	for i, col := range cb.conflictColumns {
+		on[i] = fmt.Sprintf("[target].%s = [source].%s", d.quote(col), d.quote(col))
+	}
+
+	sourceCols := make([]string, len(columns))
+	for i, col := range columns {
+		sourceCols[i] = "[source]." + d.quote(col)
+	}
+
+	var sql strings.Builder
+	sql.WriteString("MERGE INTO " + d.quote(cb.soy.getTableName()) + " AS [target]")
+	sql.WriteString(" USING (VALUES " + strings.Join(rows, ", ") + ")")
+	sql.WriteString(" AS [source] (" + d.quoteAll(columns) + ")")
+	sql.WriteString(" ON " + strings.Join(on, " AND "))
+
+	if updates := cb.sortedUpdateFields(); len(updates) > 0 {
+		assignments := make([]string, len(updates))
+		for i, field := range updates {
+			assignments[i] = fmt.Sprintf("[target].%s = [source].%s", d.quote(field), d.quote(cb.updateFields[field]))
+		}
+		sql.WriteString(" WHEN MATCHED THEN UPDATE SET " + strings.Join(assignments, ", "))
+	}
+
+	sql.WriteString(" WHEN NOT MATCHED THEN INSERT (" + d.quoteAll(columns) + ")")
+	sql.WriteString(" VALUES (" + strings.Join(sourceCols, ", ") + ")")
+	sql.WriteString(" OUTPUT $action;")
+
+	return sql.String()
+}
+
+// renderExistingCount renders a COUNT of rows matching any record's conflict keys.
+func (cb *Create[T]) renderExistingCount(n int) string {
+	d := dialectOf(cb.soy.renderer())
+
+	groups := make([]string, n)
+	for i := range n {
+		conds := make([]string, len(cb.conflictColumns))
+		for j, col := range cb.conflictColumns {
+			conds[j] = fmt.Sprintf("%s = :%s_%d", d.quote(col), col, i)
+		}
+		groups[i] = "(" + strings.Join(conds, " AND ") + ")"
+	}
+
+	return "SELECT COUNT(*) FROM " + d.quote(cb.soy.getTableName()) + " WHERE " + strings.Join(groups, " OR ")
+}
+
+// upsertColumns returns the columns written by a batch upsert.
+// These are the batch INSERT columns plus any primary key used as a conflict column.
+// Every DoUpdate Set param must be one of these columns, since its value is read from the incoming row.
+func (cb *Create[T]) upsertColumns() ([]string, error) {
+	required := make(map[string]bool, len(cb.conflictColumns))
+	for _, col := range cb.conflictColumns {
+		required[col] = true
+	}
+
+	inserted := make(map[string]bool)
+	for _, col := range cb.insertColumns(false) {
+		inserted[col] = true
+	}
+
+	var columns []string
+	for _, col := range cb.insertColumns(true) {
+		if inserted[col] || required[col] {
+			columns = append(columns, col)
+		}
+	}
+
+	included := make(map[string]bool, len(columns))
+	for _, col := range columns {
+		included[col] = true
+	}
+	for _, col := range cb.conflictColumns {
+		if !included[col] {
+			return nil, fmt.Errorf("conflict column %q is not a column of %s", col, cb.soy.getTableName())
+		}
+	}
+	for _, field := range cb.sortedUpdateFields() {
+		if param := cb.updateFields[field]; !included[param] {
+			return nil, fmt.Errorf("batch upsert param %q for field %q must name an inserted column", param, field)
+		}
+	}
+
+	return columns, nil
+}
+
+// sortedUpdateFields returns the DoUpdate fields in name order for deterministic SQL.
+func (cb *Create[T]) sortedUpdateFields() []string {
+	fields := make([]string, 0, len(cb.updateFields))
+	for field := range cb.updateFields {
+		fields = append(fields, field)
+	}
+	sort.Strings(fields)
+	return fields
+}
@@ -0,0 +1,380 @@
+package soy
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/zoobzio/astql/mariadb"
+	"github.com/zoobzio/astql/mssql"
+	"github.com/zoobzio/astql/postgres"
+	"github.com/zoobzio/astql/sqlite"
+	"github.com/zoobzio/sentinel"
+)
+
+func upsertTestUsers() []*createTestUser {
+	age1, age2 := 25, 30
+	return []*createTestUser{
+		{Email: "a@example.com", Name: "A", Age: &age1},
+		{Email: "b@example.com", Name: "B", Age: &age2},
+	}
+}
+
+func TestUpsert_RenderPostgres(t *testing.T) {
+	sentinel.Tag("db")
+	sentinel.Tag("type")
+	sentinel.Tag("constraints")
+
+	db := &sqlx.DB{}
+	s, err := New[createTestUser](db, "users", postgres.New())
+	if err != nil {
+		t.Fatalf("New() failed: %v", err)
+	}
+
+	t.Run("DO UPDATE uses excluded values", func(t *testing.T) {
+		create := s.Insert().OnConflict("email").DoUpdate().Set("name", "name").Set("age", "age").Build()
+
+		sql, params, err := create.renderBatchUpsert(t.Context(), upsertTestUsers())
+		if err != nil {
+			t.Fatalf("renderBatchUpsert() failed: %v", err)
+		}
+
+		if !strings.Contains(sql, `ON CONFLICT ("email") DO UPDATE SET "age" = excluded."age", "name" = excluded."name"`) {
+			t.Errorf("SQL missing conflict clause: %s", sql)
+		}
+		if !strings.HasSuffix(sql, `RETURNING (xmax = 0) AS "inserted"`) {
+			t.Errorf("SQL missing xmax RETURNING: %s", sql)
+		}
+		if !strings.Contains(sql, ":email_1") {
+			t.Errorf("SQL missing indexed params: %s", sql)
+		}
+		if params["email_1"] != "b@example.com" {
+			t.Errorf("params[email_1] = %v, want b@example.com", params["email_1"])
+		}
+		if _, ok := params["id_0"]; ok {
+			t.Error("primary key should not be inserted unless it is a conflict column")
+		}
+	})
+
+	t.Run("DO NOTHING", func(t *testing.T) {
+		create := s.Insert().OnConflict("email").DoNothing()
+
+		sql, _, err := create.renderBatchUpsert(t.Context(), upsertTestUsers())
+		if err != nil {
+			t.Fatalf("renderBatchUpsert() failed: %v", err)
+		}
+
+		if !strings.Contains(sql, `ON CONFLICT ("email") DO NOTHING`) {
+			t.Errorf("SQL missing DO NOTHING: %s", sql)
+		}
+		if strings.Count(sql, "ON CONFLICT") != 1 {
+			t.Errorf("expected a single ON CONFLICT clause: %s", sql)
+		}
+	})
+
+	t.Run("primary key conflict column is inserted", func(t *testing.T) {
+		create := s.Insert().OnConflict("id").DoUpdate().Set("email", "email").Build()
+
+		_, params, err := create.renderBatchUpsert(t.Context(), upsertTestUsers())
+		if err != nil {
+			t.Fatalf("renderBatchUpsert() failed: %v", err)
+		}
+		if _, ok := params["id_0"]; !ok {
+			t.Error("expected primary key param for conflict column")
+		}
+	})
+
+	t.Run("Set param must be a column", func(t *testing.T) {
+		create := s.Insert().OnConflict("email").DoUpdate().Set("name", "new_name").Build()
+
+		_, _, err := create.renderBatchUpsert(t.Context(), upsertTestUsers())
+		if err == nil {
+			t.Fatal("expected error for non-column param")
+		}
+		if !strings.Contains(err.Error(), "new_name") {
+			t.Errorf("error should mention param: %v", err)
+		}
+	})
+
+	t.Run("requires OnConflict", func(t *testing.T) {
+		_, err := s.Insert().ExecBatchUpsert(t.Context(), upsertTestUsers())
+		if err == nil {
+			t.Fatal("expected error without OnConflict")
+		}
+	})
+
+	t.Run("empty batch", func(t *testing.T) {
+		result, err := s.Insert().OnConflict("email").DoNothing().ExecBatchUpsert(t.Context(), nil)
+		if err != nil {
+			t.Fatalf("ExecBatchUpsert() failed: %v", err)
+		}
+		if *result != (UpsertResult{}) {
+			t.Errorf("expected zero result, got %+v", result)
+		}
+	})
+}
+
+func TestUpsert_RenderSQLite(t *testing.T) {
+	db := &sqlx.DB{}
+	s, err := New[createTestUser](db, "users", sqlite.New())
+	if err != nil {
+		t.Fatalf("New() failed: %v", err)
+	}
+
+	create := s.Insert().OnConflict("email").DoUpdate().Set("name", "name").Build()
+
+	sql, _, err := create.renderBatchUpsert(t.Context(), upsertTestUsers())
+	if err != nil {
+		t.Fatalf("renderBatchUpsert() failed: %v", err)
+	}
+
+	if !strings.HasSuffix(sql, `ON CONFLICT ("email") DO UPDATE SET "name" = excluded."name"`) {
+		t.Errorf("SQL missing conflict clause: %s", sql)
+	}
+
+	count := create.renderExistingCount(2)
+	if count != `SELECT COUNT(*) FROM "users" WHERE ("email" = :email_0) OR ("email" = :email_1)` {
+		t.Errorf("unexpected existing count SQL: %s", count)
+	}
+}
+
+func TestUpsert_RenderMariaDB(t *testing.T) {
+	db := &sqlx.DB{}
+	s, err := New[createTestUser](db, "users", mariadb.New())
+	if err != nil {
+		t.Fatalf("New() failed: %v", err)
+	}
+
+	t.Run("DO UPDATE uses VALUES()", func(t *testing.T) {
+		create := s.Insert().OnConflict("email").DoUpdate().Set("name", "name").Build()
+
+		sql, _, err := create.renderBatchUpsert(t.Context(), upsertTestUsers())
+		if err != nil {
+			t.Fatalf("renderBatchUpsert() failed: %v", err)
+		}
+		if !strings.HasSuffix(sql, "ON DUPLICATE KEY UPDATE `name` = VALUES(`name`)") {
+			t.Errorf("SQL missing duplicate key clause: %s", sql)
+		}
+	})
+
+	t.Run("DO NOTHING uses self-assignment", func(t *testing.T) {
+		create := s.Insert().OnConflict("email").DoNothing()
+
+		sql, _, err := create.renderBatchUpsert(t.Context(), upsertTestUsers())
+		if err != nil {
+			t.Fatalf("renderBatchUpsert() failed: %v", err)
+		}
+		if !strings.HasSuffix(sql, "ON DUPLICATE KEY UPDATE `email` = `email`") {
+			t.Errorf("SQL missing no-op clause: %s", sql)
+		}
+		if strings.Count(sql, "ON DUPLICATE KEY UPDATE") != 1 {
+			t.Errorf("expected a single duplicate key clause: %s", sql)
+		}
+	})
+}
+
+type upsertTestMember struct {
+	ID     int    `db:"id" type:"integer" constraints:"primarykey"`
+	OrgID  int    `db:"org_id" type:"integer" index:"members_org_email,unique"`
+	Email  string `db:"email" type:"text" index:"members_org_email,unique"`
+	Handle string `db:"handle" type:"text" index:"members_handle,unique,where:handle IS NOT NULL"`
+	Name   string `db:"name" type:"text"`
+}
+
+func TestUpsert_MariaDBConflictKey(t *testing.T) {
+	drv := &nearestDriver{}
+	name := fmt.Sprintf("soy_upsert_%d", verifyDriverSeq.Add(1))
+	sql.Register(name, drv)
+	db, err := sqlx.Open(name, "")
+	if err != nil {
+		t.Fatalf("sqlx.Open() failed: %v", err)
+	}
+	t.Cleanup(func() { _ = db.Close() })
+
+	s, err := New[upsertTestMember](db, "members", mariadb.New())
+	if err != nil {
+		t.Fatalf("New() failed: %v", err)
+	}
+
+	tests := []struct {
+		name    string
+		columns []string
+		valid   bool
+	}{
+		{"primary key", []string{"id"}, true},
+		{"unique index", []string{"org_id", "email"}, true},
+		{"unique index in any order", []string{"email", "org_id"}, true},
+		{"part of a unique index", []string{"email"}, false},
+		{"partial unique index", []string{"handle"}, false},
+		{"no unique key", []string{"name"}, false},
+		{"unique index and more", []string{"org_id", "email", "name"}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := s.InsertFull().OnConflict(tt.columns...).DoUpdate().Set("name", "name").Build().Render()
+			if tt.valid && err != nil {
+				t.Errorf("Render() failed: %v", err)
+			}
+			if !tt.valid && !errors.Is(err, ErrInvalidField) {
+				t.Errorf("expected ErrInvalidField, got %v", err)
+			}
+		})
+	}
+
+	t.Run("rejected before reaching the database", func(t *testing.T) {
+		upsert := s.Insert().OnConflict("email").DoUpdate().Set("name", "name").Build()
+		if _, err := upsert.Exec(t.Context(), &upsertTestMember{Email: "a@example.com"}); !errors.Is(err, ErrInvalidField) {
+			t.Errorf("Exec() error = %v", err)
+		}
+		if _, err := upsert.ExecBatchUpsert(t.Context(), []*upsertTestMember{{Email: "a@example.com"}}); !errors.Is(err, ErrInvalidField) {
+			t.Errorf("ExecBatchUpsert() error = %v", err)
+		}
+		if len(drv.log) != 0 {
+			t.Errorf("statements reached the database: %q", drv.log)
+		}
+	})
+
+	t.Run("other dialects take any conflict columns", func(t *testing.T) {
+		pg, err := New[upsertTestMember](&sqlx.DB{}, "members", postgres.New())
+		if err != nil {
+			t.Fatalf("New() failed: %v", err)
+		}
+		if _, err := pg.Insert().OnConflict("email").DoNothing().Render(); err != nil {
+			t.Errorf("Render() failed: %v", err)
+		}
+	})
+}
+
+func TestUpsert_RenderMSSQLMerge(t *testing.T) {
+	db := &sqlx.DB{}
+	s, err := New[createTestUser](db, "users", mssql.New())
+	if err != nil {
+		t.Fatalf("New() failed: %v", err)
+	}
+
+	t.Run("DO UPDATE", func(t *testing.T) {
+		create := s.Insert().OnConflict("email").DoUpdate().Set("name", "name").Build()
+
+		sql, params, err := create.renderBatchUpsert(t.Context(), upsertTestUsers())
+		if err != nil {
+			t.Fatalf("renderBatchUpsert() failed: %v", err)
+		}
+
+		want := "MERGE INTO [users] AS [target]" +
+			" USING (VALUES (:email_0, :name_0, :age_0), (:email_1, :name_1, :age_1))" +
+			" AS [source] ([email], [name], [age])" +
+			" ON [target].[email] = [source].[email]" +
+			" WHEN MATCHED THEN UPDATE SET [target].[name] = [source].[name]" +
+			" WHEN NOT MATCHED THEN INSERT ([email], [name], [age]) VALUES ([source].[email], [source].[name], [source].[age])" +
+			" OUTPUT $action;"
+		if sql != want {
+			t.Errorf("MERGE SQL =\n%s\nwant\n%s", sql, want)
+		}
+		if len(params) != 6 {
+			t.Errorf("expected 6 params, got %d", len(params))
+		}
+	})
+
+	t.Run("DO NOTHING omits WHEN MATCHED", func(t *testing.T) {
+		create := s.Insert().OnConflict("email").DoNothing()
+
+		sql, _, err := create.renderBatchUpsert(t.Context(), upsertTestUsers())
+		if err != nil {
+			t.Fatalf("renderBatchUpsert() failed: %v", err)
+		}
+		if strings.Contains(sql, "WHEN MATCHED") {
+			t.Errorf("DO NOTHING should not update matches: %s", sql)
+		}
+		if !strings.Contains(sql, "WHEN NOT MATCHED THEN INSERT") {
+			t.Errorf("SQL missing insert branch: %s", sql)
+		}
+	})
+}
+
+func TestUpsert_ExecChunked(t *testing.T) {
+	drv := &nearestDriver{respond: func(query string) ([]string, [][]driver.Value) {
+		data := make([][]driver.Value, strings.Count(query, "?")/3)
+		for i := range data {
+			data[i] = []driver.Value{"INSERT"}
+		}
+		if len(data) > 0 {
+			data[0][0] = "UPDATE"
+		}
+		return []string{"action"}, data
+	}}
+	name := fmt.Sprintf("soy_upsert_%d", verifyDriverSeq.Add(1))
+	sql.Register(name, drv)
+	db, err := sqlx.Open(name, "")
+	if err != nil {
+		t.Fatalf("sqlx.Open() failed: %v", err)
+	}
+	t.Cleanup(func() { _ = db.Close() })
+
+	s, err := New[createTestUser](db, "users", mssql.New())
+	if err != nil {
+		t.Fatalf("New() failed: %v", err)
+	}
+
+	// Three columns per record fit 700 records in SQL Server's 2100 params
+	records := make([]*createTestUser, 1500)
+	for i := range records {
+		records[i] = &createTestUser{Email: fmt.Sprintf("%d@example.com", i), Name: "N"}
+	}
+	result, err := s.Insert().OnConflict("email").DoUpdate().Set("name", "name").Build().ExecBatchUpsert(t.Context(), records)
+	if err != nil {
+		t.Fatalf("ExecBatchUpsert() failed: %v", err)
+	}
+	if *result != (UpsertResult{Inserted: 1497, Updated: 3}) {
+		t.Errorf("ExecBatchUpsert() = %+v", *result)
+	}
+	if len(drv.log) != 5 || drv.log[0] != "BEGIN" || drv.log[4] != "COMMIT" {
+		t.Errorf("expected three chunks in one transaction, got %d statements", len(drv.log))
+	}
+
+	t.Run("errors name the chunk", func(t *testing.T) {
+		records[800] = nil
+		_, err := s.Insert().OnConflict("email").DoNothing().ExecBatchUpsert(t.Context(), records)
+		if err == nil || !strings.Contains(err.Error(), "records 700 to 1399: nil record at index 100") {
+			t.Errorf("ExecBatchUpsert() error = %v", err)
+		}
+	})
+}
+
+func TestUpsert_ExecCounted(t *testing.T) {
+	drv := &nearestDriver{cols: []string{"count"}, data: [][]driver.Value{{int64(1)}}}
+	name := fmt.Sprintf("soy_upsert_%d", verifyDriverSeq.Add(1))
+	sql.Register(name, drv)
+	db, err := sqlx.Open(name, "")
+	if err != nil {
+		t.Fatalf("sqlx.Open() failed: %v", err)
+	}
+	t.Cleanup(func() { _ = db.Close() })
+
+	s, err := New[createTestUser](db, "users", sqlite.New())
+	if err != nil {
+		t.Fatalf("New() failed: %v", err)
+	}
+	create := s.Insert().OnConflict("email").DoUpdate().Set("name", "name").Build()
+
+	if _, err := create.ExecBatchUpsert(t.Context(), upsertTestUsers()); err != nil {
+		t.Fatalf("ExecBatchUpsert() failed: %v", err)
+	}
+	if len(drv.log) != 4 || drv.log[0] != "BEGIN" || !strings.HasPrefix(drv.log[1], "QUERY") || drv.log[3] != "COMMIT" {
+		t.Errorf("expected the count and upsert in one transaction, got %q", drv.log)
+	}
+
+	t.Run("repeated conflict keys are rejected", func(t *testing.T) {
+		users := append(upsertTestUsers(), &createTestUser{Email: "a@example.com", Name: "C"})
+		_, err := create.ExecBatchUpsert(t.Context(), users)
+		if err == nil || !strings.Contains(err.Error(), "records 0 and 2 have the same conflict key") {
+			t.Errorf("ExecBatchUpsert() error = %v", err)
+		}
+		if _, err := s.Insert().OnConflict("email").DoNothing().ExecBatchUpsert(t.Context(), users); err != nil {
+			t.Errorf("DO NOTHING needs no count: %v", err)
+		}
+	})
+}
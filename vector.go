@@ -0,0 +1,193 @@
+package soy
+
+import (
+	"database/sql"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/zoobzio/atom"
+	"github.com/zoobzio/soy/internal/scanner"
+)
+
+// Vector is a pgvector vector column value, stored in its text form "[1,2,3]".
+// Untagged Vector fields are vector columns without a fixed dimension; add a type tag
+// such as type:"vector(1536)" to fix the dimension, which vector indexes require.
+// A nil Vector is stored as NULL.
+type Vector []float32
+
+// HalfVector is a pgvector halfvec column value, a vector of half-precision floats.
+// It has the same text form as Vector and is converted to half precision by the database.
+type HalfVector []float32
+
+// SparseVector is a pgvector sparsevec column value: a vector of Dim dimensions whose
+// non-zero elements are listed by Indices and Values. Indices are zero-based and
+// ascending. It is stored in its text form "{1:1.5,3:2}/5", where indices are one-based.
+type SparseVector struct {
+	Dim     int
+	Indices []int
+	Values  []float32
+}
+
+func init() {
+	registerVectorType(SQLTypes{Postgres: "vector"}, func(v Vector) bool { return v == nil }, formatVector, ParseVector)
+	registerVectorType(SQLTypes{Postgres: "halfvec"}, func(v HalfVector) bool { return v == nil }, formatVector, ParseHalfVector)
+	registerVectorType(SQLTypes{Postgres: "sparsevec"}, func(SparseVector) bool { return false }, SparseVector.String, ParseSparseVector)
+}
+
+// registerVectorType registers a vector type V, stored in its text form, for PostgreSQL.
+// Unlike RegisterType, values for which isNil reports true are stored as NULL.
+func registerVectorType[V any](types SQLTypes, isNil func(V) bool, format func(V) string, parse func(string) (V, error)) {
+	goType := reflect.TypeFor[V]()
+	codec := &typeCodec{
+		goType:   goType,
+		sqlTypes: types,
+		encode: func(v any) (any, error) {
+			if isNil(v.(V)) {
+				return nil, nil
+			}
+			return format(v.(V)), nil
+		},
+		scan: func(src any) (any, bool, error) {
+			var n sql.NullString
+			if err := n.Scan(src); err != nil {
+				return nil, false, err
+			}
+			if !n.Valid {
+				return nil, true, nil
+			}
+			v, err := parse(n.String)
+			if err != nil {
+				return nil, false, err
+			}
+			return v, false, nil
+		},
+	}
+
+	codecs.Lock()
+	codecs.byType[goType] = codec
+	codecs.Unlock()
+
+	scanner.RegisterTable(goType, atom.TableStrings)
+}
+
+// String returns the vector in pgvector's text form.
+func (v Vector) String() string {
+	return formatVector(v)
+}
+
+// String returns the vector in pgvector's text form.
+func (v HalfVector) String() string {
+	return formatVector(v)
+}
+
+// String returns the vector in pgvector's text form.
+func (v SparseVector) String() string {
+	var b strings.Builder
+	b.WriteByte('{')
+	for i, index := range v.Indices {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		b.WriteString(strconv.Itoa(index + 1))
+		b.WriteByte(':')
+		if i < len(v.Values) {
+			b.WriteString(strconv.FormatFloat(float64(v.Values[i]), 'g', -1, 32))
+		}
+	}
+	b.WriteString("}/")
+	b.WriteString(strconv.Itoa(v.Dim))
+	return b.String()
+}
+
+// NewSparseVector returns the sparse form of a dense vector, keeping its non-zero elements.
+func NewSparseVector(dense []float32) SparseVector {
+	v := SparseVector{Dim: len(dense)}
+	for i, value := range dense {
+		if value != 0 {
+			v.Indices = append(v.Indices, i)
+			v.Values = append(v.Values, value)
+		}
+	}
+	return v
+}
+
+// formatVector returns the text form of a dense vector, such as "[1,2,3]".
+func formatVector[V ~[]float32](v V) string {
+	b := make([]byte, 0, 2+len(v)*8)
+	b = append(b, '[')
+	for i, value := range v {
+		if i > 0 {
+			b = append(b, ',')
+		}
+		b = strconv.AppendFloat(b, float64(value), 'g', -1, 32)
+	}
+	return string(append(b, ']'))
+}
+
+// ParseVector parses a vector in pgvector's text form, such as "[1,2,3]".
+func ParseVector(s string) (Vector, error) {
+	return parseVector[Vector](s)
+}
+
+// ParseHalfVector parses a halfvec in pgvector's text form, such as "[1,2,3]".
+func ParseHalfVector(s string) (HalfVector, error) {
+	return parseVector[HalfVector](s)
+}
+
+// parseVector parses the text form of a dense vector.
+func parseVector[V ~[]float32](s string) (V, error) {
+	inner, ok := strings.CutPrefix(strings.TrimSpace(s), "[")
+	if inner, ok = strings.CutSuffix(inner, "]"); !ok {
+		return nil, fmt.Errorf("invalid vector %q", s)
+	}
+	v := V{}
+	if strings.TrimSpace(inner) == "" {
+		return v, nil
+	}
+	for _, element := range strings.Split(inner, ",") {
+		value, err := strconv.ParseFloat(strings.TrimSpace(element), 32)
+		if err != nil {
+			return nil, fmt.Errorf("invalid vector %q: %w", s, err)
+		}
+		v = append(v, float32(value))
+	}
+	return v, nil
+}
+
+// ParseSparseVector parses a sparsevec in pgvector's text form, such as "{1:1.5,3:2}/5".
+func ParseSparseVector(s string) (SparseVector, error) {
+	elements, dim, ok := strings.Cut(strings.TrimSpace(s), "/")
+	inner, hasOpen := strings.CutPrefix(elements, "{")
+	inner, hasClose := strings.CutSuffix(inner, "}")
+	if !ok || !hasOpen || !hasClose {
+		return SparseVector{}, fmt.Errorf("invalid sparse vector %q", s)
+	}
+
+	var v SparseVector
+	var err error
+	if v.Dim, err = strconv.Atoi(dim); err != nil {
+		return SparseVector{}, fmt.Errorf("invalid sparse vector %q: %w", s, err)
+	}
+	if strings.TrimSpace(inner) == "" {
+		return v, nil
+	}
+	for _, element := range strings.Split(inner, ",") {
+		index, value, ok := strings.Cut(element, ":")
+		if !ok {
+			return SparseVector{}, fmt.Errorf("invalid sparse vector %q", s)
+		}
+		i, err := strconv.Atoi(strings.TrimSpace(index))
+		if err != nil || i < 1 {
+			return SparseVector{}, fmt.Errorf("invalid sparse vector %q: index %q", s, index)
+		}
+		f, err := strconv.ParseFloat(strings.TrimSpace(value), 32)
+		if err != nil {
+			return SparseVector{}, fmt.Errorf("invalid sparse vector %q: %w", s, err)
+		}
+		v.Indices = append(v.Indices, i-1)
+		v.Values = append(v.Values, float32(f))
+	}
+	return v, nil
+}
@@ -0,0 +1,98 @@
+package soy
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/zoobzio/astql/postgres"
+	"github.com/zoobzio/astql/sqlite"
+)
+
+func TestVector_TextForm(t *testing.T) {
+	v := Vector{1, -0.5, 3.25}
+	if got := v.String(); got != "[1,-0.5,3.25]" {
+		t.Errorf("String() = %q", got)
+	}
+	parsed, err := ParseVector(" [1, -0.5,3.25] ")
+	if err != nil || !reflect.DeepEqual(parsed, v) {
+		t.Errorf("ParseVector() = %v, %v", parsed, err)
+	}
+	if half, err := ParseHalfVector("[0.5]"); err != nil || !reflect.DeepEqual(half, HalfVector{0.5}) {
+		t.Errorf("ParseHalfVector() = %v, %v", half, err)
+	}
+	for _, bad := range []string{"1,2", "[1,x]", "{1,2}"} {
+		if _, err := ParseVector(bad); err == nil {
+			t.Errorf("ParseVector(%q) expected error", bad)
+		}
+	}
+
+	sparse := NewSparseVector([]float32{0, 1.5, 0, 2, 0})
+	if got := sparse.String(); got != "{2:1.5,4:2}/5" {
+		t.Errorf("SparseVector.String() = %q", got)
+	}
+	parsed2, err := ParseSparseVector("{2:1.5,4:2}/5")
+	if err != nil || !reflect.DeepEqual(parsed2, sparse) {
+		t.Errorf("ParseSparseVector() = %+v, %v", parsed2, err)
+	}
+	if empty, err := ParseSparseVector("{}/3"); err != nil || empty.Dim != 3 || empty.Indices != nil {
+		t.Errorf("ParseSparseVector(empty) = %+v, %v", empty, err)
+	}
+	for _, bad := range []string{"{1:1}", "{0:1}/3", "{1}/3", "1:1/3"} {
+		if _, err := ParseSparseVector(bad); err == nil {
+			t.Errorf("ParseSparseVector(%q) expected error", bad)
+		}
+	}
+}
+
+func TestVector_Codec(t *testing.T) {
+	encoded, err := encodeParams(dialectPostgres, map[string]any{
+		"v":    Vector{1, 2},
+		"h":    &HalfVector{3},
+		"s":    SparseVector{Dim: 2, Indices: []int{1}, Values: []float32{4}},
+		"null": Vector(nil),
+	})
+	if err != nil {
+		t.Fatalf("encodeParams() error = %v", err)
+	}
+	want := map[string]any{"v": "[1,2]", "h": "[3]", "s": "{2:4}/2", "null": nil}
+	if !reflect.DeepEqual(encoded, want) {
+		t.Errorf("encodeParams() = %v, want %v", encoded, want)
+	}
+
+	codec := lookupCodec(dialectPostgres, reflect.TypeFor[Vector]())
+	value, null, err := codec.scan([]byte("[1,2]"))
+	if err != nil || null || !reflect.DeepEqual(value, Vector{1, 2}) {
+		t.Errorf("scan() = %v, %v, %v", value, null, err)
+	}
+	if _, null, err := codec.scan(nil); err != nil || !null {
+		t.Errorf("scan(nil) = %v, %v", null, err)
+	}
+}
+
+func TestVector_ColumnTypes(t *testing.T) {
+	type embedding struct {
+		ID     int           `db:"id" type:"serial" constraints:"primarykey"`
+		Dense  Vector        `db:"dense" type:"vector(3)"`
+		Half   *HalfVector   `db:"half"`
+		Sparse *SparseVector `db:"sparse"`
+		Any    Vector        `db:"any"`
+	}
+	s, err := New[embedding](nil, "embeddings", postgres.New())
+	if err != nil {
+		t.Fatalf("New() failed: %v", err)
+	}
+	sql, err := s.CreateTableSQL()
+	if err != nil {
+		t.Fatalf("CreateTableSQL() error = %v", err)
+	}
+	for _, want := range []string{`"dense" vector(3)`, `"half" halfvec`, `"sparse" sparsevec`, `"any" vector`} {
+		if !strings.Contains(sql, want) {
+			t.Errorf("CreateTableSQL() missing %q:\n%s", want, sql)
+		}
+	}
+
+	if _, err := New[embedding](nil, "embeddings", sqlite.New()); err == nil {
+		t.Error("expected ErrUnmappableType for untagged vectors in SQLite")
+	}
+}
@@ -0,0 +1,658 @@
+package soy
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/zoobzio/capitan"
+	"github.com/zoobzio/dbml"
+)
+
+// DriftReport describes how the live table differs from the schema derived from
+// the model's struct tags. A report without drift has every list empty.
+type DriftReport struct {
+	Table                 string
+	TableMissing          bool                  // The table does not exist; no other fields are set
+	MissingColumns        []string              // Columns declared on the struct but absent from the table
+	ExtraColumns          []string              // Columns in the table that the struct does not declare
+	TypeMismatches        []TypeMismatch        // Columns whose live type differs from the tagged or inferred type
+	NullabilityMismatches []NullabilityMismatch // Columns whose NULL / NOT NULL setting differs
+	MissingIndexes        []MissingIndex        // Primary keys, uniques and indexes with no matching live index
+	MissingForeignKeys    []ForeignKey          // References with no live foreign key on the same columns
+	ForeignKeyMismatches  []ForeignKeyMismatch  // Foreign keys whose ON DELETE or ON UPDATE action differs
+}
+
+// TypeMismatch is a column whose live type differs from the expected type.
+// Both types are normalized, so aliases such as int4 and integer compare equal.
+type TypeMismatch struct {
+	Column   string
+	Expected string
+	Actual   string
+}
+
+// NullabilityMismatch is a column whose live nullability differs from the struct tags.
+type NullabilityMismatch struct {
+	Column           string
+	ExpectedNullable bool
+	ActualNullable   bool
+}
+
+// MissingIndex is an expected index with no live index on the same columns.
+type MissingIndex struct {
+	Name       string // Index name from the index tag; empty for primary keys and unique columns
+	Columns    []string
+	Unique     bool
+	PrimaryKey bool
+}
+
+// ForeignKey is an expected foreign key from a references tag.
+// Actions are lowercase, as in "cascade" or "set null".
+type ForeignKey struct {
+	Columns    []string
+	RefTable   string
+	RefColumns []string
+	OnDelete   string
+	OnUpdate   string
+}
+
+// ForeignKeyMismatch is a foreign key whose live referential action differs from its tag.
+type ForeignKeyMismatch struct {
+	ForeignKey
+	Clause string // "ON DELETE" or "ON UPDATE"
+	Actual string
+}
+
+// HasDrift reports whether the live table differs from the expected schema.
+func (r *DriftReport) HasDrift() bool {
+	return r.TableMissing ||
+		len(r.MissingColumns) > 0 ||
+		len(r.ExtraColumns) > 0 ||
+		len(r.TypeMismatches) > 0 ||
+		len(r.NullabilityMismatches) > 0 ||
+		len(r.MissingIndexes) > 0 ||
+		len(r.MissingForeignKeys) > 0 ||
+		len(r.ForeignKeyMismatches) > 0
+}
+
+// Err returns a *SchemaDriftError describing the drift, or nil if there is none.
+// It is convenient for readiness checks that only need pass or fail.
+func (r *DriftReport) Err() error {
+	if !r.HasDrift() {
+		return nil
+	}
+	return &SchemaDriftError{Report: r}
+}
+
+// String summarizes the drift in a single line.
+func (r *DriftReport) String() string {
+	if r.TableMissing {
+		return fmt.Sprintf("table %s does not exist", r.Table)
+	}
+	if !r.HasDrift() {
+		return fmt.Sprintf("table %s matches", r.Table)
+	}
+
+	var parts []string
+	if len(r.MissingColumns) > 0 {
+		parts = append(parts, "missing columns: "+strings.Join(r.MissingColumns, ", "))
+	}
+	if len(r.ExtraColumns) > 0 {
+		parts = append(parts, "extra columns: "+strings.Join(r.ExtraColumns, ", "))
+	}
+	for _, m := range r.TypeMismatches {
+		parts = append(parts, fmt.Sprintf("column %s is %s, expected %s", m.Column, m.Actual, m.Expected))
+	}
+	for _, m := range r.NullabilityMismatches {
+		parts = append(parts, fmt.Sprintf("column %s is %s, expected %s", m.Column, nullability(m.ActualNullable), nullability(m.ExpectedNullable)))
+	}
+	for _, idx := range r.MissingIndexes {
+		parts = append(parts, "missing "+idx.describe())
+	}
+	for _, fk := range r.MissingForeignKeys {
+		parts = append(parts, "missing "+fk.describe())
+	}
+	for _, m := range r.ForeignKeyMismatches {
+		expected := m.OnDelete
+		if m.Clause == "ON UPDATE" {
+			expected = m.OnUpdate
+		}
+		parts = append(parts, fmt.Sprintf("%s is %s %s, expected %s", m.describe(), m.Clause, m.Actual, expected))
+	}
+	return fmt.Sprintf("table %s: %s", r.Table, strings.Join(parts, "; "))
+}
+
+func (fk ForeignKey) describe() string {
+	return fmt.Sprintf("foreign key (%s) references %s (%s)",
+		strings.Join(fk.Columns, ", "), fk.RefTable, strings.Join(fk.RefColumns, ", "))
+}
+
+func (idx MissingIndex) describe() string {
+	kind := "index"
+	switch {
+	case idx.PrimaryKey:
+		kind = "primary key"
+	case idx.Unique:
+		kind = "unique index"
+	}
+	if idx.Name != "" {
+		kind += " " + idx.Name
+	}
+	return fmt.Sprintf("%s (%s)", kind, strings.Join(idx.Columns, ", "))
+}
+
+func nullability(nullable bool) string {
+	if nullable {
+		return "NULL"
+	}
+	return "NOT NULL"
+}
+
+// Verify compares the live table with the schema derived from T's struct tags.
+// The table is introspected through information_schema, the system catalogs or
+// SQLite pragmas depending on the renderer's dialect.
+//
+// Drift is reported, not returned as an error; the error is reserved for failed
+// introspection. Use report.Err() to turn drift into an error:
+//
+//	report, err := users.Verify(ctx)
+//	if err != nil {
+//	    return err
+//	}
+//	if err := report.Err(); err != nil {
+//	    return fmt.Errorf("users table is incompatible: %w", err)
+//	}
+//
+// Types are compared after normalizing dialect aliases; lengths and precision are ignored.
+// Indexes match on their ordered columns, so live index names do not need to match.
+// Foreign keys match on their columns and referenced table, then their ON DELETE and
+// ON UPDATE actions are compared; an action left out of the tag is expected to be NO ACTION.
+func (c *Soy[T]) Verify(ctx context.Context) (*DriftReport, error) {
+	if c.db == nil {
+		return nil, errors.New("soy: Verify requires a database connection")
+	}
+
+	project, expected, err := c.dbmlTable()
+	if err != nil {
+		return nil, err
+	}
+
+	d := dialectOf(c.renderer())
+	live, err := introspectTable(ctx, c.execer(), d, c.tableName)
+	if err != nil {
+		return nil, err
+	}
+	return compareSchema(d, expected, project.Refs, live), nil
+}
+
+// liveTable is the introspected shape of a table.
+type liveTable struct {
+	columns     []liveColumn
+	indexes     []liveIndex
+	foreignKeys []ForeignKey
+}
+
+type liveColumn struct {
+	name     string
+	dataType string
+	nullable bool
+}
+
+type liveIndex struct {
+	name    string
+	columns []string
+	unique  bool
+	primary bool
+}
+
+// introspectionQueries are the catalog queries for one dialect. Every dialect returns the
+// same result columns so the rows can be scanned identically:
+// columns as (column_name, data_type, nullable), indexes as
+// (index_name, column_name, is_unique, is_primary, position) and foreign keys as
+// (constraint_name, column_name, ref_table, ref_column, on_delete, on_update, position),
+// ordered by index or constraint and position. Actions are lowercase with spaces.
+type introspectionQueries struct {
+	columns     string
+	indexes     string
+	foreignKeys string
+}
+
+var introspection = map[dialect]introspectionQueries{
+	dialectPostgres: {
+		columns: `SELECT column_name,
+	CASE data_type WHEN 'ARRAY' THEN substr(udt_name, 2) || '[]' WHEN 'USER-DEFINED' THEN udt_name ELSE data_type END AS data_type,
+	is_nullable = 'YES' AS nullable
+FROM information_schema.columns
+WHERE table_schema = current_schema() AND table_name = :table
+ORDER BY ordinal_position`,
+		indexes: `SELECT ic.relname AS index_name, a.attname AS column_name, ix.indisunique AS is_unique, ix.indisprimary AS is_primary, k.ord AS position
+FROM pg_index ix
+JOIN pg_class t ON t.oid = ix.indrelid
+JOIN pg_class ic ON ic.oid = ix.indexrelid
+JOIN pg_namespace n ON n.oid = t.relnamespace
+CROSS JOIN LATERAL unnest(ix.indkey) WITH ORDINALITY AS k(attnum, ord)
+JOIN pg_attribute a ON a.attrelid = t.oid AND a.attnum = k.attnum
+WHERE n.nspname = current_schema() AND t.relname = :table
+ORDER BY index_name, position`,
+		foreignKeys: `SELECT con.conname AS constraint_name, a.attname AS column_name, rt.relname AS ref_table, ra.attname AS ref_column,
+	CASE con.confdeltype WHEN 'c' THEN 'cascade' WHEN 'r' THEN 'restrict' WHEN 'n' THEN 'set null' WHEN 'd' THEN 'set default' ELSE 'no action' END AS on_delete,
+	CASE con.confupdtype WHEN 'c' THEN 'cascade' WHEN 'r' THEN 'restrict' WHEN 'n' THEN 'set null' WHEN 'd' THEN 'set default' ELSE 'no action' END AS on_update,
+	k.ord AS position
+FROM pg_constraint con
+JOIN pg_class t ON t.oid = con.conrelid
+JOIN pg_class rt ON rt.oid = con.confrelid
+JOIN pg_namespace n ON n.oid = t.relnamespace
+CROSS JOIN LATERAL unnest(con.conkey, con.confkey) WITH ORDINALITY AS k(attnum, refattnum, ord)
+JOIN pg_attribute a ON a.attrelid = t.oid AND a.attnum = k.attnum
+JOIN pg_attribute ra ON ra.attrelid = rt.oid AND ra.attnum = k.refattnum
+WHERE con.contype = 'f' AND n.nspname = current_schema() AND t.relname = :table
+ORDER BY constraint_name, position`,
+	},
+	dialectMariaDB: {
+		columns: `SELECT COLUMN_NAME AS column_name, COLUMN_TYPE AS data_type, IS_NULLABLE = 'YES' AS nullable
+FROM information_schema.COLUMNS
+WHERE TABLE_SCHEMA = DATABASE() AND TABLE_NAME = :table
+ORDER BY ORDINAL_POSITION`,
+		indexes: `SELECT INDEX_NAME AS index_name, COLUMN_NAME AS column_name, NON_UNIQUE = 0 AS is_unique, INDEX_NAME = 'PRIMARY' AS is_primary, SEQ_IN_INDEX AS position
+FROM information_schema.STATISTICS
+WHERE TABLE_SCHEMA = DATABASE() AND TABLE_NAME = :table AND COLUMN_NAME IS NOT NULL
+ORDER BY INDEX_NAME, SEQ_IN_INDEX`,
+		foreignKeys: `SELECT k.CONSTRAINT_NAME AS constraint_name, k.COLUMN_NAME AS column_name, k.REFERENCED_TABLE_NAME AS ref_table, k.REFERENCED_COLUMN_NAME AS ref_column,
+	LOWER(r.DELETE_RULE) AS on_delete, LOWER(r.UPDATE_RULE) AS on_update, k.ORDINAL_POSITION AS position
+FROM information_schema.KEY_COLUMN_USAGE k
+JOIN information_schema.REFERENTIAL_CONSTRAINTS r ON r.CONSTRAINT_SCHEMA = k.CONSTRAINT_SCHEMA AND r.CONSTRAINT_NAME = k.CONSTRAINT_NAME AND r.TABLE_NAME = k.TABLE_NAME
+WHERE k.TABLE_SCHEMA = DATABASE() AND k.TABLE_NAME = :table AND k.REFERENCED_TABLE_NAME IS NOT NULL
+ORDER BY k.CONSTRAINT_NAME, k.ORDINAL_POSITION`,
+	},
+	dialectSQLite: {
+		columns: `SELECT name AS column_name, type AS data_type, "notnull" = 0 AND pk = 0 AS nullable
+FROM pragma_table_info(:table)
+ORDER BY cid`,
+		// INTEGER PRIMARY KEY columns alias the rowid and have no index, so primary keys
+		// come from table_info rather than index_list.
+		indexes: `SELECT 'PRIMARY' AS index_name, name AS column_name, 1 AS is_unique, 1 AS is_primary, pk AS position
+FROM pragma_table_info(:table) WHERE pk > 0
+UNION ALL
+SELECT il.name, ii.name, il."unique", 0, ii.seqno + 1
+FROM pragma_index_list(:table) AS il, pragma_index_info(il.name) AS ii
+WHERE il.origin <> 'pk' AND ii.name IS NOT NULL
+ORDER BY 1, 5`,
+		foreignKeys: `SELECT CAST(id AS TEXT) AS constraint_name, "from" AS column_name, "table" AS ref_table, COALESCE("to", '') AS ref_column,
+	lower(on_delete) AS on_delete, lower(on_update) AS on_update, seq + 1 AS position
+FROM pragma_foreign_key_list(:table)
+ORDER BY id, seq`,
+	},
+	dialectMSSQL: {
+		columns: `SELECT COLUMN_NAME AS column_name, DATA_TYPE AS data_type, CASE IS_NULLABLE WHEN 'YES' THEN 1 ELSE 0 END AS nullable
+FROM INFORMATION_SCHEMA.COLUMNS
+WHERE TABLE_SCHEMA = SCHEMA_NAME() AND TABLE_NAME = :table
+ORDER BY ORDINAL_POSITION`,
+		indexes: `SELECT i.name AS index_name, c.name AS column_name, i.is_unique, i.is_primary_key AS is_primary, ic.key_ordinal AS position
+FROM sys.indexes i
+JOIN sys.index_columns ic ON ic.object_id = i.object_id AND ic.index_id = i.index_id
+JOIN sys.columns c ON c.object_id = ic.object_id AND c.column_id = ic.column_id
+WHERE i.object_id = OBJECT_ID(QUOTENAME(SCHEMA_NAME()) + '.' + QUOTENAME(:table)) AND ic.is_included_column = 0
+ORDER BY i.name, ic.key_ordinal`,
+		foreignKeys: `SELECT fk.name AS constraint_name, c.name AS column_name, rt.name AS ref_table, rc.name AS ref_column,
+	LOWER(REPLACE(fk.delete_referential_action_desc, '_', ' ')) AS on_delete,
+	LOWER(REPLACE(fk.update_referential_action_desc, '_', ' ')) AS on_update,
+	fkc.constraint_column_id AS position
+FROM sys.foreign_keys fk
+JOIN sys.foreign_key_columns fkc ON fkc.constraint_object_id = fk.object_id
+JOIN sys.columns c ON c.object_id = fkc.parent_object_id AND c.column_id = fkc.parent_column_id
+JOIN sys.tables rt ON rt.object_id = fkc.referenced_object_id
+JOIN sys.columns rc ON rc.object_id = fkc.referenced_object_id AND rc.column_id = fkc.referenced_column_id
+WHERE fk.parent_object_id = OBJECT_ID(QUOTENAME(SCHEMA_NAME()) + '.' + QUOTENAME(:table))
+ORDER BY fk.name, fkc.constraint_column_id`,
+	},
+}
+
+// introspectTable reads the columns, indexes and foreign keys of tableName from the database catalogs.
+func introspectTable(ctx context.Context, execer sqlx.ExtContext, d dialect, tableName string) (*liveTable, error) {
+	queries := introspection[d]
+	params := map[string]any{"table": tableName}
+
+	capitan.Debug(ctx, QueryStarted,
+		TableKey.Field(tableName),
+		OperationKey.Field("VERIFY"),
+		SQLKey.Field(queries.columns),
+	)
+	startTime := time.Now()
+
+	fail := func(err error) (*liveTable, error) {
+		capitan.Error(ctx, QueryFailed,
+			TableKey.Field(tableName),
+			OperationKey.Field("VERIFY"),
+			DurationMsKey.Field(time.Since(startTime).Milliseconds()),
+			ErrorKey.Field(err.Error()),
+		)
+		return nil, err
+	}
+
+	live := &liveTable{}
+
+	rows, err := sqlx.NamedQueryContext(ctx, execer, queries.columns, params)
+	if err != nil {
+		return fail(newQueryError("VERIFY", err))
+	}
+	for rows.Next() {
+		var col liveColumn
+		if err := rows.Scan(&col.name, &col.dataType, &col.nullable); err != nil {
+			_ = rows.Close()
+			return fail(newScanError("VERIFY", err))
+		}
+		live.columns = append(live.columns, col)
+	}
+	err = rows.Err()
+	_ = rows.Close()
+	if err != nil {
+		return fail(newIterationError(err))
+	}
+
+	if len(live.columns) > 0 {
+		rows, err = sqlx.NamedQueryContext(ctx, execer, queries.indexes, params)
+		if err != nil {
+			return fail(newQueryError("VERIFY", err))
+		}
+		for rows.Next() {
+			var (
+				indexName, column string
+				unique, primary   bool
+				position          int64
+			)
+			if err := rows.Scan(&indexName, &column, &unique, &primary, &position); err != nil {
+				_ = rows.Close()
+				return fail(newScanError("VERIFY", err))
+			}
+			if n := len(live.indexes); n > 0 && live.indexes[n-1].name == indexName {
+				live.indexes[n-1].columns = append(live.indexes[n-1].columns, column)
+				continue
+			}
+			live.indexes = append(live.indexes, liveIndex{name: indexName, columns: []string{column}, unique: unique, primary: primary})
+		}
+		err = rows.Err()
+		_ = rows.Close()
+		if err != nil {
+			return fail(newIterationError(err))
+		}
+
+		rows, err = sqlx.NamedQueryContext(ctx, execer, queries.foreignKeys, params)
+		if err != nil {
+			return fail(newQueryError("VERIFY", err))
+		}
+		var lastConstraint string
+		for rows.Next() {
+			var (
+				constraint, column, refTable, refColumn, onDelete, onUpdate string
+				position                                                    int64
+			)
+			if err := rows.Scan(&constraint, &column, &refTable, &refColumn, &onDelete, &onUpdate, &position); err != nil {
+				_ = rows.Close()
+				return fail(newScanError("VERIFY", err))
+			}
+			if n := len(live.foreignKeys); n > 0 && lastConstraint == constraint {
+				live.foreignKeys[n-1].Columns = append(live.foreignKeys[n-1].Columns, column)
+				live.foreignKeys[n-1].RefColumns = append(live.foreignKeys[n-1].RefColumns, refColumn)
+				continue
+			}
+			lastConstraint = constraint
+			live.foreignKeys = append(live.foreignKeys, ForeignKey{
+				Columns:    []string{column},
+				RefTable:   refTable,
+				RefColumns: []string{refColumn},
+				OnDelete:   onDelete,
+				OnUpdate:   onUpdate,
+			})
+		}
+		err = rows.Err()
+		_ = rows.Close()
+		if err != nil {
+			return fail(newIterationError(err))
+		}
+	}
+
+	capitan.Info(ctx, QueryCompleted,
+		TableKey.Field(tableName),
+		OperationKey.Field("VERIFY"),
+		DurationMsKey.Field(time.Since(startTime).Milliseconds()),
+		RowsReturnedKey.Field(len(live.columns)),
+	)
+	return live, nil
+}
+
+// compareSchema diffs the expected DBML table and its refs against the live table.
+// Column names compare case-insensitively, as unquoted identifiers do in every dialect.
+func compareSchema(d dialect, expected *dbml.Table, refs []*dbml.Ref, live *liveTable) *DriftReport {
+	report := &DriftReport{Table: expected.Name}
+	if len(live.columns) == 0 {
+		report.TableMissing = true
+		return report
+	}
+
+	liveColumns := make(map[string]liveColumn, len(live.columns))
+	for _, col := range live.columns {
+		liveColumns[strings.ToLower(col.name)] = col
+	}
+
+	declared := make(map[string]bool, len(expected.Columns))
+	var primaryKey []string
+	var wantIndexes []MissingIndex
+	for _, col := range expected.Columns {
+		declared[strings.ToLower(col.Name)] = true
+		if col.Settings.PrimaryKey {
+			primaryKey = append(primaryKey, col.Name)
+		}
+		if col.Settings.Unique {
+			wantIndexes = append(wantIndexes, MissingIndex{Columns: []string{col.Name}, Unique: true})
+		}
+
+		actual, ok := liveColumns[strings.ToLower(col.Name)]
+		if !ok {
+			report.MissingColumns = append(report.MissingColumns, col.Name)
+			continue
+		}
+		if want, got := normalizeSQLType(d, col.Type), normalizeSQLType(d, actual.dataType); want != got {
+			report.TypeMismatches = append(report.TypeMismatches, TypeMismatch{Column: col.Name, Expected: want, Actual: got})
+		}
+		nullable := col.Settings.Null && !col.Settings.PrimaryKey
+		if nullable != actual.nullable {
+			report.NullabilityMismatches = append(report.NullabilityMismatches, NullabilityMismatch{
+				Column:           col.Name,
+				ExpectedNullable: nullable,
+				ActualNullable:   actual.nullable,
+			})
+		}
+	}
+
+	for _, col := range live.columns {
+		if !declared[strings.ToLower(col.name)] {
+			report.ExtraColumns = append(report.ExtraColumns, col.name)
+		}
+	}
+
+	if len(primaryKey) > 0 {
+		wantIndexes = append([]MissingIndex{{Columns: primaryKey, Unique: true, PrimaryKey: true}}, wantIndexes...)
+	}
+	for _, idx := range expected.Indexes {
+		want := MissingIndex{Unique: idx.Unique, PrimaryKey: idx.PrimaryKey}
+		if idx.Name != nil {
+			want.Name = *idx.Name
+		}
+		for _, ic := range idx.Columns {
+			if ic.Name != nil {
+				want.Columns = append(want.Columns, *ic.Name)
+			}
+		}
+		if len(want.Columns) > 0 {
+			wantIndexes = append(wantIndexes, want)
+		}
+	}
+
+	for _, want := range wantIndexes {
+		if !hasMatchingIndex(live.indexes, want) {
+			report.MissingIndexes = append(report.MissingIndexes, want)
+		}
+	}
+
+	for _, ref := range refs {
+		if ref.Left == nil || ref.Right == nil || ref.Left.Table != expected.Name {
+			continue
+		}
+		want := ForeignKey{
+			Columns:    ref.Left.Columns,
+			RefTable:   ref.Right.Table,
+			RefColumns: ref.Right.Columns,
+			OnDelete:   refAction(ref.OnDelete),
+			OnUpdate:   refAction(ref.OnUpdate),
+		}
+		actual, ok := matchingForeignKey(live.foreignKeys, want)
+		if !ok {
+			report.MissingForeignKeys = append(report.MissingForeignKeys, want)
+			continue
+		}
+		if !equivalentActions(want.OnDelete, actual.OnDelete) {
+			report.ForeignKeyMismatches = append(report.ForeignKeyMismatches, ForeignKeyMismatch{ForeignKey: want, Clause: "ON DELETE", Actual: actual.OnDelete})
+		}
+		if !equivalentActions(want.OnUpdate, actual.OnUpdate) {
+			report.ForeignKeyMismatches = append(report.ForeignKeyMismatches, ForeignKeyMismatch{ForeignKey: want, Clause: "ON UPDATE", Actual: actual.OnUpdate})
+		}
+	}
+
+	return report
+}
+
+// matchingForeignKey returns the live foreign key on want's columns that references
+// the same table and columns.
+func matchingForeignKey(foreignKeys []ForeignKey, want ForeignKey) (ForeignKey, bool) {
+	for _, fk := range foreignKeys {
+		if strings.EqualFold(fk.RefTable, want.RefTable) &&
+			slices.EqualFunc(fk.Columns, want.Columns, strings.EqualFold) &&
+			slices.EqualFunc(fk.RefColumns, want.RefColumns, strings.EqualFold) {
+			return fk, true
+		}
+	}
+	return ForeignKey{}, false
+}
+
+// refAction returns the expected action for a ref; unspecified actions are the
+// SQL default, NO ACTION.
+func refAction(action *dbml.RefAction) string {
+	if action == nil {
+		return string(dbml.NoAction)
+	}
+	return string(*action)
+}
+
+// equivalentActions compares referential actions. RESTRICT and NO ACTION are treated
+// as equal: they differ only for deferred checks, and MariaDB and SQL Server do not
+// distinguish them.
+func equivalentActions(expected, actual string) bool {
+	normalize := func(action string) string {
+		action = strings.ToLower(strings.TrimSpace(action))
+		if action == string(dbml.Restrict) {
+			return string(dbml.NoAction)
+		}
+		return action
+	}
+	return normalize(expected) == normalize(actual)
+}
+
+// hasMatchingIndex reports whether a live index covers want's columns in order
+// with at least the uniqueness want requires.
+func hasMatchingIndex(indexes []liveIndex, want MissingIndex) bool {
+	for _, idx := range indexes {
+		if !slices.EqualFunc(idx.columns, want.Columns, strings.EqualFold) {
+			continue
+		}
+		if want.PrimaryKey && !idx.primary {
+			continue
+		}
+		if want.Unique && !idx.unique && !idx.primary {
+			continue
+		}
+		return true
+	}
+	return false
+}
+
+// sqlTypeAliases maps dialect spellings to one canonical name per type.
+var sqlTypeAliases = map[string]string{
+	"int":                         "integer",
+	"int4":                        "integer",
+	"mediumint":                   "integer",
+	"serial":                      "integer",
+	"serial4":                     "integer",
+	"int8":                        "bigint",
+	"bigserial":                   "bigint",
+	"serial8":                     "bigint",
+	"int2":                        "smallint",
+	"smallserial":                 "smallint",
+	"serial2":                     "smallint",
+	"bool":                        "boolean",
+	"bit":                         "boolean",
+	"float4":                      "real",
+	"float8":                      "double precision",
+	"double":                      "double precision",
+	"decimal":                     "numeric",
+	"character varying":           "varchar",
+	"nvarchar":                    "varchar",
+	"character":                   "char",
+	"bpchar":                      "char",
+	"nchar":                       "char",
+	"ntext":                       "text",
+	"timestamp with time zone":    "timestamptz",
+	"datetimeoffset":              "timestamptz",
+	"timestamp without time zone": "timestamp",
+	"datetime":                    "timestamp",
+	"datetime2":                   "timestamp",
+	"time without time zone":      "time",
+	"time with time zone":         "timetz",
+	"blob":                        "bytea",
+	"longblob":                    "bytea",
+	"varbinary":                   "bytea",
+	"uniqueidentifier":            "uuid",
+}
+
+// dialectTypeAliases are aliases that hold only in one dialect's catalog.
+var dialectTypeAliases = map[dialect]map[string]string{
+	// FLOAT without a precision is double precision on PostgreSQL and SQL Server,
+	// but single precision on MariaDB, where it stays float
+	dialectPostgres: {"float": "double precision"},
+	dialectMSSQL:    {"float": "double precision"},
+	// JSON is an alias for LONGTEXT there, which is what the catalog reports
+	dialectMariaDB: {"longtext": "json"},
+}
+
+// normalizeSQLType reduces a type name to a canonical form for comparison.
+// Case, length and precision arguments, generated column expressions, MariaDB's unsigned
+// modifier and dialect aliases are normalized away; array suffixes are kept.
+func normalizeSQLType(d dialect, sqlType string) string {
+	t := strings.ToLower(strings.TrimSpace(sqlType))
+	if i := strings.Index(t, " generated "); i >= 0 {
+		t = t[:i]
+	}
+	if strings.HasSuffix(t, "[]") {
+		return normalizeSQLType(d, strings.TrimSuffix(t, "[]")) + "[]"
+	}
+	if t == "tinyint(1)" {
+		return "boolean"
+	}
+	if open := strings.IndexByte(t, '('); open >= 0 {
+		if end := strings.IndexByte(t[open:], ')'); end >= 0 {
+			t = t[:open] + t[open+end+1:]
+		}
+	}
+	t = strings.TrimSuffix(strings.TrimSpace(t), " unsigned")
+	t = strings.Join(strings.Fields(t), " ")
+	if alias, ok := dialectTypeAliases[d][t]; ok {
+		return alias
+	}
+	if alias, ok := sqlTypeAliases[t]; ok {
+		return alias
+	}
+	return t
+}
@@ -0,0 +1,338 @@
+package soy
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/zoobzio/astql/mariadb"
+	"github.com/zoobzio/astql/postgres"
+)
+
+type verifyTestUser struct {
+	ID        int       `db:"id" type:"serial" constraints:"primary_key"`
+	Email     string    `db:"email" type:"varchar(255)" constraints:"not_null,unique"`
+	Name      string    `db:"name" type:"text" index:"idx_verify_name"`
+	Tags      []string  `db:"tags"`
+	CreatedAt time.Time `db:"created_at" constraints:"not_null"`
+	OrgID     *int      `db:"org_id" type:"integer" references:"orgs(id) on_delete:set_null"`
+}
+
+// verifyDriver is a database/sql driver that answers the introspection queries
+// with scripted catalog rows.
+type verifyDriver struct {
+	columns     [][]driver.Value
+	indexes     [][]driver.Value
+	foreignKeys [][]driver.Value
+	err         error
+}
+
+func (d *verifyDriver) Open(_ string) (driver.Conn, error) { return verifyConn{d}, nil }
+
+type verifyConn struct{ driver *verifyDriver }
+
+func (c verifyConn) Prepare(query string) (driver.Stmt, error) {
+	return verifyStmt{driver: c.driver, query: query}, nil
+}
+func (verifyConn) Close() error              { return nil }
+func (verifyConn) Begin() (driver.Tx, error) { return nil, errors.New("not supported") }
+
+type verifyStmt struct {
+	driver *verifyDriver
+	query  string
+}
+
+func (verifyStmt) Close() error  { return nil }
+func (verifyStmt) NumInput() int { return -1 }
+func (verifyStmt) Exec(_ []driver.Value) (driver.Result, error) {
+	return nil, errors.New("not supported")
+}
+
+func (s verifyStmt) Query(_ []driver.Value) (driver.Rows, error) {
+	if s.driver.err != nil {
+		return nil, s.driver.err
+	}
+	if strings.Contains(s.query, "ref_table") {
+		return &verifyRows{cols: []string{"constraint_name", "column_name", "ref_table", "ref_column", "on_delete", "on_update", "position"}, data: s.driver.foreignKeys}, nil
+	}
+	if strings.Contains(s.query, "index_name") {
+		return &verifyRows{cols: []string{"index_name", "column_name", "is_unique", "is_primary", "position"}, data: s.driver.indexes}, nil
+	}
+	return &verifyRows{cols: []string{"column_name", "data_type", "nullable"}, data: s.driver.columns}, nil
+}
+
+type verifyRows struct {
+	cols []string
+	data [][]driver.Value
+}
+
+func (r *verifyRows) Columns() []string { return r.cols }
+func (*verifyRows) Close() error        { return nil }
+func (r *verifyRows) Next(dest []driver.Value) error {
+	if len(r.data) == 0 {
+		return io.EOF
+	}
+	copy(dest, r.data[0])
+	r.data = r.data[1:]
+	return nil
+}
+
+var verifyDriverSeq atomic.Int64
+
+func newVerifyTestSoy(t *testing.T, drv *verifyDriver) *Soy[verifyTestUser] {
+	t.Helper()
+	name := fmt.Sprintf("soy_verify_%d", verifyDriverSeq.Add(1))
+	sql.Register(name, drv)
+	sqlx.BindDriver(name, sqlx.DOLLAR)
+
+	db, err := sqlx.Open(name, "")
+	if err != nil {
+		t.Fatalf("sqlx.Open() failed: %v", err)
+	}
+	t.Cleanup(func() { _ = db.Close() })
+
+	s, err := New[verifyTestUser](db, "users", postgres.New())
+	if err != nil {
+		t.Fatalf("New() failed: %v", err)
+	}
+	return s
+}
+
+// matchingVerifyDriver returns catalog rows for a table that matches verifyTestUser.
+func matchingVerifyDriver() *verifyDriver {
+	return &verifyDriver{
+		columns: [][]driver.Value{
+			{"id", "integer", false},
+			{"email", "character varying", false},
+			{"name", "text", true},
+			{"tags", "text[]", true},
+			{"created_at", "timestamp with time zone", false},
+			{"org_id", "integer", true},
+		},
+		indexes: [][]driver.Value{
+			{"idx_verify_name", "name", false, false, int64(1)},
+			{"users_email_key", "email", true, false, int64(1)},
+			{"users_pkey", "id", true, true, int64(1)},
+		},
+		foreignKeys: [][]driver.Value{
+			{"users_org_id_fkey", "org_id", "orgs", "id", "set null", "no action", int64(1)},
+		},
+	}
+}
+
+func TestVerify_NoDrift(t *testing.T) {
+	s := newVerifyTestSoy(t, matchingVerifyDriver())
+
+	report, err := s.Verify(t.Context())
+	if err != nil {
+		t.Fatalf("Verify() failed: %v", err)
+	}
+	if report.HasDrift() {
+		t.Errorf("expected no drift, got %s", report)
+	}
+	if report.Err() != nil {
+		t.Errorf("Err() = %v, want nil", report.Err())
+	}
+}
+
+func TestVerify_Drift(t *testing.T) {
+	drv := &verifyDriver{
+		columns: [][]driver.Value{
+			{"id", "bigint", false},
+			{"email", "text", true},
+			{"name", "text", true},
+			{"created_at", "timestamp with time zone", false},
+			{"org_id", "integer", true},
+			{"legacy", "text", true},
+		},
+		indexes: [][]driver.Value{
+			{"users_pkey", "id", true, true, int64(1)},
+			{"users_email_idx", "email", false, false, int64(1)},
+		},
+		foreignKeys: [][]driver.Value{
+			{"users_org_id_fkey", "org_id", "orgs", "id", "cascade", "restrict", int64(1)},
+		},
+	}
+	s := newVerifyTestSoy(t, drv)
+
+	report, err := s.Verify(t.Context())
+	if err != nil {
+		t.Fatalf("Verify() failed: %v", err)
+	}
+
+	if !slices.Equal(report.MissingColumns, []string{"tags"}) {
+		t.Errorf("MissingColumns = %v", report.MissingColumns)
+	}
+	if !slices.Equal(report.ExtraColumns, []string{"legacy"}) {
+		t.Errorf("ExtraColumns = %v", report.ExtraColumns)
+	}
+	wantTypes := []TypeMismatch{
+		{Column: "id", Expected: "integer", Actual: "bigint"},
+		{Column: "email", Expected: "varchar", Actual: "text"},
+	}
+	if !slices.Equal(report.TypeMismatches, wantTypes) {
+		t.Errorf("TypeMismatches = %+v", report.TypeMismatches)
+	}
+	wantNull := []NullabilityMismatch{{Column: "email", ExpectedNullable: false, ActualNullable: true}}
+	if !slices.Equal(report.NullabilityMismatches, wantNull) {
+		t.Errorf("NullabilityMismatches = %+v", report.NullabilityMismatches)
+	}
+	if len(report.MissingIndexes) != 2 {
+		t.Fatalf("MissingIndexes = %+v, want unique email and idx_verify_name", report.MissingIndexes)
+	}
+	if idx := report.MissingIndexes[0]; !idx.Unique || !slices.Equal(idx.Columns, []string{"email"}) {
+		t.Errorf("MissingIndexes[0] = %+v", idx)
+	}
+	if idx := report.MissingIndexes[1]; idx.Name != "idx_verify_name" {
+		t.Errorf("MissingIndexes[1] = %+v", idx)
+	}
+
+	wantFK := []ForeignKeyMismatch{{
+		ForeignKey: ForeignKey{Columns: []string{"org_id"}, RefTable: "orgs", RefColumns: []string{"id"}, OnDelete: "set null", OnUpdate: "no action"},
+		Clause:     "ON DELETE",
+		Actual:     "cascade",
+	}}
+	if !slices.EqualFunc(report.ForeignKeyMismatches, wantFK, func(a, b ForeignKeyMismatch) bool {
+		return a.Clause == b.Clause && a.Actual == b.Actual && a.OnDelete == b.OnDelete && a.RefTable == b.RefTable
+	}) {
+		t.Errorf("ForeignKeyMismatches = %+v", report.ForeignKeyMismatches)
+	}
+
+	err = report.Err()
+	if !errors.Is(err, ErrSchemaDrift) {
+		t.Fatalf("Err() = %v, want ErrSchemaDrift", err)
+	}
+	if !strings.Contains(err.Error(), "missing columns: tags") {
+		t.Errorf("unexpected error message: %v", err)
+	}
+}
+
+func TestVerify_MissingForeignKey(t *testing.T) {
+	drv := matchingVerifyDriver()
+	drv.foreignKeys = [][]driver.Value{
+		{"users_org_id_fkey", "org_id", "organizations", "id", "set null", "no action", int64(1)},
+	}
+	s := newVerifyTestSoy(t, drv)
+
+	report, err := s.Verify(t.Context())
+	if err != nil {
+		t.Fatalf("Verify() failed: %v", err)
+	}
+	if len(report.MissingForeignKeys) != 1 || report.MissingForeignKeys[0].RefTable != "orgs" {
+		t.Fatalf("MissingForeignKeys = %+v", report.MissingForeignKeys)
+	}
+	if !strings.Contains(report.String(), "missing foreign key (org_id) references orgs (id)") {
+		t.Errorf("unexpected summary: %s", report)
+	}
+}
+
+func TestVerify_TableMissing(t *testing.T) {
+	s := newVerifyTestSoy(t, &verifyDriver{})
+
+	report, err := s.Verify(t.Context())
+	if err != nil {
+		t.Fatalf("Verify() failed: %v", err)
+	}
+	if !report.TableMissing || !report.HasDrift() {
+		t.Errorf("expected missing table, got %+v", report)
+	}
+}
+
+func TestVerify_Errors(t *testing.T) {
+	s := newVerifyTestSoy(t, &verifyDriver{err: errors.New("connection refused")})
+	if _, err := s.Verify(t.Context()); !errors.Is(err, ErrQueryFailed) {
+		t.Errorf("Verify() error = %v, want ErrQueryFailed", err)
+	}
+
+	noDB, err := New[verifyTestUser](nil, "users", postgres.New())
+	if err != nil {
+		t.Fatalf("New() failed: %v", err)
+	}
+	if _, err := noDB.Verify(t.Context()); err == nil {
+		t.Error("expected error without a database connection")
+	}
+}
+
+func TestNormalizeSQLType(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{"INTEGER", "integer"},
+		{"int4", "integer"},
+		{"int(11) unsigned", "integer"},
+		{"SERIAL", "integer"},
+		{"character varying(255)", "varchar"},
+		{"NVARCHAR", "varchar"},
+		{"tinyint(1)", "boolean"},
+		{"bit", "boolean"},
+		{"timestamp(6) with time zone", "timestamptz"},
+		{"DATETIME2", "timestamp"},
+		{"DOUBLE PRECISION", "double precision"},
+		{"TEXT[]", "text[]"},
+		{"int4[]", "integer[]"},
+		{"vector(3)", "vector"},
+		{"JSONB", "jsonb"},
+		{"tsvector GENERATED ALWAYS AS (to_tsvector('english', \"body\")) STORED", "tsvector"},
+	}
+	for _, tt := range tests {
+		if got := normalizeSQLType(dialectPostgres, tt.in); got != tt.want {
+			t.Errorf("normalizeSQLType(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+
+	dialectTests := []struct {
+		d    dialect
+		in   string
+		want string
+	}{
+		{dialectMariaDB, "longtext", "json"},
+		{dialectMariaDB, "JSON", "json"},
+		{dialectPostgres, "longtext", "longtext"},
+		{dialectSQLite, "longtext", "longtext"},
+		{dialectPostgres, "FLOAT", "double precision"},
+		{dialectPostgres, "float8", "double precision"},
+		{dialectMSSQL, "float", "double precision"},
+		{dialectMariaDB, "FLOAT", "float"},
+		{dialectMariaDB, "float(12)", "float"},
+		{dialectMariaDB, "double", "double precision"},
+	}
+	for _, tt := range dialectTests {
+		if got := normalizeSQLType(tt.d, tt.in); got != tt.want {
+			t.Errorf("%s normalizeSQLType(%q) = %q, want %q", tt.d, tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestVerify_MariaDBJSON(t *testing.T) {
+	type document struct {
+		ID   int            `db:"id" type:"integer" constraints:"primarykey"`
+		Body map[string]any `db:"body"`
+	}
+	s, err := New[document](nil, "documents", mariadb.New())
+	if err != nil {
+		t.Fatalf("New() failed: %v", err)
+	}
+	_, expected, err := s.dbmlTable()
+	if err != nil {
+		t.Fatalf("dbmlTable() failed: %v", err)
+	}
+	live := &liveTable{
+		columns: []liveColumn{{name: "id", dataType: "int"}, {name: "body", dataType: "longtext", nullable: true}},
+		indexes: []liveIndex{{name: "PRIMARY", columns: []string{"id"}, unique: true, primary: true}},
+	}
+	if report := compareSchema(dialectMariaDB, expected, nil, live); len(report.TypeMismatches) != 0 {
+		t.Errorf("JSON column reported as longtext drift: %v", report.TypeMismatches)
+	}
+	if report := compareSchema(dialectPostgres, expected, nil, live); len(report.TypeMismatches) != 1 {
+		t.Errorf("longtext should only match JSON on MariaDB: %v", report.TypeMismatches)
+	}
+}
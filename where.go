@@ -29,7 +29,7 @@ func (w *whereBuilder) addWhere(field, operator, param string) (*astql.Builder,
 // addWhereWithCondition adds a simple WHERE condition and also returns the condition item.
 // This is used when the caller needs to track conditions for fallback queries.
 func (w *whereBuilder) addWhereWithCondition(field, operator, param string) (*astql.Builder, astql.ConditionItem, error) {
-	astqlOp, err := validateOperator(operator)
+	astqlOp, err := validateConditionOperator(w.instance, field, operator)
 	if err != nil {
 		return w.builder, nil, err
 	}
@@ -241,7 +241,7 @@ func buildConditionWithInstance(instance *astql.ASTQL, cond Condition) (astql.Co
 		return astql.NotBetween(f, lowP, highP), nil
 	}
 
-	astqlOp, err := validateOperator(cond.operator)
+	astqlOp, err := validateConditionOperator(instance, cond.field, cond.operator)
 	if err != nil {
 		return nil, err
 	}
@@ -258,7 +258,7 @@ func buildConditionWithInstance(instance *astql.ASTQL, cond Condition) (astql.Co
 // This is extracted to avoid code duplication across SelectCaseBuilder and QueryCaseBuilder.
 // The caller is responsible for resolving the result param.
 func buildCaseWhenCondition(instance *astql.ASTQL, field, operator, param string) (astql.ConditionItem, error) {
-	astqlOp, err := validateOperator(operator)
+	astqlOp, err := validateConditionOperator(instance, field, operator)
 	if err != nil {
 		return nil, err
 	}